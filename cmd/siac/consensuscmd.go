@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -16,6 +19,36 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export consensus snapshots",
+		Long:  "Export snapshots of the consensus set that new nodes can bootstrap from.",
+	}
+
+	consensusSnapshotExportCmd = &cobra.Command{
+		Use:   "export [destination]",
+		Short: "Export a snapshot of the consensus set",
+		Long: `Export a snapshot of the consensus set to a file. By default, the snapshot
+is taken at the current height; use --height to take it at an earlier height.
+A new node can bootstrap from the snapshot by passing the file to
+'siad --bootstrap-snapshot' and the printed hash to 'siad --bootstrap-snapshot-hash'.`,
+		Run: wrap(consensussnapshotexportcmd),
+	}
+
+	consensusVerifyCmd = &cobra.Command{
+		Use:   "verify [sia-directory]",
+		Short: "Check the consensus database for corruption",
+		Long: `Check the consensus database in the given Sia directory for corruption. The
+blocks of the current path, the consensus state and the change log are checked,
+and every inconsistency is printed together with the height of the offending
+block. The database is read directly, so siad must not be running.
+
+With --rebuild, an inconsistent consensus state is rebuilt from the stored
+blocks. The original database is kept as consensus.db.bck, or as
+consensus.db.bck.N if an earlier backup exists.`,
+		Run: wrap(consensusverifycmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
@@ -45,6 +78,71 @@ Progress (estimated): %.1f%%
 	}
 }
 
+// consensussnapshotexportcmd is the handler for the command `siac consensus
+// snapshot export [destination]`. It exports a snapshot of the consensus set.
+func consensussnapshotexportcmd(destination string) {
+	height := types.BlockHeight(consensusSnapshotHeight)
+	if height == 0 {
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get current consensus state:", err)
+		}
+		height = cg.Height
+	}
+	snapshot, err := httpClient.ConsensusSnapshotGet(abs(destination), height)
+	if err != nil {
+		die("Could not export snapshot:", err)
+	}
+	fmt.Printf(`Exported snapshot to %v
+Height: %v
+Block:  %v
+Hash:   %v
+
+Bootstrap a new node from the snapshot with:
+  siad --bootstrap-snapshot %v --bootstrap-snapshot-hash %v
+`, abs(destination), snapshot.Height, snapshot.BlockID, snapshot.Hash, abs(destination), snapshot.Hash)
+}
+
+// consensusverifycmd is the handler for the command `siac consensus verify
+// [sia-directory]`. It checks the consensus database for corruption, and
+// optionally rebuilds the consensus state.
+func consensusverifycmd(siaDir string) {
+	consensusDir := filepath.Join(abs(siaDir), modules.ConsensusDir)
+	report, err := consensus.Verify(consensusDir)
+	if err != nil {
+		die("Could not verify consensus database:", err)
+	}
+	fmt.Printf("Verified consensus database up to height %v (block %v)\n", report.Height, report.CurrentBlock)
+	if len(report.Inconsistencies) == 0 {
+		fmt.Println("No inconsistencies found.")
+		return
+	}
+	fmt.Printf("Found %v inconsistencies:\n", len(report.Inconsistencies))
+	for _, inc := range report.Inconsistencies {
+		fmt.Println(" ", inc)
+	}
+	if !consensusVerifyRebuild {
+		die("Run 'siac consensus verify --rebuild' to rebuild the consensus state from the stored blocks.")
+	}
+
+	fmt.Println("Rebuilding consensus state...")
+	backup, err := consensus.Rebuild(consensusDir)
+	if err != nil {
+		die("Could not rebuild consensus state:", err)
+	}
+	report, err = consensus.Verify(consensusDir)
+	if err != nil {
+		die("Could not verify rebuilt consensus database:", err)
+	}
+	if len(report.Inconsistencies) != 0 {
+		for _, inc := range report.Inconsistencies {
+			fmt.Println(" ", inc)
+		}
+		die("The rebuilt consensus database is still inconsistent. Restore it from a backup or delete it to resync.")
+	}
+	fmt.Printf("Rebuilt consensus state up to height %v. The original database was kept as %v\n", report.Height, backup)
+}
+
 // estimatedHeightAt returns the estimated block height for the given time.
 // Block height is estimated by calculating the minutes since a known block in
 // the past and dividing by 10 minutes (the block time).
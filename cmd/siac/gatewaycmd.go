@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/spf13/cobra"
@@ -17,6 +18,22 @@ var (
 		Run:   wrap(gatewayaddresscmd),
 	}
 
+	gatewayBanCmd = &cobra.Command{
+		Use:   "ban [host]",
+		Short: "Ban an IP address or subnet",
+		Long: `Ban an IP address, a subnet in CIDR notation such as 1.2.3.0/24, or an
+onion address. Peers of a banned host are disconnected and can not reconnect until the ban
+expires or is lifted.`,
+		Run: wrap(gatewaybancmd),
+	}
+
+	gatewayBansCmd = &cobra.Command{
+		Use:   "bans",
+		Short: "View the banned hosts",
+		Long:  "View the IP addresses and subnets that are currently banned.",
+		Run:   wrap(gatewaybanscmd),
+	}
+
 	gatewayCmd = &cobra.Command{
 		Use:   "gateway",
 		Short: "Perform gateway actions",
@@ -38,6 +55,13 @@ var (
 		Run:   wrap(gatewaydisconnectcmd),
 	}
 
+	gatewayUnbanCmd = &cobra.Command{
+		Use:   "unban [host]",
+		Short: "Lift the ban of an IP address or subnet",
+		Long:  "Lift the ban of an IP address, or of a subnet in CIDR notation.",
+		Run:   wrap(gatewayunbancmd),
+	}
+
 	gatewayListCmd = &cobra.Command{
 		Use:   "list",
 		Short: "View a list of peers",
@@ -106,3 +130,51 @@ func gatewaylistcmd() {
 	}
 	w.Flush()
 }
+
+// gatewaybancmd is the handler for the command `siac gateway ban [host]`.
+// Bans an IP address or subnet.
+func gatewaybancmd(host string) {
+	var duration time.Duration
+	if gatewayBanDuration != "" {
+		var err error
+		duration, err = time.ParseDuration(gatewayBanDuration)
+		if err != nil || duration <= 0 {
+			die("Could not parse ban duration:", gatewayBanDuration)
+		}
+	}
+	err := httpClient.GatewayBanPost(host, duration, gatewayBanReason)
+	if err != nil {
+		die("Could not ban host:", err)
+	}
+	fmt.Println("Banned", host+".")
+}
+
+// gatewayunbancmd is the handler for the command `siac gateway unban [host]`.
+// Lifts the ban of an IP address or subnet.
+func gatewayunbancmd(host string) {
+	err := httpClient.GatewayUnbanPost(host)
+	if err != nil {
+		die("Could not unban host:", err)
+	}
+	fmt.Println("Lifted the ban of", host+".")
+}
+
+// gatewaybanscmd is the handler for the command `siac gateway bans`.
+// Prints the banned IP addresses and subnets.
+func gatewaybanscmd() {
+	bg, err := httpClient.GatewayBansGet()
+	if err != nil {
+		die("Could not get ban list:", err)
+	}
+	if len(bg.Bans) == 0 {
+		fmt.Println("No banned hosts.")
+		return
+	}
+	fmt.Println(len(bg.Bans), "banned hosts:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Host\tExpires\tReason")
+	for _, b := range bg.Bans {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", b.Host, b.Expiry.Format(time.RFC822), b.Reason)
+	}
+	w.Flush()
+}
@@ -5,8 +5,10 @@ import (
 	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
@@ -17,6 +19,16 @@ import (
 )
 
 var (
+	hostAlertsCmd = &cobra.Command{
+		Use:   "alerts",
+		Short: "Show the problems the host is experiencing",
+		Long: `Show the alerts of the host, such as an unreachable host, failing storage
+folders, a wallet balance that is too low to fund collateral, or storage proofs
+at risk of missing their deadline. Alerts clear themselves once the condition
+has resolved.`,
+		Run: wrap(hostalertscmd),
+	}
+
 	hostAnnounceCmd = &cobra.Command{
 		Use:   "announce",
 		Short: "Announce yourself as a host",
@@ -26,6 +38,7 @@ You can revert this by running:
 	siac host config acceptingcontracts false
 You may also supply a specific address to be announced, e.g.:
 	siac host announce my-host-domain.com:9001
+A host that runs as a Tor onion service may announce its onion address.
 Doing so will override the standard connectivity checks.`,
 		Run: hostannouncecmd,
 	}
@@ -44,6 +57,7 @@ Doing so will override the standard connectivity checks.`,
 
 Available settings:
      acceptingcontracts:   boolean
+     maintenancemode:      boolean
      maxduration:          blocks
      maxdownloadbatchsize: bytes
      maxrevisebatchsize:   bytes
@@ -81,10 +95,20 @@ To configure the host to accept new contracts, set acceptingcontracts to true:
 Available output types:
      value:  show financial information
      status: show status information
+
+Contracts can be filtered by status (unresolved, rejected, succeeded, failed).
 `,
 		Run: wrap(hostcontractcmd),
 	}
 
+	hostContractViewCmd = &cobra.Command{
+		Use:   "view [contract-id]",
+		Short: "View details of the specified contract",
+		Long: `View all details of the specified contract, including its sector roots and the
+heights at which the host will next act on the contract.`,
+		Run: wrap(hostcontractviewcmd),
+	}
+
 	hostFolderAddCmd = &cobra.Command{
 		Use:   "add [path] [size]",
 		Short: "Add a storage folder to the host",
@@ -92,10 +116,28 @@ Available output types:
 		Run:   wrap(hostfolderaddcmd),
 	}
 
+	hostFolderCancelMigrationCmd = &cobra.Command{
+		Use:   "cancel-migration [path]",
+		Short: "Cancel the migration of a storage folder",
+		Long: `Stop migrating sectors out of a storage folder. Sectors that have already been
+moved stay in their new storage folders.`,
+		Run: wrap(hostfoldercancelmigrationcmd),
+	}
+
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, or migrate a storage folder",
+		Long:  "Add, remove, resize, or migrate a storage folder.",
+	}
+
+	hostFolderMigrateCmd = &cobra.Command{
+		Use:   "migrate [path] [destination]...",
+		Short: "Move all sectors out of a storage folder",
+		Long: `Move all sectors out of a storage folder in the background, without removing
+the folder. If destination folders are provided, the sectors will only be
+moved into those folders. No new data is placed into the folder while the
+migration is running. The progress of the migration is shown by 'siac host'.`,
+		Run: hostfoldermigratecmd,
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -116,6 +158,28 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostMaintenanceCmd = &cobra.Command{
+		Use:   "maintenance [on|off]",
+		Short: "Enable, disable, or view the maintenance mode of the host",
+		Long: `Enable or disable the maintenance mode of the host. In maintenance mode, the
+host refuses new contracts and renewals, but keeps serving downloads and
+submitting storage proofs, so that it can retire once all of its contracts have
+resolved. Without arguments, the progress of the contract wind-down is shown.
+
+With --announce, the host is announced after the mode changes, prompting
+renters to pick up its new settings.`,
+		Run: hostmaintenancecmd,
+	}
+
+	hostMetricsCmd = &cobra.Command{
+		Use:   "metrics",
+		Short: "Show the history of the host metrics",
+		Long: `Show a summary of the host's revenue, collateral, RPC calls and storage usage
+over time. The granularity can be "hour", "day", "week", or a number of
+seconds. Revenue and download calls are shown per period.`,
+		Run: wrap(hostmetricscmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -193,6 +257,7 @@ func hostcmd() {
 
 Host Internal Settings:
 	acceptingcontracts:   %v
+	maintenancemode:      %v
 	maxduration:          %v Weeks
 	maxdownloadbatchsize: %v
 	maxrevisebatchsize:   %v
@@ -237,7 +302,8 @@ RPC Stats:
 `,
 			connectabilityString,
 
-			yesNo(is.AcceptingContracts), periodUnits(is.MaxDuration),
+			yesNo(is.AcceptingContracts), yesNo(is.MaintenanceMode),
+			periodUnits(is.MaxDuration),
 			filesizeUnits(int64(is.MaxDownloadBatchSize)),
 			filesizeUnits(int64(is.MaxReviseBatchSize)), netaddr,
 			is.WindowSize/6,
@@ -321,6 +387,13 @@ RPC Stats:
 		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\n", filesizeUnits(curSize), filesizeUnits(int64(folder.Capacity)), pctUsed, folder.Path)
 	}
 	w.Flush()
+
+	// display the progress of any running migrations
+	for _, folder := range sg.Folders {
+		if folder.Migrating {
+			fmt.Printf("Migrating %v: %v sectors moved, %v remaining\n", folder.Path, folder.SectorsMigrated, folder.SectorsRemaining)
+		}
+	}
 }
 
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
@@ -356,7 +429,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "maintenancemode":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -396,9 +469,35 @@ func hostconfigcmd(param, value string) {
 	fmt.Printf("Estimated conversion rate: %v%%\n", eg.ConversionRate)
 }
 
+// hostalertscmd is the handler for the command `siac host alerts`. Prints the
+// alerts of the host, most severe first.
+func hostalertscmd() {
+	hag, err := httpClient.HostAlertsGet()
+	if err != nil {
+		die("Could not get host alerts:", err)
+	}
+	if len(hag.Alerts) == 0 {
+		fmt.Println("No alerts.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Severity\tCause\tFirst Seen\tAffected\tMessage")
+	for _, alert := range hag.Alerts {
+		affected := "-"
+		if alert.ObligationID != nil {
+			affected = alert.ObligationID.String()
+		} else if alert.StorageFolder != "" {
+			affected = alert.StorageFolder
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", alert.Severity, alert.Cause,
+			alert.FirstSeen.Format(time.RFC822), affected, alert.Message)
+	}
+	w.Flush()
+}
+
 // hostcontractcmd is the handler for the command `siac host contracts [type]`.
 func hostcontractcmd() {
-	cg, err := httpClient.HostContractInfoGet()
+	cg, err := httpClient.HostContractInfoFilteredGet(hostContractStatus, 0, 0, 0, 0)
 	if err != nil {
 		die("Could not fetch host contract info:", err)
 	}
@@ -424,6 +523,184 @@ func hostcontractcmd() {
 	w.Flush()
 }
 
+// hostcontractviewcmd is the handler for the command `siac host contracts view
+// [contract-id]`. Prints the details of a single contract.
+func hostcontractviewcmd(cid string) {
+	var hash crypto.Hash
+	err := hash.LoadString(cid)
+	if err != nil {
+		die("Could not parse contract id:", err)
+	}
+	hcg, err := httpClient.HostContractGet(types.FileContractID(hash))
+	if err != nil {
+		die("Could not fetch contract:", err)
+	}
+	so := hcg.Contract
+	potentialRevenue := so.PotentialDownloadRevenue.Add(so.PotentialUploadRevenue).Add(so.PotentialStorageRevenue)
+	fmt.Printf(`Contract %v
+  Status: %v
+
+  Negotiation Height: %v
+  Expiration Height:  %v
+  Proof Deadline:     %v
+
+  Contract Cost:      %v
+  Locked Collateral:  %v
+  Risked Collateral:  %v
+  Potential Revenue:  %v
+  Transaction Fees:   %v
+
+  Data Size: %v (%v sectors)
+
+  Origin Confirmed:     %v (%v transactions)
+  Revision Constructed: %v
+  Revision Confirmed:   %v (%v transactions)
+  Proof Constructed:    %v
+  Proof Confirmed:      %v
+`, so.ObligationId, strings.TrimPrefix(so.ObligationStatus, "obligation"),
+		so.NegotiationHeight, so.ExpirationHeight, so.ProofDeadLine,
+		currencyUnits(so.ContractCost), currencyUnits(so.LockedCollateral),
+		currencyUnits(so.RiskedCollateral), currencyUnits(potentialRevenue),
+		currencyUnits(so.TransactionFeesAdded),
+		filesizeUnits(int64(so.DataSize)), len(so.SectorRoots),
+		yesNo(so.OriginConfirmed), len(so.OriginTransactionSet),
+		yesNo(so.RevisionConstructed),
+		yesNo(so.RevisionConfirmed), len(so.RevisionTransactionSet),
+		yesNo(so.ProofConstructed), yesNo(so.ProofConfirmed))
+
+	fmt.Println("\nQueued Action Items:")
+	if len(so.ActionItemHeights) == 0 {
+		fmt.Println("  none")
+	}
+	for _, height := range so.ActionItemHeights {
+		fmt.Println("  height", height)
+	}
+
+	fmt.Println("\nSector Roots:")
+	for _, root := range so.SectorRoots {
+		fmt.Println(" ", root)
+	}
+}
+
+// hostmaintenancecmd is the handler for the command `siac host maintenance
+// [on|off]`. Changes or displays the maintenance mode of the host.
+func hostmaintenancecmd(cmd *cobra.Command, args []string) {
+	switch len(args) {
+	case 0:
+		hmg, err := httpClient.HostMaintenanceGet()
+		if err != nil {
+			die("Could not get maintenance status:", err)
+		}
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get current height:", err)
+		}
+		var blocksLeft types.BlockHeight
+		if hmg.LastObligationHeight > cg.Height {
+			blocksLeft = hmg.LastObligationHeight - cg.Height
+		}
+		fmt.Printf(`Maintenance Mode:       %v
+Active Obligations:     %v
+Last Obligation Height: %v (%v blocks from now)
+Locked Collateral:      %v
+Risked Collateral:      %v
+`, yesNo(hmg.MaintenanceMode), hmg.ActiveObligations, hmg.LastObligationHeight,
+			blocksLeft, currencyUnits(hmg.LockedCollateral), currencyUnits(hmg.RiskedCollateral))
+	case 1:
+		var enabled bool
+		switch strings.ToLower(args[0]) {
+		case "on":
+			enabled = true
+		case "off":
+			enabled = false
+		default:
+			cmd.UsageFunc()(cmd)
+			os.Exit(exitCodeUsage)
+		}
+		err := httpClient.HostMaintenancePost(enabled, hostMaintenanceAnnounce)
+		if err != nil {
+			die("Could not change maintenance mode:", err)
+		}
+		if enabled {
+			fmt.Println("Host is now in maintenance mode, new contracts and renewals will be refused.")
+		} else {
+			fmt.Println("Host is no longer in maintenance mode.")
+		}
+		if hostMaintenanceAnnounce {
+			fmt.Println("Host announcement submitted to network.")
+		}
+	default:
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+}
+
+// hostmetricscmd is the handler for the command `siac host metrics`.
+// Prints a summary table of the host's metrics history.
+func hostmetricscmd() {
+	var period time.Duration
+	switch hostMetricsGranularity {
+	case "hour":
+		period = time.Hour
+	case "day":
+		period = 24 * time.Hour
+	case "week":
+		period = 7 * 24 * time.Hour
+	default:
+		seconds, err := strconv.ParseInt(hostMetricsGranularity, 10, 64)
+		if err != nil || seconds <= 0 {
+			die("\"" + hostMetricsGranularity + "\" is not a valid granularity")
+		}
+		period = time.Duration(seconds) * time.Second
+	}
+	if hostMetricsPeriods <= 0 {
+		die("Number of periods must be positive")
+	}
+
+	// Request one extra period so that the first displayed period has a
+	// predecessor to compute deltas against.
+	start := time.Now().Add(-period * time.Duration(hostMetricsPeriods+1))
+	hmg, err := httpClient.HostMetricsGet(strconv.FormatInt(start.Unix(), 10), "", hostMetricsGranularity)
+	if err != nil {
+		die("Could not fetch host metrics:", err)
+	}
+	if len(hmg.Snapshots) == 0 {
+		fmt.Println("No metrics have been recorded yet.")
+		return
+	}
+
+	// totalRevenue sums up all realized revenue of a snapshot.
+	totalRevenue := func(fm modules.HostFinancialMetrics) types.Currency {
+		return fm.ContractCompensation.Add(fm.StorageRevenue).Add(fm.DownloadBandwidthRevenue).Add(fm.UploadBandwidthRevenue)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "Time\tHeight\tContracts\tRevenue\tLocked Collateral\tDownload Calls\tStorage Used\n")
+	for i, snapshot := range hmg.Snapshots {
+		// The first snapshot only serves as a baseline if there are more
+		// snapshots than requested periods.
+		if i == 0 && len(hmg.Snapshots) > hostMetricsPeriods {
+			continue
+		}
+		revenue := totalRevenue(snapshot.FinancialMetrics)
+		downloadCalls := snapshot.NetworkMetrics.DownloadCalls
+		if i > 0 {
+			prev := hmg.Snapshots[i-1]
+			if prevRevenue := totalRevenue(prev.FinancialMetrics); revenue.Cmp(prevRevenue) >= 0 {
+				revenue = revenue.Sub(prevRevenue)
+			}
+			// Network metrics are reset when the host restarts.
+			if downloadCalls >= prev.NetworkMetrics.DownloadCalls {
+				downloadCalls -= prev.NetworkMetrics.DownloadCalls
+			}
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", snapshot.Timestamp.Format("2006-01-02 15:04"), snapshot.BlockHeight,
+			snapshot.FinancialMetrics.ContractCount, currencyUnits(revenue), currencyUnits(snapshot.FinancialMetrics.LockedStorageCollateral),
+			downloadCalls, filesizeUnits(int64(snapshot.TotalStorage-snapshot.RemainingStorage)))
+	}
+	w.Flush()
+}
+
 // hostannouncecmd is the handler for the command `siac host announce`.
 // Announces yourself as a host to the network. Optionally takes an address to
 // announce as.
@@ -472,6 +749,33 @@ func hostfolderaddcmd(path, size string) {
 	fmt.Println("Added folder", path)
 }
 
+// hostfoldercancelmigrationcmd cancels the migration of a folder.
+func hostfoldercancelmigrationcmd(path string) {
+	err := httpClient.HostStorageFoldersMigrateCancelPost(abs(path))
+	if err != nil {
+		die("Could not cancel migration:", err)
+	}
+	fmt.Println("Cancelled migration of folder", path)
+}
+
+// hostfoldermigratecmd starts migrating the sectors of a folder into other
+// folders.
+func hostfoldermigratecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var destinations []string
+	for _, dest := range args[1:] {
+		destinations = append(destinations, abs(dest))
+	}
+	err := httpClient.HostStorageFoldersMigratePost(abs(args[0]), destinations)
+	if err != nil {
+		die("Could not migrate folder:", err)
+	}
+	fmt.Println("Started migration of folder", args[0])
+}
+
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
 	err := httpClient.HostStorageFoldersRemovePost(abs(path))
@@ -13,14 +13,40 @@ import (
 
 var (
 	// Flags.
-	hostContractOutputType string // output type for host contracts
-	hostVerbose            bool   // display additional host info
-	initForce              bool   // destroy and re-encrypt the wallet on init if it already exists
-	initPassword           bool   // supply a custom password when creating a wallet
-	renterAllContracts     bool   // Show all active and expired contracts
-	renterDownloadAsync    bool   // Downloads files asynchronously
-	renterListVerbose      bool   // Show additional info about uploaded files.
-	renterShowHistory      bool   // Show download history in addition to download queue.
+	consensusSnapshotHeight     uint64 // height at which a consensus snapshot is taken
+	consensusVerifyRebuild      bool   // rebuild the consensus state if it is inconsistent
+	hostContractOutputType      string // output type for host contracts
+	hostContractStatus          string // status filter for host contracts
+	gatewayBanDuration          string // duration of a ban
+	gatewayBanReason            string // reason recorded with a ban
+	hostMaintenanceAnnounce     bool   // announce the host after changing the maintenance mode
+	hostMetricsGranularity      string // granularity of the host metrics history
+	hostMetricsPeriods          int    // number of periods of host metrics to display
+	hostVerbose                 bool   // display additional host info
+	initForce                   bool   // destroy and re-encrypt the wallet on init if it already exists
+	initPassword                bool   // supply a custom password when creating a wallet
+	renterAllContracts          bool   // Show all active and expired contracts
+	renterDownloadAsync         bool   // Downloads files asynchronously
+	renterListVerbose           bool   // Show additional info about uploaded files.
+	renterShowHistory           bool   // Show download history in addition to download queue.
+	walletBumpFee               string // additional fee paid when bumping a transaction
+	walletBumpReplace           bool   // replace the transaction instead of spending its change
+	walletExportFormat          string // format of the exported transaction history
+	walletNoteTags              string // comma-separated tags attached to a transaction
+	walletScheduleCatchUp       string // catch-up policy of a scheduled payment
+	walletScheduleInterval      string // interval between scheduled payments
+	walletScheduleMaxCount      uint64 // number of scheduled payments
+	walletScheduleStartHeight   uint64 // height of the first scheduled payment
+	walletScheduleStartTime     string // time of the first scheduled payment
+	walletSendInputs            string // comma-separated output IDs that fund the transaction
+	walletSendLabel             string // label assigned to the destination address
+	walletSendNote              string // note attached to the sent transaction
+	walletTransactionsLabel     string // only show transactions with this label
+	walletTxnSignSeed           bool   // sign the transaction with keys derived from a seed
+	walletUnlockHeight          uint64 // height before which received siacoins cannot be spent
+	walletWatchRemove           bool   // remove the watch-only addresses instead of adding them
+	walletWatchUnlockConditions string // file containing unlock conditions of addresses to watch
+	walletWatchUnused           bool   // skip the rescan when adding watch-only addresses
 )
 
 var (
@@ -90,11 +116,16 @@ func main() {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostContractCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostCmd.AddCommand(hostAlertsCmd, hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostContractCmd, hostMaintenanceCmd, hostMetricsCmd, hostSectorCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderCancelMigrationCmd, hostFolderMigrateCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostContractCmd.AddCommand(hostContractViewCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostCmd.Flags().BoolVarP(&hostVerbose, "verbose", "v", false, "Display detailed host info")
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostContractCmd.Flags().StringVarP(&hostContractStatus, "status", "s", "", "Only show contracts with this status")
+	hostMaintenanceCmd.Flags().BoolVarP(&hostMaintenanceAnnounce, "announce", "a", false, "Announce the host after changing the maintenance mode")
+	hostMetricsCmd.Flags().StringVarP(&hostMetricsGranularity, "granularity", "g", "day", "Length of each period: hour, day, week or seconds")
+	hostMetricsCmd.Flags().IntVarP(&hostMetricsPeriods, "periods", "n", 7, "Number of periods to display")
 
 	root.AddCommand(hostdbCmd)
 	hostdbCmd.AddCommand(hostdbViewCmd)
@@ -105,15 +136,41 @@ func main() {
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
-		walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd,
-		walletBalanceCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBumpCmd, walletChangepasswordCmd, walletExportCmd,
+		walletInitCmd, walletInitSeedCmd, walletLabelCmd, walletLoadCmd, walletLockCmd, walletNoteCmd, walletScheduleCmd,
+		walletSeedsCmd, walletSendCmd, walletSweepCmd,
+		walletBalanceCmd, walletMultisigCmd, walletTransactionsCmd, walletTxnCmd, walletUnlockCmd, walletUnspentCmd, walletWatchCmd)
+	walletAddressCmd.Flags().Uint64VarP(&walletUnlockHeight, "unlock-height", "", 0, "Create a time-locked address that cannot be spent from before this height")
+	walletBumpCmd.Flags().StringVarP(&walletBumpFee, "fee", "", "", "Additional fee to pay, e.g. '1SC'")
+	walletBumpCmd.Flags().BoolVarP(&walletBumpReplace, "replace", "", false, "Replace the transaction instead of spending its change")
+	walletExportCmd.Flags().StringVarP(&walletExportFormat, "format", "", "csv", "Output format, either 'csv' or 'json'")
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
+	walletScheduleCmd.AddCommand(walletScheduleAddCmd, walletScheduleCancelCmd, walletScheduleHistoryCmd)
+	walletScheduleAddCmd.Flags().StringVarP(&walletScheduleCatchUp, "catch-up", "", "all", "What to do with missed payments: 'all', 'latest' or 'skip'")
+	walletScheduleAddCmd.Flags().StringVarP(&walletScheduleInterval, "interval", "", "", "Blocks, or duration if --start-time is set, between payments")
+	walletScheduleAddCmd.Flags().Uint64VarP(&walletScheduleMaxCount, "max-count", "", 0, "Number of payments to make, 0 for no limit")
+	walletScheduleAddCmd.Flags().Uint64VarP(&walletScheduleStartHeight, "start-height", "", 0, "Height of the first payment")
+	walletScheduleAddCmd.Flags().StringVarP(&walletScheduleStartTime, "start-time", "", "", "Time of the first payment, in RFC 3339 format")
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
+	walletNoteCmd.Flags().StringVarP(&walletNoteTags, "tags", "", "", "Comma-separated tags of the transaction")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendInputs, "inputs", "", "", "Comma-separated IDs of the outputs that fund the transaction")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendLabel, "label", "", "", "Label to assign to the destination address")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendNote, "note", "", "", "Note to attach to the transaction")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletNoteTags, "tags", "", "", "Comma-separated tags of the transaction")
+	walletSendSiacoinsCmd.Flags().Uint64VarP(&walletUnlockHeight, "unlock-height", "", 0, "Lock the siacoins until this height; the destination must be a wallet address")
+	walletTransactionsCmd.Flags().StringVarP(&walletTransactionsLabel, "label", "", "", "Only show transactions with this tag or address label")
+	walletMultisigCmd.AddCommand(walletMultisigCreateCmd, walletMultisigPubkeyCmd)
+	walletMultisigCreateCmd.Flags().BoolVarP(&walletWatchUnused, "unused", "", false, "Skip the blockchain rescan, for addresses that have never been used")
+	walletTxnCmd.AddCommand(walletTxnBroadcastCmd, walletTxnCreateCmd, walletTxnMergeCmd, walletTxnSignCmd)
+	walletTxnSignCmd.Flags().BoolVarP(&walletTxnSignSeed, "seed", "", false, "Sign with keys derived from a seed")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
+	walletUnspentCmd.AddCommand(walletUnspentLockCmd, walletUnspentUnlockCmd)
+	walletWatchCmd.Flags().BoolVarP(&walletWatchRemove, "remove", "", false, "Stop watching the addresses")
+	walletWatchCmd.Flags().StringVarP(&walletWatchUnlockConditions, "unlockconditions", "", "", "Watch the addresses of the unlock conditions in a JSON file")
+	walletWatchCmd.Flags().BoolVarP(&walletWatchUnused, "unused", "", false, "Skip the blockchain rescan, for addresses that have never been used")
 
 	root.AddCommand(renterCmd)
 	renterCmd.AddCommand(renterFilesDeleteCmd, renterFilesDownloadCmd,
@@ -133,9 +190,16 @@ func main() {
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 
 	root.AddCommand(gatewayCmd)
-	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd)
+	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd, gatewayBanCmd, gatewayUnbanCmd, gatewayBansCmd)
+	gatewayBanCmd.Flags().StringVarP(&gatewayBanDuration, "duration", "", "", "Duration of the ban, e.g. 12h (default: the ban duration of the gateway)")
+	gatewayBanCmd.Flags().StringVarP(&gatewayBanReason, "reason", "", "", "Reason recorded with the ban")
 
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
+	consensusSnapshotCmd.AddCommand(consensusSnapshotExportCmd)
+	consensusSnapshotExportCmd.Flags().Uint64VarP(&consensusSnapshotHeight, "height", "", 0, "Height of the snapshot, defaults to the current height")
+	consensusCmd.AddCommand(consensusVerifyCmd)
+	consensusVerifyCmd.Flags().BoolVarP(&consensusVerifyRebuild, "rebuild", "", false, "Rebuild the consensus state from the stored blocks if it is inconsistent")
 
 	root.AddCommand(bashcomplCmd)
 	root.AddCommand(mangenCmd)
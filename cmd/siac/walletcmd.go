@@ -1,17 +1,27 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"os"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/NebulousLabs/entropy-mnemonics"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/wallet"
+	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -19,8 +29,12 @@ var (
 	walletAddressCmd = &cobra.Command{
 		Use:   "address",
 		Short: "Get a new wallet address",
-		Long:  "Generate a new wallet address from the wallet's primary seed.",
-		Run:   wrap(walletaddresscmd),
+		Long: `Generate a new wallet address from the wallet's primary seed.
+
+Use --unlock-height to create a time-locked address instead. Siacoins sent to
+it are shown as the time-locked balance, and are spent like any other siacoins
+of the wallet once the unlock height has been reached.`,
+		Run: wrap(walletaddresscmd),
 	}
 
 	walletAddressesCmd = &cobra.Command{
@@ -30,6 +44,38 @@ var (
 		Run:   wrap(walletaddressescmd),
 	}
 
+	walletBumpCmd = &cobra.Command{
+		Use:   "bump [txid]",
+		Short: "Increase the fee of an unconfirmed transaction",
+		Long: `Increase the fee of an unconfirmed transaction that is stuck in the
+transaction pool. By default, a child transaction spends the change of the
+transaction and pays the additional fee. With --replace, the transaction and
+its unconfirmed parents are replaced by a transaction that pays the additional
+fee out of the change. Only siacoin transfers funded by the wallet can be
+replaced. If --fee is not set, the recommended fee is used.`,
+		Run: wrap(walletbumpcmd),
+	}
+
+	walletLabelCmd = &cobra.Command{
+		Use:   "label [address] [label]",
+		Short: "Label an address",
+		Long: `Assign a label to an address. The address does not need to belong to the
+wallet. If no label is provided, the address's label is removed. Labels are
+shown by 'siac wallet addresses' and can be used to search the transaction
+history with 'siac wallet transactions --label'.`,
+		Run: walletlabelcmd,
+	}
+
+	walletNoteCmd = &cobra.Command{
+		Use:   "note [txid] [note]",
+		Short: "Attach a note to a transaction",
+		Long: `Attach a free-text note to a transaction. Use --tags to attach a
+comma-separated list of tags, which can be used to search the transaction
+history with 'siac wallet transactions --label'. An empty note without tags
+removes the transaction's note.`,
+		Run: wrap(walletnotecmd),
+	}
+
 	walletBalanceCmd = &cobra.Command{
 		Use:   "balance",
 		Short: "View wallet balance",
@@ -63,6 +109,18 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run: wrap(walletbalancecmd),
 	}
 
+	walletExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the transaction history",
+		Long: `Export the confirmed transaction history of the wallet to stdout, as CSV
+or JSON. Each transaction is split into incoming, outgoing, fee, host
+collateral, contract funding, contract payout and siafund claim amounts, and
+classified by its role: transfer, minerpayout, filecontract, storageproof or
+siafundclaim. Running balances are included. All amounts are in hastings,
+except for siafunds.`,
+		Run: wrap(walletexportcmd),
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
@@ -136,7 +194,18 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.`,
+A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.
+
+Use --inputs to fund the transaction with specific outputs, given as a
+comma-separated list of output IDs from 'siac wallet unspent'. All of the
+selected outputs are spent, and the change is returned to the wallet.
+
+Use --note and --tags to attach a note to the transaction, and --label to
+assign a label to the destination address.
+
+Use --unlock-height to lock the siacoins until a block height, e.g. for
+vesting. The destination must then be an address of the wallet; the siacoins
+are sent to a time-locked address that is spendable by the same key.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -159,8 +228,10 @@ will be sent to your wallet.`,
 	walletTransactionsCmd = &cobra.Command{
 		Use:   "transactions",
 		Short: "View transactions",
-		Long:  "View transactions related to addresses spendable by the wallet, providing a net flow of siacoins and siafunds for each transaction",
-		Run:   wrap(wallettransactionscmd),
+		Long: `View transactions related to addresses spendable by the wallet, providing a net flow of siacoins and siafunds for each transaction.
+Notes attached to transactions are shown below them. Use --label to only show
+the transactions with a tag, or involving an address with a label.`,
+		Run: wrap(wallettransactionscmd),
 	}
 
 	walletUnlockCmd = &cobra.Command{
@@ -172,6 +243,162 @@ SIA_WALLET_PASSWORD environment variable is set, the unlock command will
 use it instead of displaying the typical interactive prompt.`,
 		Run: wrap(walletunlockcmd),
 	}
+
+	walletWatchCmd = &cobra.Command{
+		Use:   "watch [address...]",
+		Short: "Add, remove, or list watch-only addresses",
+		Long: `Track addresses without importing their keys. The wallet reports the balance
+of watch-only addresses separately and includes their transactions in the
+wallet history, but cannot spend from them. Adding addresses rescans the
+blockchain, unless --unused is set. Use --remove to stop watching addresses.
+Without arguments, the watch-only addresses are listed.
+
+Addresses can also be watched by their unlock conditions, read as a JSON array
+from the file given by --unlockconditions. The wallet can create unsigned
+transactions spending from these addresses with 'siac wallet txn create'.`,
+		Run: walletwatchcmd,
+	}
+
+	walletScheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "List scheduled payments",
+		Long: `List the scheduled payments of the wallet that have not ended yet. Use
+the subcommands to add and cancel payments, and to view the payments that
+have been made.`,
+		Run: wrap(walletschedulecmd),
+	}
+
+	walletScheduleAddCmd = &cobra.Command{
+		Use:   "add [amount] [dest]",
+		Short: "Schedule a payment",
+		Long: `Schedule a payment of amount siacoins to dest. The first payment is made at
+--start-height, or at the first block whose timestamp is at least --start-time
+(RFC 3339, e.g. 2018-06-01T12:00:00Z). If neither is set, the first payment is
+made right away. --interval repeats the payment every number of blocks, or
+every duration (e.g. 720h) if --start-time is set. --max-count limits the
+number of payments.
+
+Payments are only made while the wallet is unlocked. --catch-up decides what
+happens to payments that were missed in the meantime: 'all' makes every missed
+payment, 'latest' makes only the most recent one, and 'skip' makes none, unless
+the most recent payment is only a little late.`,
+		Run: wrap(walletscheduleaddcmd),
+	}
+
+	walletScheduleCancelCmd = &cobra.Command{
+		Use:   "cancel [id]",
+		Short: "Cancel a scheduled payment",
+		Long:  "Cancel a scheduled payment. Payments that have already been made are not affected.",
+		Run:   wrap(walletschedulecancelcmd),
+	}
+
+	walletScheduleHistoryCmd = &cobra.Command{
+		Use:   "history",
+		Short: "List the payments made on a schedule",
+		Long:  "List every run of a scheduled payment that has been made or skipped.",
+		Run:   wrap(walletschedulehistorycmd),
+	}
+
+	walletUnspentCmd = &cobra.Command{
+		Use:   "unspent",
+		Short: "List the spendable outputs of the wallet",
+		Long: `List the confirmed siacoin and siafund outputs of the wallet, with their
+address, value, confirmation height and whether they are locked. Locked outputs
+are not used to fund transactions unless they are selected explicitly with
+'siac wallet send siacoins --inputs'.`,
+		Run: wrap(walletunspentcmd),
+	}
+
+	walletUnspentLockCmd = &cobra.Command{
+		Use:   "lock [id...]",
+		Short: "Lock outputs",
+		Long:  "Prevent the wallet from funding transactions with the provided outputs.",
+		Run:   walletunspentlockcmd,
+	}
+
+	walletUnspentUnlockCmd = &cobra.Command{
+		Use:   "unlock [id...]",
+		Short: "Unlock outputs",
+		Long:  "Allow the wallet to fund transactions with the provided outputs again.",
+		Run:   walletunspentunlockcmd,
+	}
+
+	walletTxnCmd = &cobra.Command{
+		Use:   "txn",
+		Short: "Create, sign and broadcast transactions offline",
+		Long: `Create transactions on a node watching the addresses of an offline seed, sign
+them on a machine that is not connected to the network, and broadcast the
+signed transactions. Transactions are exchanged as JSON files.`,
+		// Run field is not set, as the txn command itself is not a valid command.
+		// A subcommand must be provided.
+	}
+
+	walletTxnBroadcastCmd = &cobra.Command{
+		Use:   "broadcast [file]",
+		Short: "Broadcast a signed transaction",
+		Long:  "Submit a transaction signed by 'siac wallet txn sign' to the transaction pool.",
+		Run:   wrap(wallettxnbroadcastcmd),
+	}
+
+	walletTxnCreateCmd = &cobra.Command{
+		Use:   "create [amount] [dest]",
+		Short: "Create an unsigned transaction",
+		Long: `Create an unsigned transaction sending siacoins to an address, funded by the
+watch-only addresses whose unlock conditions are known to the wallet. The
+transaction is written to stdout as JSON. 'amount' can be specified in units,
+e.g. 1.23KS. If no unit is supplied, hastings will be assumed.`,
+		Run: wrap(wallettxncreatecmd),
+	}
+
+	walletTxnMergeCmd = &cobra.Command{
+		Use:   "merge [file...]",
+		Short: "Merge the signatures of partially signed transactions",
+		Long: `Combine copies of the same transaction signed by different cosigners of a
+multisig address. No daemon is contacted. The merged transaction is written to
+stdout as JSON, and can be broadcast once every input has the signatures it
+requires.`,
+		Run: wallettxnmergecmd,
+	}
+
+	walletTxnSignCmd = &cobra.Command{
+		Use:   "sign [file]",
+		Short: "Sign an unsigned transaction",
+		Long: `Sign a transaction created by 'siac wallet txn create' with the keys of the
+wallet. With --seed, the transaction is signed using keys derived from a seed
+instead; the seed is requested interactively and no daemon is contacted, so the
+command can be run on an offline machine. For multisig addresses, only the
+signatures of the available keys are added. The signed transaction is written
+to stdout as JSON.`,
+		Run: wrap(wallettxnsigncmd),
+	}
+
+	walletMultisigCmd = &cobra.Command{
+		Use:   "multisig",
+		Short: "Create multisig addresses",
+		Long: `Create M-of-N multisig addresses from the public keys of the cosigners.
+Transactions spending from multisig addresses are created with
+'siac wallet txn create', signed by each cosigner with 'siac wallet txn sign',
+and combined with 'siac wallet txn merge'.`,
+		// Run field is not set, as the multisig command itself is not a valid
+		// command. A subcommand must be provided.
+	}
+
+	walletMultisigCreateCmd = &cobra.Command{
+		Use:   "create [required] [publickey...]",
+		Short: "Add a multisig address to the wallet",
+		Long: `Add an address that requires 'required' signatures from the provided public
+keys. Public keys are given as 'ed25519:<hex>', as printed by
+'siac wallet multisig pubkey'. The wallet tracks the outputs of the address as
+watch-only outputs. The blockchain is rescanned, unless --unused is set.`,
+		Run: walletmultisigcreatecmd,
+	}
+
+	walletMultisigPubkeyCmd = &cobra.Command{
+		Use:   "pubkey",
+		Short: "Get a new public key to share with cosigners",
+		Long:  "Generate a new public key from the wallet's primary seed, for use in a multisig address.",
+		Run:   wrap(walletmultisigpubkeycmd),
+	}
 )
 
 const askPasswordText = "We need to encrypt the new data using the current wallet password, please provide: "
@@ -206,6 +433,14 @@ func confirmPassword(prev string) error {
 // walletaddresscmd fetches a new address from the wallet that will be able to
 // receive coins.
 func walletaddresscmd() {
+	if walletUnlockHeight != 0 {
+		addr, err := httpClient.WalletAddressTimelockedGet(types.BlockHeight(walletUnlockHeight))
+		if err != nil {
+			die("Could not generate new address:", err)
+		}
+		fmt.Printf("Created new address: %s\nSiacoins sent to this address cannot be spent before height %v.\n", addr.Address, walletUnlockHeight)
+		return
+	}
 	addr, err := httpClient.WalletAddressGet()
 	if err != nil {
 		die("Could not generate new address:", err)
@@ -220,7 +455,11 @@ func walletaddressescmd() {
 		die("Failed to fetch addresses:", err)
 	}
 	for _, addr := range addrs.Addresses {
-		fmt.Println(addr)
+		if label, ok := addrs.Labels[addr.String()]; ok {
+			fmt.Println(addr, label)
+		} else {
+			fmt.Println(addr)
+		}
 	}
 }
 
@@ -243,6 +482,77 @@ func walletchangepasswordcmd() {
 	fmt.Println("Password changed successfully.")
 }
 
+// walletbumpcmd increases the fee of an unconfirmed transaction.
+func walletbumpcmd(txidStr string) {
+	var h crypto.Hash
+	if err := h.LoadString(txidStr); err != nil {
+		die("Could not parse transaction ID:", err)
+	}
+	var fee types.Currency
+	if walletBumpFee != "" {
+		hastings, err := parseCurrency(walletBumpFee)
+		if err != nil {
+			die("Could not parse fee:", err)
+		}
+		if _, err := fmt.Sscan(hastings, &fee); err != nil {
+			die("Failed to parse fee", err)
+		}
+	}
+	wbp, err := httpClient.WalletBumpPost(types.TransactionID(h), fee, walletBumpReplace)
+	if err != nil {
+		die("Could not bump transaction fee:", err)
+	}
+	fmt.Println("Submitted transaction set:")
+	for _, txid := range wbp.TransactionIDs {
+		fmt.Println("\t", txid)
+	}
+}
+
+// walletexportcmd exports the transaction history of the wallet.
+func walletexportcmd() {
+	if walletExportFormat != "csv" && walletExportFormat != "json" {
+		die("Unknown format:", walletExportFormat)
+	}
+	weg, err := httpClient.WalletExportGet(0, math.MaxUint64)
+	if err != nil {
+		die("Could not export transaction history:", err)
+	}
+	if walletExportFormat == "json" {
+		printJSON(weg.Transactions)
+		return
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"transactionid", "confirmationheight", "confirmationtime", "role",
+		"incoming", "outgoing", "fee", "hostcollateral", "contractfunding", "contractpayout", "siafundclaim", "balance",
+		"siafundsincoming", "siafundsoutgoing", "siafundbalance", "note", "tags"})
+	for _, et := range weg.Transactions {
+		w.Write([]string{
+			et.TransactionID.String(),
+			fmt.Sprint(et.ConfirmationHeight),
+			time.Unix(int64(et.ConfirmationTimestamp), 0).UTC().Format(time.RFC3339),
+			et.Role,
+			et.Incoming.String(),
+			et.Outgoing.String(),
+			et.Fee.String(),
+			et.HostCollateral.String(),
+			et.ContractFunding.String(),
+			et.ContractPayout.String(),
+			et.SiafundClaim.String(),
+			et.Balance.String(),
+			et.SiafundsIncoming.String(),
+			et.SiafundsOutgoing.String(),
+			et.SiafundBalance.String(),
+			et.Note,
+			strings.Join(et.Tags, ";"),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		die("Could not write transaction history:", err)
+	}
+}
+
 // walletinitcmd encrypts the wallet with the given password
 func walletinitcmd() {
 	var password string
@@ -381,11 +691,56 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash)
-	if err != nil {
-		die("Could not send siacoins:", err)
+	note := modules.TransactionNote{Note: walletSendNote, Tags: parseTags(walletNoteTags)}
+	if err := note.Validate(); err != nil {
+		die("Invalid note:", err)
+	}
+	if err := modules.ValidateAddressLabel(walletSendLabel); err != nil {
+		die("Invalid label:", err)
+	}
+	var wsp api.WalletSiacoinsPOST
+	if walletUnlockHeight != 0 {
+		if walletSendInputs != "" {
+			die("--unlock-height cannot be combined with --inputs")
+		}
+		wsp, err = httpClient.WalletSiacoinsTimelockedPost(value, hash, types.BlockHeight(walletUnlockHeight), note, walletSendLabel)
+		if err != nil {
+			die("Could not send siacoins:", err)
+		}
+	} else if walletSendInputs != "" {
+		var inputs []types.SiacoinOutputID
+		for _, id := range parseOutputIDs(strings.Split(walletSendInputs, ",")) {
+			inputs = append(inputs, types.SiacoinOutputID(id))
+		}
+		wsp, err = httpClient.WalletSiacoinsInputsPost([]types.SiacoinOutput{{Value: value, UnlockHash: hash}}, inputs)
+		if err != nil {
+			die("Could not send siacoins:", err)
+		}
+		// The note is attached to the transaction that sends the coins.
+		if note.Note != "" || len(note.Tags) != 0 {
+			err = httpClient.WalletTransactionNotePost(wsp.TransactionIDs[len(wsp.TransactionIDs)-1], note)
+			if err != nil {
+				die("Siacoins were sent, but the note could not be saved:", err)
+			}
+		}
+		if walletSendLabel != "" {
+			if err = httpClient.WalletLabelPost(hash, walletSendLabel); err != nil {
+				die("Siacoins were sent, but the label could not be saved:", err)
+			}
+		}
+	} else {
+		wsp, err = httpClient.WalletSiacoinsNotePost(value, hash, note, walletSendLabel)
+		if err != nil {
+			die("Could not send siacoins:", err)
+		}
 	}
 	fmt.Printf("Sent %s hastings to %s\n", hastings, dest)
+	if wsp.MetadataError != "" {
+		fmt.Println("Warning: the siacoins were sent, but", wsp.MetadataError)
+	}
+	if walletUnlockHeight != 0 {
+		fmt.Printf("The siacoins cannot be spent before height %v.\n", walletUnlockHeight)
+	}
 }
 
 // walletsendsiafundscmd sends siafunds to a destination address.
@@ -448,6 +803,18 @@ Estimated Fee:       %v / KB
 `, encStatus, status.Height, currencyUnits(status.ConfirmedSiacoinBalance), delta,
 		status.ConfirmedSiacoinBalance, status.SiafundBalance, status.SiacoinClaimBalance,
 		fees.Maximum.Mul64(1e3).HumanString())
+
+	if !status.TimelockedSiacoinBalance.IsZero() {
+		fmt.Printf(`
+Time-locked Balance: %v
+`, currencyUnits(status.TimelockedSiacoinBalance))
+	}
+	if !status.WatchOnlySiacoinBalance.IsZero() || !status.WatchOnlySiafundBalance.IsZero() {
+		fmt.Printf(`
+Watch-only Balance:  %v
+Watch-only Siafunds: %v SF
+`, currencyUnits(status.WatchOnlySiacoinBalance), status.WatchOnlySiafundBalance)
+	}
 }
 
 // walletsweepcmd sweeps coins and funds from a seed.
@@ -467,7 +834,7 @@ func walletsweepcmd() {
 // wallettransactionscmd lists all of the transactions related to the wallet,
 // providing a net flow of siacoins and siafunds for each.
 func wallettransactionscmd() {
-	wtg, err := httpClient.WalletTransactionsGet(0, math.MaxInt64)
+	wtg, err := httpClient.WalletTransactionsLabelGet(0, math.MaxInt64, walletTransactionsLabel)
 	if err != nil {
 		die("Could not fetch transaction history:", err)
 	}
@@ -523,6 +890,13 @@ func wallettransactionscmd() {
 		} else {
 			fmt.Printf("-%14v SF\n", outgoingSiafunds.Sub(incomingSiafunds))
 		}
+		if note, ok := wtg.Notes[txn.TransactionID.String()]; ok {
+			fmt.Printf("%24v%v", "", note.Note)
+			if len(note.Tags) != 0 {
+				fmt.Printf(" [%v]", strings.Join(note.Tags, ", "))
+			}
+			fmt.Println()
+		}
 	}
 }
 
@@ -550,3 +924,418 @@ func walletunlockcmd() {
 		die("Could not unlock wallet:", err)
 	}
 }
+
+// walletwatchcmd adds, removes or lists the watch-only addresses of the
+// wallet.
+func walletwatchcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 && walletWatchUnlockConditions == "" {
+		if walletWatchRemove {
+			cmd.UsageFunc()(cmd)
+			os.Exit(exitCodeUsage)
+		}
+		wwg, err := httpClient.WalletWatchGet()
+		if err != nil {
+			die("Could not get watch-only addresses:", err)
+		}
+		if len(wwg.Addresses) == 0 {
+			fmt.Println("No watch-only addresses.")
+			return
+		}
+		for _, addr := range wwg.Addresses {
+			fmt.Println(addr.Address)
+		}
+		return
+	}
+
+	if walletWatchUnlockConditions != "" {
+		if walletWatchRemove || len(args) != 0 {
+			cmd.UsageFunc()(cmd)
+			os.Exit(exitCodeUsage)
+		}
+		var ucs []types.UnlockConditions
+		if err := readJSONFile(walletWatchUnlockConditions, &ucs); err != nil {
+			die("Could not read unlock conditions:", err)
+		}
+		if err := httpClient.WalletWatchUnlockConditionsPost(ucs, walletWatchUnused); err != nil {
+			die("Could not add watch-only addresses:", err)
+		}
+		fmt.Printf("Added %v watch-only addresses\n", len(ucs))
+		return
+	}
+
+	addrs := make([]types.UnlockHash, len(args))
+	for i, arg := range args {
+		if err := addrs[i].LoadString(arg); err != nil {
+			die("Could not parse address:", err)
+		}
+	}
+	if walletWatchRemove {
+		if err := httpClient.WalletWatchRemovePost(addrs); err != nil {
+			die("Could not remove watch-only addresses:", err)
+		}
+		fmt.Printf("Removed %v watch-only addresses\n", len(addrs))
+		return
+	}
+	if err := httpClient.WalletWatchAddPost(addrs, walletWatchUnused); err != nil {
+		die("Could not add watch-only addresses:", err)
+	}
+	fmt.Printf("Added %v watch-only addresses\n", len(addrs))
+}
+
+// readJSONFile decodes the JSON contents of a file into obj.
+func readJSONFile(filename string, obj interface{}) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(obj)
+}
+
+// printJSON writes obj to stdout as indented JSON.
+func printJSON(obj interface{}) {
+	b, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		die("Could not encode transaction:", err)
+	}
+	fmt.Println(string(b))
+}
+
+// wallettxncreatecmd creates an unsigned transaction that sends siacoins from
+// the watch-only addresses of the wallet.
+func wallettxncreatecmd(amount, dest string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var hash types.UnlockHash
+	if _, err := fmt.Sscan(dest, &hash); err != nil {
+		die("Failed to parse destination address", err)
+	}
+	wutp, err := httpClient.WalletUnsignedTransactionPost([]types.SiacoinOutput{{Value: value, UnlockHash: hash}})
+	if err != nil {
+		die("Could not create transaction:", err)
+	}
+	printJSON(wutp.Transaction)
+}
+
+// wallettxnsigncmd signs an unsigned transaction with the keys of the wallet,
+// or with keys derived from a seed without contacting the daemon.
+func wallettxnsigncmd(filename string) {
+	var utxn modules.UnsignedTransaction
+	if err := readJSONFile(filename, &utxn); err != nil {
+		die("Could not read transaction:", err)
+	}
+	if !walletTxnSignSeed {
+		wsp, err := httpClient.WalletSignPost(utxn)
+		if err != nil {
+			die("Could not sign transaction:", err)
+		}
+		printJSON(wsp.Transaction)
+		return
+	}
+	seedStr, err := passwordPrompt("Seed: ")
+	if err != nil {
+		die("Reading seed failed:", err)
+	}
+	seed, err := modules.StringToSeed(seedStr, mnemonics.English)
+	if err != nil {
+		die("Invalid seed:", err)
+	}
+	if err := wallet.SignUnsignedTransaction(&utxn, seed); err != nil {
+		die("Could not sign transaction:", err)
+	}
+	printJSON(utxn)
+}
+
+// wallettxnbroadcastcmd submits a signed transaction to the transaction pool.
+func wallettxnbroadcastcmd(filename string) {
+	var utxn modules.UnsignedTransaction
+	if err := readJSONFile(filename, &utxn); err != nil {
+		die("Could not read transaction:", err)
+	}
+	if len(utxn.Inputs) != 0 {
+		die("Transaction has not been signed, run 'siac wallet txn sign' first")
+	}
+	if err := httpClient.TransactionPoolRawPost(utxn.Transaction, utxn.Parents); err != nil {
+		die("Could not broadcast transaction:", err)
+	}
+	fmt.Println("Broadcast transaction", utxn.Transaction.ID())
+}
+
+// wallettxnmergecmd merges the signatures of partially signed copies of a
+// transaction. It does not contact the daemon.
+func wallettxnmergecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	utxns := make([]modules.UnsignedTransaction, len(args))
+	for i, filename := range args {
+		if err := readJSONFile(filename, &utxns[i]); err != nil {
+			die("Could not read transaction:", err)
+		}
+	}
+	merged, err := wallet.MergeUnsignedTransactions(utxns...)
+	if err != nil {
+		die("Could not merge transactions:", err)
+	}
+	printJSON(merged)
+}
+
+// walletmultisigcreatecmd adds a multisig address to the wallet.
+func walletmultisigcreatecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	required, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		die("Could not parse number of required signatures:", err)
+	}
+	publicKeys := make([]types.SiaPublicKey, len(args)-1)
+	for i, arg := range args[1:] {
+		publicKeys[i].LoadString(arg)
+		if publicKeys[i].Key == nil {
+			die("Could not parse public key:", arg)
+		}
+	}
+	wmp, err := httpClient.WalletMultisigPost(publicKeys, required, walletWatchUnused)
+	if err != nil {
+		die("Could not add multisig address:", err)
+	}
+	fmt.Printf("Added %v-of-%v multisig address %v\n", required, len(publicKeys), wmp.Address)
+}
+
+// walletmultisigpubkeycmd prints a new public key of the wallet.
+func walletmultisigpubkeycmd() {
+	wpkg, err := httpClient.WalletPublicKeyGet()
+	if err != nil {
+		die("Could not generate new public key:", err)
+	}
+	fmt.Println(wpkg.PublicKey.String())
+}
+
+// parseOutputIDs parses a list of hex-encoded output IDs.
+func parseOutputIDs(args []string) []types.OutputID {
+	ids := make([]types.OutputID, len(args))
+	for i, arg := range args {
+		var h crypto.Hash
+		if err := h.LoadString(strings.TrimSpace(arg)); err != nil {
+			die("Could not parse output ID:", err)
+		}
+		ids[i] = types.OutputID(h)
+	}
+	return ids
+}
+
+// walletunspentcmd lists the spendable outputs of the wallet.
+func walletunspentcmd() {
+	wug, err := httpClient.WalletUnspentGet()
+	if err != nil {
+		die("Could not get unspent outputs:", err)
+	}
+	if len(wug.Outputs) == 0 {
+		fmt.Println("No unspent outputs.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAddress\tValue\tHeight\tLocked\tUnlock Height")
+	for _, o := range wug.Outputs {
+		value := currencyUnits(o.Value)
+		if o.FundType == types.SpecifierSiafundOutput {
+			value = o.Value.String() + " SF"
+		}
+		unlockHeight := "-"
+		if o.UnlockHeight != 0 {
+			unlockHeight = fmt.Sprint(o.UnlockHeight)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", o.ID, o.UnlockHash, value, o.ConfirmationHeight, yesNo(o.Locked), unlockHeight)
+	}
+	w.Flush()
+}
+
+// walletschedulecmd lists the scheduled payments of the wallet.
+func walletschedulecmd() {
+	wsg, err := httpClient.WalletScheduleGet()
+	if err != nil {
+		die("Could not get scheduled payments:", err)
+	}
+	if len(wsg.Payments) == 0 {
+		fmt.Println("No scheduled payments.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDestination\tAmount\tStart\tInterval\tRuns\tCatch-up\tLast Error")
+	for _, sp := range wsg.Payments {
+		start := fmt.Sprintf("height %v", sp.StartHeight)
+		interval := fmt.Sprintf("%v blocks", sp.Interval)
+		if sp.StartTime != 0 {
+			start = time.Unix(int64(sp.StartTime), 0).Format(time.RFC3339)
+			interval = (time.Duration(sp.Interval) * time.Second).String()
+		}
+		if sp.Interval == 0 {
+			interval = "once"
+		}
+		runs := fmt.Sprint(sp.Runs)
+		if sp.MaxCount != 0 {
+			runs += fmt.Sprintf("/%v", sp.MaxCount)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", sp.ID, sp.Destination, currencyUnits(sp.Amount), start, interval, runs, sp.CatchUp, sp.LastError)
+	}
+	w.Flush()
+}
+
+// walletscheduleaddcmd schedules a payment.
+func walletscheduleaddcmd(amount, dest string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	sp := modules.ScheduledPayment{
+		StartHeight: types.BlockHeight(walletScheduleStartHeight),
+		MaxCount:    walletScheduleMaxCount,
+		CatchUp:     walletScheduleCatchUp,
+	}
+	if _, err := fmt.Sscan(hastings, &sp.Amount); err != nil {
+		die("Failed to parse amount", err)
+	}
+	if err := sp.Destination.LoadString(dest); err != nil {
+		die("Could not parse destination address:", err)
+	}
+	if walletScheduleStartTime != "" {
+		start, err := time.Parse(time.RFC3339, walletScheduleStartTime)
+		if err != nil {
+			die("Could not parse start time:", err)
+		}
+		sp.StartTime = types.Timestamp(start.Unix())
+	}
+	if walletScheduleInterval != "" {
+		if sp.StartTime != 0 {
+			interval, err := time.ParseDuration(walletScheduleInterval)
+			if err != nil {
+				die("Could not parse interval:", err)
+			}
+			sp.Interval = uint64(interval / time.Second)
+		} else if sp.Interval, err = strconv.ParseUint(walletScheduleInterval, 10, 64); err != nil {
+			die("Could not parse interval:", err)
+		}
+	}
+	wsp, err := httpClient.WalletSchedulePost(sp)
+	if err != nil {
+		die("Could not schedule payment:", err)
+	}
+	fmt.Println("Scheduled payment", wsp.Payment.ID)
+}
+
+// walletschedulecancelcmd cancels a scheduled payment.
+func walletschedulecancelcmd(idStr string) {
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		die("Could not parse ID:", err)
+	}
+	if err := httpClient.WalletScheduleCancelPost(id); err != nil {
+		die("Could not cancel scheduled payment:", err)
+	}
+	fmt.Println("Cancelled scheduled payment", id)
+}
+
+// walletschedulehistorycmd lists the runs of scheduled payments that have
+// been made or skipped.
+func walletschedulehistorycmd() {
+	wshg, err := httpClient.WalletScheduleHistoryGet()
+	if err != nil {
+		die("Could not get scheduled payment history:", err)
+	}
+	if len(wshg.Executions) == 0 {
+		fmt.Println("No scheduled payments have been made.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tRun\tHeight\tDestination\tAmount\tTransaction")
+	for _, spe := range wshg.Executions {
+		txn := spe.TransactionID.String()
+		if spe.Skipped {
+			txn = "skipped"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", spe.PaymentID, spe.Run, spe.Height, spe.Destination, currencyUnits(spe.Amount), txn)
+	}
+	w.Flush()
+}
+
+// walletlabelcmd assigns a label to an address, or removes it.
+func walletlabelcmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var addr types.UnlockHash
+	if err := addr.LoadString(args[0]); err != nil {
+		die("Could not parse address:", err)
+	}
+	var label string
+	if len(args) == 2 {
+		label = args[1]
+	}
+	if err := httpClient.WalletLabelPost(addr, label); err != nil {
+		die("Could not set label:", err)
+	}
+	if label == "" {
+		fmt.Println("Removed the label of", addr)
+	} else {
+		fmt.Printf("Labeled %v as %q\n", addr, label)
+	}
+}
+
+// walletnotecmd attaches a note to a transaction.
+func walletnotecmd(txidStr, note string) {
+	var h crypto.Hash
+	if err := h.LoadString(txidStr); err != nil {
+		die("Could not parse transaction ID:", err)
+	}
+	txid := types.TransactionID(h)
+	err := httpClient.WalletTransactionNotePost(txid, modules.TransactionNote{Note: note, Tags: parseTags(walletNoteTags)})
+	if err != nil {
+		die("Could not set note:", err)
+	}
+	fmt.Println("Saved the note of", txid)
+}
+
+// parseTags splits a comma-separated list of tags, ignoring empty tags.
+func parseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// walletunspentlockcmd locks outputs of the wallet.
+func walletunspentlockcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	if err := httpClient.WalletUnspentLockPost(parseOutputIDs(args)); err != nil {
+		die("Could not lock outputs:", err)
+	}
+	fmt.Printf("Locked %v outputs\n", len(args))
+}
+
+// walletunspentunlockcmd unlocks outputs of the wallet.
+func walletunspentunlockcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	if err := httpClient.WalletUnspentUnlockPost(parseOutputIDs(args)); err != nil {
+		die("Could not unlock outputs:", err)
+	}
+	fmt.Printf("Unlocked %v outputs\n", len(args))
+}
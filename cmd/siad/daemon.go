@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -13,7 +14,9 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
 	"github.com/NebulousLabs/Sia/profile"
+	"github.com/NebulousLabs/Sia/types"
 	mnemonics "github.com/NebulousLabs/entropy-mnemonics"
 
 	"github.com/spf13/cobra"
@@ -52,6 +55,140 @@ func verifyAPISecurity(config Config) error {
 	return nil
 }
 
+// verifyBootstrapSnapshot checks that the consensus snapshot flags are
+// consistent with each other and with the enabled modules.
+func verifyBootstrapSnapshot(config Config) error {
+	if config.Siad.BootstrapSnapshot == "" {
+		if config.Siad.BootstrapSnapshotHash != "" {
+			return errors.New("--bootstrap-snapshot-hash can only be used with --bootstrap-snapshot")
+		}
+		return nil
+	}
+	var hash crypto.Hash
+	if err := hash.LoadString(config.Siad.BootstrapSnapshotHash); err != nil {
+		return errors.New("--bootstrap-snapshot requires the trusted hash of the snapshot to be passed with --bootstrap-snapshot-hash")
+	}
+	if !strings.Contains(config.Siad.Modules, "c") {
+		return errors.New("--bootstrap-snapshot requires the consensus module")
+	}
+	if strings.Contains(config.Siad.Modules, "e") {
+		return errors.New("the explorer module cannot be used with a consensus set bootstrapped from a snapshot")
+	}
+	return nil
+}
+
+// loadNetwork reads the network definition file passed to --network and
+// checks that its bootstrap peers are valid addresses.
+func loadNetwork(filename string) (types.Network, error) {
+	n, err := types.LoadNetwork(filename)
+	if err != nil {
+		return types.Network{}, fmt.Errorf("unable to load --network file: %v", err)
+	}
+	for _, addr := range n.BootstrapPeers {
+		if err := modules.NetAddress(addr).IsValid(); err != nil {
+			return types.Network{}, fmt.Errorf("invalid bootstrap peer %q in --network file: %v", addr, err)
+		}
+	}
+	return n, nil
+}
+
+// verifyNetwork checks that the network definition file passed to --network
+// can be loaded.
+func verifyNetwork(config Config) error {
+	if config.Siad.Network == "" {
+		return nil
+	}
+	_, err := loadNetwork(config.Siad.Network)
+	return err
+}
+
+// setNetwork replaces the built-in network parameters and bootstrap peers
+// with those of the network definition file passed to --network. It must be
+// called before any module is loaded.
+func setNetwork(config Config) error {
+	if config.Siad.Network == "" {
+		return nil
+	}
+	n, err := loadNetwork(config.Siad.Network)
+	if err != nil {
+		return err
+	}
+	types.SetNetwork(n)
+	modules.BootstrapPeers = nil
+	for _, addr := range n.BootstrapPeers {
+		modules.BootstrapPeers = append(modules.BootstrapPeers, modules.NetAddress(addr))
+	}
+	fmt.Printf("Using network %q with genesis block %v\n", n.Name, types.GenesisID)
+	return nil
+}
+
+// verifyProxy checks that the address passed to --proxy is valid.
+func verifyProxy(config Config) error {
+	if config.Siad.Proxy == "" {
+		return nil
+	}
+	if err := modules.NetAddress(config.Siad.Proxy).IsStdValid(); err != nil {
+		return fmt.Errorf("invalid --proxy address: %v", err)
+	}
+	return nil
+}
+
+// setProxy routes the outbound connections of the modules through the SOCKS5
+// proxy passed to --proxy. It must be called before any module is loaded.
+func setProxy(config Config) error {
+	if config.Siad.Proxy == "" {
+		return nil
+	}
+	if err := modules.SetProxy(modules.NetAddress(config.Siad.Proxy)); err != nil {
+		return err
+	}
+	fmt.Println("Routing outbound connections through SOCKS5 proxy", config.Siad.Proxy)
+	return nil
+}
+
+// verifyConsensus checks the consensus database for corruption if
+// --verify-consensus was passed. It must be called before the consensus set is
+// loaded.
+func verifyConsensus(config Config) error {
+	if !config.Siad.VerifyConsensus {
+		return nil
+	}
+	fmt.Println("Verifying consensus database...")
+	report, err := consensus.Verify(filepath.Join(config.Siad.SiaDir, modules.ConsensusDir))
+	if os.IsNotExist(err) {
+		fmt.Println("No consensus database to verify.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(report.Inconsistencies) != 0 {
+		for _, inc := range report.Inconsistencies {
+			fmt.Println(" ", inc)
+		}
+		return fmt.Errorf("consensus database has %v inconsistencies, run 'siac consensus verify --rebuild' to rebuild the consensus state", len(report.Inconsistencies))
+	}
+	fmt.Printf("Consensus database is consistent up to height %v\n", report.Height)
+	return nil
+}
+
+// verifyPruneConsensus checks that the consensus pruning flag is consistent
+// with the enabled modules.
+func verifyPruneConsensus(config Config) error {
+	if config.Siad.PruneConsensus == 0 {
+		return nil
+	}
+	if types.BlockHeight(config.Siad.PruneConsensus) < consensus.MinPruneKeepBlocks {
+		return fmt.Errorf("--prune-consensus must keep at least %v blocks", consensus.MinPruneKeepBlocks)
+	}
+	if !strings.Contains(config.Siad.Modules, "c") {
+		return errors.New("--prune-consensus requires the consensus module")
+	}
+	if strings.Contains(config.Siad.Modules, "e") {
+		return errors.New("the explorer module cannot be used with a pruned consensus set")
+	}
+	return nil
+}
+
 // processNetAddr adds a ':' to a bare integer, so that it is a proper port
 // number.
 func processNetAddr(addr string) string {
@@ -103,7 +240,11 @@ func processConfig(config Config) (Config, error) {
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
 	config.Siad.Profile, err2 = processProfileFlags(config.Siad.Profile)
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	err4 := verifyBootstrapSnapshot(config)
+	err5 := verifyPruneConsensus(config)
+	err6 := verifyNetwork(config)
+	err7 := verifyProxy(config)
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5, err6, err7}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
@@ -174,6 +315,17 @@ func startDaemon(config Config) (err error) {
 		os.Exit(1)
 	}()
 
+	// Replace the built-in network parameters before any module reads them.
+	if err := setNetwork(config); err != nil {
+		return err
+	}
+	if err := setProxy(config); err != nil {
+		return err
+	}
+	if err := verifyConsensus(config); err != nil {
+		return err
+	}
+
 	// Print a startup message.
 	fmt.Println("Loading...")
 	loadStart := time.Now()
@@ -2,6 +2,9 @@ package main
 
 import (
 	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules/consensus"
 )
 
 // TestUnitProcessNetAddr probes the 'processNetAddr' function.
@@ -200,3 +203,86 @@ func TestVerifyAPISecurity(t *testing.T) {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
 }
+
+// TestVerifyBootstrapSnapshot checks that the verifyBootstrapSnapshot function
+// requires a trusted hash and the consensus module when bootstrapping from a
+// snapshot, and rejects the explorer module.
+func TestVerifyBootstrapSnapshot(t *testing.T) {
+	hash := crypto.Hash{1}.String()
+	tests := []struct {
+		snapshot string
+		hash     string
+		modules  string
+		valid    bool
+	}{
+		{"", "", "cgtw", true},
+		{"", hash, "cgtw", false},
+		{"snapshot", hash, "cgtw", true},
+		{"snapshot", "", "cgtw", false},
+		{"snapshot", "bad", "cgtw", false},
+		{"snapshot", hash, "g", false},
+		{"snapshot", hash, "cge", false},
+	}
+	for _, test := range tests {
+		var config Config
+		config.Siad.BootstrapSnapshot = test.snapshot
+		config.Siad.BootstrapSnapshotHash = test.hash
+		config.Siad.Modules = test.modules
+		err := verifyBootstrapSnapshot(config)
+		if (err == nil) != test.valid {
+			t.Errorf("verifyBootstrapSnapshot(%q, %q, %q) returned %v", test.snapshot, test.hash, test.modules, err)
+		}
+	}
+}
+
+// TestVerifyPruneConsensus checks that the consensus pruning flag is only
+// accepted together with compatible modules.
+func TestVerifyPruneConsensus(t *testing.T) {
+	keep := uint64(consensus.MinPruneKeepBlocks)
+	tests := []struct {
+		prune   uint64
+		modules string
+		valid   bool
+	}{
+		{0, "cgtw", true},
+		{0, "cge", true},
+		{keep, "cgtw", true},
+		{keep - 1, "cgtw", false},
+		{keep, "g", false},
+		{keep, "cge", false},
+	}
+	for _, test := range tests {
+		var config Config
+		config.Siad.PruneConsensus = test.prune
+		config.Siad.Modules = test.modules
+		err := verifyPruneConsensus(config)
+		if (err == nil) != test.valid {
+			t.Errorf("verifyPruneConsensus(%v, %q) returned %v", test.prune, test.modules, err)
+		}
+	}
+}
+
+// TestVerifyProxy checks that the verifyProxy function rejects invalid proxy
+// addresses.
+func TestVerifyProxy(t *testing.T) {
+	tests := []struct {
+		proxy string
+		valid bool
+	}{
+		{"", true},
+		{"127.0.0.1:9050", true},
+		{"localhost:9050", true},
+		{"proxy.example.com:1080", true},
+		{"127.0.0.1", false},
+		{"127.0.0.1:0", false},
+		{"foo", false},
+	}
+	for _, test := range tests {
+		var config Config
+		config.Siad.Proxy = test.proxy
+		err := verifyProxy(config)
+		if (err == nil) != test.valid {
+			t.Errorf("verifyProxy(%q) returned %v", test.proxy, err)
+		}
+	}
+}
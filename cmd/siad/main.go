@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -37,10 +38,19 @@ type Config struct {
 		HostAddr     string
 		AllowAPIBind bool
 
-		Modules           string
-		NoBootstrap       bool
-		RequiredUserAgent string
-		AuthenticateAPI   bool
+		Modules            string
+		NoBootstrap        bool
+		RequiredUserAgent  string
+		AuthenticateAPI    bool
+		GatewayBanDuration time.Duration
+
+		BootstrapSnapshot     string
+		BootstrapSnapshotHash string
+		PruneConsensus        uint64
+		VerifyConsensus       bool
+
+		Network string
+		Proxy   string
 
 		Profile    string
 		ProfileDir string
@@ -163,7 +173,14 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().StringVarP(&globalConfig.Siad.BootstrapSnapshot, "bootstrap-snapshot", "", "", "create the consensus set from a snapshot file instead of syncing from genesis")
+	root.Flags().StringVarP(&globalConfig.Siad.BootstrapSnapshotHash, "bootstrap-snapshot-hash", "", "", "trusted hash of the snapshot passed to --bootstrap-snapshot")
+	root.Flags().Uint64VarP(&globalConfig.Siad.PruneConsensus, "prune-consensus", "", 0, "discard the bodies of consensus blocks older than this many blocks (0 disables pruning)")
+	root.Flags().BoolVarP(&globalConfig.Siad.VerifyConsensus, "verify-consensus", "", false, "check the consensus database for corruption before loading the modules")
+	root.Flags().StringVarP(&globalConfig.Siad.Network, "network", "", "", "run a private network defined by a JSON network definition file")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
+	root.Flags().DurationVarP(&globalConfig.Siad.GatewayBanDuration, "gateway-ban-duration", "", 0, "how long misbehaving peers are banned (0 uses the default of 24h)")
+	root.Flags().StringVarP(&globalConfig.Siad.Proxy, "proxy", "", "", "route outbound connections through a SOCKS5 proxy, e.g. 127.0.0.1:9050 for Tor")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "cghrtw", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", false, "enable API password protection")
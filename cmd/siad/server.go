@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/consensus"
 	"github.com/NebulousLabs/Sia/modules/explorer"
@@ -190,7 +191,7 @@ func fetchLatestRelease() (githubRelease, error) {
 		return githubRelease{}, err
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := modules.HTTPClient(0).Do(req)
 	if err != nil {
 		return githubRelease{}, err
 	}
@@ -236,7 +237,7 @@ func updateToRelease(release githubRelease) error {
 	}
 
 	// download release archive
-	resp, err := http.Get(downloadURL)
+	resp, err := modules.HTTPClient(0).Get(downloadURL)
 	if err != nil {
 		return err
 	}
@@ -491,17 +492,38 @@ func (srv *Server) loadModules() error {
 	if strings.Contains(srv.config.Siad.Modules, "g") {
 		i++
 		fmt.Printf("(%d/%d) Loading gateway...\n", i, len(srv.config.Siad.Modules))
-		g, err = gateway.New(srv.config.Siad.RPCaddr, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.GatewayDir))
+		gw, err := gateway.New(srv.config.Siad.RPCaddr, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.GatewayDir))
 		if err != nil {
 			return err
 		}
+		if srv.config.Siad.GatewayBanDuration > 0 {
+			gw.SetBanDuration(srv.config.Siad.GatewayBanDuration)
+		}
+		g = gw
 		srv.moduleClosers = append(srv.moduleClosers, moduleCloser{name: "gateway", Closer: g})
 	}
 	var cs modules.ConsensusSet
 	if strings.Contains(srv.config.Siad.Modules, "c") {
 		i++
 		fmt.Printf("(%d/%d) Loading consensus...\n", i, len(srv.config.Siad.Modules))
-		cs, err = consensus.New(g, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir))
+		consensusDir := filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir)
+		if srv.config.Siad.BootstrapSnapshot != "" {
+			var hash crypto.Hash
+			if err := hash.LoadString(srv.config.Siad.BootstrapSnapshotHash); err != nil {
+				return err
+			}
+			fmt.Println("Importing consensus snapshot...")
+			snapshot, err := consensus.ImportSnapshot(consensusDir, srv.config.Siad.BootstrapSnapshot, hash)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Consensus set starts at snapshot height %v\n", snapshot.Height)
+		}
+		if srv.config.Siad.PruneConsensus != 0 {
+			cs, err = consensus.NewPruned(g, !srv.config.Siad.NoBootstrap, consensusDir, types.BlockHeight(srv.config.Siad.PruneConsensus))
+		} else {
+			cs, err = consensus.New(g, !srv.config.Siad.NoBootstrap, consensusDir)
+		}
 		if err != nil {
 			return err
 		}
@@ -39,6 +39,18 @@ var (
 	// target.
 	ErrBlockUnsolved = errors.New("block does not meet target")
 
+	// ErrConsensusPruned indicates that a consensus set subscription would
+	// require blocks that the consensus set has discarded because it is
+	// running in pruned mode. Subscribers that need a full rescan, starting
+	// from ConsensusChangeBeginning, cannot be used with a pruned consensus
+	// set.
+	ErrConsensusPruned = errors.New("consensus set has pruned the blocks required by the subscription; a full rescan requires an unpruned consensus set")
+
+	// ErrConsensusObjectNotFound indicates that an output or file contract is
+	// not part of the consensus set, either because it never existed or
+	// because it has been spent or resolved.
+	ErrConsensusObjectNotFound = errors.New("object not found in the consensus set")
+
 	// ErrInvalidConsensusChangeID indicates that ConsensusSetPersistSubscribe
 	// was called with a consensus change id that is not recognized. Most
 	// commonly, this means that the consensus set was deleted or replaced and
@@ -124,6 +136,14 @@ type (
 		// peers.
 		Synced bool
 
+		// SnapshotHeight is only set for the first consensus change of a
+		// consensus set that was bootstrapped from a snapshot. The only
+		// applied block of that change is the block at SnapshotHeight, and
+		// the diffs create the entire consensus state at that height.
+		// Subscribers that track the height should treat the height before
+		// the change as SnapshotHeight-1.
+		SnapshotHeight types.BlockHeight
+
 		// TryTransactionSet is an unlocked version of
 		// ConsensusSet.TryTransactionSet. This allows the TryTransactionSet
 		// function to be called by a subscriber during
@@ -175,6 +195,16 @@ type (
 		Adjusted  types.Currency
 	}
 
+	// A ConsensusSnapshot describes a snapshot of the consensus set at a
+	// height of the current path. A new consensus set can be bootstrapped
+	// from a snapshot instead of processing all of the blocks up to that
+	// height.
+	ConsensusSnapshot struct {
+		Height  types.BlockHeight `json:"height"`
+		BlockID types.BlockID     `json:"blockid"`
+		Hash    crypto.Hash       `json:"hash"`
+	}
+
 	// A ConsensusSet accepts blocks and builds an understanding of network
 	// consensus.
 	ConsensusSet interface {
@@ -214,6 +244,19 @@ type (
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// ExportSnapshot writes a snapshot of the consensus set at the given
+		// height of the current path to a file.
+		ExportSnapshot(filename string, height types.BlockHeight) (ConsensusSnapshot, error)
+
+		// Snapshot returns the snapshot that the consensus set was
+		// bootstrapped from, and false if it was not bootstrapped from a
+		// snapshot.
+		Snapshot() (ConsensusSnapshot, bool)
+
+		// FileContract returns the file contract with the given id, if it is
+		// part of the consensus set.
+		FileContract(types.FileContractID) (types.FileContract, error)
+
 		// Flush will cause the consensus set to finish all in-progress
 		// routines.
 		Flush() error
@@ -234,6 +277,22 @@ type (
 		// risk of mining invalid blocks.
 		MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool)
 
+		// SiacoinOutput returns the unspent siacoin output with the given id,
+		// and the height at which it matures if it is a delayed output.
+		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, types.BlockHeight, error)
+
+		// SiafundOutput returns the unspent siafund output with the given id.
+		SiafundOutput(types.SiafundOutputID) (types.SiafundOutput, error)
+
+		// SiafundPool returns the number of siacoins that have been paid to
+		// the siafund pool.
+		SiafundPool() types.Currency
+
+		// TransactionProof returns a transaction of a block in the current
+		// path, together with a proof that the transaction is part of the
+		// block, and the header and height of the block.
+		TransactionProof(types.BlockID, types.TransactionID) (types.Transaction, types.TransactionProof, types.BlockHeader, types.BlockHeight, error)
+
 		// StorageProofSegment returns the segment to be used in the storage proof for
 		// a given file contract.
 		StorageProofSegment(types.FileContractID) (uint64, error)
@@ -58,6 +58,9 @@ func (cs *ConsensusSet) validateHeaderAndBlock(tx dbTx, b types.Block, id types.
 	if err != nil {
 		return nil, err
 	}
+	if err := cs.checkForkHeight(parent.Height); err != nil {
+		return nil, err
+	}
 	// Check that the timestamp is not too far in the past to be acceptable.
 	minTimestamp := cs.blockRuleHelper.minimumValidChildTimestamp(blockMap, parent)
 
@@ -106,6 +109,9 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 	if err != nil {
 		return err
 	}
+	if err := cs.checkForkHeight(parent.Height); err != nil {
+		return err
+	}
 
 	// Check that the target of the new block is sufficient.
 	if !checkHeaderTarget(h, parent.ChildTarget) {
@@ -307,6 +313,12 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	for i := 0; i < len(changes); i++ {
 		cs.updateSubscribers(changes[i])
 	}
+	// Discard the bodies of blocks that can no longer be reverted. This
+	// happens after the subscribers have been updated, because computing their
+	// consensus changes requires the blocks.
+	if err := cs.pruneBlocks(); err != nil {
+		cs.log.Println("WARN: unable to prune the consensus set:", err)
+	}
 	return chainExtended, nil
 }
 
@@ -111,32 +111,38 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 		return err
 	}
 
+	// Add the genesis block to the block structures - checksum must be taken
+	// after pushing the genesis block into the path.
+	applyGenesisState(tx, &cs.blockRoot)
+	if build.DEBUG {
+		cs.blockRoot.ConsensusChecksum = consensusChecksum(tx)
+	}
+	addBlockMap(tx, &cs.blockRoot)
+	return nil
+}
+
+// applyGenesisState creates the consensus state of the genesis block, which is
+// not created by a diff set, and adds the genesis block to the current path.
+// The block height must be set to -1 beforehand.
+func applyGenesisState(tx *bolt.Tx, root *processedBlock) {
 	// Set the siafund pool to 0.
 	setSiafundPool(tx, types.NewCurrency64(0))
 
 	// Update the siafund output diffs map for the genesis block on disk. This
 	// needs to happen between the database being opened/initilized and the
 	// consensus set hash being calculated
-	for _, sfod := range cs.blockRoot.SiafundOutputDiffs {
+	for _, sfod := range root.SiafundOutputDiffs {
 		commitSiafundOutputDiff(tx, sfod, modules.DiffApply)
 	}
 
 	// Add the miner payout from the genesis block to the delayed siacoin
 	// outputs - unspendable, as the unlock hash is blank.
 	createDSCOBucket(tx, types.MaturityDelay)
-	addDSCO(tx, types.MaturityDelay, cs.blockRoot.Block.MinerPayoutID(0), types.SiacoinOutput{
+	addDSCO(tx, types.MaturityDelay, root.Block.MinerPayoutID(0), types.SiacoinOutput{
 		Value:      types.CalculateCoinbase(0),
 		UnlockHash: types.UnlockHash{},
 	})
-
-	// Add the genesis block to the block structures - checksum must be taken
-	// after pushing the genesis block into the path.
-	pushPath(tx, cs.blockRoot.Block.ID())
-	if build.DEBUG {
-		cs.blockRoot.ConsensusChecksum = consensusChecksum(tx)
-	}
-	addBlockMap(tx, &cs.blockRoot)
-	return nil
+	pushPath(tx, root.Block.ID())
 }
 
 // blockHeight returns the height of the blockchain.
@@ -81,6 +81,17 @@ type ConsensusSet struct {
 	// whether the consensus set is synced with the network.
 	synced bool
 
+	// snapshot is the snapshot that the consensus set was bootstrapped from.
+	// It is empty if the consensus set was built from the genesis block.
+	snapshot modules.ConsensusSnapshot
+
+	// pruneHeight is the height of the oldest block of the current path that
+	// the consensus set is able to revert. It is zero unless the consensus set
+	// has been pruned. pruneKeep is the number of recent blocks that a pruned
+	// consensus set keeps, or zero if pruning is disabled.
+	pruneHeight types.BlockHeight
+	pruneKeep   types.BlockHeight
+
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       marshaler
 	blockRuleHelper blockRuleHelper
@@ -150,6 +161,11 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 		return nil, err
 	}
 
+	// Tell peers that old blocks are not available.
+	if cs.floorHeight() > 0 {
+		gateway.SetPruned(true)
+	}
+
 	go func() {
 		// Sync with the network. Don't sync if we are testing because
 		// typically we don't have any mock peers to synchronize with in
@@ -176,11 +192,15 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 		gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
 		gateway.RegisterRPC("RelayHeader", cs.threadedRPCRelayHeader)
 		gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+		gateway.RegisterRPC("SendHeaders", cs.rpcSendHeaders)
+		gateway.RegisterRPC("SendTxnProof", cs.rpcSendTxnProof)
 		gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
 		cs.tg.OnStop(func() {
 			cs.gateway.UnregisterRPC("SendBlocks")
 			cs.gateway.UnregisterRPC("RelayHeader")
 			cs.gateway.UnregisterRPC("SendBlk")
+			cs.gateway.UnregisterRPC("SendHeaders")
+			cs.gateway.UnregisterRPC("SendTxnProof")
 			cs.gateway.UnregisterConnectCall("SendBlocks")
 		})
 
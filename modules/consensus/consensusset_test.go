@@ -92,8 +92,12 @@ func (cst *consensusSetTester) mineSiacoins() {
 // blankConsensusSetTester creates a consensusSetTester that has only the
 // genesis block.
 func blankConsensusSetTester(name string, deps modules.Dependencies) (*consensusSetTester, error) {
-	testdir := build.TempDir(modules.ConsensusDir, name)
+	return openConsensusSetTester(build.TempDir(modules.ConsensusDir, name), deps)
+}
 
+// openConsensusSetTester creates a consensusSetTester using the persist
+// directories in testdir.
+func openConsensusSetTester(testdir string, deps modules.Dependencies) (*consensusSetTester, error) {
 	// Create modules.
 	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
 	if err != nil {
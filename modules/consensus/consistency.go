@@ -14,6 +14,15 @@ import (
 	"github.com/coreos/bbolt"
 )
 
+// stateChecks are the checks of the consistency of the consensus state at the
+// current height.
+var stateChecks = []func(*bolt.Tx) error{
+	checkDSCOs,
+	checkSiacoinCount,
+	checkSiafundCount,
+	checkFileContracts,
+}
+
 // manageErr handles an error detected by the consistency checks.
 func manageErr(tx *bolt.Tx, err error) {
 	markInconsistency(tx)
@@ -78,7 +87,7 @@ func consensusChecksum(tx *bolt.Tx) crypto.Hash {
 
 // checkSiacoinCount checks that the number of siacoins countable within the
 // consensus set equal the expected number of siacoins for the block height.
-func checkSiacoinCount(tx *bolt.Tx) {
+func checkSiacoinCount(tx *bolt.Tx) error {
 	// Iterate through all the buckets looking for the delayed siacoin output
 	// buckets, and check that they are for the correct heights.
 	var dscoSiacoins types.Currency
@@ -89,22 +98,18 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		}
 
 		// Sum up the delayed outputs in this bucket.
-		err := b.ForEach(func(_, delayedOutput []byte) error {
+		return b.ForEach(func(_, delayedOutput []byte) error {
 			var sco types.SiacoinOutput
 			err := encoding.Unmarshal(delayedOutput, &sco)
 			if err != nil {
-				manageErr(tx, err)
+				return err
 			}
 			dscoSiacoins = dscoSiacoins.Add(sco.Value)
 			return nil
 		})
-		if err != nil {
-			return err
-		}
-		return nil
 	})
 	if err != nil {
-		manageErr(tx, err)
+		return err
 	}
 
 	// Add all of the siacoin outputs.
@@ -113,13 +118,13 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		var sco types.SiacoinOutput
 		err := encoding.Unmarshal(scoBytes, &sco)
 		if err != nil {
-			manageErr(tx, err)
+			return err
 		}
 		scoSiacoins = scoSiacoins.Add(sco.Value)
 		return nil
 	})
 	if err != nil {
-		manageErr(tx, err)
+		return err
 	}
 
 	// Add all of the payouts from file contracts.
@@ -128,7 +133,7 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		var fc types.FileContract
 		err := encoding.Unmarshal(fcBytes, &fc)
 		if err != nil {
-			manageErr(tx, err)
+			return err
 		}
 		var fcCoins types.Currency
 		for _, output := range fc.ValidProofOutputs {
@@ -138,7 +143,7 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		return nil
 	})
 	if err != nil {
-		manageErr(tx, err)
+		return err
 	}
 
 	// Add all of the siafund claims.
@@ -147,7 +152,7 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		var sfo types.SiafundOutput
 		err := encoding.Unmarshal(sfoBytes, &sfo)
 		if err != nil {
-			manageErr(tx, err)
+			return err
 		}
 
 		coinsPerFund := getSiafundPool(tx).Sub(sfo.ClaimStart)
@@ -156,7 +161,7 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		return nil
 	})
 	if err != nil {
-		manageErr(tx, err)
+		return err
 	}
 
 	expectedSiacoins := types.CalculateNumSiacoins(blockHeight(tx))
@@ -168,34 +173,36 @@ func checkSiacoinCount(tx *bolt.Tx) {
 		} else {
 			diagnostics += fmt.Sprintf("total: %v\nexpected: %v\n expected is bigger: %v", totalSiacoins, expectedSiacoins, totalSiacoins.Sub(expectedSiacoins))
 		}
-		manageErr(tx, errors.New(diagnostics))
+		return errors.New(diagnostics)
 	}
+	return nil
 }
 
 // checkSiafundCount checks that the number of siafunds countable within the
 // consensus set equal the expected number of siafunds for the block height.
-func checkSiafundCount(tx *bolt.Tx) {
+func checkSiafundCount(tx *bolt.Tx) error {
 	var total types.Currency
 	err := tx.Bucket(SiafundOutputs).ForEach(func(_, siafundOutputBytes []byte) error {
 		var sfo types.SiafundOutput
 		err := encoding.Unmarshal(siafundOutputBytes, &sfo)
 		if err != nil {
-			manageErr(tx, err)
+			return err
 		}
 		total = total.Add(sfo.Value)
 		return nil
 	})
 	if err != nil {
-		manageErr(tx, err)
+		return err
 	}
 	if !total.Equals(types.SiafundCount) {
-		manageErr(tx, errors.New("wrong number of siafunds in the consensus set"))
+		return errors.New("wrong number of siafunds in the consensus set")
 	}
+	return nil
 }
 
 // checkDSCOs scans the sets of delayed siacoin outputs and checks for
 // consistency.
-func checkDSCOs(tx *bolt.Tx) {
+func checkDSCOs(tx *bolt.Tx) error {
 	// Create a map to track which delayed siacoin output maps exist, and
 	// another map to track which ids have appeared in the dsco set.
 	dscoTracker := make(map[types.BlockHeight]struct{})
@@ -214,7 +221,7 @@ func checkDSCOs(tx *bolt.Tx) {
 		var height types.BlockHeight
 		err := encoding.Unmarshal(name[len(prefixDSCO):], &height)
 		if err != nil {
-			manageErr(tx, err)
+			return err
 		}
 		_, exists := dscoTracker[height]
 		if exists {
@@ -237,7 +244,7 @@ func checkDSCOs(tx *bolt.Tx) {
 			var sco types.SiacoinOutput
 			err := encoding.Unmarshal(delayedOutput, &sco)
 			if err != nil {
-				manageErr(tx, err)
+				return err
 			}
 			total = total.Add(sco.Value)
 			return nil
@@ -255,7 +262,7 @@ func checkDSCOs(tx *bolt.Tx) {
 		return nil
 	})
 	if err != nil {
-		manageErr(tx, err)
+		return err
 	}
 
 	// Check that all of the correct heights are represented.
@@ -267,13 +274,58 @@ func checkDSCOs(tx *bolt.Tx) {
 		}
 		_, exists := dscoTracker[i]
 		if !exists {
-			manageErr(tx, errors.New("missing a dsco bucket"))
+			return errors.New("missing a dsco bucket")
 		}
 		expectedBuckets++
 	}
 	if len(dscoTracker) != expectedBuckets {
-		manageErr(tx, errors.New("too many dsco buckets"))
+		return errors.New("too many dsco buckets")
+	}
+	return nil
+}
+
+// checkFileContracts checks that every file contract has an expiration at the
+// end of its proof window, that every expiration belongs to a file contract,
+// and that no contract has expired before the current height.
+func checkFileContracts(tx *bolt.Tx) error {
+	var contracts int
+	err := tx.Bucket(FileContracts).ForEach(func(id, fcBytes []byte) error {
+		var fc types.FileContract
+		err := encoding.Unmarshal(fcBytes, &fc)
+		if err != nil {
+			return err
+		}
+		if fc.WindowEnd <= blockHeight(tx) {
+			return errors.New("file contract has expired but is still in the consensus set")
+		}
+		expirations := tx.Bucket(append(prefixFCEX, encoding.Marshal(fc.WindowEnd)...))
+		if expirations == nil || expirations.Get(id) == nil {
+			return errors.New("file contract has no expiration")
+		}
+		contracts++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var expirations int
+	err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefixFCEX) {
+			return nil
+		}
+		return b.ForEach(func(_, _ []byte) error {
+			expirations++
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if contracts != expirations {
+		return errors.New("number of file contract expirations does not match the number of file contracts")
 	}
+	return nil
 }
 
 // checkRevertApply reverts the most recent block, checking to see that the
@@ -282,8 +334,9 @@ func checkDSCOs(tx *bolt.Tx) {
 // original consensus set hash.
 func (cs *ConsensusSet) checkRevertApply(tx *bolt.Tx) {
 	current := currentProcessedBlock(tx)
-	// Don't perform the check if this block is the genesis block.
-	if current.Block.ID() == cs.blockRoot.Block.ID() {
+	// Don't perform the check if this block is the genesis block, or a block
+	// that the consensus set is not able to revert.
+	if current.Block.ID() == cs.blockRoot.Block.ID() || current.Height <= cs.floorHeight() {
 		return
 	}
 
@@ -318,9 +371,11 @@ func (cs *ConsensusSet) checkConsistency(tx *bolt.Tx) {
 	}
 
 	cs.checkingConsistency = true
-	checkDSCOs(tx)
-	checkSiacoinCount(tx)
-	checkSiafundCount(tx)
+	for _, check := range stateChecks {
+		if err := check(tx); err != nil {
+			manageErr(tx, err)
+		}
+	}
 	if build.DEBUG {
 		cs.checkRevertApply(tx)
 	}
@@ -336,6 +391,3 @@ func (cs *ConsensusSet) maybeCheckConsistency(tx *bolt.Tx) {
 		cs.checkConsistency(tx)
 	}
 }
-
-// TODO: Check that every file contract has an expiration too, and that the
-// number of file contracts + the number of expirations is equal.
@@ -68,7 +68,7 @@ var (
 // however we do not use the child block deltas because that would allow the
 // child block to influence the target of the following block, which makes abuse
 // easier in selfish mining scenarios.
-func (cs *ConsensusSet) childTargetOak(parentTotalTime int64, parentTotalTarget, currentTarget types.Target, parentHeight types.BlockHeight, parentTimestamp types.Timestamp) types.Target {
+func childTargetOak(parentTotalTime int64, parentTotalTarget, currentTarget types.Target, parentHeight types.BlockHeight, parentTimestamp types.Timestamp) types.Target {
 	// Determine the delta of the current total time vs. the desired total time.
 	// The desired total time is the difference between the genesis block
 	// timestamp and the current block timestamp.
@@ -151,10 +151,9 @@ func (cs *ConsensusSet) getBlockTotals(tx *bolt.Tx, id types.BlockID) (totalTime
 	return
 }
 
-// storeBlockTotals computes the new total time and total target for the current
-// block and stores that new time in the database. It also returns the new
-// totals.
-func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockHeight, currentBlockID types.BlockID, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target, err error) {
+// blockTotals computes the new total time and total target for the current
+// block from the totals of its parent.
+func blockTotals(currentHeight types.BlockHeight, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target) {
 	// Reset the prevTotalTime to a delta of zero just before the hardfork.
 	//
 	// NOTICE: This code is broken, an incorrectly executed hardfork. The
@@ -179,6 +178,14 @@ func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockH
 	// delta.
 	newTotalTime = (prevTotalTime * types.OakDecayNum / types.OakDecayDenom) + (int64(currentTimestamp) - int64(parentTimestamp))
 	newTotalTarget = prevTotalTarget.MulDifficulty(big.NewRat(types.OakDecayNum, types.OakDecayDenom)).AddDifficulties(targetOfCurrentBlock)
+	return newTotalTime, newTotalTarget
+}
+
+// storeBlockTotals computes the new total time and total target for the current
+// block and stores that new time in the database. It also returns the new
+// totals.
+func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockHeight, currentBlockID types.BlockID, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target, err error) {
+	newTotalTime, newTotalTarget = blockTotals(currentHeight, prevTotalTime, parentTimestamp, currentTimestamp, prevTotalTarget, targetOfCurrentBlock)
 
 	// Store the new total time and total target in the database at the
 	// appropriate id.
@@ -22,7 +22,6 @@ func TestChildTargetOak(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer cst.Close()
-	cs := cst.cs
 	// NOTE: Test must not be run in parallel.
 	//
 	// Set the constants to match the real-network constants, and then make sure
@@ -54,7 +53,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTarget := types.RootTarget
 	// newTarget should match the root target, as the hashrate and blocktime all
 	// match the existing target - there should be no reason for adjustment.
-	newTarget := cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget := childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// New target should be barely moving. Some imprecision may cause slight
 	// adjustments, but the total difference should be less than 0.01%.
 	maxNewTarget := parentTarget.MulDifficulty(big.NewRat(10e3, 10001))
@@ -78,7 +77,7 @@ func TestChildTargetOak(t *testing.T) {
 	// Set the target to types.RootTarget, causing the max difficulty adjustment
 	// clamp to be in effect.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) <= 0 {
 		t.Error("Difficulty did not decrease in response to increased total time")
 	}
@@ -100,7 +99,7 @@ func TestChildTargetOak(t *testing.T) {
 	// Set the target to types.RootTarget, causing the max difficulty adjustment
 	// clamp to be in effect.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) >= 0 {
 		t.Error("Difficulty did not increase in response to decreased total time")
 	}
@@ -121,7 +120,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) + 5e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty decreased, but not by the max amount.
 	minNewTarget = parentTarget.MulDifficulty(types.OakMaxDrop)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) <= 0 {
@@ -142,7 +141,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) - 5e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty increased, but not by the max amount.
 	maxNewTarget = parentTarget.MulDifficulty(types.OakMaxRise)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) >= 0 {
@@ -163,7 +162,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) + 10e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty decreased, but not by the max amount.
 	minNewTarget = parentTarget.MulDifficulty(types.OakMaxDrop)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) <= 0 {
@@ -184,7 +183,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) - 10e3
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// Check that the difficulty increased, but not by the max amount.
 	maxNewTarget = parentTarget.MulDifficulty(types.OakMaxRise)
 	if parentTarget.Difficulty().Cmp(newTarget.Difficulty()) >= 0 {
@@ -205,7 +204,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) + 500e6
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget.MulDifficulty(big.NewRat(1, types.OakMaxBlockShift))
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// New target should be barely moving. Some imprecision may cause slight
 	// adjustments, but the total difference should be less than 0.01%.
 	maxNewTarget = parentTarget.MulDifficulty(big.NewRat(10e3, 10001))
@@ -228,7 +227,7 @@ func TestChildTargetOak(t *testing.T) {
 	parentTimestamp = types.GenesisTimestamp + types.Timestamp((types.BlockFrequency * parentHeight)) - 500e6
 	// Set the target to types.RootTarget.
 	parentTarget = types.RootTarget.MulDifficulty(big.NewRat(types.OakMaxBlockShift, 1))
-	newTarget = cs.childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
+	newTarget = childTargetOak(parentTotalTime, parentTotalTarget, parentTarget, parentHeight, parentTimestamp)
 	// New target should be barely moving. Some imprecision may cause slight
 	// adjustments, but the total difference should be less than 0.01%.
 	maxNewTarget = parentTarget.MulDifficulty(big.NewRat(10e3, 10001))
@@ -0,0 +1,722 @@
+package consensus
+
+// headers.go implements the headers-first initial blockchain download. The
+// header chain of a peer is fetched with the SendHeaders RPC and validated
+// using only the proof of work, the timestamps and the difficulty adjustment,
+// which is cheap compared to validating the blocks. The blocks of the valid
+// headers are then downloaded in parallel from all outbound peers with the
+// SendBlk RPC, buffered, and given to the consensus set in order.
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/errors"
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// ibdMinPeerDownloads is the number of blocks that must be downloaded
+	// from a peer before its throughput is compared to the other peers.
+	ibdMinPeerDownloads = 3
+
+	// ibdSlowPeerFactor is the factor by which the throughput of a peer can
+	// be lower than the throughput of the fastest peer before the peer is no
+	// longer used to download blocks.
+	ibdSlowPeerFactor = 4
+)
+
+var (
+	errBadBlockBody    = errors.New("peer sent a block that does not match the requested header")
+	errBadHeaders      = errors.New("peer sent an invalid header chain")
+	errNoDownloadPeers = errors.New("no peers are left to download blocks from")
+
+	// ibdDownloadWindow is the maximum number of blocks past the next block
+	// to be accepted that are downloaded during the headers-first initial
+	// blockchain download. It limits the number of out-of-order blocks that
+	// are buffered in memory.
+	ibdDownloadWindow = build.Select(build.Var{
+		Standard: 100,
+		Dev:      50,
+		Testing:  5,
+	}).(int)
+)
+
+type (
+	// A HeaderState is a header that has been validated using only the proof
+	// of work, the timestamps and the difficulty adjustment, together with
+	// the values that are needed to validate its children. It allows the
+	// header chain to be followed without a consensus set, for example by
+	// light clients.
+	HeaderState struct {
+		Header      types.BlockHeader
+		Height      types.BlockHeight
+		ChildTarget types.Target
+		Depth       types.Target
+		TotalTime   int64
+		TotalTarget types.Target
+	}
+
+	// headerChain is a chain of validated headers that extends a block of the
+	// consensus set.
+	headerChain struct {
+		tip HeaderState
+
+		// timestamps holds the timestamps of the blocks from height
+		// timestampsHeight up to the tip of the chain. They are used to
+		// compute the minimum timestamp and the pre-Oak difficulty
+		// adjustment.
+		timestamps       []types.Timestamp
+		timestampsHeight types.BlockHeight
+
+		// ids are the ids of the headers in the chain whose blocks are not in
+		// the consensus set yet.
+		ids []types.BlockID
+	}
+
+	// downloadPeer tracks the throughput of a peer that blocks are
+	// downloaded from.
+	downloadPeer struct {
+		addr      modules.NetAddress
+		bytes     uint64
+		duration  time.Duration
+		downloads int
+		dropped   bool
+	}
+
+	// blockDownloader downloads blocks in parallel from a set of peers and
+	// accepts them in order. Peers that fail to send a block, or that are
+	// much slower than the other peers, are dropped for the lifetime of the
+	// downloader.
+	blockDownloader struct {
+		cs    *ConsensusSet
+		peers []*downloadPeer
+
+		// ids are the ids of the blocks of the current download, in order.
+		// next is the index of the next block that has not been requested
+		// yet, and retry holds the indices of blocks that need to be
+		// requested again. blocks buffers the downloaded blocks until they
+		// are accepted, and accepted is the index of the next block to be
+		// accepted.
+		ids      []types.BlockID
+		next     int
+		retry    []int
+		blocks   map[int]types.Block
+		accepted int
+		workers  int
+		err      error
+
+		mu   sync.Mutex
+		cond *sync.Cond
+	}
+)
+
+// throughput returns the number of bytes per second that the peer has
+// delivered.
+func (dp *downloadPeer) throughput() float64 {
+	if dp.duration <= 0 {
+		return 0
+	}
+	return float64(dp.bytes) / dp.duration.Seconds()
+}
+
+// newHeaderChain returns a header chain that starts at the block with the
+// given id.
+func (cs *ConsensusSet) newHeaderChain(tx *bolt.Tx, id types.BlockID) (*headerChain, error) {
+	pb, err := getBlockMap(tx, id)
+	if err != nil {
+		return nil, errOrphan
+	}
+	if err := cs.checkForkHeight(pb.Height); err != nil {
+		return nil, err
+	}
+	hc := &headerChain{
+		tip: HeaderState{
+			Header:      pb.Block.Header(),
+			Height:      pb.Height,
+			ChildTarget: pb.ChildTarget,
+			Depth:       pb.Depth,
+		},
+	}
+	hc.tip.TotalTime, hc.tip.TotalTarget = cs.getBlockTotals(tx, id)
+
+	// Collect the timestamps of the ancestors that are needed to validate
+	// the children of the block. A consensus set that was bootstrapped from a
+	// snapshot only has the most recent blocks before the snapshot, which is
+	// sufficient because snapshots are taken after the Oak hardfork.
+	window := types.TargetWindow
+	if types.BlockHeight(types.MedianTimestampWindow) > window {
+		window = types.BlockHeight(types.MedianTimestampWindow)
+	}
+	blockMap := tx.Bucket(BlockMap)
+	timestamps := []types.Timestamp{pb.Block.Timestamp}
+	parent := pb.Block.ParentID
+	for i := types.BlockHeight(0); i < window && parent != (types.BlockID{}); i++ {
+		parentBytes := blockMap.Get(parent[:])
+		if parentBytes == nil {
+			// The headers of pruned blocks are kept.
+			h, exists := getPrunedHeader(tx, parent)
+			if !exists {
+				break
+			}
+			parent = h.ParentID
+			timestamps = append(timestamps, h.Timestamp)
+			continue
+		}
+		copy(parent[:], parentBytes[:32])
+		timestamps = append(timestamps, types.Timestamp(encoding.DecUint64(parentBytes[40:48])))
+	}
+	hc.timestampsHeight = pb.Height - types.BlockHeight(len(timestamps)-1)
+	for i := len(timestamps) - 1; i >= 0; i-- {
+		hc.timestamps = append(hc.timestamps, timestamps[i])
+	}
+	return hc, nil
+}
+
+// timestamp returns the timestamp of the block of the header chain at the
+// given height. If the chain does not go back far enough, the earliest
+// timestamp is returned, which is the timestamp of the genesis block.
+func (hc *headerChain) timestamp(height types.BlockHeight) types.Timestamp {
+	if height < hc.timestampsHeight {
+		return hc.timestamps[0]
+	}
+	return hc.timestamps[height-hc.timestampsHeight]
+}
+
+// extendHeaderChain validates a header and adds it to the tip of the header
+// chain. Headers of blocks that are known to be invalid are rejected, the
+// rest of the validation is performed by ChildHeaderState.
+func (cs *ConsensusSet) extendHeaderChain(tx *bolt.Tx, hc *headerChain, h types.BlockHeader) error {
+	id := h.ID()
+	if _, exists := cs.dosBlocks[id]; exists {
+		return errDoSBlock
+	}
+	child, err := ChildHeaderState(hc.tip, hc.timestamp, h)
+	if err != nil {
+		return err
+	}
+	hc.timestamps = append(hc.timestamps, h.Timestamp)
+	hc.tip = child
+
+	// Blocks that are already known do not need to be downloaded. Because
+	// the parents of known blocks are known as well, the known blocks are
+	// always at the start of the chain.
+	if tx.Bucket(BlockMap).Get(id[:]) == nil {
+		hc.ids = append(hc.ids, id)
+	}
+	return nil
+}
+
+// GenesisHeaderState returns the header state of the genesis block.
+func GenesisHeaderState() HeaderState {
+	hs := HeaderState{
+		Header:      types.GenesisBlock.Header(),
+		ChildTarget: types.RootTarget,
+		Depth:       types.RootDepth,
+	}
+	hs.TotalTime, hs.TotalTarget = blockTotals(0, 0, types.GenesisTimestamp, types.GenesisTimestamp, types.RootDepth, types.RootTarget)
+	return hs
+}
+
+// ChildHeaderState validates a header that extends the header of 'parent' and
+// returns its header state. The header is checked to be a child of the
+// parent, to meet the target of the parent, and to have a valid timestamp,
+// which is the same validation that validateHeader performs for headers of
+// the consensus set. 'timestamp' must return the timestamp of the ancestor of
+// the header at the given height, which is at most the height of the parent.
+func ChildHeaderState(parent HeaderState, timestamp func(types.BlockHeight) types.Timestamp, h types.BlockHeader) (HeaderState, error) {
+	if h.ParentID != parent.Header.ID() {
+		return HeaderState{}, errNonLinearChain
+	}
+	if !checkHeaderTarget(h, parent.ChildTarget) {
+		return HeaderState{}, modules.ErrBlockUnsolved
+	}
+	if h.Timestamp < minimumValidChildTimestamp(parent.Height, timestamp) {
+		return HeaderState{}, errEarlyTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold {
+		return HeaderState{}, errExtremeFutureTimestamp
+	}
+
+	// Compute the values that are needed to validate the children of the
+	// header, the same way that newChild does for blocks.
+	child := HeaderState{
+		Header: h,
+		Height: parent.Height + 1,
+		Depth:  parent.Depth.AddDifficulties(parent.ChildTarget),
+	}
+	child.TotalTime, child.TotalTarget = blockTotals(child.Height, parent.TotalTime, parent.Header.Timestamp, h.Timestamp, parent.TotalTarget, parent.ChildTarget)
+	if parent.Height < types.OakHardforkBlock {
+		child.ChildTarget = headerChildTarget(parent, child, timestamp)
+	} else {
+		child.ChildTarget = childTargetOak(parent.TotalTime, parent.TotalTarget, parent.ChildTarget, parent.Height, parent.Header.Timestamp)
+	}
+	return child, nil
+}
+
+// HeavierThan returns true if the chain that ends with the header is
+// sufficiently heavier than the chain that ends with 'cmp'. It is the header
+// equivalent of processedBlock.heavierThan.
+func (hs HeaderState) HeavierThan(cmp HeaderState) bool {
+	requirement := cmp.Depth.AddDifficulties(cmp.ChildTarget.MulDifficulty(SurpassThreshold))
+	return requirement.Cmp(hs.Depth) > 0 // Inversed, because the smaller target is actually heavier.
+}
+
+// minimumValidChildTimestamp returns the earliest timestamp that a child of
+// the header at the given height can have. It is the header equivalent of
+// stdBlockRuleHelper.minimumValidChildTimestamp.
+func minimumValidChildTimestamp(height types.BlockHeight, timestamp func(types.BlockHeight) types.Timestamp) types.Timestamp {
+	windowTimes := make(types.TimestampSlice, types.MedianTimestampWindow)
+	for i := range windowTimes {
+		// The genesis block is used for the heights before the genesis
+		// block.
+		var ancestor types.BlockHeight
+		if height >= types.BlockHeight(i) {
+			ancestor = height - types.BlockHeight(i)
+		}
+		windowTimes[i] = timestamp(ancestor)
+	}
+	sort.Sort(windowTimes)
+	return windowTimes[len(windowTimes)/2]
+}
+
+// headerChildTarget computes the target of the children of a header before
+// the Oak hardfork. It is the header equivalent of setChildTarget.
+func headerChildTarget(parent, child HeaderState, timestamp func(types.BlockHeight) types.Timestamp) types.Target {
+	if child.Height%(types.TargetWindow/2) != 0 {
+		return parent.ChildTarget
+	}
+	windowSize := types.TargetWindow
+	if child.Height < windowSize {
+		windowSize = child.Height
+	}
+	timePassed := child.Header.Timestamp - timestamp(child.Height-windowSize)
+	expectedTimePassed := types.BlockFrequency * windowSize
+	adjustment := clampTargetAdjustment(big.NewRat(int64(timePassed), int64(expectedTimePassed)))
+	return types.RatToTarget(new(big.Rat).Mul(parent.ChildTarget.Rat(), adjustment))
+}
+
+// managedReceiveHeaders is the calling end of the SendHeaders RPC. The
+// headers that follow the block with the id 'start' are requested, or the
+// headers that follow the current path if 'start' is the empty id. The
+// received headers are validated, and the ids of the headers whose blocks are
+// not known yet are returned.
+func (cs *ConsensusSet) managedReceiveHeaders(conn modules.PeerConn, start types.BlockID) (ids []types.BlockID, moreAvailable bool, err error) {
+	err = conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+	if err != nil {
+		return nil, false, err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+
+	// Send the block history, starting with the requested block.
+	var history [32]types.BlockID
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		history = blockHistory(tx)
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return nil, false, err
+	}
+	if start != (types.BlockID{}) {
+		copy(history[1:31], history[:30])
+		history[0] = start
+	}
+	if err := encoding.WriteObject(conn, history); err != nil {
+		return nil, false, err
+	}
+
+	// Read the headers.
+	var headers []types.BlockHeader
+	if err := encoding.ReadObject(conn, &headers, uint64(MaxCatchUpHeaders)*types.BlockHeaderSize+8); err != nil {
+		return nil, false, err
+	}
+	if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+		return nil, false, err
+	}
+	if len(headers) == 0 {
+		return nil, false, nil
+	}
+
+	// Validate the headers.
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		hc, err := cs.newHeaderChain(tx, headers[0].ParentID)
+		if err != nil {
+			return err
+		}
+		for _, h := range headers {
+			if err := cs.extendHeaderChain(tx, hc, h); err != nil {
+				return err
+			}
+		}
+		ids = hc.ids
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return nil, false, errors.Extend(errBadHeaders, err)
+	}
+	return ids, moreAvailable, nil
+}
+
+// rpcSendHeaders is the receiving end of the SendHeaders RPC. It is the
+// headers-first counterpart of SendBlocks: it reads the 32 block ids of the
+// caller's block history, and sends up to 'MaxCatchUpHeaders' headers of the
+// current path that follow the most recent known block, followed by a boolean
+// indicating whether more headers are available.
+func (cs *ConsensusSet) rpcSendHeaders(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Read a list of blocks known to the requester.
+	var knownBlocks [32]types.BlockID
+	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+
+	// Collect the headers that follow the most recent known block.
+	headers := []types.BlockHeader{}
+	moreAvailable := false
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		start, found := cs.findSendStart(tx, knownBlocks)
+		if !found {
+			return nil
+		}
+		height := blockHeight(tx)
+		for i := start; i <= height && i < start+MaxCatchUpHeaders; i++ {
+			id, err := getPath(tx, i)
+			if err != nil {
+				cs.log.Critical("Unable to get path: height", height, ":: request", i)
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				cs.log.Critical("Unable to get block from block map: height", height, ":: request", i, ":: id", id)
+				return err
+			}
+			headers = append(headers, pb.Block.Header())
+		}
+		moreAvailable = start+MaxCatchUpHeaders <= height
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err = encoding.WriteObject(conn, headers); err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, moreAvailable)
+}
+
+// managedHeadersFirstSync synchronizes with a peer headers-first. The header
+// chain of the peer is requested in batches, and the blocks of each batch are
+// downloaded in parallel from all of the given peers. An error is returned if
+// the peer sent an invalid header chain or a chain with invalid blocks. If the
+// peer does not support the SendHeaders RPC, nil is returned and the caller
+// is expected to fall back to SendBlocks.
+func (cs *ConsensusSet) managedHeadersFirstSync(addr modules.NetAddress, peers []modules.Peer) error {
+	bd := cs.newBlockDownloader(peers)
+	var start types.BlockID
+	for {
+		var ids []types.BlockID
+		var moreAvailable bool
+		err := cs.gateway.RPC(addr, "SendHeaders", func(conn modules.PeerConn) (err error) {
+			ids, moreAvailable, err = cs.managedReceiveHeaders(conn, start)
+			return err
+		})
+		if errors.Contains(err, errBadHeaders) {
+			cs.gateway.ReportViolation(addr, modules.ViolationMinor, err.Error())
+			return err
+		} else if err != nil {
+			// The peer may not support the SendHeaders RPC.
+			cs.log.Debugf("WARN: SendHeaders with peer %v failed: %v", addr, err)
+			return nil
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		accepted, err := bd.download(ids)
+		if err == errNoDownloadPeers || err == errEarlyStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		cs.log.Debugf("INFO: downloaded %v blocks headers-first, synced to %v", accepted, ids[accepted-1])
+		if accepted < len(ids) || !moreAvailable {
+			return nil
+		}
+		start = ids[accepted-1]
+	}
+}
+
+// newBlockDownloader returns a block downloader that downloads from the given
+// peers.
+func (cs *ConsensusSet) newBlockDownloader(peers []modules.Peer) *blockDownloader {
+	bd := &blockDownloader{
+		cs: cs,
+	}
+	for _, p := range peers {
+		bd.peers = append(bd.peers, &downloadPeer{addr: p.NetAddress})
+	}
+	bd.cond = sync.NewCond(&bd.mu)
+	return bd
+}
+
+// download downloads the blocks with the given ids in parallel and accepts
+// them in order. The ids must form a chain that extends a known block. The
+// number of blocks that were accepted is returned.
+func (bd *blockDownloader) download(ids []types.BlockID) (int, error) {
+	bd.mu.Lock()
+	bd.ids = ids
+	bd.next = 0
+	bd.retry = nil
+	bd.blocks = make(map[int]types.Block)
+	bd.accepted = 0
+	bd.err = nil
+	var wg sync.WaitGroup
+	for _, dp := range bd.peers {
+		if dp.dropped {
+			continue
+		}
+		bd.workers++
+		wg.Add(1)
+		go func(dp *downloadPeer) {
+			defer wg.Done()
+			bd.threadedDownloadBlocks(dp)
+		}(dp)
+	}
+	bd.mu.Unlock()
+
+	// Wake up the workers and the acceptor if the consensus set is stopped.
+	finishedChan := make(chan struct{})
+	go func() {
+		select {
+		case <-bd.cs.tg.StopChan():
+			bd.mu.Lock()
+			bd.err = errEarlyStop
+			bd.cond.Broadcast()
+			bd.mu.Unlock()
+		case <-finishedChan:
+		}
+	}()
+
+	// Accept the downloaded blocks in order.
+	bd.mu.Lock()
+	for bd.accepted < len(bd.ids) && bd.err == nil {
+		if _, ok := bd.blocks[bd.accepted]; !ok {
+			if bd.workers == 0 {
+				bd.err = errNoDownloadPeers
+				break
+			}
+			bd.cond.Wait()
+			continue
+		}
+		var blocks []types.Block
+		for i := bd.accepted; ; i++ {
+			b, ok := bd.blocks[i]
+			if !ok {
+				break
+			}
+			blocks = append(blocks, b)
+			delete(bd.blocks, i)
+		}
+		bd.mu.Unlock()
+		_, err := bd.cs.managedAcceptBlocks(blocks)
+		bd.mu.Lock()
+		if err == errFutureTimestamp {
+			// The block has been queued to be accepted once its timestamp
+			// has arrived. The blocks that follow it can not be accepted
+			// yet.
+			break
+		}
+		if err != nil && err != modules.ErrNonExtendingBlock && err != modules.ErrBlockKnown {
+			bd.err = err
+			break
+		}
+		bd.accepted += len(blocks)
+		bd.cond.Broadcast()
+	}
+	accepted, err := bd.accepted, bd.err
+	if err == nil {
+		// Stop the workers.
+		bd.err = errEarlyStop
+	}
+	bd.cond.Broadcast()
+	bd.mu.Unlock()
+	close(finishedChan)
+	wg.Wait()
+	if accepted == 0 && err == nil {
+		err = errNoDownloadPeers
+	}
+	return accepted, err
+}
+
+// threadedDownloadBlocks downloads blocks from a peer until all blocks have
+// been downloaded, or until the peer is dropped.
+func (bd *blockDownloader) threadedDownloadBlocks(dp *downloadPeer) {
+	defer func() {
+		bd.mu.Lock()
+		bd.workers--
+		bd.cond.Broadcast()
+		bd.mu.Unlock()
+	}()
+	for {
+		// Wait for a block to download. Blocks that failed to download are
+		// requested first, and no block past the download window is
+		// requested, so that the number of buffered blocks stays limited.
+		bd.mu.Lock()
+		i := -1
+		var id types.BlockID
+		for bd.err == nil && !dp.dropped {
+			if len(bd.retry) > 0 {
+				i = bd.retry[len(bd.retry)-1]
+				bd.retry = bd.retry[:len(bd.retry)-1]
+				id = bd.ids[i]
+				break
+			}
+			if bd.next < len(bd.ids) && bd.next < bd.accepted+ibdDownloadWindow {
+				i = bd.next
+				id = bd.ids[i]
+				bd.next++
+				break
+			}
+			if bd.accepted+len(bd.blocks) == len(bd.ids) && bd.next == len(bd.ids) {
+				break
+			}
+			bd.cond.Wait()
+		}
+		bd.mu.Unlock()
+		if i < 0 {
+			return
+		}
+
+		// Download the block.
+		var b types.Block
+		var size int
+		begin := time.Now()
+		err := bd.cs.gateway.RPC(dp.addr, "SendBlk", func(conn modules.PeerConn) (err error) {
+			b, size, err = bd.cs.managedDownloadBlock(conn, id)
+			return err
+		})
+		elapsed := time.Since(begin)
+
+		bd.mu.Lock()
+		if err != nil {
+			// Drop the peer and let another peer download the block.
+			bd.retry = append(bd.retry, i)
+			dp.dropped = true
+			bd.cond.Broadcast()
+			bd.mu.Unlock()
+			bd.cs.log.Debugf("WARN: dropping peer %v from the block download: %v", dp.addr, err)
+			if errors.Contains(err, errBadBlockBody) {
+				bd.cs.gateway.ReportViolation(dp.addr, modules.ViolationSevere, err.Error())
+			}
+			return
+		}
+		bd.blocks[i] = b
+		dp.bytes += uint64(size)
+		dp.duration += elapsed
+		dp.downloads++
+		if bd.slow(dp) {
+			dp.dropped = true
+			bd.cs.log.Debugf("INFO: dropping slow peer %v from the block download", dp.addr)
+		}
+		bd.cond.Broadcast()
+		bd.mu.Unlock()
+	}
+}
+
+// slow returns true if the throughput of the peer is much lower than the
+// throughput of the fastest peer. A peer is never slow if it is the only one
+// left.
+func (bd *blockDownloader) slow(dp *downloadPeer) bool {
+	if dp.downloads < ibdMinPeerDownloads {
+		return false
+	}
+	var fastest float64
+	active := 0
+	for _, p := range bd.peers {
+		if p.dropped {
+			continue
+		}
+		active++
+		if p.downloads >= ibdMinPeerDownloads && p.throughput() > fastest {
+			fastest = p.throughput()
+		}
+	}
+	return active > 1 && dp.throughput()*ibdSlowPeerFactor < fastest
+}
+
+// managedDownloadBlock is the calling end of the SendBlk RPC during the
+// headers-first initial blockchain download. Unlike managedReceiveBlock, it
+// does not accept the block, and it checks that the block matches the
+// requested id. The size of the encoded block is returned as well.
+func (cs *ConsensusSet) managedDownloadBlock(conn modules.PeerConn, id types.BlockID) (b types.Block, size int, err error) {
+	err = conn.SetDeadline(time.Now().Add(sendBlkTimeout))
+	if err != nil {
+		return types.Block{}, 0, err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+
+	if err := encoding.WriteObject(conn, id); err != nil {
+		return types.Block{}, 0, err
+	}
+	data, err := encoding.ReadPrefixedBytes(conn, types.BlockSizeLimit)
+	if err != nil {
+		return types.Block{}, 0, err
+	}
+	if err := encoding.Unmarshal(data, &b); err != nil {
+		return types.Block{}, 0, errors.Extend(errBadBlockBody, err)
+	}
+	if b.ID() != id {
+		return types.Block{}, 0, errBadBlockBody
+	}
+	return b, len(data), nil
+}
@@ -0,0 +1,252 @@
+package consensus
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestHeaderChain checks that a header chain computes the same child targets
+// as the consensus set, and that it rejects invalid headers.
+func TestHeaderChain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	for cst.cs.Height() < types.OakHardforkFixBlock+5 {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blank, err := blankConsensusSetTester(t.Name()+"-blank", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blank.Close()
+
+	// Build a header chain of all blocks on the consensus set that only has
+	// the genesis block.
+	var hc *headerChain
+	err = blank.cs.db.View(func(tx *bolt.Tx) error {
+		hc, err = blank.cs.newHeaderChain(tx, types.GenesisID)
+		if err != nil {
+			return err
+		}
+		if hc.tip != GenesisHeaderState() {
+			t.Fatal("header chain does not start with the genesis header state")
+		}
+		for i := types.BlockHeight(1); i <= cst.cs.Height(); i++ {
+			b, _ := cst.cs.BlockAtHeight(i)
+			if err := blank.cs.extendHeaderChain(tx, hc, b.Header()); err != nil {
+				return err
+			}
+			pb, err := cst.cs.dbGetBlockMap(b.ID())
+			if err != nil {
+				return err
+			}
+			if hc.tip.ChildTarget != pb.ChildTarget {
+				t.Fatal("header chain computed the wrong child target at height", i)
+			}
+			if hc.tip.Depth != pb.Depth {
+				t.Fatal("header chain computed the wrong depth at height", i)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.ids) != int(cst.cs.Height()) || hc.ids[len(hc.ids)-1] != cst.cs.CurrentBlock().ID() {
+		t.Fatal("header chain returned the wrong ids to download")
+	}
+
+	// Invalid headers are rejected.
+	tip := hc.tip.Header
+	h := types.BlockHeader{
+		ParentID:  tip.ID(),
+		Timestamp: types.CurrentTimestamp(),
+	}
+	for checkHeaderTarget(h, hc.tip.ChildTarget) {
+		h.Nonce[0]++
+	}
+	tests := []struct {
+		header types.BlockHeader
+		err    error
+	}{
+		{types.BlockHeader{ParentID: tip.ParentID, Timestamp: h.Timestamp}, errNonLinearChain},
+		{h, modules.ErrBlockUnsolved},
+	}
+	for _, test := range tests {
+		err := blank.cs.db.View(func(tx *bolt.Tx) error {
+			return blank.cs.extendHeaderChain(tx, hc, test.header)
+		})
+		if err != test.err {
+			t.Errorf("expected %v, got %v", test.err, err)
+		}
+	}
+
+	// Headers of blocks that are already known are validated, but their ids
+	// are not returned.
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		hc, err = cst.cs.newHeaderChain(tx, types.GenesisID)
+		if err != nil {
+			return err
+		}
+		for i := types.BlockHeight(1); i <= cst.cs.Height(); i++ {
+			b, _ := cst.cs.BlockAtHeight(i)
+			if err := cst.cs.extendHeaderChain(tx, hc, b.Header()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hc.ids) != 0 {
+		t.Fatal("header chain returned known ids to download")
+	}
+}
+
+// mockGatewayFailSendBlk is a gateway that fails the SendBlk RPC for a set of
+// peers. It does not call the consensus set on connect, so that synchronizing
+// only happens when the test asks for it.
+type mockGatewayFailSendBlk struct {
+	modules.Gateway
+	fail  map[modules.NetAddress]bool
+	calls map[modules.NetAddress]int
+	mu    sync.Mutex
+}
+
+// RPC fails the SendBlk RPC for the peers that are marked to fail.
+func (g *mockGatewayFailSendBlk) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	if name == "SendBlk" {
+		g.mu.Lock()
+		g.calls[addr]++
+		fail := g.fail[addr]
+		g.mu.Unlock()
+		if fail {
+			return errors.New("mock SendBlk failure")
+		}
+	}
+	return g.Gateway.RPC(addr, name, fn)
+}
+
+// RegisterConnectCall does not register the call.
+func (g *mockGatewayFailSendBlk) RegisterConnectCall(string, modules.RPCFunc) {}
+
+// UnregisterConnectCall does nothing, because no calls are registered.
+func (g *mockGatewayFailSendBlk) UnregisterConnectCall(string) {}
+
+// sendBlkCalls returns the number of SendBlk RPCs made to a peer.
+func (g *mockGatewayFailSendBlk) sendBlkCalls(addr modules.NetAddress) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls[addr]
+}
+
+// TestHeadersFirstSync checks that a consensus set can synchronize
+// headers-first, downloading the blocks from multiple peers, and that peers
+// that fail to send blocks are dropped from the download.
+func TestHeadersFirstSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a few remote consensus sets with the same blockchain, which is
+	// longer than multiple batches of headers.
+	remote, err := createConsensusSetTester(t.Name() + "-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	for remote.cs.Height() < types.OakHardforkBlock+3*MaxCatchUpHeaders {
+		if _, err := remote.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	peers := []*consensusSetTester{remote}
+	for i := 0; i < 2; i++ {
+		cst, err := blankConsensusSetTester(t.Name()+"-remote"+strconv.Itoa(i), modules.ProdDependencies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cst.Close()
+		for h := types.BlockHeight(1); h <= remote.cs.Height(); h++ {
+			b, _ := remote.cs.BlockAtHeight(h)
+			if err := cst.cs.AcceptBlock(b); err != nil {
+				t.Fatal(err)
+			}
+		}
+		peers = append(peers, cst)
+	}
+
+	// Create the local consensus set with a gateway that fails SendBlk for
+	// one of the peers.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-local")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	mg := &mockGatewayFailSendBlk{
+		Gateway: g,
+		fail:    map[modules.NetAddress]bool{peers[2].gateway.Address(): true},
+		calls:   make(map[modules.NetAddress]int),
+	}
+	cs, err := New(mg, false, filepath.Join(testdir, modules.ConsensusDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	for _, cst := range peers {
+		if err := g.Connect(cst.gateway.Address()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outbound := g.Peers()
+	if len(outbound) != len(peers) {
+		t.Fatal("expected", len(peers), "outbound peers, got", len(outbound))
+	}
+
+	// Synchronize headers-first with the first peer.
+	if err := cs.managedHeadersFirstSync(remote.gateway.Address(), outbound); err != nil {
+		t.Fatal(err)
+	}
+	if cs.CurrentBlock().ID() != remote.cs.CurrentBlock().ID() {
+		t.Fatal("headers-first sync did not reach the current block of the remote peer:", cs.Height(), remote.cs.Height())
+	}
+
+	// The blocks were downloaded from multiple peers, and the failing peer
+	// was only tried once.
+	if mg.sendBlkCalls(peers[0].gateway.Address()) == 0 || mg.sendBlkCalls(peers[1].gateway.Address()) == 0 {
+		t.Error("blocks were not downloaded from multiple peers:", mg.calls)
+	}
+	if mg.sendBlkCalls(peers[2].gateway.Address()) != 1 {
+		t.Error("failing peer was not dropped:", mg.calls)
+	}
+
+	// Synchronizing again does not download any blocks.
+	calls := mg.sendBlkCalls(peers[0].gateway.Address())
+	if err := cs.managedHeadersFirstSync(remote.gateway.Address(), outbound); err != nil {
+		t.Fatal(err)
+	}
+	if mg.sendBlkCalls(peers[0].gateway.Address()) != calls {
+		t.Error("blocks were downloaded although the consensus set is synced")
+	}
+}
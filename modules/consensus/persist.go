@@ -56,7 +56,15 @@ func (cs *ConsensusSet) loadDB() error {
 		if genesisID != cs.blockRoot.Block.ID() {
 			return errors.New("Blockchain has wrong genesis block, exiting.")
 		}
-		return nil
+
+		// Load the snapshot that the consensus set was bootstrapped from and
+		// the prune height, if any.
+		cs.snapshot, err = getSnapshot(tx)
+		if err != nil {
+			return err
+		}
+		cs.pruneHeight, err = getPruneHeight(tx)
+		return err
 	})
 }
 
@@ -0,0 +1,105 @@
+package consensus
+
+// proof.go contains the lookup of transaction inclusion proofs, and the
+// SendTxnProof RPC that serves them to light clients, which only keep the
+// headers of the blocks and can verify the proofs against the merkle roots of
+// the headers.
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	errBlockNotInPath = errors.New("block is not in the current path")
+)
+
+// TransactionProof returns the transaction with the given id from the block
+// with the given id, together with a proof that the transaction is part of the
+// block, and the header and height of the block. The block must be in the
+// current path.
+func (cs *ConsensusSet) TransactionProof(blockID types.BlockID, txid types.TransactionID) (txn types.Transaction, proof types.TransactionProof, header types.BlockHeader, height types.BlockHeight, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.Transaction{}, types.TransactionProof{}, types.BlockHeader{}, 0, err
+	}
+	defer cs.tg.Done()
+
+	var b types.Block
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		pb, err := getBlockMap(tx, blockID)
+		if err == errNilItem {
+			return modules.ErrConsensusObjectNotFound
+		} else if err != nil {
+			return err
+		}
+		if id, err := getPath(tx, pb.Height); err != nil || id != blockID {
+			return errBlockNotInPath
+		}
+		b, height = pb.Block, pb.Height
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return types.Transaction{}, types.TransactionProof{}, types.BlockHeader{}, 0, err
+	}
+
+	proof, ok := b.TransactionProof(txid)
+	if !ok {
+		return types.Transaction{}, types.TransactionProof{}, types.BlockHeader{}, 0, modules.ErrConsensusObjectNotFound
+	}
+	return b.Transactions[proof.Index-uint64(len(b.MinerPayouts))], proof, b.Header(), height, nil
+}
+
+// rpcSendTxnProof is the receiving end of the SendTxnProof RPC. It reads a
+// block id and a transaction id, and writes the transaction together with the
+// proof that it is part of the block. If the block is not in the current path
+// or does not contain the transaction, the connection is closed instead.
+func (cs *ConsensusSet) rpcSendTxnProof(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendTxnProofTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Decode the block id and the transaction id from the connection.
+	var blockID types.BlockID
+	var txid types.TransactionID
+	if err := encoding.ReadObject(conn, &blockID, crypto.HashSize); err != nil {
+		return err
+	}
+	if err := encoding.ReadObject(conn, &txid, crypto.HashSize); err != nil {
+		return err
+	}
+
+	// Send the transaction and the proof.
+	txn, proof, _, _, err := cs.TransactionProof(blockID, txid)
+	if err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(conn, txn); err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, proof)
+}
@@ -0,0 +1,68 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestTransactionProof checks that the consensus set returns proofs for the
+// transactions of the blocks in the current path.
+func TestTransactionProof(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	txns, err := cst.wallet.SendSiacoins(types.NewCurrency64(1), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	txn, proof, header, height, err := cst.cs.TransactionProof(b.ID(), txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txn.ID() != txid || height != cst.cs.Height() || header.ID() != b.ID() {
+		t.Fatal("wrong transaction, header or height:", txn.ID(), header.ID(), height)
+	}
+	if !proof.Verify(txn, b.Header()) {
+		t.Fatal("proof does not verify against the block header")
+	}
+
+	// Transactions that are not in the block are not found.
+	if _, _, _, _, err := cst.cs.TransactionProof(b.ID(), types.TransactionID{}); err != modules.ErrConsensusObjectNotFound {
+		t.Error("expected ErrConsensusObjectNotFound, got", err)
+	}
+	if _, _, _, _, err := cst.cs.TransactionProof(types.BlockID{}, txid); err != modules.ErrConsensusObjectNotFound {
+		t.Error("expected ErrConsensusObjectNotFound, got", err)
+	}
+
+	// Blocks that are not in the current path are rejected.
+	target, _ := cst.cs.ChildTarget(b.ParentID)
+	fork := b
+	fork.Timestamp++
+	fork.Nonce = types.BlockNonce{}
+	for !checkTarget(fork, fork.ID(), target) {
+		fork.Nonce[0]++
+		if fork.Nonce[0] == 0 {
+			fork.Nonce[1]++
+		}
+	}
+	if _, err := cst.cs.managedAcceptBlocks([]types.Block{fork}); err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	if _, _, _, _, err := cst.cs.TransactionProof(fork.ID(), txid); err != errBlockNotInPath {
+		t.Error("expected errBlockNotInPath, got", err)
+	}
+}
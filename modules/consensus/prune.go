@@ -0,0 +1,212 @@
+package consensus
+
+// prune.go implements the pruned mode of the consensus set. A pruned consensus
+// set keeps the bodies of the most recent blocks of the current path, which
+// are needed to revert them during a reorg, and discards the bodies of older
+// blocks. The headers of discarded blocks are kept in the Pruned bucket, and
+// the change log is left untouched.
+//
+// Like a consensus set that was bootstrapped from a snapshot, a pruned
+// consensus set rejects any fork that would revert a block at or below the
+// prune height. The bodies of the MedianTimestampWindow blocks up to the prune
+// height are kept, so that the children of the block at the prune height can
+// be validated. Blocks that are not in the current path are never discarded.
+
+import (
+	"fmt"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/errors"
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// Pruned is a database bucket that only exists if the consensus set has
+	// been run in pruned mode. It contains the prune height, and the headers
+	// of the blocks that were discarded.
+	Pruned = []byte("Pruned")
+
+	// FieldPruneHeight is a field in the Pruned bucket that contains the
+	// height of the oldest block that the consensus set is able to revert.
+	FieldPruneHeight = []byte("PruneHeight")
+
+	// bucketPrunedHeaders is the bucket nested in the Pruned bucket that holds
+	// the headers of the discarded blocks, keyed by their id.
+	bucketPrunedHeaders = []byte("Headers")
+)
+
+var (
+	// MinPruneKeepBlocks is the minimum number of recent blocks that a pruned
+	// consensus set keeps for reorgs.
+	MinPruneKeepBlocks = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Dev:      types.BlockHeight(50),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+)
+
+var (
+	errPruneKeep  = fmt.Errorf("a pruned consensus set must keep at least %v blocks", MinPruneKeepBlocks)
+	errPrunedFork = errors.New("block would fork the blockchain before the blocks that the consensus set has pruned")
+)
+
+// NewPruned returns a new ConsensusSet that discards the bodies of blocks that
+// are more than keepBlocks blocks below the current block. The existing block
+// database in the persist directory is pruned right away. Once blocks have
+// been discarded, the consensus set stays unable to revert them or to serve
+// them to peers, even if it is later loaded with New.
+func NewPruned(gateway modules.Gateway, bootstrap bool, persistDir string, keepBlocks types.BlockHeight) (*ConsensusSet, error) {
+	if keepBlocks < MinPruneKeepBlocks {
+		return nil, errPruneKeep
+	}
+	cs, err := New(gateway, bootstrap, persistDir)
+	if err != nil {
+		return nil, err
+	}
+	cs.mu.Lock()
+	cs.pruneKeep = keepBlocks
+	err = cs.pruneBlocks()
+	cs.mu.Unlock()
+	if err != nil {
+		return nil, errors.Compose(err, cs.Close())
+	}
+	return cs, nil
+}
+
+// floorHeight returns the height of the oldest block of the current path that
+// the consensus set is able to revert.
+func (cs *ConsensusSet) floorHeight() types.BlockHeight {
+	if cs.pruneHeight > cs.snapshot.Height {
+		return cs.pruneHeight
+	}
+	return cs.snapshot.Height
+}
+
+// checkForkHeight returns an error if a child of the block at parentHeight
+// would fork the blockchain below the oldest block that the consensus set is
+// able to revert.
+func (cs *ConsensusSet) checkForkHeight(parentHeight types.BlockHeight) error {
+	if parentHeight < cs.snapshot.Height {
+		return errSnapshotFork
+	}
+	if parentHeight < cs.pruneHeight {
+		return errPrunedFork
+	}
+	return nil
+}
+
+// pruneBlocks discards the bodies of the blocks of the current path that are
+// no longer needed to revert the most recent blocks, and raises the prune
+// height accordingly. It does nothing unless the consensus set was created
+// with NewPruned.
+func (cs *ConsensusSet) pruneBlocks() error {
+	if cs.pruneKeep == 0 {
+		return nil
+	}
+	var pruneHeight types.BlockHeight
+	var discarded int
+	err := cs.db.Update(func(tx *bolt.Tx) error {
+		height := blockHeight(tx)
+		if height < cs.pruneKeep || height-cs.pruneKeep <= cs.pruneHeight {
+			return nil
+		}
+		pruneHeight = height - cs.pruneKeep
+
+		b, err := tx.CreateBucketIfNotExists(Pruned)
+		if err != nil {
+			return err
+		}
+		headers, err := b.CreateBucketIfNotExists(bucketPrunedHeaders)
+		if err != nil {
+			return err
+		}
+		// The blocks up to the previous prune height that are still needed
+		// to validate children have been kept, start with those. The genesis
+		// block is never discarded.
+		window := types.BlockHeight(types.MedianTimestampWindow)
+		start := types.BlockHeight(1)
+		if cs.pruneHeight >= window {
+			start = cs.pruneHeight - window + 1
+		}
+		blockMap := tx.Bucket(BlockMap)
+		for i := start; i+window <= pruneHeight; i++ {
+			id, err := getPath(tx, i)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				// The blocks before a snapshot that the consensus set was
+				// bootstrapped from are not available.
+				continue
+			}
+			if err := headers.Put(id[:], encoding.Marshal(pb.Block.Header())); err != nil {
+				return err
+			}
+			if err := blockMap.Delete(id[:]); err != nil {
+				return err
+			}
+			discarded++
+		}
+		return b.Put(FieldPruneHeight, encoding.Marshal(pruneHeight))
+	})
+	if err != nil {
+		return err
+	}
+	if pruneHeight == 0 {
+		return nil
+	}
+	if cs.pruneHeight == 0 {
+		cs.gateway.SetPruned(true)
+	}
+	cs.pruneHeight = pruneHeight
+	if discarded > 0 {
+		cs.log.Debugf("Pruned %v blocks, prune height is now %v", discarded, pruneHeight)
+	}
+	return nil
+}
+
+// getPruneHeight returns the prune height of the consensus set, or 0 if the
+// consensus set has never been pruned.
+func getPruneHeight(tx *bolt.Tx) (pruneHeight types.BlockHeight, err error) {
+	b := tx.Bucket(Pruned)
+	if b == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(b.Get(FieldPruneHeight), &pruneHeight)
+	return
+}
+
+// getPrunedHeader returns the header of a block whose body has been discarded.
+func getPrunedHeader(tx *bolt.Tx, id types.BlockID) (header types.BlockHeader, exists bool) {
+	b := tx.Bucket(Pruned)
+	if b == nil {
+		return types.BlockHeader{}, false
+	}
+	headerBytes := b.Bucket(bucketPrunedHeaders).Get(id[:])
+	if headerBytes == nil {
+		return types.BlockHeader{}, false
+	}
+	err := encoding.Unmarshal(headerBytes, &header)
+	return header, err == nil
+}
+
+// entryPruned returns true if the consensus change of a change entry can no
+// longer be computed, because it contains blocks whose bodies have been
+// discarded, or blocks below the prune height whose ancestors are needed to
+// compute the minimum valid child timestamp.
+func (cs *ConsensusSet) entryPruned(tx *bolt.Tx, ce changeEntry) bool {
+	for _, ids := range [][]types.BlockID{ce.RevertedBlocks, ce.AppliedBlocks} {
+		for _, id := range ids {
+			pb, err := getBlockMap(tx, id)
+			if err != nil || pb.Height < cs.pruneHeight {
+				return true
+			}
+		}
+	}
+	return false
+}
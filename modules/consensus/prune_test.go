@@ -0,0 +1,152 @@
+package consensus
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestPrunedConsensusSet checks that a pruned consensus set discards the
+// bodies of old blocks, keeps their headers, rejects forks and subscriptions
+// that would need the discarded blocks, and advertises itself as pruned.
+func TestPrunedConsensusSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remote, err := createConsensusSetTester(t.Name() + "-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	for remote.cs.Height() < 4*MinPruneKeepBlocks {
+		if _, err := remote.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Create a pruned consensus set with a subscriber that receives all of the
+	// blocks as they are accepted.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name())
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	if _, err := NewPruned(g, false, csDir, MinPruneKeepBlocks-1); err != errPruneKeep {
+		t.Fatal("expected errPruneKeep, got", err)
+	}
+	cs, err := NewPruned(g, false, csDir, MinPruneKeepBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := newMockSubscriber()
+	if err := cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	for h := types.BlockHeight(1); h <= remote.cs.Height(); h++ {
+		b, _ := remote.cs.BlockAtHeight(h)
+		if err := cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ms.updates) != int(cs.Height())+1 {
+		t.Fatal("subscriber did not receive all of the changes:", len(ms.updates))
+	}
+
+	// The bodies of the blocks below the window before the prune height are
+	// discarded, but their headers are kept.
+	pruneHeight := cs.Height() - MinPruneKeepBlocks
+	if cs.pruneHeight != pruneHeight {
+		t.Fatal("wrong prune height:", cs.pruneHeight, pruneHeight)
+	}
+	oldest := pruneHeight - types.BlockHeight(types.MedianTimestampWindow) + 1
+	if _, exists := cs.BlockAtHeight(oldest - 1); exists {
+		t.Fatal("block below the prune window was not discarded")
+	}
+	if _, exists := cs.BlockAtHeight(oldest); !exists {
+		t.Fatal("block in the prune window was discarded")
+	}
+	if _, exists := cs.BlockAtHeight(0); !exists {
+		t.Fatal("genesis block was discarded")
+	}
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		for h := types.BlockHeight(1); h < oldest; h++ {
+			b, _ := remote.cs.BlockAtHeight(h)
+			if header, exists := getPrunedHeader(tx, b.ID()); !exists || header != b.Header() {
+				t.Fatal("header of a discarded block was not kept at height", h)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Forks below the prune height are rejected.
+	parent, _ := cs.BlockAtHeight(pruneHeight - 1)
+	fork := types.Block{
+		ParentID:  parent.ID(),
+		Timestamp: types.CurrentTimestamp(),
+	}
+	if err := cs.AcceptBlock(fork); err != errPrunedFork {
+		t.Fatal("expected errPrunedFork, got", err)
+	}
+
+	// Subscriptions that need discarded blocks fail, recent ones succeed.
+	subscribeTests := []struct {
+		start modules.ConsensusChangeID
+		err   error
+	}{
+		{modules.ConsensusChangeBeginning, modules.ErrConsensusPruned},
+		{ms.updates[1].ID, modules.ErrConsensusPruned},
+		{ms.updates[pruneHeight-2].ID, modules.ErrConsensusPruned},
+		{ms.updates[pruneHeight-1].ID, nil},
+	}
+	for _, test := range subscribeTests {
+		sub := newMockSubscriber()
+		err := cs.ConsensusSetSubscribe(&sub, test.start, nil)
+		if err != test.err {
+			t.Errorf("expected %v, got %v", test.err, err)
+		}
+		cs.Unsubscribe(&sub)
+	}
+
+	// The gateway advertises that the node is pruned.
+	g2, err := gateway.New("localhost:0", false, build.TempDir(modules.ConsensusDir, t.Name(), "g2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g2.Close()
+	if err := g2.Connect(g.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if peers := g2.Peers(); len(peers) != 1 || !peers[0].Pruned {
+		t.Fatal("pruned consensus set was not advertised:", peers)
+	}
+
+	// The prune height is kept when the consensus set is loaded without
+	// pruning.
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, err = New(g, false, csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	if cs.pruneHeight != pruneHeight {
+		t.Fatal("prune height was not persisted:", cs.pruneHeight)
+	}
+	sub := newMockSubscriber()
+	if err := cs.ConsensusSetSubscribe(&sub, modules.ConsensusChangeBeginning, nil); err != modules.ErrConsensusPruned {
+		t.Fatal("expected ErrConsensusPruned, got", err)
+	}
+}
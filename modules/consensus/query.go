@@ -0,0 +1,107 @@
+package consensus
+
+// query.go contains the methods that look up the current state of individual
+// outputs and file contracts, so that callers do not need to follow the
+// consensus changes to build their own index.
+
+import (
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// getDSCO searches the buckets of the delayed siacoin outputs that have not
+// matured yet for an output, and returns the output and its maturity height.
+func getDSCO(tx *bolt.Tx, id types.SiacoinOutputID) (sco types.SiacoinOutput, maturityHeight types.BlockHeight, err error) {
+	height := blockHeight(tx)
+	for bh := height + 1; bh <= height+types.MaturityDelay; bh++ {
+		bucket := tx.Bucket(append(prefixDSCO, encoding.Marshal(bh)...))
+		if bucket == nil {
+			continue
+		}
+		if scoBytes := bucket.Get(id[:]); scoBytes != nil {
+			return sco, bh, encoding.Unmarshal(scoBytes, &sco)
+		}
+	}
+	return types.SiacoinOutput{}, 0, errNilItem
+}
+
+// FileContract returns the file contract with the given id, if it is part of
+// the consensus set. Contracts leave the consensus set when their storage
+// proof is submitted or when their proof window ends.
+func (cs *ConsensusSet) FileContract(id types.FileContractID) (fc types.FileContract, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.FileContract{}, err
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		fc, err = getFileContract(tx, id)
+		return nil
+	})
+	if err == errNilItem {
+		return types.FileContract{}, modules.ErrConsensusObjectNotFound
+	}
+	return fc, err
+}
+
+// SiacoinOutput returns the unspent siacoin output with the given id. Delayed
+// outputs, such as block rewards and file contract payouts, are returned with
+// the height at which they mature; outputs that can already be spent are
+// returned with a maturity height of 0.
+func (cs *ConsensusSet) SiacoinOutput(id types.SiacoinOutputID) (sco types.SiacoinOutput, maturityHeight types.BlockHeight, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.SiacoinOutput{}, 0, err
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		sco, err = getSiacoinOutput(tx, id)
+		if err == errNilItem {
+			sco, maturityHeight, err = getDSCO(tx, id)
+		}
+		return nil
+	})
+	if err == errNilItem {
+		return types.SiacoinOutput{}, 0, modules.ErrConsensusObjectNotFound
+	}
+	return sco, maturityHeight, err
+}
+
+// SiafundOutput returns the unspent siafund output with the given id.
+func (cs *ConsensusSet) SiafundOutput(id types.SiafundOutputID) (sfo types.SiafundOutput, err error) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.SiafundOutput{}, err
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		sfo, err = getSiafundOutput(tx, id)
+		return nil
+	})
+	if err == errNilItem {
+		return types.SiafundOutput{}, modules.ErrConsensusObjectNotFound
+	}
+	return sfo, err
+}
+
+// SiafundPool returns the number of siacoins that have been paid to the
+// siafund pool by file contracts.
+func (cs *ConsensusSet) SiafundPool() (pool types.Currency) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.ZeroCurrency
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		pool = getSiafundPool(tx)
+		return nil
+	})
+	return pool
+}
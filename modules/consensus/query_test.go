@@ -0,0 +1,120 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestQueries checks that outputs, file contracts and the siafund pool can be
+// looked up in the consensus set.
+func TestQueries(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// The payout of the current block is delayed, the payout of the first
+	// block can be spent.
+	height := cst.cs.Height()
+	current := cst.cs.CurrentBlock()
+	sco, maturityHeight, err := cst.cs.SiacoinOutput(current.MinerPayoutID(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maturityHeight != height+types.MaturityDelay || sco.UnlockHash != current.MinerPayouts[0].UnlockHash || !sco.Value.Equals(current.MinerPayouts[0].Value) {
+		t.Fatal("wrong delayed siacoin output:", sco, maturityHeight)
+	}
+	first, _ := cst.cs.BlockAtHeight(1)
+	sco, maturityHeight, err = cst.cs.SiacoinOutput(first.MinerPayoutID(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maturityHeight != 0 || !sco.Value.Equals(first.MinerPayouts[0].Value) {
+		t.Fatal("wrong siacoin output:", sco, maturityHeight)
+	}
+
+	// The genesis siafund outputs can be looked up.
+	sfo, err := cst.cs.SiafundOutput(types.GenesisBlock.Transactions[0].SiafundOutputID(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sfo.Value.Equals(types.GenesisSiafundAllocation[0].Value) {
+		t.Fatal("wrong siafund output:", sfo)
+	}
+
+	// Create a file contract, which pays a tax to the siafund pool.
+	pool := cst.cs.SiafundPool()
+	payout := types.NewCurrency64(400e6)
+	fc := types.FileContract{
+		FileSize:    4e3,
+		WindowStart: height + 3,
+		WindowEnd:   height + 5,
+		Payout:      payout,
+		ValidProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(height, payout),
+		}},
+		MissedProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(height, payout),
+		}},
+	}
+	txnBuilder, err := cst.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(payout); err != nil {
+		t.Fatal(err)
+	}
+	fcIndex := txnBuilder.AddFileContract(fc)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	fcid := txnSet[len(txnSet)-1].FileContractID(fcIndex)
+	dbfc, err := cst.cs.FileContract(fcid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dbfc.WindowStart != fc.WindowStart || dbfc.WindowEnd != fc.WindowEnd || !dbfc.Payout.Equals(payout) {
+		t.Fatal("wrong file contract:", dbfc)
+	}
+	if !cst.cs.SiafundPool().Equals(pool.Add(types.Tax(height, payout))) {
+		t.Fatal("siafund pool did not receive the tax:", cst.cs.SiafundPool())
+	}
+
+	// Once the proof window has ended, the contract is gone.
+	for cst.cs.Height() < fc.WindowEnd {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cst.cs.FileContract(fcid); err != modules.ErrConsensusObjectNotFound {
+		t.Fatal("expected ErrConsensusObjectNotFound, got", err)
+	}
+
+	// Unknown ids are not found.
+	var id crypto.Hash
+	id[0] = 1
+	if _, _, err := cst.cs.SiacoinOutput(types.SiacoinOutputID(id)); err != modules.ErrConsensusObjectNotFound {
+		t.Error("expected ErrConsensusObjectNotFound, got", err)
+	}
+	if _, err := cst.cs.SiafundOutput(types.SiafundOutputID(id)); err != modules.ErrConsensusObjectNotFound {
+		t.Error("expected ErrConsensusObjectNotFound, got", err)
+	}
+	if _, err := cst.cs.FileContract(types.FileContractID(id)); err != modules.ErrConsensusObjectNotFound {
+		t.Error("expected ErrConsensusObjectNotFound, got", err)
+	}
+}
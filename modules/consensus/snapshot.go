@@ -0,0 +1,594 @@
+package consensus
+
+// snapshot.go implements exporting the consensus set to a snapshot file, and
+// bootstrapping a new consensus database from such a file. A snapshot holds
+// the complete consensus state at one height of the current path, which allows
+// a new node to skip processing all of the blocks up to that height.
+//
+// The snapshot file is a stream of individually encoded objects, because the
+// unspent output sets of a mature blockchain are much larger than the size
+// limits of the encoding package:
+//
+//	snapshotVersion
+//	height
+//	the ids of the blocks in the path from the genesis block to height
+//	the last MedianTimestampWindow blocks as snapshotBlocks, oldest first
+//	the oak total time and total target of the block at height
+//	the siacoin outputs, file contracts and siafund outputs
+//	the delayed siacoin outputs, grouped by maturity height
+//	the siafund pool
+//	the hash of all of the above
+//
+// The entries of a set are each preceded by 'true' and the set is terminated
+// by 'false'. Bolt iterates over buckets in byte order, so two consensus sets
+// that agree on the current path produce identical snapshots for a height.
+// This means that the hash of a snapshot can be checked against any number of
+// independent nodes before trusting it.
+//
+// Blocks before the snapshot height are not available to a bootstrapped
+// consensus set: it does not serve them to peers, and it rejects any fork
+// that would revert the block at the snapshot height.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/errors"
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// Snapshot is a database bucket that only exists if the consensus set was
+	// bootstrapped from a snapshot. It contains the description of the
+	// snapshot, and a copy of the state that the snapshot created, which is
+	// sent to subscribers as the diffs of the first consensus change.
+	Snapshot = []byte("Snapshot")
+
+	// FieldSnapshot is a field in the Snapshot bucket that contains the
+	// modules.ConsensusSnapshot describing the snapshot.
+	FieldSnapshot = []byte("Snapshot")
+
+	// bucketSnapshotDSCOs is the bucket nested in the Snapshot bucket that
+	// holds the delayed siacoin outputs of the snapshot, keyed by their
+	// maturity height followed by their id.
+	bucketSnapshotDSCOs = []byte("DelayedSiacoinOutputs")
+
+	// snapshotVersion is the first object in a snapshot file.
+	snapshotVersion = types.Specifier{'C', 'o', 'n', 's', 'e', 'n', 's', 'u', 's', 'S', 'n', 'a', 'p', 'v', '1'}
+)
+
+var (
+	errSnapshotCorrupt     = errors.New("snapshot does not match its content hash")
+	errSnapshotDB          = errors.New("a consensus database already exists; a snapshot can only be imported into an empty consensus directory")
+	errSnapshotFork        = errors.New("block would fork the blockchain before the snapshot that the consensus set was bootstrapped from")
+	errSnapshotFutureBlock = errors.New("cannot create a snapshot above the current height")
+	errSnapshotHeight      = errors.New("cannot create a snapshot below the oak hardfork height or below the oldest block that the consensus set is able to revert")
+	errSnapshotUntrusted   = errors.New("snapshot hash does not match the trusted hash")
+	errSnapshotVersion     = errors.New("file is not a consensus snapshot")
+	errSnapshotWrongChain  = errors.New("snapshot was created for a different genesis block")
+)
+
+// snapshotBlock is one of the most recent blocks of a snapshot, together with
+// the values that are needed to validate its children.
+type snapshotBlock struct {
+	Block       types.Block
+	Depth       types.Target
+	ChildTarget types.Target
+}
+
+// snapshotBuckets are the buckets of the consensus set that are copied into
+// a snapshot as they are. The delayed siacoin output buckets and the siafund
+// pool are handled separately.
+var snapshotBuckets = [][]byte{
+	SiacoinOutputs,
+	FileContracts,
+	SiafundOutputs,
+}
+
+// writeSnapshotEntries writes the key/value pairs of a bucket to enc.
+func writeSnapshotEntries(enc *encoding.Encoder, b *bolt.Bucket) error {
+	err := b.ForEach(func(k, v []byte) error {
+		return enc.EncodeAll(true, k, v)
+	})
+	if err != nil {
+		return err
+	}
+	return enc.Encode(false)
+}
+
+// readSnapshotEntries reads key/value pairs written by writeSnapshotEntries,
+// calling fn for each of them.
+func readSnapshotEntries(dec *encoding.Decoder, fn func(k, v []byte) error) error {
+	for {
+		var more bool
+		if err := dec.Decode(&more); err != nil {
+			return err
+		} else if !more {
+			return nil
+		}
+		var k, v []byte
+		if err := dec.DecodeAll(&k, &v); err != nil {
+			return err
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+}
+
+// writeSnapshot writes the consensus state of tx to w, returning the hash of
+// the snapshot.
+func (cs *ConsensusSet) writeSnapshot(tx *bolt.Tx, w io.Writer) (crypto.Hash, error) {
+	h := crypto.NewHash()
+	enc := encoding.NewEncoder(io.MultiWriter(w, h))
+
+	// Write the current path and the blocks that are needed to validate the
+	// child of the current block.
+	height := blockHeight(tx)
+	enc.EncodeAll(snapshotVersion, height)
+	for i := types.BlockHeight(0); i <= height; i++ {
+		id, err := getPath(tx, i)
+		if err != nil {
+			return crypto.Hash{}, err
+		}
+		enc.Encode(id)
+	}
+	for i := height + 1 - types.BlockHeight(types.MedianTimestampWindow); i <= height; i++ {
+		id, _ := getPath(tx, i)
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return crypto.Hash{}, err
+		}
+		enc.Encode(snapshotBlock{
+			Block:       pb.Block,
+			Depth:       pb.Depth,
+			ChildTarget: pb.ChildTarget,
+		})
+	}
+	totalTime, totalTarget := cs.getBlockTotals(tx, currentBlockID(tx))
+	enc.EncodeAll(totalTime, totalTarget)
+
+	// Write the consensus state.
+	for _, bucket := range snapshotBuckets {
+		if err := writeSnapshotEntries(enc, tx.Bucket(bucket)); err != nil {
+			return crypto.Hash{}, err
+		}
+	}
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefixDSCO) {
+			return nil
+		}
+		var maturityHeight types.BlockHeight
+		if err := encoding.Unmarshal(name[len(prefixDSCO):], &maturityHeight); err != nil {
+			return err
+		}
+		enc.EncodeAll(true, maturityHeight)
+		return writeSnapshotEntries(enc, b)
+	})
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	enc.EncodeAll(false, getSiafundPool(tx))
+	if err := enc.Err(); err != nil {
+		return crypto.Hash{}, err
+	}
+
+	// Append the hash of the snapshot.
+	var snapshotHash crypto.Hash
+	copy(snapshotHash[:], h.Sum(nil))
+	_, err = w.Write(snapshotHash[:])
+	return snapshotHash, err
+}
+
+// Snapshot returns the snapshot that the consensus set was bootstrapped from,
+// and false if it was not bootstrapped from a snapshot.
+func (cs *ConsensusSet) Snapshot() (modules.ConsensusSnapshot, bool) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	return cs.snapshot, cs.snapshot.Height != 0
+}
+
+// ExportSnapshot writes a snapshot of the consensus set at the given height
+// of the current path to filename. Snapshots below the current height are
+// created by reverting blocks in a database transaction that is never
+// committed, so the consensus set does not accept blocks until the snapshot
+// has been written.
+func (cs *ConsensusSet) ExportSnapshot(filename string, height types.BlockHeight) (modules.ConsensusSnapshot, error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	defer cs.tg.Done()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	tx, err := cs.db.Begin(true)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	defer tx.Rollback()
+	if height > blockHeight(tx) {
+		return modules.ConsensusSnapshot{}, errSnapshotFutureBlock
+	}
+	if height < types.OakHardforkBlock || height < cs.floorHeight() {
+		return modules.ConsensusSnapshot{}, errSnapshotHeight
+	}
+	for blockHeight(tx) > height {
+		commitDiffSet(tx, currentProcessedBlock(tx), modules.DiffRevert)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	snapshot := modules.ConsensusSnapshot{
+		Height:  height,
+		BlockID: currentBlockID(tx),
+	}
+	bw := bufio.NewWriter(f)
+	snapshot.Hash, err = cs.writeSnapshot(tx, bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		os.Remove(filename)
+		return modules.ConsensusSnapshot{}, errors.AddContext(err, "unable to write snapshot")
+	}
+	cs.log.Printf("Exported snapshot of height %v with hash %v", height, snapshot.Hash)
+	return snapshot, nil
+}
+
+// hashingReader hashes all of the bytes that are read from it.
+type hashingReader struct {
+	r io.Reader
+	h hash.Hash
+}
+
+// Read implements io.Reader.
+func (hr hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+	return n, err
+}
+
+// readSnapshot creates the consensus database in tx from the snapshot in r.
+func readSnapshot(tx *bolt.Tx, r io.Reader, trustedHash crypto.Hash) (snapshot modules.ConsensusSnapshot, err error) {
+	h := crypto.NewHash()
+	dec := encoding.NewDecoder(hashingReader{r: r, h: h})
+
+	// Create the buckets of the consensus database.
+	buckets := [][]byte{
+		BlockHeight,
+		BlockMap,
+		BlockPath,
+		BucketOak,
+		ChangeLog,
+		Consistency,
+		SiacoinOutputs,
+		FileContracts,
+		SiafundOutputs,
+		SiafundPool,
+		Snapshot,
+	}
+	for _, bucket := range buckets {
+		if _, err := tx.CreateBucket(bucket); err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+	}
+	snapshotBucket := tx.Bucket(Snapshot)
+	for _, bucket := range snapshotBuckets {
+		if _, err := snapshotBucket.CreateBucket(bucket); err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+	}
+	if _, err := snapshotBucket.CreateBucket(bucketSnapshotDSCOs); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	if err := tx.Bucket(Consistency).Put(Consistency, encoding.Marshal(false)); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+
+	// Read the current path.
+	var version types.Specifier
+	if err := dec.DecodeAll(&version, &snapshot.Height); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	} else if version != snapshotVersion {
+		return modules.ConsensusSnapshot{}, errSnapshotVersion
+	} else if snapshot.Height < types.OakHardforkBlock {
+		return modules.ConsensusSnapshot{}, errSnapshotHeight
+	}
+	underflow := types.BlockHeight(0)
+	if err := tx.Bucket(BlockHeight).Put(BlockHeight, encoding.Marshal(underflow-1)); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	for i := types.BlockHeight(0); i <= snapshot.Height; i++ {
+		var id types.BlockID
+		if err := dec.Decode(&id); err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+		if i == 0 && id != types.GenesisID {
+			return modules.ConsensusSnapshot{}, errSnapshotWrongChain
+		}
+		pushPath(tx, id)
+		snapshot.BlockID = id
+	}
+
+	// Read the recent blocks. Their diffs are not known, which is fine because
+	// they can never be reverted.
+	var pb processedBlock
+	for i := snapshot.Height + 1 - types.BlockHeight(types.MedianTimestampWindow); i <= snapshot.Height; i++ {
+		var sb snapshotBlock
+		if err := dec.Decode(&sb); err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+		if id, _ := getPath(tx, i); sb.Block.ID() != id {
+			return modules.ConsensusSnapshot{}, errors.New("snapshot block does not match the block path")
+		}
+		pb = processedBlock{
+			Block:       sb.Block,
+			Height:      i,
+			Depth:       sb.Depth,
+			ChildTarget: sb.ChildTarget,
+
+			DiffsGenerated: true,
+		}
+		addBlockMap(tx, &pb)
+	}
+	var totalTime int64
+	var totalTarget types.Target
+	if err := dec.DecodeAll(&totalTime, &totalTarget); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	totals := make([]byte, 40)
+	binary.LittleEndian.PutUint64(totals[:8], uint64(totalTime))
+	copy(totals[8:], totalTarget[:])
+	if err := tx.Bucket(BucketOak).Put(snapshot.BlockID[:], totals); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	if err := tx.Bucket(BucketOak).Put(FieldOakInit, ValueOakInit); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+
+	// Read the consensus state, keeping a copy for subscribers.
+	err = readSnapshotEntries(dec, func(k, v []byte) error {
+		var id types.SiacoinOutputID
+		var sco types.SiacoinOutput
+		copy(id[:], k)
+		if err := encoding.Unmarshal(v, &sco); err != nil {
+			return err
+		}
+		addSiacoinOutput(tx, id, sco)
+		return snapshotBucket.Bucket(SiacoinOutputs).Put(id[:], v)
+	})
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	err = readSnapshotEntries(dec, func(k, v []byte) error {
+		var id types.FileContractID
+		var fc types.FileContract
+		copy(id[:], k)
+		if err := encoding.Unmarshal(v, &fc); err != nil {
+			return err
+		}
+		addFileContract(tx, id, fc)
+		return snapshotBucket.Bucket(FileContracts).Put(id[:], v)
+	})
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	err = readSnapshotEntries(dec, func(k, v []byte) error {
+		var id types.SiafundOutputID
+		var sfo types.SiafundOutput
+		copy(id[:], k)
+		if err := encoding.Unmarshal(v, &sfo); err != nil {
+			return err
+		}
+		addSiafundOutput(tx, id, sfo)
+		return snapshotBucket.Bucket(SiafundOutputs).Put(id[:], v)
+	})
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	for {
+		var more bool
+		var maturityHeight types.BlockHeight
+		if err := dec.Decode(&more); err != nil {
+			return modules.ConsensusSnapshot{}, err
+		} else if !more {
+			break
+		}
+		if err := dec.Decode(&maturityHeight); err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+		createDSCOBucket(tx, maturityHeight)
+		err = readSnapshotEntries(dec, func(k, v []byte) error {
+			var id types.SiacoinOutputID
+			var sco types.SiacoinOutput
+			copy(id[:], k)
+			if err := encoding.Unmarshal(v, &sco); err != nil {
+				return err
+			}
+			addDSCO(tx, maturityHeight, id, sco)
+			key := append(encoding.Marshal(maturityHeight), id[:]...)
+			return snapshotBucket.Bucket(bucketSnapshotDSCOs).Put(key, v)
+		})
+		if err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+	}
+	var pool types.Currency
+	if err := dec.Decode(&pool); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	setSiafundPool(tx, pool)
+	if err := snapshotBucket.Put(SiafundPool, encoding.Marshal(pool)); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+
+	// Check the hash of the snapshot, first against the hash in the file to
+	// detect corruption, and then against the hash provided by the user.
+	copy(snapshot.Hash[:], h.Sum(nil))
+	var fileHash crypto.Hash
+	if _, err := io.ReadFull(r, fileHash[:]); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	if fileHash != snapshot.Hash {
+		return modules.ConsensusSnapshot{}, errSnapshotCorrupt
+	} else if snapshot.Hash != trustedHash {
+		return modules.ConsensusSnapshot{}, errSnapshotUntrusted
+	}
+
+	// The checksum of the current block is used by the consistency checks,
+	// which revert and reapply the child of the current block.
+	if build.DEBUG {
+		pb.ConsensusChecksum = consensusChecksum(tx)
+		addBlockMap(tx, &pb)
+	}
+	if err := snapshotBucket.Put(FieldSnapshot, encoding.Marshal(snapshot)); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	return snapshot, appendChangeLog(tx, snapshotEntry(snapshot))
+}
+
+// ImportSnapshot creates the consensus database in persistDir from the
+// snapshot in filename, after checking that the hash of the snapshot matches
+// trustedHash. A consensus set created in persistDir afterwards continues
+// from the snapshot height. Importing the same snapshot again is a no-op, but
+// any other existing consensus database is left untouched and an error is
+// returned.
+func ImportSnapshot(persistDir, filename string, trustedHash crypto.Hash) (modules.ConsensusSnapshot, error) {
+	err := os.MkdirAll(persistDir, 0700)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	dbFilename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(dbFilename); err == nil {
+		db, err := persist.OpenDatabase(dbMetadata, dbFilename)
+		if err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+		var snapshot modules.ConsensusSnapshot
+		err = db.View(func(tx *bolt.Tx) error {
+			snapshot, err = getSnapshot(tx)
+			return err
+		})
+		err = errors.Compose(err, db.Close())
+		if err != nil {
+			return modules.ConsensusSnapshot{}, err
+		}
+		if snapshot.Hash != trustedHash {
+			return modules.ConsensusSnapshot{}, errSnapshotDB
+		}
+		return snapshot, nil
+	}
+
+	// The database is created next to its final location, so that a failed
+	// import does not leave a partial consensus database behind.
+	f, err := os.Open(filename)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	defer f.Close()
+	tmpFilename := dbFilename + "_temp"
+	os.Remove(tmpFilename)
+	db, err := persist.OpenDatabase(dbMetadata, tmpFilename)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	var snapshot modules.ConsensusSnapshot
+	err = db.Update(func(tx *bolt.Tx) error {
+		snapshot, err = readSnapshot(tx, bufio.NewReader(f), trustedHash)
+		return err
+	})
+	err = errors.Compose(err, db.Close())
+	if err != nil {
+		os.Remove(tmpFilename)
+		return modules.ConsensusSnapshot{}, errors.AddContext(err, "unable to import snapshot")
+	}
+	return snapshot, os.Rename(tmpFilename, dbFilename)
+}
+
+// getSnapshot returns the snapshot that the consensus set was bootstrapped
+// from, or an empty snapshot if the consensus set was not bootstrapped.
+func getSnapshot(tx *bolt.Tx) (snapshot modules.ConsensusSnapshot, err error) {
+	b := tx.Bucket(Snapshot)
+	if b == nil {
+		return modules.ConsensusSnapshot{}, nil
+	}
+	err = encoding.Unmarshal(b.Get(FieldSnapshot), &snapshot)
+	return
+}
+
+// snapshotEntry returns the change entry that applies a snapshot. It is the
+// first entry in the change log of a bootstrapped consensus set.
+func snapshotEntry(snapshot modules.ConsensusSnapshot) changeEntry {
+	return changeEntry{
+		AppliedBlocks: []types.BlockID{snapshot.BlockID},
+	}
+}
+
+// addSnapshotDiffs adds the diffs that create the consensus state of the
+// snapshot that the consensus set was bootstrapped from to cc.
+func addSnapshotDiffs(tx *bolt.Tx, cc *modules.ConsensusChange) error {
+	b := tx.Bucket(Snapshot)
+	err := b.Bucket(SiacoinOutputs).ForEach(func(k, v []byte) error {
+		scod := modules.SiacoinOutputDiff{Direction: modules.DiffApply}
+		copy(scod.ID[:], k)
+		cc.SiacoinOutputDiffs = append(cc.SiacoinOutputDiffs, scod)
+		return encoding.Unmarshal(v, &cc.SiacoinOutputDiffs[len(cc.SiacoinOutputDiffs)-1].SiacoinOutput)
+	})
+	if err != nil {
+		return err
+	}
+	err = b.Bucket(FileContracts).ForEach(func(k, v []byte) error {
+		fcd := modules.FileContractDiff{Direction: modules.DiffApply}
+		copy(fcd.ID[:], k)
+		cc.FileContractDiffs = append(cc.FileContractDiffs, fcd)
+		return encoding.Unmarshal(v, &cc.FileContractDiffs[len(cc.FileContractDiffs)-1].FileContract)
+	})
+	if err != nil {
+		return err
+	}
+	err = b.Bucket(SiafundOutputs).ForEach(func(k, v []byte) error {
+		sfod := modules.SiafundOutputDiff{Direction: modules.DiffApply}
+		copy(sfod.ID[:], k)
+		cc.SiafundOutputDiffs = append(cc.SiafundOutputDiffs, sfod)
+		return encoding.Unmarshal(v, &cc.SiafundOutputDiffs[len(cc.SiafundOutputDiffs)-1].SiafundOutput)
+	})
+	if err != nil {
+		return err
+	}
+	err = b.Bucket(bucketSnapshotDSCOs).ForEach(func(k, v []byte) error {
+		dscod := modules.DelayedSiacoinOutputDiff{Direction: modules.DiffApply}
+		if err := encoding.Unmarshal(k[:8], &dscod.MaturityHeight); err != nil {
+			return err
+		}
+		copy(dscod.ID[:], k[8:])
+		cc.DelayedSiacoinOutputDiffs = append(cc.DelayedSiacoinOutputDiffs, dscod)
+		return encoding.Unmarshal(v, &cc.DelayedSiacoinOutputDiffs[len(cc.DelayedSiacoinOutputDiffs)-1].SiacoinOutput)
+	})
+	if err != nil {
+		return err
+	}
+	sfpd := modules.SiafundPoolDiff{Direction: modules.DiffApply}
+	if err := encoding.Unmarshal(b.Get(SiafundPool), &sfpd.Adjusted); err != nil {
+		return err
+	}
+	cc.SiafundPoolDiffs = append(cc.SiafundPoolDiffs, sfpd)
+	return nil
+}
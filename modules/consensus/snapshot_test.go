@@ -0,0 +1,214 @@
+package consensus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/explorer"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/errors"
+	"github.com/coreos/bbolt"
+)
+
+// TestSnapshot exports a snapshot of a consensus set, bootstraps a second
+// consensus set from it, and checks that both consensus sets stay in sync.
+func TestSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Create a file contract, so that the snapshot contains one and the
+	// siafund pool is not empty.
+	payout := types.NewCurrency64(400e6)
+	txnBuilder, err := cst.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(payout); err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddFileContract(types.FileContract{
+		WindowStart: cst.cs.dbBlockHeight() + 50,
+		WindowEnd:   cst.cs.dbBlockHeight() + 60,
+		Payout:      payout,
+		ValidProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(cst.cs.dbBlockHeight(), payout),
+		}},
+		MissedProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(cst.cs.dbBlockHeight(), payout),
+		}},
+	})
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	for cst.cs.Height() < types.OakHardforkBlock+2 {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Snapshots can only be created for heights of the current path past the
+	// oak hardfork.
+	snapshotFile := filepath.Join(cst.persistDir, "snapshot")
+	if _, err := cst.cs.ExportSnapshot(snapshotFile, cst.cs.Height()+1); err != errSnapshotFutureBlock {
+		t.Fatal("expected errSnapshotFutureBlock, got", err)
+	}
+	if _, err := cst.cs.ExportSnapshot(snapshotFile, types.OakHardforkBlock-1); err != errSnapshotHeight {
+		t.Fatal("expected errSnapshotHeight, got", err)
+	}
+
+	// A snapshot of an earlier height is the same as the snapshot that was
+	// created at that height.
+	height := cst.cs.Height()
+	snapshot, err := cst.cs.ExportSnapshot(snapshotFile, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Height != height || snapshot.BlockID != cst.cs.CurrentBlock().ID() {
+		t.Fatal("wrong snapshot:", snapshot)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshotFile2 := filepath.Join(cst.persistDir, "snapshot2")
+	snapshot2, err := cst.cs.ExportSnapshot(snapshotFile2, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot2 != snapshot {
+		t.Fatal("snapshots of the same height do not match:", snapshot, snapshot2)
+	}
+	b1, err := ioutil.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := ioutil.ReadFile(snapshotFile2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b1, b2) {
+		t.Fatal("snapshot files of the same height do not match")
+	}
+
+	// The snapshot is only imported if its hash matches the trusted hash, and
+	// if it was not modified.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-bootstrap")
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	if _, err := ImportSnapshot(csDir, snapshotFile, crypto.Hash{}); !errors.Contains(err, errSnapshotUntrusted) {
+		t.Fatal("expected errSnapshotUntrusted, got", err)
+	}
+	b1[len(b1)/2]++
+	corruptFile := filepath.Join(cst.persistDir, "corrupt")
+	if err := ioutil.WriteFile(corruptFile, b1, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportSnapshot(csDir, corruptFile, snapshot.Hash); err == nil {
+		t.Fatal("corrupt snapshot was imported")
+	}
+	if _, err := os.Stat(filepath.Join(csDir, DatabaseFilename)); !os.IsNotExist(err) {
+		t.Fatal("failed import left a consensus database behind:", err)
+	}
+	if imported, err := ImportSnapshot(csDir, snapshotFile, snapshot.Hash); err != nil {
+		t.Fatal(err)
+	} else if imported != snapshot {
+		t.Fatal("wrong snapshot imported:", imported)
+	}
+	if _, err := ImportSnapshot(csDir, snapshotFile, snapshot.Hash); err != nil {
+		t.Fatal("importing the same snapshot again should be a no-op:", err)
+	}
+	if _, err := ImportSnapshot(csDir, snapshotFile, crypto.Hash{1}); err != errSnapshotDB {
+		t.Fatal("expected errSnapshotDB, got", err)
+	}
+
+	// Create a consensus set with subscribers from the snapshot.
+	cst2, err := openConsensusSetTester(testdir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+	if cst2.cs.Height() != height || cst2.cs.CurrentBlock().ID() != snapshot.BlockID {
+		t.Fatal("bootstrapped consensus set is not at the snapshot height")
+	}
+	if _, err := cst2.cs.ExportSnapshot(snapshotFile2, height-1); err != errSnapshotHeight {
+		t.Fatal("expected errSnapshotHeight, got", err)
+	}
+
+	// Blocks that fork the blockchain before the snapshot are rejected.
+	parent, _ := cst.cs.BlockAtHeight(height - 1)
+	fork := types.Block{
+		ParentID:  parent.ID(),
+		Timestamp: types.CurrentTimestamp(),
+	}
+	if err := cst2.cs.AcceptBlock(fork); err != errSnapshotFork {
+		t.Fatal("expected errSnapshotFork, got", err)
+	}
+
+	// Give the blocks after the snapshot to the bootstrapped consensus set,
+	// and mine a block on it that the original consensus set accepts.
+	for i := height + 1; i <= cst.cs.Height(); i++ {
+		b, _ := cst.cs.BlockAtHeight(i)
+		if err := cst2.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := cst2.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	checksum := func(cs *ConsensusSet) (c crypto.Hash) {
+		cs.db.View(func(tx *bolt.Tx) error {
+			c = consensusChecksum(tx)
+			return nil
+		})
+		return
+	}
+	if checksum(cst.cs) != checksum(cst2.cs) {
+		t.Fatal("consensus sets do not match")
+	}
+
+	// The bootstrapped consensus set restarts from the snapshot.
+	if err := cst2.cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := New(cst2.gateway, false, csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst2.cs = cs
+	if cs.snapshot != snapshot || cs.Height() != cst.cs.Height() {
+		t.Fatal("snapshot was not loaded")
+	}
+	if s, ok := cs.Snapshot(); !ok || s != snapshot {
+		t.Fatal("bootstrapped consensus set did not report its snapshot")
+	}
+	if _, ok := cst.cs.Snapshot(); ok {
+		t.Fatal("consensus set reported a snapshot it was not bootstrapped from")
+	}
+
+	// The explorer refuses to use the bootstrapped consensus set.
+	if _, err := explorer.New(cs, filepath.Join(testdir, modules.ExplorerDir)); err == nil {
+		t.Fatal("explorer accepted a bootstrapped consensus set")
+	}
+}
@@ -61,6 +61,14 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		}
 
 		cc.AppliedBlocks = append(cc.AppliedBlocks, appliedBlock.Block)
+		if cs.snapshot.Height != 0 && appliedBlockID == cs.snapshot.BlockID {
+			cc.SnapshotHeight = cs.snapshot.Height
+			if err := addSnapshotDiffs(tx, &cc); err != nil {
+				cs.log.Critical("addSnapshotDiffs failed in computeConsensusChange:", err)
+				return modules.ConsensusChange{}, err
+			}
+			continue
+		}
 		for _, scod := range appliedBlock.SiacoinOutputDiffs {
 			cc.SiacoinOutputDiffs = append(cc.SiacoinOutputDiffs, scod)
 		}
@@ -143,12 +151,25 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 	cs.mu.RLock()
 	err := cs.db.View(func(tx *bolt.Tx) error {
 		if start == modules.ConsensusChangeBeginning {
+			// A pruned consensus set is not able to send the blocks that
+			// it has discarded.
+			if cs.pruneHeight != 0 {
+				return modules.ErrConsensusPruned
+			}
+
 			// Special case: for modules.ConsensusChangeBeginning, create an
 			// initial node pointing to the genesis block. The subscriber will
 			// receive the diffs for all blocks in the consensus set, including
 			// the genesis block.
 			entry = cs.genesisEntry()
 			exists = true
+
+			// A consensus set that was bootstrapped from a snapshot has no
+			// blocks before the snapshot, so the first change applies the
+			// snapshot instead.
+			if cs.snapshot.Height != 0 {
+				entry = snapshotEntry(cs.snapshot)
+			}
 		} else {
 			// The subscriber has provided an existing consensus change.
 			// Because the subscriber already has this consensus change,
@@ -191,6 +212,9 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 					return siasync.ErrStopped
 				default:
 				}
+				if cs.entryPruned(tx, entry) {
+					return modules.ErrConsensusPruned
+				}
 				cc, err := cs.computeConsensusChange(tx, entry)
 				if err != nil {
 					return err
@@ -228,7 +252,9 @@ func (cs *ConsensusSet) recentConsensusChangeID() (cid modules.ConsensusChangeID
 // the provided id.
 //
 // As a special case, using an empty id as the start will have all the changes
-// sent to the modules starting with the genesis block.
+// sent to the modules starting with the genesis block. A pruned consensus set
+// returns modules.ErrConsensusPruned if any of the changes contain blocks that
+// it has discarded.
 func (cs *ConsensusSet) ConsensusSetSubscribe(subscriber modules.ConsensusSetSubscriber, start modules.ConsensusChangeID,
 	cancel <-chan struct{}) error {
 
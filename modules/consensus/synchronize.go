@@ -44,6 +44,15 @@ var (
 		Testing:  types.BlockHeight(3),
 	}).(types.BlockHeight)
 
+	// MaxCatchUpHeaders is the maximum number of headers that are sent in a
+	// single SendHeaders RPC during the headers-first initial blockchain
+	// download.
+	MaxCatchUpHeaders = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
 	// minIBDWaitTime is the time threadedInitialBlockchainDownload waits before
 	// exiting if there are >= 1 and <= minNumOutbound peers synced. This timeout
 	// will primarily affect miners who have multiple nodes daisy chained off each
@@ -69,6 +78,20 @@ var (
 		Testing:  4 * time.Second,
 	}).(time.Duration)
 
+	// sendHeadersTimeout is the timeout for the SendHeaders RPC.
+	sendHeadersTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Dev:      30 * time.Second,
+		Testing:  4 * time.Second,
+	}).(time.Duration)
+
+	// sendTxnProofTimeout is the timeout for the SendTxnProof RPC.
+	sendTxnProofTimeout = build.Select(build.Var{
+		Standard: 60 * time.Second,
+		Dev:      20 * time.Second,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
 	// sendBlocksTimeout is the timeout for the SendBlocks RPC.
 	sendBlocksTimeout = build.Select(build.Var{
 		Standard: 180 * time.Second,
@@ -132,6 +155,73 @@ func blockHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
 	return blockIDs
 }
 
+// findSendStart finds the most recent block of knownBlocks in the current
+// path, and returns the height of its child. found is false if none of the
+// blocks are in the current path, or if the most recent one is the current
+// block.
+func (cs *ConsensusSet) findSendStart(tx *bolt.Tx, knownBlocks [32]types.BlockID) (start types.BlockHeight, found bool) {
+	csHeight := blockHeight(tx)
+	for _, id := range knownBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			continue
+		}
+		pathID, err := getPath(tx, pb.Height)
+		if err != nil {
+			continue
+		}
+		if pathID != pb.Block.ID() {
+			continue
+		}
+		// The blocks before the snapshot that the consensus set was
+		// bootstrapped from, or before the prune height, are not available.
+		if pb.Height < cs.floorHeight() {
+			continue
+		}
+		if pb.Height == csHeight {
+			break
+		}
+		// Start from the child of the common block.
+		return pb.Height + 1, true
+	}
+	return 0, false
+}
+
+// blockViolation returns the misbehavior score of a peer that sent a block or
+// header that was rejected with the given error. Errors that an honest peer
+// can cause, because they depend on the blocks that the consensus set knows
+// about or on the local clock, have a score of 0.
+func blockViolation(err error) modules.Violation {
+	switch err {
+	case modules.ErrBlockUnsolved, errEarlyTimestamp, errNonLinearChain:
+		return modules.ViolationMinor
+	case errBadMinerPayouts, errDoSBlock, errLargeBlock:
+		return modules.ViolationSevere
+	}
+	return 0
+}
+
+// managedReportInvalidBlocks reports the peer that sent blocks which were
+// rejected with the given error to the gateway, unless an honest peer could
+// have sent them.
+func (cs *ConsensusSet) managedReportInvalidBlocks(addr modules.NetAddress, blocks []types.Block, err error) {
+	v := blockViolation(err)
+	if v == 0 {
+		// Blocks that are found to be invalid after their header has been
+		// validated are marked as DoS blocks.
+		cs.mu.RLock()
+		for _, b := range blocks {
+			if _, exists := cs.dosBlocks[b.ID()]; exists {
+				v = modules.ViolationSevere
+			}
+		}
+		cs.mu.RUnlock()
+	}
+	if v != 0 {
+		cs.gateway.ReportViolation(addr, v, "sent an invalid block: "+err.Error())
+	}
+}
+
 // managedReceiveBlocks is the calling end of the SendBlocks RPC, without the
 // threadgroup wrapping.
 func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr error) {
@@ -230,6 +320,7 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 		// sharing is implemented, block already in database should also be
 		// ignored.
 		if acceptErr != nil && acceptErr != modules.ErrNonExtendingBlock && acceptErr != modules.ErrBlockKnown {
+			cs.managedReportInvalidBlocks(conn.RPCAddr(), newBlocks, acceptErr)
 			return acceptErr
 		}
 	}
@@ -256,6 +347,13 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) error {
 		return err
 	}
 	defer cs.tg.Done()
+
+	// Don't request blocks from pruned peers, they may have discarded them.
+	for _, p := range cs.gateway.Peers() {
+		if p.NetAddress == conn.RPCAddr() && p.Pruned {
+			return nil
+		}
+	}
 	return cs.managedReceiveBlocks(conn)
 }
 
@@ -293,32 +391,11 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	}
 
 	// Find the most recent block from knownBlocks in the current path.
-	found := false
+	var found bool
 	var start types.BlockHeight
-	var csHeight types.BlockHeight
 	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
-		csHeight = blockHeight(tx)
-		for _, id := range knownBlocks {
-			pb, err := getBlockMap(tx, id)
-			if err != nil {
-				continue
-			}
-			pathID, err := getPath(tx, pb.Height)
-			if err != nil {
-				continue
-			}
-			if pathID != pb.Block.ID() {
-				continue
-			}
-			if pb.Height == csHeight {
-				break
-			}
-			found = true
-			// Start from the child of the common block.
-			start = pb.Height + 1
-			break
-		}
+		start, found = cs.findSendStart(tx, knownBlocks)
 		return nil
 	})
 	cs.mu.RUnlock()
@@ -449,6 +526,9 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 		}()
 		return nil
 	} else if err != nil {
+		if v := blockViolation(err); v != 0 {
+			cs.gateway.ReportViolation(conn.RPCAddr(), v, "relayed an invalid header: "+err.Error())
+		}
 		return err
 	}
 
@@ -531,15 +611,19 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 			cs.managedBroadcastBlock(block)
 		}
 		if err != nil {
+			cs.managedReportInvalidBlocks(conn.RPCAddr(), []types.Block{block}, err)
 			return err
 		}
 		return nil
 	}
 }
 
-// threadedInitialBlockchainDownload performs the IBD on outbound peers. Blocks
-// are downloaded from one peer at a time in 5 minute intervals, so as to
-// prevent any one peer from significantly slowing down IBD.
+// threadedInitialBlockchainDownload performs the IBD on outbound peers. The
+// header chain of each peer is downloaded and validated first, and the blocks
+// are then downloaded in parallel from all outbound peers, so as to prevent
+// any one peer from significantly slowing down IBD. Afterwards, or if the peer
+// does not support headers-first downloads, the remaining blocks are
+// downloaded from the peer with SendBlocks.
 //
 // NOTE: IBD will succeed right now when each peer has a different blockchain.
 // The height and the block id of the remote peers' current blocks are not
@@ -559,14 +643,17 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 	for {
 		numOutboundSynced = 0
 		numOutboundNotSynced = 0
+		// We only sync on outbound peers at first to make IBD less susceptible to
+		// fast-mining and other attacks, as outbound peers are more difficult to
+		// manipulate. Pruned peers are skipped, because they are unable to send
+		// old blocks.
+		var outbound []modules.Peer
 		for _, p := range cs.gateway.Peers() {
-			// We only sync on outbound peers at first to make IBD less susceptible to
-			// fast-mining and other attacks, as outbound peers are more difficult to
-			// manipulate.
-			if p.Inbound {
-				continue
+			if !p.Inbound && !p.Pruned {
+				outbound = append(outbound, p)
 			}
-
+		}
+		for _, p := range outbound {
 			// Put the rest of the iteration inside of a thread group.
 			err := func() error {
 				err := cs.tg.Add()
@@ -575,9 +662,14 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 				}
 				defer cs.tg.Done()
 
-				// Request blocks from the peer. The error returned will only be
-				// 'nil' if there are no more blocks to receive.
-				err = cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.managedReceiveBlocks)
+				// Request the header chain of the peer and download its blocks
+				// from all outbound peers, then request the remaining blocks
+				// from the peer. The error returned will only be 'nil' if there
+				// are no more blocks to receive.
+				err = cs.managedHeadersFirstSync(p.NetAddress, outbound)
+				if err == nil {
+					err = cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.managedReceiveBlocks)
+				}
 				if err == nil {
 					numOutboundSynced++
 					// In this case, 'return nil' is equivalent to skipping to
@@ -0,0 +1,441 @@
+package consensus
+
+// verify.go implements an offline check of a consensus database. The check
+// walks the current path, the consensus state and the change log, and reports
+// every inconsistency that it finds together with the height of the offending
+// block. Databases whose consensus state is inconsistent can be repaired by
+// rebuilding the consensus state from the blocks of the current path.
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/errors"
+	"github.com/coreos/bbolt"
+)
+
+var (
+	errRebuildUnavailable = errors.New("the consensus state can only be rebuilt if all blocks are available, which is not the case for pruned consensus sets and consensus sets bootstrapped from a snapshot")
+
+	// rebuildBatchSize is the number of blocks that are applied in a single
+	// database transaction when the consensus state is rebuilt.
+	rebuildBatchSize = build.Select(build.Var{
+		Standard: 1000,
+		Dev:      100,
+		Testing:  7,
+	}).(int)
+)
+
+type (
+	// An Inconsistency is a problem in a consensus database that was found by
+	// Verify, together with the height of the block of the current path at
+	// which it was found.
+	Inconsistency struct {
+		Height types.BlockHeight
+		Err    error
+	}
+
+	// A VerifyReport is the result of verifying a consensus database.
+	VerifyReport struct {
+		Height          types.BlockHeight
+		CurrentBlock    types.BlockID
+		Inconsistencies []Inconsistency
+	}
+)
+
+// Error implements the error interface.
+func (i Inconsistency) Error() string {
+	return fmt.Sprintf("block %v: %v", i.Height, i.Err)
+}
+
+// add records an inconsistency at the given height.
+func (vr *VerifyReport) add(height types.BlockHeight, err error) {
+	vr.Inconsistencies = append(vr.Inconsistencies, Inconsistency{Height: height, Err: err})
+}
+
+// openExistingDB opens the consensus database in persistDir, which must
+// exist. The database can not be opened while a consensus set is using it.
+func openExistingDB(persistDir string) (*persist.BoltDatabase, error) {
+	filename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	db, err := persist.OpenDatabase(dbMetadata, filename)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open consensus database, make sure that siad is not running")
+	}
+	return db, nil
+}
+
+// Verify checks the consistency of the consensus database in persistDir. The
+// blocks of the current path are checked to be linked and to have valid
+// headers, the consensus state is checked with the same invariants that the
+// consensus set checks in debug builds, and the change log is checked to lead
+// from the genesis block to the current block. The consensus set must not be
+// running.
+func Verify(persistDir string) (VerifyReport, error) {
+	db, err := openExistingDB(persistDir)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	var report VerifyReport
+	err = db.View(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{BlockHeight, BlockMap, BlockPath, BucketOak, ChangeLog, Consistency, FileContracts, SiacoinOutputs, SiafundOutputs, SiafundPool} {
+			if tx.Bucket(bucket) == nil {
+				return fmt.Errorf("consensus database is missing the %s bucket", bucket)
+			}
+		}
+		snapshot, err := getSnapshot(tx)
+		if err != nil {
+			return err
+		}
+		report.Height = blockHeight(tx)
+		report.CurrentBlock, _ = getPath(tx, report.Height)
+
+		var inconsistent bool
+		if err := encoding.Unmarshal(tx.Bucket(Consistency).Get(Consistency), &inconsistent); err != nil || inconsistent {
+			report.add(report.Height, errors.New("consensus set has marked the database as inconsistent"))
+		}
+		verifyPath(tx, snapshot, &report)
+		for _, check := range stateChecks {
+			if err := check(tx); err != nil {
+				report.add(report.Height, err)
+			}
+		}
+		if pb, err := getBlockMap(tx, report.CurrentBlock); err == nil && pb.ConsensusChecksum != (crypto.Hash{}) && consensusChecksum(tx) != pb.ConsensusChecksum {
+			report.add(report.Height, errors.New("consensus checksum does not match the checksum of the current block"))
+		}
+		verifyChangeLog(tx, snapshot, &report)
+		return nil
+	})
+	return report, errors.Compose(err, db.Close())
+}
+
+// verifyPath checks that the blocks of the current path are linked, that
+// their headers are valid, and that the child target, depth and difficulty
+// totals stored for them match the values computed from the headers.
+func verifyPath(tx *bolt.Tx, snapshot modules.ConsensusSnapshot, report *VerifyReport) {
+	// The header chain only uses the consensus set for the fork height check,
+	// which must not apply to the blocks of the current path.
+	cs := new(ConsensusSet)
+	var hc *headerChain
+	var parentID types.BlockID
+	for height := types.BlockHeight(0); height <= report.Height; height++ {
+		id, err := getPath(tx, height)
+		if err != nil {
+			report.add(height, errors.New("block is missing from the current path"))
+			hc, parentID = nil, types.BlockID{}
+			continue
+		}
+
+		// Blocks whose bodies have been pruned are checked using their
+		// headers. The blocks before the snapshot that the consensus set was
+		// bootstrapped from are not available.
+		var header types.BlockHeader
+		pb, err := getBlockMap(tx, id)
+		if err == nil {
+			header = pb.Block.Header()
+			if pb.Height != height {
+				report.add(height, fmt.Errorf("block is stored with height %v", pb.Height))
+			}
+		} else if h, exists := getPrunedHeader(tx, id); exists {
+			header, pb = h, nil
+		} else if height < snapshot.Height {
+			parentID = id
+			continue
+		} else {
+			report.add(height, errors.New("block of the current path is missing from the block map"))
+			hc, parentID = nil, id
+			continue
+		}
+		if header.ID() != id {
+			report.add(height, errors.New("stored block does not match its id"))
+			hc, parentID = nil, id
+			continue
+		}
+		if parentID != (types.BlockID{}) && header.ParentID != parentID {
+			report.add(height, errors.New("block is not a child of the previous block of the current path"))
+			hc = nil
+		}
+		parentID = id
+
+		// Validate the header and compare the computed values to the stored
+		// values.
+		totals := tx.Bucket(BucketOak).Get(id[:])
+		if hc != nil {
+			if err := cs.extendHeaderChain(tx, hc, header); err != nil {
+				report.add(height, errors.AddContext(err, "invalid block header"))
+				hc = nil
+			} else if pb != nil && (hc.tip.ChildTarget != pb.ChildTarget || hc.tip.Depth != pb.Depth) {
+				report.add(height, errors.New("stored child target or depth does not match the header chain"))
+				hc = nil
+			} else if totals != nil {
+				if totalTime, totalTarget := cs.getBlockTotals(tx, id); totalTime != hc.tip.TotalTime || totalTarget != hc.tip.TotalTarget {
+					report.add(height, errors.New("stored difficulty totals do not match the header chain"))
+					hc = nil
+				}
+			}
+		}
+
+		// Continue validating from the stored values of the block after an
+		// inconsistency, or from the first block whose values are stored.
+		if hc == nil && pb != nil && totals != nil {
+			hc, err = cs.newHeaderChain(tx, id)
+			if err != nil {
+				hc = nil
+			}
+		}
+	}
+}
+
+// verifyChangeLog checks that the change log is a linked list of entries that
+// starts with the genesis block, or with the snapshot that the consensus set
+// was bootstrapped from, and that reverting and applying the blocks of its
+// entries leads to the current block.
+func verifyChangeLog(tx *bolt.Tx, snapshot modules.ConsensusSnapshot, report *VerifyReport) {
+	cl := tx.Bucket(ChangeLog)
+	var numEntries int
+	cl.ForEach(func(k, _ []byte) error {
+		if !bytes.Equal(k, ChangeLogTailID) {
+			numEntries++
+		}
+		return nil
+	})
+
+	// parentOf returns the parent of a block in the block map, or of a block
+	// whose body has been pruned.
+	parentOf := func(id types.BlockID) (types.BlockID, bool) {
+		if pb, err := getBlockMap(tx, id); err == nil {
+			return pb.Block.ParentID, true
+		}
+		h, exists := getPrunedHeader(tx, id)
+		return h.ParentID, exists
+	}
+
+	// Follow the blocks of the entries, starting before the first block. The
+	// genesis block is taken from the database, which allows databases of
+	// other networks to be verified.
+	genesisID, _ := getPath(tx, 0)
+	first := changeEntry{AppliedBlocks: []types.BlockID{genesisID}}
+	var tipID types.BlockID
+	underflow := types.BlockHeight(0)
+	tipHeight := underflow - 1
+	if snapshot.Height > 0 {
+		first = snapshotEntry(snapshot)
+		tipID, _ = parentOf(snapshot.BlockID)
+		tipHeight = snapshot.Height - 1
+	}
+	id := first.ID()
+	for visited := 1; ; visited++ {
+		if visited > numEntries {
+			report.add(tipHeight, errors.New("change log contains a cycle"))
+			return
+		}
+		var cn changeNode
+		cnBytes := cl.Get(id[:])
+		if cnBytes == nil {
+			report.add(tipHeight, errors.New("change log entry is missing"))
+			return
+		} else if err := encoding.Unmarshal(cnBytes, &cn); err != nil {
+			report.add(tipHeight, errors.AddContext(err, "unable to decode change log entry"))
+			return
+		} else if cn.Entry.ID() != id {
+			report.add(tipHeight, errors.New("change log entry does not match its id"))
+			return
+		}
+		for _, bid := range cn.Entry.RevertedBlocks {
+			parent, exists := parentOf(bid)
+			if !exists || bid != tipID {
+				report.add(tipHeight, errors.New("change log reverts a block that is not the current block"))
+				return
+			}
+			tipID, tipHeight = parent, tipHeight-1
+		}
+		for _, bid := range cn.Entry.AppliedBlocks {
+			parent, exists := parentOf(bid)
+			if !exists || parent != tipID {
+				report.add(tipHeight+1, errors.New("change log applies a block that is not a child of the current block"))
+				return
+			}
+			tipID, tipHeight = bid, tipHeight+1
+		}
+
+		if cn.Next == (modules.ConsensusChangeID{}) {
+			if !bytes.Equal(cl.Get(ChangeLogTailID), id[:]) {
+				report.add(tipHeight, errors.New("change log tail does not point to the last entry"))
+			}
+			if tipID != report.CurrentBlock {
+				report.add(tipHeight, errors.New("change log does not end with the current block"))
+			}
+			if visited != numEntries {
+				report.add(tipHeight, fmt.Errorf("change log contains %v entries that are not linked", numEntries-visited))
+			}
+			return
+		}
+		id = cn.Next
+	}
+}
+
+// Rebuild recreates the consensus state of the consensus database in
+// persistDir, which consists of the outputs, the file contracts, the siafund
+// pool and the current path, by applying the blocks of the current path again
+// starting from the genesis block. The blocks are validated in the process.
+// This repairs a database whose consensus state is inconsistent, as long as
+// its blocks are intact. The original database is kept as a backup, whose
+// path is returned. The consensus set must not be running.
+func Rebuild(persistDir string) (backup string, err error) {
+	db, err := openExistingDB(persistDir)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			err = errors.Compose(err, closeErr)
+		}
+	}()
+
+	// Collect the current path. The blocks of the current path must be
+	// intact, because the consensus state is rebuilt from them.
+	var ids []types.BlockID
+	err = db.View(func(tx *bolt.Tx) error {
+		snapshot, err := getSnapshot(tx)
+		if err != nil {
+			return err
+		}
+		pruneHeight, err := getPruneHeight(tx)
+		if err != nil {
+			return err
+		}
+		if snapshot.Height > 0 || pruneHeight > 0 {
+			return errRebuildUnavailable
+		}
+		height := blockHeight(tx)
+		for i := types.BlockHeight(0); i <= height; i++ {
+			id, err := getPath(tx, i)
+			if err != nil {
+				return Inconsistency{Height: i, Err: errors.New("block is missing from the current path")}
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return Inconsistency{Height: i, Err: errors.New("block of the current path is missing from the block map")}
+			}
+			if i > 0 && pb.Block.ParentID != ids[i-1] {
+				return Inconsistency{Height: i, Err: errors.New("block is not a child of the previous block of the current path")}
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Back up the database before it is modified.
+	err = db.View(func(tx *bolt.Tx) error {
+		f, err := createBackupFile(filepath.Join(persistDir, DatabaseFilename))
+		if err != nil {
+			return err
+		}
+		backup = f.Name()
+		_, err = tx.WriteTo(f)
+		return errors.Compose(err, f.Sync(), f.Close())
+	})
+	if err != nil {
+		return "", errors.AddContext(err, "unable to back up consensus database")
+	}
+
+	// Remove the consensus state.
+	err = db.Update(func(tx *bolt.Tx) error {
+		// The database stays marked as inconsistent until the rebuild has
+		// finished.
+		markInconsistency(tx)
+		for _, bucket := range [][]byte{BlockPath, FileContracts, SiacoinOutputs, SiafundOutputs, SiafundPool} {
+			if err := tx.DeleteBucket(bucket); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(bucket); err != nil {
+				return err
+			}
+		}
+		var prefixed [][]byte
+		err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if bytes.HasPrefix(name, prefixDSCO) || bytes.HasPrefix(name, prefixFCEX) {
+				prefixed = append(prefixed, append([]byte(nil), name...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range prefixed {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		underflow := types.BlockHeight(0)
+		if err := tx.Bucket(BlockHeight).Put(BlockHeight, encoding.Marshal(underflow-1)); err != nil {
+			return err
+		}
+		genesis, err := getBlockMap(tx, ids[0])
+		if err != nil {
+			return err
+		}
+		applyGenesisState(tx, genesis)
+		return nil
+	})
+	if err != nil {
+		return backup, err
+	}
+
+	// Apply the blocks in batches, so that the changes of a single database
+	// transaction fit into memory.
+	for start := 1; start < len(ids); start += rebuildBatchSize {
+		err = db.Update(func(tx *bolt.Tx) error {
+			for i := start; i < start+rebuildBatchSize && i < len(ids); i++ {
+				pb, err := getBlockMap(tx, ids[i])
+				if err != nil {
+					return err
+				}
+				pb.SiacoinOutputDiffs = nil
+				pb.FileContractDiffs = nil
+				pb.SiafundOutputDiffs = nil
+				pb.DelayedSiacoinOutputDiffs = nil
+				pb.SiafundPoolDiffs = nil
+				pb.DiffsGenerated = false
+				if err := generateAndApplyDiff(tx, pb); err != nil {
+					return Inconsistency{Height: types.BlockHeight(i), Err: err}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return backup, errors.AddContext(err, "unable to rebuild consensus state, the backup of the consensus database can be restored from "+backup)
+		}
+	}
+	return backup, db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(Consistency).Put(Consistency, encoding.Marshal(false))
+	})
+}
+
+// createBackupFile creates the file for a backup of the database at filename.
+// The backup is named filename.bck, or filename.bck.N if that file exists, so
+// that earlier backups are never overwritten.
+func createBackupFile(filename string) (*os.File, error) {
+	name := filename + ".bck"
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if !os.IsExist(err) {
+			return f, err
+		}
+		name = fmt.Sprintf("%v.bck.%v", filename, i)
+	}
+}
@@ -0,0 +1,168 @@
+package consensus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestVerify checks that Verify finds corruption of a consensus database, and
+// that Rebuild repairs a corrupted consensus state.
+func TestVerify(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Reorg onto a longer chain, so that the change log reverts blocks.
+	cst2, err := blankConsensusSetTester(t.Name()+"-fork", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+	for cst2.cs.Height() <= cst.cs.Height() {
+		if _, err := cst2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := types.BlockHeight(1); i <= cst2.cs.Height(); i++ {
+		b, _ := cst2.cs.BlockAtHeight(i)
+		if err := cst.cs.AcceptBlock(b); err != nil && err != modules.ErrBlockKnown && err != modules.ErrNonExtendingBlock {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	height := cst.cs.Height()
+	currentBlock := cst.cs.CurrentBlock().ID()
+	csDir := filepath.Join(cst.persistDir, modules.ConsensusDir)
+	if err := cst.cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The consensus database is consistent.
+	report, err := Verify(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Inconsistencies) != 0 {
+		t.Fatal("consistent database has inconsistencies:", report.Inconsistencies)
+	}
+	if report.Height != height || report.CurrentBlock != currentBlock {
+		t.Fatal("wrong height or current block:", report.Height, report.CurrentBlock)
+	}
+
+	// corrupt applies a change to the consensus database.
+	corrupt := func(fn func(tx *bolt.Tx) error) {
+		db, err := persist.OpenDatabase(dbMetadata, filepath.Join(csDir, DatabaseFilename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Update(fn); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Remove a siacoin output from the consensus state.
+	corrupt(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(SiacoinOutputs).Cursor().First()
+		return tx.Bucket(SiacoinOutputs).Delete(k)
+	})
+	report, err = Verify(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Inconsistencies) == 0 {
+		t.Fatal("missing output was not detected")
+	}
+	for _, inc := range report.Inconsistencies {
+		if inc.Height != height {
+			t.Fatal("expected inconsistencies at the current height, got", inc)
+		}
+	}
+
+	// Rebuild the consensus state.
+	backup, err := Rebuild(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != filepath.Join(csDir, DatabaseFilename+".bck") {
+		t.Fatal("database was backed up to the wrong file:", backup)
+	}
+	if _, err := os.Stat(backup); err != nil {
+		t.Fatal("database was not backed up:", err)
+	}
+
+	// A second rebuild keeps the first backup.
+	backup, err = Rebuild(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != filepath.Join(csDir, DatabaseFilename+".bck.1") {
+		t.Fatal("earlier backup was overwritten:", backup)
+	}
+	report, err = Verify(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Inconsistencies) != 0 {
+		t.Fatal("rebuilt database has inconsistencies:", report.Inconsistencies)
+	}
+
+	// Remove a block of the current path.
+	var pbBytes []byte
+	corrupt(func(tx *bolt.Tx) error {
+		id, _ := getPath(tx, 5)
+		pbBytes = append([]byte(nil), tx.Bucket(BlockMap).Get(id[:])...)
+		return tx.Bucket(BlockMap).Delete(id[:])
+	})
+	report, err = Verify(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Inconsistencies) == 0 || report.Inconsistencies[0].Height != 5 {
+		t.Fatal("expected an inconsistency at height 5, got", report.Inconsistencies)
+	}
+	if _, err := Rebuild(csDir); err == nil || err.(Inconsistency).Height != 5 {
+		t.Fatal("expected rebuild to fail at height 5, got", err)
+	}
+
+	// Restore the block, and check that the consensus set loads the rebuilt
+	// database.
+	corrupt(func(tx *bolt.Tx) error {
+		id, _ := getPath(tx, 5)
+		return tx.Bucket(BlockMap).Put(id[:], pbBytes)
+	})
+	report, err = Verify(csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Inconsistencies) != 0 {
+		t.Fatal("restored database has inconsistencies:", report.Inconsistencies)
+	}
+	cs, err := New(cst.gateway, false, csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst.cs = cs
+	if cs.Height() != height || cs.CurrentBlock().ID() != currentBlock {
+		t.Fatal("rebuilt consensus set has the wrong current block")
+	}
+}
@@ -210,7 +210,7 @@ func (pd *ProductionDependencies) Destruct() {
 // DialTimeout creates a tcp connection to a certain address with the specified
 // timeout.
 func (*ProductionDependencies) DialTimeout(addr NetAddress, timeout time.Duration) (net.Conn, error) {
-	return net.DialTimeout("tcp", string(addr), timeout)
+	return (&Dialer{Timeout: timeout}).Dial(addr)
 }
 
 // Disrupt can be used to inject specific behavior into a module by overwriting
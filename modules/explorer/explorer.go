@@ -17,7 +17,8 @@ const (
 )
 
 var (
-	errNilCS = errors.New("explorer cannot use a nil consensus set")
+	errNilCS      = errors.New("explorer cannot use a nil consensus set")
+	errSnapshotCS = errors.New("explorer needs the full history of the blockchain, which is not available to a consensus set that was bootstrapped from a snapshot")
 )
 
 type (
@@ -57,6 +58,9 @@ func New(cs modules.ConsensusSet, persistDir string) (*Explorer, error) {
 	if cs == nil {
 		return nil, errNilCS
 	}
+	if _, ok := cs.Snapshot(); ok {
+		return nil, errSnapshotCS
+	}
 
 	// Initialize the explorer.
 	e := &Explorer{
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"net"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 )
@@ -12,6 +13,18 @@ const (
 	GatewayDir = "gateway"
 )
 
+const (
+	// ViolationMinor is the misbehavior score of a violation that an honest
+	// peer is unlikely to commit, such as relaying a header that does not
+	// meet its target. A peer is banned after repeated minor violations.
+	ViolationMinor Violation = 10
+
+	// ViolationSevere is the misbehavior score of a violation that an honest
+	// peer never commits, such as sending a block that is invalid. A peer is
+	// banned after a single severe violation.
+	ViolationSevere Violation = 100
+)
+
 var (
 	// BootstrapPeers is a list of peers that can be used to find other peers -
 	// when a client first connects to the network, the only options for
@@ -134,9 +147,23 @@ type (
 		Inbound    bool       `json:"inbound"`
 		Local      bool       `json:"local"`
 		NetAddress NetAddress `json:"netaddress"`
+		Pruned     bool       `json:"pruned"`
 		Version    string     `json:"version"`
 	}
 
+	// A PeerBan is a ban of an IP address or of a subnet. The gateway does not
+	// connect to banned nodes and does not accept connections from them.
+	PeerBan struct {
+		// Host is an IP address, or a subnet in CIDR notation.
+		Host   string    `json:"host"`
+		Expiry time.Time `json:"expiry"`
+		Reason string    `json:"reason"`
+	}
+
+	// A Violation is the misbehavior score of a protocol violation committed
+	// by a peer.
+	Violation int
+
 	// A PeerConn is the connection type used when communicating with peers during
 	// an RPC. It is identical to a net.Conn with the additional RPCAddr method.
 	// This method acts as an identifier for peers and is the address that the
@@ -202,6 +229,28 @@ type (
 		// Online returns true if the gateway is connected to remote hosts
 		Online() bool
 
+		// SetPruned sets whether the gateway advertises to new peers that the
+		// node has discarded the bodies of old blocks.
+		SetPruned(bool)
+
+		// ReportViolation adds the score of a violation committed by the peer
+		// at the given address to the misbehavior scores of its IP address
+		// and its subnet. Peers whose score exceeds the ban threshold are
+		// disconnected and banned.
+		ReportViolation(addr NetAddress, v Violation, reason string)
+
+		// Ban bans an IP address, or a subnet in CIDR notation, for the given
+		// duration, and disconnects the peers that it covers. A duration of 0
+		// uses the default ban duration of the gateway.
+		Ban(host string, duration time.Duration, reason string) error
+
+		// Unban lifts the ban of an IP address or subnet.
+		Unban(host string) error
+
+		// Bans returns the IP addresses and subnets that are currently
+		// banned.
+		Bans() []PeerBan
+
 		// Close safely stops the Gateway's listener process.
 		Close() error
 	}
@@ -0,0 +1,287 @@
+package gateway
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	errBanNotFound   = errors.New("host is not banned")
+	errInvalidBanArg = errors.New("host must be an IP address, a subnet in CIDR notation or an onion address")
+	errPeerBanned    = errors.New("peer is banned")
+)
+
+// A misbehavior is the misbehavior score of an IP address or subnet. The
+// score decays over time, halving every scoreHalfLife.
+type misbehavior struct {
+	score   float64
+	updated time.Time
+}
+
+// decayedScore returns the score of the misbehavior at the given time.
+func (m misbehavior) decayedScore(now time.Time) float64 {
+	halfLives := float64(now.Sub(m.updated)) / float64(scoreHalfLife)
+	return m.score * math.Pow(0.5, halfLives)
+}
+
+// subnetOf returns the subnet that the misbehavior of an IP address is
+// attributed to, which is the /24 subnet for IPv4 addresses and the /64
+// subnet for IPv6 addresses.
+func subnetOf(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		mask := net.CIDRMask(24, 32)
+		return &net.IPNet{IP: ip4.Mask(mask), Mask: mask}
+	}
+	mask := net.CIDRMask(64, 128)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// normalizeBanHost returns the canonical form of an IP address, a subnet in
+// CIDR notation or an onion address.
+func normalizeBanHost(host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	if _, subnet, err := net.ParseCIDR(host); err == nil {
+		return subnet.String(), nil
+	}
+	if na := modules.NetAddress(net.JoinHostPort(host, "1")); na.IsOnion() && na.IsStdValid() == nil {
+		return strings.TrimSuffix(strings.ToLower(host), "."), nil
+	}
+	return "", errInvalidBanArg
+}
+
+// banCovers returns true if the banned host, which is an IP address or a
+// subnet, covers the given host.
+func banCovers(banHost, host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return banHost == strings.TrimSuffix(strings.ToLower(host), ".")
+	}
+	if _, subnet, err := net.ParseCIDR(banHost); err == nil {
+		return subnet.Contains(ip)
+	}
+	return banHost == ip.String()
+}
+
+// isBanned returns true if the host of a node is covered by a ban that has not
+// expired.
+func (g *Gateway) isBanned(host string) bool {
+	now := time.Now()
+	for _, b := range g.bans {
+		if now.Before(b.Expiry) && banCovers(b.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// banHost bans a normalized IP address or subnet until the given time, and
+// removes the nodes that it covers. The addresses of the peers that it covers,
+// including inbound onion peers that claim a covered onion address, are
+// returned, so that the caller can disconnect them once it has released the
+// lock.
+func (g *Gateway) banHost(host string, expiry time.Time, reason string) (covered []modules.NetAddress) {
+	g.bans[host] = modules.PeerBan{
+		Host:   host,
+		Expiry: expiry,
+		Reason: reason,
+	}
+	delete(g.scores, host)
+	for addr, p := range g.peers {
+		if banCovers(host, addr.Host()) || (p.onion != "" && banCovers(host, p.onion.Host())) {
+			covered = append(covered, addr)
+		}
+	}
+	for addr := range g.nodes {
+		if banCovers(host, addr.Host()) {
+			delete(g.nodes, addr)
+		}
+	}
+	g.log.Printf("INFO: banned %v until %v: %v", host, expiry.Format(time.RFC3339), reason)
+	return covered
+}
+
+// managedDisconnectBanned disconnects from the peers that were covered by a
+// ban.
+func (g *Gateway) managedDisconnectBanned(addrs []modules.NetAddress) {
+	for _, addr := range addrs {
+		// The peer might have disconnected in the meantime.
+		if err := g.Disconnect(addr); err == nil {
+			g.log.Printf("INFO: disconnected from banned peer %v", addr)
+		}
+	}
+}
+
+// addScore adds the score of a violation to the misbehavior score of an IP
+// address or subnet, and returns the new score.
+func (g *Gateway) addScore(host string, v modules.Violation, now time.Time) float64 {
+	m, exists := g.scores[host]
+	if !exists {
+		m = &misbehavior{}
+		g.scores[host] = m
+	}
+	m.score = m.decayedScore(now) + float64(v)
+	m.updated = now
+	return m.score
+}
+
+// purgeBans removes the bans that have expired and the misbehavior scores
+// that have decayed.
+func (g *Gateway) purgeBans() {
+	now := time.Now()
+	for host, b := range g.bans {
+		if !now.Before(b.Expiry) {
+			delete(g.bans, host)
+		}
+	}
+	for host, m := range g.scores {
+		if m.decayedScore(now) < 1 {
+			delete(g.scores, host)
+		}
+	}
+}
+
+// ReportViolation adds the score of a violation committed by the peer at the
+// given address to the misbehavior scores of its IP address and its subnet.
+// If the score of the IP address exceeds banThreshold, the IP address is
+// banned, and if the score of the subnet exceeds subnetBanThreshold, the
+// whole subnet is banned. Onion addresses have no subnet, and are scored
+// individually. Inbound peers that connect through a Tor onion service can
+// not prove their onion address, so they are scored per connection, and are
+// disconnected rather than banned.
+func (g *Gateway) ReportViolation(addr modules.NetAddress, v modules.Violation, reason string) {
+	g.mu.Lock()
+	if p, exists := g.peers[addr]; exists && p.onion != "" {
+		now := time.Now()
+		p.score.score = p.score.decayedScore(now) + float64(v)
+		p.score.updated = now
+		disconnect := p.score.score >= float64(banThreshold)
+		g.log.Debugf("INFO: peer %v claiming %v committed a violation with score %v: %v", addr, p.onion, v, reason)
+		g.mu.Unlock()
+		if disconnect {
+			if err := g.Disconnect(addr); err == nil {
+				g.log.Printf("INFO: disconnected from misbehaving peer %v: %v", addr, reason)
+			}
+		}
+		return
+	}
+	ip := net.ParseIP(addr.Host())
+	if ip == nil && !addr.IsOnion() {
+		g.mu.Unlock()
+		return
+	}
+	g.log.Debugf("INFO: peer %v committed a violation with score %v: %v", addr, v, reason)
+
+	now := time.Now()
+	banned := false
+	var covered []modules.NetAddress
+	if ip == nil {
+		host, err := normalizeBanHost(addr.Host())
+		if err == nil && g.addScore(host, v, now) >= float64(banThreshold) {
+			covered = g.banHost(host, now.Add(g.banDuration), reason)
+			banned = true
+		}
+	} else {
+		if g.addScore(ip.String(), v, now) >= float64(banThreshold) {
+			covered = g.banHost(ip.String(), now.Add(g.banDuration), reason)
+			banned = true
+		}
+		subnet := subnetOf(ip).String()
+		if g.addScore(subnet, v, now) >= float64(subnetBanThreshold) {
+			covered = append(covered, g.banHost(subnet, now.Add(g.banDuration), "multiple peers of the subnet misbehaved, last violation: "+reason)...)
+			banned = true
+		}
+	}
+	if banned {
+		if err := g.saveSync(); err != nil {
+			g.log.Println("ERROR: Unable to save gateway persist:", err)
+		}
+	}
+	g.mu.Unlock()
+	g.managedDisconnectBanned(covered)
+}
+
+// Ban bans an IP address, or a subnet in CIDR notation, for the given
+// duration, and disconnects the peers that it covers. A duration of 0 uses
+// the default ban duration of the gateway.
+func (g *Gateway) Ban(host string, duration time.Duration, reason string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	host, err := normalizeBanHost(host)
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return errors.New("ban duration must not be negative")
+	}
+
+	g.mu.Lock()
+	if duration == 0 {
+		duration = g.banDuration
+	}
+	if reason == "" {
+		reason = "banned manually"
+	}
+	covered := g.banHost(host, time.Now().Add(duration), reason)
+	err = g.saveSync()
+	g.mu.Unlock()
+	g.managedDisconnectBanned(covered)
+	return err
+}
+
+// Unban lifts the ban of an IP address or subnet.
+func (g *Gateway) Unban(host string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	host, err := normalizeBanHost(host)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.purgeBans()
+	if _, exists := g.bans[host]; !exists {
+		return errBanNotFound
+	}
+	delete(g.bans, host)
+	g.log.Println("INFO: lifted the ban of", host)
+	return g.saveSync()
+}
+
+// Bans returns the IP addresses and subnets that are currently banned, sorted
+// by host.
+func (g *Gateway) Bans() []modules.PeerBan {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	now := time.Now()
+	var bans []modules.PeerBan
+	for _, b := range g.bans {
+		if now.Before(b.Expiry) {
+			bans = append(bans, b)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Host < bans[j].Host
+	})
+	return bans
+}
+
+// SetBanDuration sets the duration of the bans that are caused by
+// misbehavior, and of manual bans without a duration.
+func (g *Gateway) SetBanDuration(duration time.Duration) {
+	g.mu.Lock()
+	g.banDuration = duration
+	g.mu.Unlock()
+}
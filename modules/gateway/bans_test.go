@@ -0,0 +1,170 @@
+package gateway
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+)
+
+// TestReportViolation checks that a peer is banned once its misbehavior score
+// exceeds the ban threshold, and that the ban is persisted.
+func TestReportViolation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Minor violations do not lead to a ban until they add up.
+	for i := modules.Violation(0); i < banThreshold/modules.ViolationMinor-1; i++ {
+		g1.ReportViolation(g2.Address(), modules.ViolationMinor, "test violation")
+	}
+	if len(g1.Bans()) != 0 || len(g1.Peers()) != 1 {
+		t.Fatal("peer was banned before reaching the ban threshold")
+	}
+	// The score has decayed slightly since the first violation, so it takes
+	// two more violations to exceed the threshold.
+	g1.ReportViolation(g2.Address(), modules.ViolationMinor, "test violation")
+	g1.ReportViolation(g2.Address(), modules.ViolationMinor, "test violation")
+	bans := g1.Bans()
+	if len(bans) != 1 || bans[0].Host != g2.Address().Host() || bans[0].Reason != "test violation" {
+		t.Fatal("peer was not banned:", bans)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("banned peer is still connected")
+	}
+	var saved []modules.PeerBan
+	if err := persist.LoadJSON(bansMetadata, &saved, filepath.Join(g1.persistDir, bansFile)); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Host != g2.Address().Host() {
+		t.Fatal("ban was not saved right away:", saved)
+	}
+
+	// The banned peer can not be connected to, and can not connect.
+	if err := g1.Connect(g2.Address()); err != errPeerBanned {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("banned peer was able to connect")
+	}
+	g1.mu.Lock()
+	err := g1.addNode(g2.Address())
+	g1.mu.Unlock()
+	if err != errPeerBanned {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+
+	// The ban is persisted.
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err = New("localhost:0", false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.Bans()) != 1 {
+		t.Fatal("ban was not persisted")
+	}
+
+	// The peer can connect again after the ban has been lifted.
+	if err := g1.Unban(g2.Address().Host()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Unban(g2.Address().Host()); err != errBanNotFound {
+		t.Fatal("expected errBanNotFound, got", err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBan checks that IP addresses and subnets can be banned manually, and that
+// bans expire.
+func TestBan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g1.Ban("not an address", 0, ""); err != errInvalidBanArg {
+		t.Fatal("expected errInvalidBanArg, got", err)
+	}
+
+	// Banning the subnet of the peer disconnects it. The subnet is
+	// normalized.
+	if err := g1.Ban("127.0.0.5/24", time.Second, ""); err != nil {
+		t.Fatal(err)
+	}
+	bans := g1.Bans()
+	if len(bans) != 1 || bans[0].Host != "127.0.0.0/24" || bans[0].Reason == "" {
+		t.Fatal("subnet was not banned:", bans)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("peer of the banned subnet is still connected")
+	}
+	if err := g1.Connect(g2.Address()); err != errPeerBanned {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+
+	// The ban expires.
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		if len(g1.Bans()) != 0 {
+			return errPeerBanned
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("ban did not expire")
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMisbehaviorScore checks the decay of misbehavior scores and the subnets
+// that misbehavior is attributed to.
+func TestMisbehaviorScore(t *testing.T) {
+	now := time.Now()
+	m := misbehavior{score: 100, updated: now}
+	if s := m.decayedScore(now); s != 100 {
+		t.Error("score decayed without time passing:", s)
+	}
+	if s := m.decayedScore(now.Add(scoreHalfLife)); s != 50 {
+		t.Error("score did not halve after one half-life:", s)
+	}
+
+	tests := []struct {
+		ip     string
+		subnet string
+	}{
+		{"1.2.3.4", "1.2.3.0/24"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+	}
+	for _, test := range tests {
+		if s := subnetOf(net.ParseIP(test.ip)).String(); s != test.subnet {
+			t.Errorf("expected subnet %v for %v, got %v", test.subnet, test.ip, s)
+		}
+	}
+	if !banCovers("1.2.3.0/24", "1.2.3.4") || banCovers("1.2.3.0/24", "1.2.4.4") || !banCovers("1.2.3.4", "1.2.3.4") {
+		t.Error("banCovers returned the wrong result")
+	}
+}
@@ -23,11 +23,11 @@ func (pc peerConn) RPCAddr() modules.NetAddress {
 // handles things like clean shutdown, fast shutdown, and chooses the correct
 // communication protocol.
 func (g *Gateway) staticDial(addr modules.NetAddress) (net.Conn, error) {
-	dialer := &net.Dialer{
+	dialer := &modules.Dialer{
 		Cancel:  g.threads.StopChan(),
 		Timeout: dialTimeout,
 	}
-	conn, err := dialer.Dial("tcp", string(addr))
+	conn, err := dialer.Dial(addr)
 	if err != nil {
 		return nil, err
 	}
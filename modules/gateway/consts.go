@@ -14,7 +14,7 @@ const (
 
 	// maxEncodedSessionHeaderSize is the maximum allowed size of an encoded
 	// sessionHeader object.
-	maxEncodedSessionHeaderSize = 40 + modules.MaxEncodedNetAddressLength
+	maxEncodedSessionHeaderSize = 41 + modules.MaxEncodedNetAddressLength
 
 	// maxLocalOutbound is currently set to 3, meaning the gateway will not
 	// consider a local node to be an outbound peer if the gateway already has
@@ -250,3 +250,30 @@ var (
 		Testing:  100 * time.Millisecond,
 	}).(time.Duration)
 )
+
+var (
+	// banThreshold is the misbehavior score at which an IP address is
+	// banned.
+	banThreshold = modules.ViolationSevere
+
+	// subnetBanThreshold is the misbehavior score at which a subnet is
+	// banned. It is higher than banThreshold, so that a subnet is only banned
+	// if several of its IP addresses misbehave.
+	subnetBanThreshold = 3 * modules.ViolationSevere
+
+	// defaultBanDuration is the duration of a ban if no other duration is
+	// set.
+	defaultBanDuration = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      10 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// scoreHalfLife is the time after which half of a misbehavior score is
+	// forgiven.
+	scoreHalfLife = build.Select(build.Var{
+		Standard: 1 * time.Hour,
+		Dev:      10 * time.Minute,
+		Testing:  1 * time.Minute,
+	}).(time.Duration)
+)
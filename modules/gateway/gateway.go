@@ -145,6 +145,19 @@ type Gateway struct {
 	peers  map[modules.NetAddress]*peer
 	peerTG siasync.ThreadGroup
 
+	// bans are the banned IP addresses and subnets, keyed by their host.
+	//
+	// scores are the misbehavior scores of the IP addresses and subnets of
+	// peers that have committed violations. A host whose score exceeds the
+	// ban threshold is banned for banDuration.
+	bans        map[string]modules.PeerBan
+	scores      map[string]*misbehavior
+	banDuration time.Duration
+
+	// pruned is advertised to peers in the session header. It is set by a
+	// consensus set that discards the bodies of old blocks.
+	pruned bool
+
 	// Utilities.
 	log        *persist.Logger
 	mu         sync.RWMutex
@@ -201,6 +214,10 @@ func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 		nodes: make(map[modules.NetAddress]*node),
 		peers: make(map[modules.NetAddress]*peer),
 
+		bans:        make(map[string]modules.PeerBan),
+		scores:      make(map[string]*misbehavior),
+		banDuration: defaultBanDuration,
+
 		persistDir: persistDir,
 	}
 
@@ -34,8 +34,12 @@ func (g *Gateway) addNode(addr modules.NetAddress) error {
 		return errNodeExists
 	} else if addr.IsStdValid() != nil {
 		return errors.New("address is not valid: " + string(addr))
-	} else if net.ParseIP(addr.Host()) == nil {
+	} else if net.ParseIP(addr.Host()) == nil && !(addr.IsOnion() && modules.Proxy() != "") {
+		// Onion addresses are only added if they can be reached through a
+		// proxy.
 		return errors.New("address must be an IP address: " + string(addr))
+	} else if g.isBanned(addr.Host()) {
+		return errPeerBanned
 	}
 	g.nodes[addr] = &node{
 		NetAddress:      addr,
@@ -176,7 +180,7 @@ func (g *Gateway) requestNodes(conn modules.PeerConn) error {
 	changed := false
 	for _, node := range nodes {
 		err := g.addNode(node)
-		if err != nil && err != errNodeExists && err != errOurAddress {
+		if err != nil && err != errNodeExists && err != errOurAddress && err != errPeerBanned {
 			g.log.Printf("WARN: peer '%v' sent the invalid addr '%v'", conn.RPCAddr(), node)
 		}
 		if err == nil {
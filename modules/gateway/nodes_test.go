@@ -47,6 +47,49 @@ func TestAddNode(t *testing.T) {
 	}
 }
 
+// TestAddNodeOnion checks that onion addresses are only added to the node list
+// if a proxy is set, and that they can be banned.
+func TestAddNodeOnion(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	g := newTestingGateway(t)
+	defer g.Close()
+
+	const onionNode = "expyuzz4wqqyqhjn.onion:9981"
+	g.mu.Lock()
+	err := g.addNode(onionNode)
+	g.mu.Unlock()
+	if err == nil {
+		t.Fatal("addNode added an onion address without a proxy")
+	}
+	if err := g.Connect(onionNode); err != modules.ErrOnionWithoutProxy {
+		t.Fatal("expected ErrOnionWithoutProxy, got", err)
+	}
+
+	// The proxy is not used, so it does not have to exist.
+	if err := modules.SetProxy("127.0.0.1:9050"); err != nil {
+		t.Fatal(err)
+	}
+	defer modules.SetProxy("")
+	g.mu.Lock()
+	err = g.addNode(onionNode)
+	g.mu.Unlock()
+	if err != nil {
+		t.Fatal("addNode failed:", err)
+	}
+
+	if err := g.Ban("EXPYUZZ4WQQYQHJN.onion", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	g.mu.RLock()
+	_, exists := g.nodes[onionNode]
+	g.mu.RUnlock()
+	if exists {
+		t.Fatal("banned onion node was not removed")
+	}
+}
+
 // TestRemoveNode tries remiving a node from the gateway.
 func TestRemoveNode(t *testing.T) {
 	if testing.Short() {
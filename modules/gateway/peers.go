@@ -3,6 +3,7 @@ package gateway
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"time"
 
@@ -37,15 +38,49 @@ func (s invalidVersionError) Error() string {
 type peer struct {
 	modules.Peer
 	sess streamSession
+
+	// onion is the onion address claimed by an inbound peer that connected
+	// through a Tor onion service. The claim can not be proven, so the
+	// violations of such a peer are scored by score, which belongs to the
+	// connection, rather than by its address.
+	onion modules.NetAddress
+	score misbehavior
 }
 
 // sessionHeader is sent after the initial version exchange. It prevents peers
 // on different blockchains from connecting to each other, and prevents the
-// gateway from connecting to itself.
+// gateway from connecting to itself. Pruned is set by nodes that have
+// discarded the bodies of old blocks.
 type sessionHeader struct {
 	GenesisID  types.BlockID
 	UniqueID   gatewayID
 	NetAddress modules.NetAddress
+	Pruned     bool
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (sh sessionHeader) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(sh.GenesisID, sh.UniqueID, sh.NetAddress, sh.Pruned)
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (sh *sessionHeader) UnmarshalSia(r io.Reader) error {
+	err := encoding.NewDecoder(r).DecodeAll(&sh.GenesisID, &sh.UniqueID, &sh.NetAddress)
+	if err != nil {
+		return err
+	}
+	// COMPATv1.3.3 - older peers do not send the Pruned field.
+	var b [1]byte
+	if _, err := io.ReadFull(r, b[:]); err == io.EOF {
+		sh.Pruned = false
+		return nil
+	} else if err != nil {
+		return err
+	} else if b[0] > 1 {
+		return errors.New("boolean value was not 0 or 1")
+	}
+	sh.Pruned = b[0] == 1
+	return nil
 }
 
 func (p *peer) open() (modules.PeerConn, error) {
@@ -146,6 +181,15 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 	addr := modules.NetAddress(conn.RemoteAddr().String())
 	g.log.Debugf("INFO: %v wants to connect", addr)
 
+	g.mu.RLock()
+	banned := g.isBanned(addr.Host())
+	g.mu.RUnlock()
+	if banned {
+		g.log.Debugf("INFO: rejected connection from banned peer %v", addr)
+		conn.Close()
+		return
+	}
+
 	remoteVersion, err := acceptVersionHandshake(conn, build.Version)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -194,6 +238,7 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 		GenesisID:  types.GenesisID,
 		UniqueID:   g.staticId,
 		NetAddress: g.myAddr,
+		Pruned:     g.pruned,
 	}
 	g.mu.RUnlock()
 
@@ -201,30 +246,51 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	if err != nil {
 		return err
 	}
-	if err := exchangeOurHeader(conn, ourHeader); err != nil {
-		return err
-	}
 
 	// Get the remote address on which the connecting peer is listening on.
 	// This means we need to combine the incoming connections ip address with
 	// the announced open port of the peer.
-	remoteIP := modules.NetAddress(conn.RemoteAddr().String()).Host()
+	socketAddr := modules.NetAddress(conn.RemoteAddr().String())
+	remoteIP := socketAddr.Host()
 	remotePort := remoteHeader.NetAddress.Port()
 	remoteAddr := modules.NetAddress(net.JoinHostPort(remoteIP, remotePort))
 
+	// Peers that connect through a Tor onion service appear to connect from
+	// the loopback address. Nothing proves that such a peer owns the onion
+	// address that it claims, so the peer is kept under its socket address,
+	// which is unique among the onion peers, and the claimed address is only
+	// added to the node list once a node is reachable at it. Peers that claim
+	// a banned onion address are rejected before our header is sent.
+	var onion modules.NetAddress
+	if remoteHeader.NetAddress.IsOnion() && socketAddr.IsLoopback() {
+		onion = remoteHeader.NetAddress
+		remoteAddr = socketAddr
+		g.mu.RLock()
+		banned := g.isBanned(onion.Host())
+		g.mu.RUnlock()
+		if banned {
+			return errPeerBanned
+		}
+	}
+	if err := exchangeOurHeader(conn, ourHeader); err != nil {
+		return err
+	}
+
 	// Accept the peer.
 	peer := &peer{
 		Peer: modules.Peer{
 			Inbound: true,
 			// NOTE: local may be true even if the supplied NetAddress is not
 			// actually reachable.
-			Local: remoteAddr.IsLocal(),
+			Local: remoteAddr.IsLocal() && onion == "",
 			// Ignoring claimed IP address (which should be == to the socket address)
 			// by the host but keeping note of the port number so we can call back
 			NetAddress: remoteAddr,
+			Pruned:     remoteHeader.Pruned,
 			Version:    remoteVersion,
 		},
-		sess: newServerStream(conn, remoteVersion),
+		sess:  newServerStream(conn, remoteVersion),
+		onion: onion,
 	}
 	g.mu.Lock()
 	g.acceptPeer(peer)
@@ -235,10 +301,14 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	// do this in a goroutine so that we can begin communicating with the peer
 	// immediately.
 	go func() {
-		err := g.staticPingNode(remoteAddr)
+		nodeAddr := remoteAddr
+		if onion != "" {
+			nodeAddr = onion
+		}
+		err := g.staticPingNode(nodeAddr)
 		if err == nil {
 			g.mu.Lock()
-			g.addNode(remoteAddr)
+			g.addNode(nodeAddr)
 			g.mu.Unlock()
 		}
 	}()
@@ -380,9 +450,9 @@ func exchangeRemoteHeader(conn net.Conn, ourHeader sessionHeader) (sessionHeader
 	return remoteHeader, nil
 }
 
-// managedConnectPeer connects to peers >= v1.3.1. The peer is added as a
-// node and a peer. The peer is only added if a nil error is returned.
-func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remoteAddr modules.NetAddress) error {
+// managedConnectPeer connects to peers >= v1.3.1 and returns the header of the
+// remote peer. The peer is only added if a nil error is returned.
+func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remoteAddr modules.NetAddress) (sessionHeader, error) {
 	g.log.Debugln("Sending sessionHeader with address", g.myAddr, g.myAddr.IsLocal())
 	// Perform header handshake.
 	g.mu.RLock()
@@ -390,15 +460,14 @@ func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remote
 		GenesisID:  types.GenesisID,
 		UniqueID:   g.staticId,
 		NetAddress: g.myAddr,
+		Pruned:     g.pruned,
 	}
 	g.mu.RUnlock()
 
 	if err := exchangeOurHeader(conn, ourHeader); err != nil {
-		return err
-	} else if _, err := exchangeRemoteHeader(conn, ourHeader); err != nil {
-		return err
+		return sessionHeader{}, err
 	}
-	return nil
+	return exchangeRemoteHeader(conn, ourHeader)
 }
 
 // managedConnect establishes a persistent connection to a peer, and adds it to
@@ -414,14 +483,17 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	if err := addr.IsStdValid(); err != nil {
 		return errors.New("can't connect to invalid address")
 	}
-	if net.ParseIP(addr.Host()) == nil {
-		return errors.New("address must be an IP address")
+	if net.ParseIP(addr.Host()) == nil && !addr.IsOnion() {
+		return errors.New("address must be an IP address or an onion address")
 	}
 	g.mu.RLock()
 	_, exists := g.peers[addr]
+	banned := g.isBanned(addr.Host())
 	g.mu.RUnlock()
 	if exists {
 		return errPeerExists
+	} else if banned {
+		return errPeerBanned
 	}
 
 	// Dial the peer and perform peer initialization.
@@ -437,8 +509,9 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 		return err
 	}
 
+	var remoteHeader sessionHeader
 	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) >= 0 {
-		err = g.managedConnectPeer(conn, remoteVersion, addr)
+		remoteHeader, err = g.managedConnectPeer(conn, remoteVersion, addr)
 	} else {
 		err = errors.New("version number is below threshold")
 	}
@@ -460,12 +533,15 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 			Inbound:    false,
 			Local:      addr.IsLocal(),
 			NetAddress: addr,
+			Pruned:     remoteHeader.Pruned,
 			Version:    remoteVersion,
 		},
 		sess: newClientStream(conn, remoteVersion),
 	})
 	g.addNode(addr)
-	g.nodes[addr].WasOutboundPeer = true
+	if n, exists := g.nodes[addr]; exists {
+		n.WasOutboundPeer = true
+	}
 
 	if err := g.saveSync(); err != nil {
 		g.log.Println("ERROR: Unable to save new outbound peer to gateway:", err)
@@ -543,3 +619,12 @@ func (g *Gateway) Online() bool {
 	}
 	return false
 }
+
+// SetPruned sets whether the gateway advertises to new peers that the node has
+// discarded the bodies of old blocks. Peers that are already connected are not
+// notified.
+func (g *Gateway) SetPruned(pruned bool) {
+	g.mu.Lock()
+	g.pruned = pruned
+	g.mu.Unlock()
+}
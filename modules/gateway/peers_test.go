@@ -1,11 +1,14 @@
 package gateway
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -349,6 +352,64 @@ func TestConnect(t *testing.T) {
 	g.mu.RUnlock()
 }
 
+// TestSessionHeaderPruned checks that the Pruned field of the session header
+// is exchanged between peers, and that it decodes as false when it is sent by
+// an older peer.
+func TestSessionHeaderPruned(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// A header without the Pruned field decodes as an unpruned header.
+	oldHeader := encoding.MarshalAll(types.GenesisID, gatewayID{1}, modules.NetAddress("127.0.0.1:9981"))
+	header := sessionHeader{Pruned: true}
+	if err := encoding.Unmarshal(oldHeader, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Pruned || header.UniqueID != (gatewayID{1}) || header.NetAddress != "127.0.0.1:9981" {
+		t.Fatal("old session header was decoded incorrectly:", header)
+	}
+	header.Pruned = true
+	var decoded sessionHeader
+	if err := encoding.Unmarshal(encoding.Marshal(header), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != header {
+		t.Fatal("session header did not survive a round trip:", decoded)
+	}
+
+	// Peers learn that a gateway is pruned both when connecting to it and when
+	// it connects to them.
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+	g3 := newNamedTestingGateway(t, "3")
+	defer g3.Close()
+	g1.SetPruned(true)
+	if err := g2.Connect(g1.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Connect(g3.Address()); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*Gateway{g2, g3} {
+		err := build.Retry(50, 100*time.Millisecond, func() error {
+			if peers := g.Peers(); len(peers) != 1 || !peers[0].Pruned {
+				return fmt.Errorf("pruned peer was not advertised: %v", peers)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if peers := g1.Peers(); len(peers) != 2 || peers[0].Pruned || peers[1].Pruned {
+		t.Fatal("unpruned peers were advertised as pruned:", peers)
+	}
+}
+
 // TestUnitAcceptableVersion tests that the acceptableVersion func returns an
 // error for unacceptable versions.
 func TestUnitAcceptableVersion(t *testing.T) {
@@ -1164,3 +1225,231 @@ func TestBuildPeerManagerNodeList(t *testing.T) {
 		t.Fatal("bad nodelist:", nodelist)
 	}
 }
+
+// onionProxy is a minimal SOCKS5 proxy that forwards connections to onion
+// addresses to a gateway on the loopback address, like a Tor client does for
+// an onion service.
+type onionProxy struct {
+	listener net.Listener
+
+	target     modules.NetAddress
+	onionsDone int
+	mu         sync.Mutex
+}
+
+// newOnionProxy starts an onionProxy that forwards onion addresses to target.
+func newOnionProxy(t *testing.T, target modules.NetAddress) *onionProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &onionProxy{listener: l, target: target}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.serve(conn)
+		}
+	}()
+	return p
+}
+
+// serve handles a single SOCKS5 connection.
+func (p *onionProxy) serve(conn net.Conn) {
+	defer conn.Close()
+	var greeting [3]byte
+	if _, err := io.ReadFull(conn, greeting[:]); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 0})
+
+	// Read the connect request. Only IPv4 addresses and hostnames are used
+	// by the tests.
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return
+	}
+	var host string
+	if req[3] == 1 {
+		ip := make([]byte, net.IPv4len)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	} else {
+		var l [1]byte
+		io.ReadFull(conn, l[:])
+		name := make([]byte, l[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(conn, port[:]); err != nil {
+		return
+	}
+	addr := modules.NetAddress(net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))))
+	if addr.IsOnion() {
+		p.mu.Lock()
+		addr = p.target
+		p.mu.Unlock()
+		defer func() {
+			p.mu.Lock()
+			p.onionsDone++
+			p.mu.Unlock()
+		}()
+	}
+
+	targetConn, err := net.Dial("tcp", string(addr))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer targetConn.Close()
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+	go func() {
+		io.Copy(targetConn, conn)
+		targetConn.Close()
+	}()
+	io.Copy(conn, targetConn)
+}
+
+// TestAcceptOnionPeer checks that an inbound peer that connects from the
+// loopback address and claims an onion address is kept under its socket
+// address, that the onion address is only added to the node list once it has
+// been dialed back through the proxy, and that the violations of the peer are
+// scored per connection.
+func TestAcceptOnionPeer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	g2Addr := g2.Address()
+	onion := modules.NetAddress(net.JoinHostPort("expyuzz4wqqyqhjn.onion", g2Addr.Port()))
+	g2.mu.Lock()
+	g2.myAddr = onion
+	g2.mu.Unlock()
+
+	// The proxy forwards the onion address to a closed port, so no node can
+	// be reached at the onion address.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := modules.NetAddress(l.Addr().String())
+	l.Close()
+	p := newOnionProxy(t, closedAddr)
+	defer p.listener.Close()
+	if err := modules.SetProxy(modules.NetAddress(p.listener.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	defer modules.SetProxy("")
+
+	// connectOnion connects g2 to g1 and waits until g1 has dialed the onion
+	// address. It returns the address that g1 keeps the peer under.
+	connectOnion := func() modules.NetAddress {
+		err := build.Retry(50, 100*time.Millisecond, func() error {
+			if len(g1.Peers()) != 0 || len(g2.Peers()) != 0 {
+				return errors.New("previous connection is still open")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.mu.Lock()
+		done := p.onionsDone
+		p.mu.Unlock()
+		if err := g2.Connect(g1.Address()); err != nil {
+			t.Fatal(err)
+		}
+		err = build.Retry(50, 100*time.Millisecond, func() error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.onionsDone == done {
+				return errors.New("onion address was not dialed")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		g1.mu.RLock()
+		defer g1.mu.RUnlock()
+		if len(g1.peers) != 1 {
+			t.Fatal("expected 1 peer, got", len(g1.peers))
+		}
+		for addr, peer := range g1.peers {
+			if !addr.IsLoopback() || peer.NetAddress != addr || peer.onion != onion || peer.Local {
+				t.Fatalf("onion peer was accepted as %v (local: %v, onion: %v)", addr, peer.Local, peer.onion)
+			}
+			return addr
+		}
+		return ""
+	}
+
+	addr := connectOnion()
+	g1.mu.RLock()
+	_, onionNode := g1.nodes[onion]
+	_, loopbackNode := g1.nodes[addr]
+	g1.mu.RUnlock()
+	if onionNode || loopbackNode {
+		t.Fatal("unreachable onion address or loopback address was added to the node list")
+	}
+
+	// Violations are scored per connection. Neither the loopback address nor
+	// the claimed onion address are scored or banned.
+	g1.ReportViolation(addr, modules.ViolationMinor, "test violation")
+	if len(g1.Peers()) != 1 {
+		t.Fatal("peer was disconnected before reaching the ban threshold")
+	}
+	g1.ReportViolation(addr, modules.ViolationSevere, "test violation")
+	if len(g1.Peers()) != 0 {
+		t.Fatal("misbehaving onion peer is still connected")
+	}
+	g1.mu.RLock()
+	scores := len(g1.scores)
+	g1.mu.RUnlock()
+	if scores != 0 || len(g1.Bans()) != 0 {
+		t.Fatal("violations of an onion peer were attributed to an address")
+	}
+
+	// Reconnect with the proxy forwarding the onion address to g2.
+	p.mu.Lock()
+	p.target = g2Addr
+	p.mu.Unlock()
+	addr = connectOnion()
+	g1.mu.RLock()
+	_, onionNode = g1.nodes[onion]
+	_, loopbackNode = g1.nodes[addr]
+	g1.mu.RUnlock()
+	if !onionNode || loopbackNode {
+		t.Fatal("reachable onion address was not added to the node list")
+	}
+
+	// Banning the onion address disconnects the peer that claims it, and
+	// peers that claim it can not connect.
+	if err := g1.Ban(onion.Host(), 0, "test ban"); err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("peer of the banned onion address is still connected")
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if len(g2.Peers()) != 0 {
+			return errors.New("peer is still connected")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("peer claiming a banned onion address was able to connect")
+	}
+}
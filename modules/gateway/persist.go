@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"os"
 	"path/filepath"
 	"time"
 
@@ -14,6 +15,10 @@ const (
 
 	// nodesFile is the name of the file that contains all seen nodes.
 	nodesFile = "nodes.json"
+
+	// bansFile is the name of the file that contains the banned IP addresses
+	// and subnets.
+	bansFile = "bans.json"
 )
 
 // persistMetadata contains the header and version strings that identify the
@@ -23,6 +28,13 @@ var persistMetadata = persist.Metadata{
 	Version: "1.3.0",
 }
 
+// bansMetadata contains the header and version strings that identify the
+// gateway's ban list.
+var bansMetadata = persist.Metadata{
+	Header:  "Sia Gateway Bans",
+	Version: "1.3.3",
+}
+
 // persistData returns the data in the Gateway that will be saved to disk.
 func (g *Gateway) persistData() (nodes []*node) {
 	for _, node := range g.nodes {
@@ -31,10 +43,29 @@ func (g *Gateway) persistData() (nodes []*node) {
 	return
 }
 
+// bansPersistData returns the bans of the Gateway that will be saved to disk.
+func (g *Gateway) bansPersistData() (bans []modules.PeerBan) {
+	for _, b := range g.bans {
+		bans = append(bans, b)
+	}
+	return
+}
+
 // load loads the Gateway's persistent data from disk.
 func (g *Gateway) load() error {
+	// Load the ban list. Nodes that were persisted before the ban list was
+	// introduced do not have one.
+	var bans []modules.PeerBan
+	err := persist.LoadJSON(bansMetadata, &bans, filepath.Join(g.persistDir, bansFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, b := range bans {
+		g.bans[b.Host] = b
+	}
+
 	var nodes []*node
-	err := persist.LoadJSON(persistMetadata, &nodes, filepath.Join(g.persistDir, nodesFile))
+	err = persist.LoadJSON(persistMetadata, &nodes, filepath.Join(g.persistDir, nodesFile))
 	if err != nil {
 		// COMPATv1.3.0
 		return g.loadv033persist()
@@ -48,6 +79,10 @@ func (g *Gateway) load() error {
 // saveSync stores the Gateway's persistent data on disk, and then syncs to
 // disk to minimize the possibility of data loss.
 func (g *Gateway) saveSync() error {
+	g.purgeBans()
+	if err := persist.SaveJSON(bansMetadata, g.bansPersistData(), filepath.Join(g.persistDir, bansFile)); err != nil {
+		return err
+	}
 	return persist.SaveJSON(persistMetadata, g.persistData(), filepath.Join(g.persistDir, nodesFile))
 }
 
@@ -21,7 +21,7 @@ import (
 // service, http://myexternalip.com.
 func myExternalIP() (string, error) {
 	// timeout after 10 seconds
-	client := http.Client{Timeout: time.Duration(10 * time.Second)}
+	client := modules.HTTPClient(10 * time.Second)
 	resp, err := client.Get("http://myexternalip.com/raw")
 	if err != nil {
 		return "", err
@@ -43,13 +43,14 @@ func myExternalIP() (string, error) {
 }
 
 // threadedLearnHostname discovers the external IP of the Gateway regularly.
+// If a proxy is set, the IP is not discovered, so that it is not revealed.
 func (g *Gateway) threadedLearnHostname() {
 	if err := g.threads.Add(); err != nil {
 		return
 	}
 	defer g.threads.Done()
 
-	if build.Release == "testing" {
+	if build.Release == "testing" || modules.Proxy() != "" {
 		return
 	}
 
@@ -115,14 +116,15 @@ func (g *Gateway) threadedLearnHostname() {
 	}
 }
 
-// threadedForwardPort adds a port mapping to the router.
+// threadedForwardPort adds a port mapping to the router. If a proxy is set,
+// the node is not meant to be reachable at its IP, and no port is forwarded.
 func (g *Gateway) threadedForwardPort(port string) {
 	if err := g.threads.Add(); err != nil {
 		return
 	}
 	defer g.threads.Done()
 
-	if build.Release == "testing" {
+	if build.Release == "testing" || modules.Proxy() != "" {
 		return
 	}
 
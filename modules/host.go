@@ -1,6 +1,9 @@
 package modules
 
 import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -16,6 +19,18 @@ var (
 	// BytesPerTerabyte is the conversion rate between bytes and terabytes.
 	BytesPerTerabyte = types.NewCurrency64(1e12)
 
+	// HostAlertSeverityWarning indicates a problem that does not put any
+	// storage obligations at risk yet.
+	HostAlertSeverityWarning = HostAlertSeverity("warning")
+
+	// HostAlertSeverityError indicates a problem that prevents the host from
+	// operating normally, such as refusing contracts.
+	HostAlertSeverityError = HostAlertSeverity("error")
+
+	// HostAlertSeverityCritical indicates a problem that puts collateral or
+	// renter data at risk.
+	HostAlertSeverityCritical = HostAlertSeverity("critical")
+
 	// HostConnectabilityStatusChecking is returned from ConnectabilityStatus()
 	// if the host is still determining if it is connectable.
 	HostConnectabilityStatusChecking = HostConnectabilityStatus("checking")
@@ -80,6 +95,7 @@ type (
 	// HostInternalSettings contains a list of settings that can be changed.
 	HostInternalSettings struct {
 		AcceptingContracts   bool              `json:"acceptingcontracts"`
+		MaintenanceMode      bool              `json:"maintenancemode"`
 		MaxDownloadBatchSize uint64            `json:"maxdownloadbatchsize"`
 		MaxDuration          types.BlockHeight `json:"maxduration"`
 		MaxReviseBatchSize   uint64            `json:"maxrevisebatchsize"`
@@ -96,6 +112,56 @@ type (
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
 	}
 
+	// HostAlertSeverity indicates how urgently a host alert needs attention.
+	// Can be one of "warning", "error", or "critical".
+	HostAlertSeverity string
+
+	// HostAlert describes a problem detected by the host. Alerts are cleared
+	// automatically once the condition that caused them has resolved.
+	HostAlert struct {
+		Cause     string            `json:"cause"`
+		Message   string            `json:"message"`
+		Severity  HostAlertSeverity `json:"severity"`
+		FirstSeen time.Time         `json:"firstseen"`
+
+		// The storage obligation or storage folder affected by the alert, if
+		// any.
+		ObligationID  *types.FileContractID `json:"obligationid,omitempty"`
+		StorageFolder string                `json:"storagefolder,omitempty"`
+	}
+
+	// HostMaintenanceStatus reports the progress of a host in maintenance
+	// mode towards resolving all of its storage obligations. While in
+	// maintenance mode, the host refuses new contracts and renewals, but
+	// keeps serving downloads and submitting storage proofs.
+	HostMaintenanceStatus struct {
+		MaintenanceMode bool `json:"maintenancemode"`
+
+		// LastObligationHeight is the proof deadline of the unresolved
+		// storage obligation that resolves last.
+		ActiveObligations    uint64            `json:"activeobligations"`
+		LastObligationHeight types.BlockHeight `json:"lastobligationheight"`
+
+		// The collateral that is locked in and put at risk by the unresolved
+		// storage obligations.
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+	}
+
+	// HostMetricsSnapshot is a point-in-time record of the host's financial
+	// metrics, network metrics and storage usage. The host periodically
+	// persists snapshots so that the history of the metrics can be queried.
+	HostMetricsSnapshot struct {
+		BlockHeight types.BlockHeight `json:"blockheight"`
+		Timestamp   time.Time         `json:"timestamp"`
+
+		FinancialMetrics HostFinancialMetrics `json:"financialmetrics"`
+		NetworkMetrics   HostNetworkMetrics   `json:"networkmetrics"`
+
+		RemainingStorage uint64 `json:"remainingstorage"`
+		TotalStorage     uint64 `json:"totalstorage"`
+	}
+
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
 	// has been made to the host.
 	HostNetworkMetrics struct {
@@ -140,6 +206,19 @@ type (
 		RevisionConstructed bool   `json:"revisionconstructed"`
 	}
 
+	// StorageObligationDetails contains the full information about a storage
+	// obligation, including the sector roots of the stored data, the
+	// transaction sets of the file contract and the heights at which the host
+	// has queued action items for the obligation.
+	StorageObligationDetails struct {
+		StorageObligation
+
+		ActionItemHeights      []types.BlockHeight `json:"actionitemheights"`
+		OriginTransactionSet   []types.Transaction `json:"origintransactionset"`
+		RevisionTransactionSet []types.Transaction `json:"revisiontransactionset"`
+		SectorRoots            []crypto.Hash       `json:"sectorroots"`
+	}
+
 	// HostWorkingStatus reports the working state of a host. Can be one of
 	// "checking", "working", or "not working".
 	HostWorkingStatus string
@@ -152,6 +231,10 @@ type (
 	// things such as announcements, settings, and implementing all of the RPCs
 	// of the host protocol.
 	Host interface {
+		// Alerts returns the problems that the host is currently
+		// experiencing, ordered from most to least severe.
+		Alerts() []HostAlert
+
 		// Announce submits a host announcement to the blockchain.
 		Announce() error
 
@@ -169,6 +252,17 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// MaintenanceStatus reports the unresolved storage obligations of
+		// the host, which is used to follow the wind-down of a host in
+		// maintenance mode.
+		MaintenanceStatus() (HostMaintenanceStatus, error)
+
+		// MetricsHistory returns the persisted metrics snapshots of the host
+		// that were taken between start and end. If granularity is nonzero,
+		// only the most recent snapshot of each granularity-sized period is
+		// returned.
+		MetricsHistory(start, end time.Time, granularity time.Duration) ([]HostMetricsSnapshot, error)
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
@@ -179,6 +273,10 @@ type (
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// StorageObligation returns the full details of the storage
+		// obligation with the provided id.
+		StorageObligation(id types.FileContractID) (StorageObligationDetails, error)
+
 		// StorageObligations returns the set of storage obligations held by
 		// the host.
 		StorageObligations() []StorageObligation
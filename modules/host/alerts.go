@@ -0,0 +1,289 @@
+package host
+
+// alerts.go keeps a registry of problems that the host is experiencing, such
+// as being unreachable, failing storage folders, a wallet balance that is too
+// low to fund collateral, or storage proofs that are at risk of missing their
+// deadline. Alerts are registered when a problem is detected and cleared once
+// the condition has resolved. The registry is not persisted, the conditions
+// are detected again after a restart.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// alertCauseLowBalance is the cause of the alert raised when the wallet
+	// cannot fund the collateral of a contract.
+	alertCauseLowBalance = "lowbalance"
+
+	// alertCauseProofDeadline is the cause of the alert raised when the proof
+	// deadline of a storage obligation approaches without a storage proof.
+	alertCauseProofDeadline = "proofdeadline"
+
+	// alertCauseStorageFolder is the cause of the alert raised when a storage
+	// folder is unavailable or failing reads or writes.
+	alertCauseStorageFolder = "storagefolder"
+
+	// alertCauseUnreachable is the cause of the alert raised when the host
+	// cannot connect to itself on its net address.
+	alertCauseUnreachable = "unreachable"
+)
+
+// alertSeverityRank orders the alert severities from most to least severe.
+var alertSeverityRank = map[modules.HostAlertSeverity]int{
+	modules.HostAlertSeverityCritical: 0,
+	modules.HostAlertSeverityError:    1,
+	modules.HostAlertSeverityWarning:  2,
+}
+
+// alertRegistry tracks the alerts that are currently active in the host. The
+// registry has its own lock so that alerts can be registered regardless of
+// whether the host lock is held.
+type alertRegistry struct {
+	alerts map[string]modules.HostAlert
+	// lowBalanceAmount is the collateral that the wallet was unable to fund
+	// when the low balance alert was raised.
+	lowBalanceAmount types.Currency
+	// storageFolderFailures holds the failure counts of each storage folder,
+	// keyed by path, as seen at the previous storage folder check.
+	storageFolderFailures map[string]storageFolderFailures
+	mu                    sync.Mutex
+}
+
+// storageFolderFailures are the failed reads and writes of a storage folder.
+type storageFolderFailures struct {
+	reads  uint64
+	writes uint64
+}
+
+// register adds an alert under the provided key. If an alert with the same
+// key is already registered, its first-seen time is preserved.
+func (ar *alertRegistry) register(key string, alert modules.HostAlert) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if ar.alerts == nil {
+		ar.alerts = make(map[string]modules.HostAlert)
+	}
+	if existing, exists := ar.alerts[key]; exists {
+		alert.FirstSeen = existing.FirstSeen
+	} else {
+		alert.FirstSeen = time.Now()
+	}
+	ar.alerts[key] = alert
+}
+
+// unregister removes the alert with the provided key, if it exists.
+func (ar *alertRegistry) unregister(key string) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	delete(ar.alerts, key)
+}
+
+// update replaces all alerts whose keys start with prefix with the provided
+// set of alerts, clearing the alerts whose conditions have resolved.
+func (ar *alertRegistry) update(prefix string, current map[string]modules.HostAlert) {
+	ar.mu.Lock()
+	for key := range ar.alerts {
+		if _, exists := current[key]; strings.HasPrefix(key, prefix) && !exists {
+			delete(ar.alerts, key)
+		}
+	}
+	ar.mu.Unlock()
+	for key, alert := range current {
+		ar.register(key, alert)
+	}
+}
+
+// list returns the registered alerts, sorted by severity and then by the time
+// they were first seen.
+func (ar *alertRegistry) list() []modules.HostAlert {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	alerts := make([]modules.HostAlert, 0, len(ar.alerts))
+	for _, alert := range ar.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alertSeverityRank[alerts[i].Severity] < alertSeverityRank[alerts[j].Severity]
+		}
+		return alerts[i].FirstSeen.Before(alerts[j].FirstSeen)
+	})
+	return alerts
+}
+
+// managedUpdateCollateralAlert raises the low balance alert if the wallet
+// failed to fund the provided collateral, and clears it once funding
+// succeeds.
+func (h *Host) managedUpdateCollateralAlert(collateral types.Currency, err error) {
+	if err == nil {
+		h.alerts.unregister(alertCauseLowBalance)
+		return
+	}
+	if err != modules.ErrLowBalance && err != modules.ErrIncompleteTransactions {
+		return
+	}
+	h.alerts.mu.Lock()
+	h.alerts.lowBalanceAmount = collateral
+	h.alerts.mu.Unlock()
+	h.alerts.register(alertCauseLowBalance, modules.HostAlert{
+		Cause:    alertCauseLowBalance,
+		Message:  fmt.Sprintf("wallet balance is too low to fund %v of collateral, contracts are being refused", collateral.HumanString()),
+		Severity: modules.HostAlertSeverityError,
+	})
+}
+
+// managedUpdateConnectabilityAlert raises or clears the alert for an
+// unreachable host.
+func (h *Host) managedUpdateConnectabilityAlert(status modules.HostConnectabilityStatus, addr modules.NetAddress) {
+	if status != modules.HostConnectabilityStatusNotConnectable {
+		h.alerts.unregister(alertCauseUnreachable)
+		return
+	}
+	h.alerts.register(alertCauseUnreachable, modules.HostAlert{
+		Cause:    alertCauseUnreachable,
+		Message:  fmt.Sprintf("host is not reachable at %v", addr),
+		Severity: modules.HostAlertSeverityError,
+	})
+}
+
+// storageFolderAlerts returns the alerts for storage folders that are
+// unavailable or that had failed reads or writes since the previous check,
+// along with the failure counts to compare against at the next check. Since
+// only new failures raise an alert, the alert of a folder clears once the
+// folder stops failing.
+func storageFolderAlerts(folders []modules.StorageFolderMetadata, last map[string]storageFolderFailures) (map[string]modules.HostAlert, map[string]storageFolderFailures) {
+	current := make(map[string]modules.HostAlert)
+	seen := make(map[string]storageFolderFailures)
+	for _, sf := range folders {
+		key := alertCauseStorageFolder + ":" + sf.Path
+		prev := last[sf.Path]
+
+		// The storage manager reports extreme numbers of failures for
+		// folders that are unavailable. The previous counts are kept, so
+		// that the folder is compared against its real counts once it is
+		// available again.
+		if sf.FailedReads >= modules.StorageFolderUnavailableFailures || sf.FailedWrites >= modules.StorageFolderUnavailableFailures {
+			seen[sf.Path] = prev
+			current[key] = modules.HostAlert{
+				Cause:         alertCauseStorageFolder,
+				Message:       "storage folder is unavailable",
+				Severity:      modules.HostAlertSeverityCritical,
+				StorageFolder: sf.Path,
+			}
+			continue
+		}
+
+		// The counts may have been reset with ResetStorageFolderHealth, in
+		// which case all of the current failures are new.
+		newReads, newWrites := sf.FailedReads, sf.FailedWrites
+		if sf.FailedReads >= prev.reads && sf.FailedWrites >= prev.writes {
+			newReads -= prev.reads
+			newWrites -= prev.writes
+		}
+		seen[sf.Path] = storageFolderFailures{reads: sf.FailedReads, writes: sf.FailedWrites}
+		if newReads == 0 && newWrites == 0 {
+			continue
+		}
+		current[key] = modules.HostAlert{
+			Cause:         alertCauseStorageFolder,
+			Message:       fmt.Sprintf("storage folder had %v failed reads and %v failed writes since the last check", newReads, newWrites),
+			Severity:      modules.HostAlertSeverityWarning,
+			StorageFolder: sf.Path,
+		}
+	}
+	return current, seen
+}
+
+// managedCheckStorageFolderAlerts raises alerts for storage folders that are
+// unavailable or have failed reads or writes since the previous check.
+func (h *Host) managedCheckStorageFolderAlerts() {
+	folders := h.StorageFolders()
+	h.alerts.mu.Lock()
+	current, seen := storageFolderAlerts(folders, h.alerts.storageFolderFailures)
+	h.alerts.storageFolderFailures = seen
+	h.alerts.mu.Unlock()
+	h.alerts.update(alertCauseStorageFolder+":", current)
+}
+
+// managedCheckLowBalanceAlert clears the low balance alert once the wallet
+// holds enough coins to fund the collateral that previously failed.
+func (h *Host) managedCheckLowBalanceAlert() {
+	h.alerts.mu.Lock()
+	_, exists := h.alerts.alerts[alertCauseLowBalance]
+	amount := h.alerts.lowBalanceAmount
+	h.alerts.mu.Unlock()
+	if !exists {
+		return
+	}
+	balance, _, _, err := h.wallet.ConfirmedBalance()
+	if err != nil {
+		return
+	}
+	if balance.Cmp(amount) >= 0 {
+		h.alerts.unregister(alertCauseLowBalance)
+	}
+}
+
+// managedCheckProofDeadlineAlerts raises alerts for storage obligations whose
+// proof window is open and whose proof deadline is approaching without a
+// storage proof having been constructed.
+func (h *Host) managedCheckProofDeadlineAlerts() {
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	h.mu.RUnlock()
+
+	current := make(map[string]modules.HostAlert)
+	for _, so := range h.StorageObligations() {
+		if so.ObligationStatus != obligationUnresolved.String() || so.ProofConstructed {
+			continue
+		}
+		if blockHeight < so.ExpirationHeight || blockHeight+proofDeadlineAlertThreshold < so.ProofDeadLine {
+			continue
+		}
+		id := so.ObligationId
+		current[alertCauseProofDeadline+":"+id.String()] = modules.HostAlert{
+			Cause:        alertCauseProofDeadline,
+			Message:      fmt.Sprintf("storage proof has not been constructed, the proof deadline is at height %v", so.ProofDeadLine),
+			Severity:     modules.HostAlertSeverityCritical,
+			ObligationID: &id,
+		}
+	}
+	h.alerts.update(alertCauseProofDeadline+":", current)
+}
+
+// threadedCheckAlerts periodically checks for problems that are not detected
+// as part of the host's normal operation.
+func (h *Host) threadedCheckAlerts(closeChan chan struct{}) {
+	defer close(closeChan)
+
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(alertCheckFrequency):
+		}
+
+		h.managedCheckStorageFolderAlerts()
+		h.managedCheckLowBalanceAlert()
+		h.managedCheckProofDeadlineAlerts()
+	}
+}
+
+// Alerts returns the problems that the host is currently experiencing,
+// ordered from most to least severe.
+func (h *Host) Alerts() []modules.HostAlert {
+	err := h.tg.Add()
+	if err != nil {
+		return nil
+	}
+	defer h.tg.Done()
+	return h.alerts.list()
+}
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestAlertRegistry checks that alerts keep their first-seen time while they
+// stay registered, are sorted by severity, and are cleared by update once
+// their condition has resolved.
+func TestAlertRegistry(t *testing.T) {
+	var ar alertRegistry
+	ar.register("folder:a", modules.HostAlert{Cause: alertCauseStorageFolder, Severity: modules.HostAlertSeverityWarning})
+	ar.register(alertCauseUnreachable, modules.HostAlert{Cause: alertCauseUnreachable, Severity: modules.HostAlertSeverityError})
+	firstSeen := ar.list()[1].FirstSeen
+
+	// Registering the alert again should not reset its first-seen time.
+	ar.register("folder:a", modules.HostAlert{Cause: alertCauseStorageFolder, Severity: modules.HostAlertSeverityCritical})
+	alerts := ar.list()
+	if len(alerts) != 2 {
+		t.Fatal("expected 2 alerts, got", len(alerts))
+	}
+	if alerts[0].Severity != modules.HostAlertSeverityCritical || alerts[1].Severity != modules.HostAlertSeverityError {
+		t.Fatal("alerts are not sorted by severity")
+	}
+	if !alerts[0].FirstSeen.Equal(firstSeen) {
+		t.Fatal("first-seen time was reset")
+	}
+
+	// Updating the prefix without the alert should clear it, leaving alerts
+	// with other prefixes alone.
+	ar.update("folder:", map[string]modules.HostAlert{
+		"folder:b": {Cause: alertCauseStorageFolder, Severity: modules.HostAlertSeverityWarning},
+	})
+	alerts = ar.list()
+	if len(alerts) != 2 || alerts[0].Cause != alertCauseUnreachable || alerts[1].Cause != alertCauseStorageFolder {
+		t.Fatal("wrong alerts after update:", alerts)
+	}
+	ar.unregister(alertCauseUnreachable)
+	if len(ar.list()) != 1 {
+		t.Fatal("alert was not unregistered")
+	}
+}
+
+// TestStorageFolderAlerts checks that storage folder alerts are raised for new
+// failures only, so that they clear once a folder stops failing.
+func TestStorageFolderAlerts(t *testing.T) {
+	folder := func(reads, writes uint64) []modules.StorageFolderMetadata {
+		return []modules.StorageFolderMetadata{{Path: "a", FailedReads: reads, FailedWrites: writes}}
+	}
+	checks := []struct {
+		folders  []modules.StorageFolderMetadata
+		severity modules.HostAlertSeverity // empty if no alert is expected
+	}{
+		{folder(0, 0), ""},
+		{folder(1, 0), modules.HostAlertSeverityWarning},
+		{folder(1, 0), ""},
+		{folder(1, 2), modules.HostAlertSeverityWarning},
+		{folder(modules.StorageFolderUnavailableFailures, modules.StorageFolderUnavailableFailures), modules.HostAlertSeverityCritical},
+		{folder(1, 2), ""},
+		{folder(0, 0), ""},
+		{folder(1, 0), modules.HostAlertSeverityWarning},
+	}
+	var last map[string]storageFolderFailures
+	for i, check := range checks {
+		var alerts map[string]modules.HostAlert
+		alerts, last = storageFolderAlerts(check.folders, last)
+		alert, exists := alerts[alertCauseStorageFolder+":a"]
+		if exists != (check.severity != "") || alert.Severity != check.severity {
+			t.Fatalf("check %v: expected alert with severity %q, got %v", i, check.severity, alerts)
+		}
+	}
+}
+
+// TestLowBalanceAlert checks that a failure to fund collateral raises an
+// alert, and that the alert is cleared once the wallet can cover the
+// collateral.
+func TestLowBalanceAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestLowBalanceAlert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	ht.host.managedUpdateCollateralAlert(types.SiacoinPrecision, modules.ErrLowBalance)
+	alerts := ht.host.Alerts()
+	if len(alerts) != 1 || alerts[0].Cause != alertCauseLowBalance {
+		t.Fatal("expected a low balance alert, got", alerts)
+	}
+
+	// The host tester's wallet holds more than enough coins, so the periodic
+	// check should clear the alert.
+	ht.host.managedCheckLowBalanceAlert()
+	if len(ht.host.Alerts()) != 0 {
+		t.Fatal("low balance alert was not cleared")
+	}
+}
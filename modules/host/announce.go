@@ -96,6 +96,11 @@ func (h *Host) Announce() error {
 	userSet := h.settings.NetAddress
 	autoSet := h.autoAddress
 	h.mu.RUnlock()
+	// If a proxy is set, an address discovered earlier would reveal the IP of
+	// the host, so it is not used.
+	if modules.Proxy() != "" {
+		autoSet = ""
+	}
 
 	// Check that we have at least one address to work with.
 	if userSet == "" && autoSet == "" {
@@ -137,6 +137,44 @@ func TestHostAnnounceAddress(t *testing.T) {
 	}
 }
 
+// TestHostAnnounceOnion checks that the host can announce an onion address.
+func TestHostAnnounceOnion(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	af, err := newAnnouncementFinder(ht.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+
+	// Invalid onion addresses are rejected.
+	if err := ht.host.AnnounceAddress("foo.onion:9982"); err == nil {
+		t.Fatal("invalid onion address was announced")
+	}
+
+	addr := modules.NetAddress("sp3k262uwy4r2k3ycr5awluarykdpag6a7y33jxop4cs2lu5uz5sseqd.onion:9982")
+	if err := ht.host.AnnounceAddress(addr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(af.netAddresses) != 1 || af.netAddresses[0] != addr {
+		t.Fatal("could not find onion announcement in blockchain")
+	}
+	if ht.host.InternalSettings().NetAddress != addr {
+		t.Error("host did not update its net address")
+	}
+}
+
 // TestHostAnnounceCheckUnlockHash verifies that the host's unlock hash is
 // checked when an announcement is performed.
 func TestHostAnnounceCheckUnlockHash(t *testing.T) {
@@ -34,6 +34,15 @@ const (
 )
 
 var (
+	// alertCheckFrequency defines how often the host checks its storage
+	// folders, wallet balance and storage obligations for problems that
+	// should be reported as alerts.
+	alertCheckFrequency = build.Select(build.Var{
+		Standard: time.Minute * 5,
+		Dev:      time.Minute * 1,
+		Testing:  time.Second * 1,
+	}).(time.Duration)
+
 	// connectablityCheckFirstWait defines how often the host's connectability
 	// check is run.
 	connectabilityCheckFirstWait = build.Select(build.Var{
@@ -170,6 +179,14 @@ var (
 		Testing:  uint64(5),
 	}).(uint64)
 
+	// metricsSnapshotFrequency defines how often the host persists a snapshot
+	// of its financial metrics, network metrics and storage usage.
+	metricsSnapshotFrequency = build.Select(build.Var{
+		Dev:      time.Minute * 5,
+		Standard: time.Hour,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
 	// obligationLockTimeout defines how long a thread will wait to get a lock
 	// on a storage obligation before timing out and reporting an error to the
 	// renter.
@@ -179,6 +196,15 @@ var (
 		Testing:  time.Second * 3,
 	}).(time.Duration)
 
+	// proofDeadlineAlertThreshold is the number of blocks before the proof
+	// deadline of a storage obligation at which the host raises an alert if
+	// the storage proof has not been constructed yet.
+	proofDeadlineAlertThreshold = build.Select(build.Var{
+		Dev:      types.BlockHeight(10),
+		Standard: types.BlockHeight(36), // 6 hours.
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
 	// revisionSubmissionBuffer describes the number of blocks ahead of time
 	// that the host will submit a file contract revision. The host will not
 	// accept any more revisions once inside the submission buffer.
@@ -234,6 +260,11 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketMetricsSnapshots maps a unix timestamp to a json encoded
+	// modules.HostMetricsSnapshot. The timestamp is stored as a big endian
+	// int64, so that bolt keeps the snapshots sorted chronologically.
+	bucketMetricsSnapshots = []byte("BucketMetricsSnapshots")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
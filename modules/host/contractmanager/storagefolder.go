@@ -95,6 +95,11 @@ type storageFolder struct {
 	atomicProgressNumerator   uint64
 	atomicProgressDenominator uint64
 
+	// Progress statistics of the most recent operation that moved sectors
+	// out of the storage folder.
+	atomicSectorsMigrated  uint64
+	atomicSectorsRemaining uint64
+
 	// Disk statistics for this boot cycle.
 	atomicFailedReads      uint64
 	atomicFailedWrites     uint64
@@ -121,6 +126,12 @@ type storageFolder struct {
 	availableSectors map[sectorID]uint32
 	sectors          uint64
 
+	// migrationCancel is closed to cancel a migration that is in progress
+	// for the storage folder, and migrationDone is closed by the migration
+	// thread once it has exited. Both are nil if no migration is running.
+	migrationCancel chan struct{}
+	migrationDone   chan struct{}
+
 	// An open file handle is kept so that writes can easily be made to the
 	// storage folder without needing to grab a new file handle. This also
 	// makes it easy to do delayed-syncing.
@@ -410,6 +421,10 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			ProgressNumerator:   atomic.LoadUint64(&sf.atomicProgressNumerator),
 			ProgressDenominator: atomic.LoadUint64(&sf.atomicProgressDenominator),
 
+			Migrating:        sf.migrationCancel != nil,
+			SectorsMigrated:  atomic.LoadUint64(&sf.atomicSectorsMigrated),
+			SectorsRemaining: atomic.LoadUint64(&sf.atomicSectorsRemaining),
+
 			FailedReads:      atomic.LoadUint64(&sf.atomicFailedReads),
 			FailedWrites:     atomic.LoadUint64(&sf.atomicFailedWrites),
 			SuccessfulReads:  atomic.LoadUint64(&sf.atomicSuccessfulReads),
@@ -424,8 +439,8 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 		// Set some of the values to extreme numbers if the storage folder is
 		// unavailable, to flag the user's attention.
 		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
-			sfm.FailedReads = 9999999999
-			sfm.FailedWrites = 9999999999
+			sfm.FailedReads = modules.StorageFolderUnavailableFailures
+			sfm.FailedWrites = modules.StorageFolderUnavailableFailures
 		}
 
 		// Add this storage folder to the list of storage folders.
@@ -2,6 +2,7 @@ package contractmanager
 
 import (
 	"errors"
+	"math/bits"
 	"sync"
 	"sync/atomic"
 
@@ -16,8 +17,9 @@ var (
 )
 
 // managedMoveSector will move a sector from its current storage folder to
-// another.
-func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
+// another. If destinations are provided, only those storage folders will be
+// considered for the new location of the sector.
+func (wal *writeAheadLog) managedMoveSector(id sectorID, destinations []uint16) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
@@ -51,6 +53,9 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	wal.mu.Lock()
 	storageFolders := wal.cm.availableStorageFolders()
 	wal.mu.Unlock()
+	if len(destinations) > 0 {
+		storageFolders = filterStorageFolders(storageFolders, destinations)
+	}
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
 		err := func() error {
@@ -152,6 +157,21 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	return nil
 }
 
+// filterStorageFolders returns the storage folders whose index is in the
+// provided set of indexes.
+func filterStorageFolders(sfs []*storageFolder, indexes []uint16) []*storageFolder {
+	var filtered []*storageFolder
+	for _, sf := range sfs {
+		for _, index := range indexes {
+			if sf.index == index {
+				filtered = append(filtered, sf)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // managedEmptyStorageFolder will empty out the storage folder with the
 // provided index starting with the 'startingPoint'th sector all the way to the
 // end of the storage folder, allowing the storage folder to be safely
@@ -162,6 +182,18 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 // invisible to AddSector, and that this is the only thread that will be
 // interacting with the storage folder.
 func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoint uint32) (uint64, error) {
+	return wal.managedMigrateSectors(sfIndex, startingPoint, nil, nil)
+}
+
+// managedMigrateSectors moves the sectors of a storage folder, starting with
+// the 'startingPoint'th sector, into the destination storage folders, or into
+// any other storage folder if no destinations are provided. The progress of
+// the migration is tracked in the storage folder. If the cancel channel is
+// non-nil, the migration will stop when the channel is closed or when the
+// contract manager is shut down, returning ErrMigrationCancelled.
+//
+// The same assumptions as for managedEmptyStorageFolder apply.
+func (wal *writeAheadLog) managedMigrateSectors(sfIndex uint16, startingPoint uint32, destinations []uint16, cancel <-chan struct{}) (uint64, error) {
 	// Grab the storage folder in question.
 	wal.mu.Lock()
 	sf, exists := wal.cm.storageFolders[sfIndex]
@@ -179,6 +211,22 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 	}
 	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
 
+	// Count the sectors that need to be moved so that progress can be
+	// reported.
+	var remaining uint64
+	for _, usage := range sf.usage[startingPoint/storageFolderGranularity:] {
+		remaining += uint64(bits.OnesCount64(usage))
+	}
+	atomic.StoreUint64(&sf.atomicSectorsMigrated, 0)
+	atomic.StoreUint64(&sf.atomicSectorsRemaining, remaining)
+
+	// Only an explicit migration can be interrupted by shutdown, the other
+	// callers need to know that the folder has been emptied completely.
+	var stopChan <-chan struct{}
+	if cancel != nil {
+		stopChan = wal.cm.tg.StopChan()
+	}
+
 	// Before iterating through the sectors and moving them, set up a thread
 	// pool that can parallelize the transfers without spinning up 250,000
 	// goroutines per TB.
@@ -192,10 +240,13 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 			for {
 				select {
 				case id := <-workChan:
-					err := wal.managedMoveSector(id)
+					err := wal.managedMoveSector(id, destinations)
 					if err != nil {
 						atomic.AddUint64(&errCount, 1)
 						wal.cm.log.Println("Unable to write sector:", err)
+					} else {
+						atomic.AddUint64(&sf.atomicSectorsMigrated, 1)
+						atomic.AddUint64(&sf.atomicSectorsRemaining, ^uint64(0))
 					}
 					wg.Done()
 				case <-doneChan:
@@ -207,8 +258,12 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 
 	// Iterate through all of the sectors and perform the move operation on
 	// them.
+	cancelled := false
 	readHead := startingPoint * sectorMetadataDiskSize
 	for _, usage := range sf.usage[startingPoint/storageFolderGranularity:] {
+		if cancelled {
+			break
+		}
 		// The usage is a bitfield indicating where sectors exist. Iterate
 		// through each bit to check for a sector.
 		usageMask := uint64(1)
@@ -226,12 +281,25 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 				if !exists {
 					// The sector has been deleted, but the usage has not been
 					// updated yet. Safe to ignore.
+					atomic.AddUint64(&sf.atomicSectorsRemaining, ^uint64(0))
+					readHead += sectorMetadataDiskSize
+					usageMask = usageMask << 1
 					continue
 				}
 
 				// Queue the sector move.
 				wg.Add(1)
-				workChan <- id
+				select {
+				case workChan <- id:
+				case <-cancel:
+					cancelled = true
+				case <-stopChan:
+					cancelled = true
+				}
+				if cancelled {
+					wg.Done()
+					break
+				}
 			}
 			readHead += sectorMetadataDiskSize
 			usageMask = usageMask << 1
@@ -239,6 +307,9 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 	}
 	wg.Wait()
 	close(doneChan)
+	if cancelled {
+		return atomic.LoadUint64(&sf.atomicSectorsRemaining), ErrMigrationCancelled
+	}
 
 	// Return errPartialRelocation if not every sector was migrated out
 	// successfully.
@@ -0,0 +1,140 @@
+package contractmanager
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	// ErrMigrationCancelled is returned if a migration of sectors out of a
+	// storage folder is cancelled before all of the sectors have been moved.
+	ErrMigrationCancelled = errors.New("sector migration was cancelled")
+
+	// errBadMigrationDestination is returned if a destination folder of a
+	// migration does not exist, is unavailable, or is the folder being
+	// migrated.
+	errBadMigrationDestination = errors.New("invalid migration destination folder")
+
+	// errMigrationInProgress is returned if a migration is started for a
+	// storage folder that already has a migration running.
+	errMigrationInProgress = errors.New("a migration is already in progress for that storage folder")
+
+	// errNoMigrationInProgress is returned when trying to cancel a migration
+	// for a storage folder that is not being migrated.
+	errNoMigrationInProgress = errors.New("no migration is in progress for that storage folder")
+
+	// errStorageFolderBusy is returned if a migration is started for a storage
+	// folder that is currently being added, resized or removed.
+	errStorageFolderBusy = errors.New("storage folder is busy with another operation")
+)
+
+// managedMigrateStorageFolder moves all of the sectors out of the storage
+// folder in the background, marking the folder as unavailable for new sectors
+// for the duration of the migration. The storage folder lock is held by the
+// migration thread, and released once the migration completes or is
+// cancelled.
+func (cm *ContractManager) managedMigrateStorageFolder(sf *storageFolder, destinations []uint16, cancel, done chan struct{}) {
+	defer cm.tg.Done()
+	defer func() {
+		// Release the storage folder before the migration is reported as
+		// stopped, so that the folder can be used as soon as a cancel
+		// returns.
+		sf.mu.Unlock()
+		cm.wal.mu.Lock()
+		sf.migrationCancel = nil
+		sf.migrationDone = nil
+		cm.wal.mu.Unlock()
+		close(done)
+	}()
+
+	failed, err := cm.wal.managedMigrateSectors(sf.index, 0, destinations, cancel)
+	if err != nil {
+		cm.log.Printf("Migration of storage folder %v stopped with %v sectors that could not be moved: %v\n", sf.path, failed, err)
+		return
+	}
+	cm.log.Printf("Migration of storage folder %v completed, %v sectors moved\n", sf.path, atomic.LoadUint64(&sf.atomicSectorsMigrated))
+}
+
+// CancelStorageFolderMigration stops a migration that is in progress for the
+// provided storage folder, and waits for the migration thread to exit. Sectors
+// that have already been moved stay in their new storage folders.
+func (cm *ContractManager) CancelStorageFolderMigration(index uint16) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	if !exists {
+		cm.wal.mu.Unlock()
+		return errStorageFolderNotFound
+	}
+	if sf.migrationCancel == nil {
+		cm.wal.mu.Unlock()
+		return errNoMigrationInProgress
+	}
+	// The migration may already have been cancelled by a concurrent call
+	// that is still waiting for the thread to exit.
+	select {
+	case <-sf.migrationCancel:
+	default:
+		close(sf.migrationCancel)
+	}
+	done := sf.migrationDone
+	cm.wal.mu.Unlock()
+
+	// The migration thread needs the wal lock to exit.
+	<-done
+	return nil
+}
+
+// MigrateStorageFolder starts moving all of the sectors in a storage folder
+// into other storage folders. The migration happens in the background, and
+// its progress is reported through StorageFolders. If destinations are
+// provided, sectors will only be moved into those storage folders. No new
+// sectors are added to the storage folder while the migration is running,
+// which allows a failing drive to be evacuated before it is removed.
+func (cm *ContractManager) MigrateStorageFolder(index uint16, destinations []uint16) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+
+	cm.wal.mu.Lock()
+	sf, exists := cm.storageFolders[index]
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		cm.wal.mu.Unlock()
+		cm.tg.Done()
+		return errStorageFolderNotFound
+	}
+	if sf.migrationCancel != nil {
+		cm.wal.mu.Unlock()
+		cm.tg.Done()
+		return errMigrationInProgress
+	}
+	for _, dest := range destinations {
+		destFolder, exists := cm.storageFolders[dest]
+		if !exists || dest == index || atomic.LoadUint64(&destFolder.atomicUnavailable) == 1 {
+			cm.wal.mu.Unlock()
+			cm.tg.Done()
+			return errBadMigrationDestination
+		}
+	}
+
+	// Lock the storage folder so that no new sectors are added to it during
+	// the migration. The lock is released by the migration thread.
+	if !sf.mu.TryLock() {
+		cm.wal.mu.Unlock()
+		cm.tg.Done()
+		return errStorageFolderBusy
+	}
+	cancel := make(chan struct{})
+	done := make(chan struct{})
+	sf.migrationCancel = cancel
+	sf.migrationDone = done
+	cm.wal.mu.Unlock()
+
+	go cm.managedMigrateStorageFolder(sf, destinations, cancel, done)
+	return nil
+}
@@ -0,0 +1,180 @@
+package contractmanager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestMigrateStorageFolder checks that the sectors of a storage folder can be
+// migrated into a chosen destination folder in the background.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester("TestMigrateStorageFolder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add the source folder and fill it with a few sectors before the other
+	// folders are added, so that all sectors end up in the source folder.
+	var dirs []string
+	for _, name := range []string{"storageFolderOne", "storageFolderTwo", "storageFolderThree"} {
+		dir := filepath.Join(cmt.persistDir, name)
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	err = cmt.cm.AddStorageFolder(dirs[0], modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+	for _, dir := range dirs[1:] {
+		err = cmt.cm.AddStorageFolder(dir, modules.SectorSize*storageFolderGranularity*2)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexes := make(map[string]uint16)
+	for _, sf := range cmt.cm.StorageFolders() {
+		indexes[sf.Path] = sf.Index
+	}
+	src, dest := indexes[dirs[0]], indexes[dirs[2]]
+
+	// Invalid migrations should be rejected.
+	if err := cmt.cm.MigrateStorageFolder(src, []uint16{src}); err != errBadMigrationDestination {
+		t.Fatal("expected errBadMigrationDestination, got", err)
+	}
+	if err := cmt.cm.CancelStorageFolderMigration(src); err != errNoMigrationInProgress {
+		t.Fatal("expected errNoMigrationInProgress, got", err)
+	}
+
+	// Migrate the sectors into the third folder and wait for the migration
+	// to complete.
+	err = cmt.cm.MigrateStorageFolder(src, []uint16{dest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		for _, sf := range cmt.cm.StorageFolders() {
+			if sf.Index == src && sf.Migrating {
+				return errors.New("migration has not finished")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All sectors should have moved into the destination folder.
+	for _, sf := range cmt.cm.StorageFolders() {
+		switch sf.Index {
+		case src:
+			if sf.CapacityRemaining != sf.Capacity {
+				t.Error("source folder should be empty")
+			}
+			if sf.SectorsMigrated != 5 || sf.SectorsRemaining != 0 {
+				t.Error("wrong migration progress reported:", sf.SectorsMigrated, sf.SectorsRemaining)
+			}
+		case dest:
+			if sf.CapacityRemaining != sf.Capacity-5*modules.SectorSize {
+				t.Error("destination folder should contain the migrated sectors")
+			}
+		default:
+			if sf.CapacityRemaining != sf.Capacity {
+				t.Error("sectors were moved into a folder that was not a destination")
+			}
+		}
+	}
+	for i, root := range roots {
+		data, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[i]) {
+			t.Fatal("migrated sector has the wrong data")
+		}
+	}
+}
+
+// TestCancelStorageFolderMigration checks that a cancelled migration has
+// stopped by the time CancelStorageFolderMigration returns.
+func TestCancelStorageFolderMigration(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	var dirs []string
+	for _, name := range []string{"storageFolderOne", "storageFolderTwo"} {
+		dir := filepath.Join(cmt.persistDir, name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	if err := cmt.cm.AddStorageFolder(dirs[0], modules.SectorSize*storageFolderGranularity*2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		root, data := randSector()
+		if err := cmt.cm.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cmt.cm.AddStorageFolder(dirs[1], modules.SectorSize*storageFolderGranularity*2); err != nil {
+		t.Fatal(err)
+	}
+	var src uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == dirs[0] {
+			src = sf.Index
+		}
+	}
+
+	// Start and cancel migrations. Once the cancel has returned, the folder
+	// must not be reported as migrating, and a new migration can be started.
+	for i := 0; i < 3; i++ {
+		if err := cmt.cm.MigrateStorageFolder(src, nil); err != nil {
+			t.Fatal(err)
+		}
+		err := cmt.cm.CancelStorageFolderMigration(src)
+		if err != nil && err != errNoMigrationInProgress {
+			t.Fatal(err)
+		}
+		for _, sf := range cmt.cm.StorageFolders() {
+			if sf.Index == src && sf.Migrating {
+				t.Fatal("storage folder is still migrating after the migration was cancelled")
+			}
+		}
+	}
+}
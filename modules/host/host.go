@@ -159,6 +159,7 @@ type Host struct {
 	revisionNumber       uint64
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
+	alerts               alertRegistry
 
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
@@ -303,6 +304,20 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, tpool m
 		h.log.Println("Could not initialize host networking:", err)
 		return nil, err
 	}
+
+	// Start persisting periodic snapshots of the host metrics.
+	threadedSnapshotMetricsClosedChan := make(chan struct{})
+	go h.threadedSnapshotMetrics(threadedSnapshotMetricsClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedSnapshotMetricsClosedChan
+	})
+
+	// Start checking for problems that should be reported as alerts.
+	threadedCheckAlertsClosedChan := make(chan struct{})
+	go h.threadedCheckAlerts(threadedCheckAlertsClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedCheckAlertsClosedChan
+	})
 	return h, nil
 }
 
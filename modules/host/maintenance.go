@@ -0,0 +1,55 @@
+package host
+
+// maintenance.go reports the progress of a host in maintenance mode. While in
+// maintenance mode, the host advertises that it is not accepting contracts and
+// refuses both new contracts and renewals, but continues to serve downloads
+// and submit storage proofs for its existing storage obligations.
+
+import (
+	"encoding/json"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+
+	"github.com/coreos/bbolt"
+)
+
+// MaintenanceStatus reports the unresolved storage obligations of the host,
+// including the height at which the last of them resolves and the collateral
+// that is still at risk.
+func (h *Host) MaintenanceStatus() (modules.HostMaintenanceStatus, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostMaintenanceStatus{}, err
+	}
+	defer h.tg.Done()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := modules.HostMaintenanceStatus{
+		MaintenanceMode: h.settings.MaintenanceMode,
+	}
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			status.ActiveObligations++
+			if deadline := so.proofDeadline(); deadline > status.LastObligationHeight {
+				status.LastObligationHeight = deadline
+			}
+			status.LockedCollateral = status.LockedCollateral.Add(so.LockedCollateral)
+			status.RiskedCollateral = status.RiskedCollateral.Add(so.RiskedCollateral)
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostMaintenanceStatus{}, err
+	}
+	return status, nil
+}
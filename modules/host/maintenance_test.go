@@ -0,0 +1,76 @@
+package host
+
+import (
+	"testing"
+)
+
+// TestMaintenanceMode checks that a host in maintenance mode stops advertising
+// that it accepts contracts, and that its unresolved storage obligations are
+// reported.
+func TestMaintenanceMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestMaintenanceMode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation to the host.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	// Have the host accept contracts, then put it into maintenance mode.
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host should be accepting contracts")
+	}
+	settings.MaintenanceMode = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host in maintenance mode should not advertise that it accepts contracts")
+	}
+
+	// The storage obligation should be reported in the maintenance status.
+	status, err := ht.host.MaintenanceStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.MaintenanceMode {
+		t.Error("maintenance mode not reported")
+	}
+	if status.ActiveObligations != 1 {
+		t.Error("expected 1 active obligation, got", status.ActiveObligations)
+	}
+	if status.LastObligationHeight != so.proofDeadline() {
+		t.Error("wrong last obligation height:", status.LastObligationHeight, so.proofDeadline())
+	}
+
+	// Leaving maintenance mode should restore the advertised settings.
+	settings.MaintenanceMode = false
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Fatal("host should be accepting contracts again")
+	}
+}
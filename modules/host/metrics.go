@@ -0,0 +1,151 @@
+package host
+
+// metrics.go is responsible for periodically persisting snapshots of the
+// host's financial metrics, network metrics and storage usage, so that the
+// history of those metrics can be queried by time range.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errBadMetricsGranularity is returned if a negative granularity is
+	// provided when querying the metrics history.
+	errBadMetricsGranularity = errors.New("metrics granularity cannot be negative")
+
+	// errBadMetricsRange is returned if the end of a requested metrics range
+	// is before the start of the range.
+	errBadMetricsRange = errors.New("end of metrics range is before the start of the range")
+)
+
+// metricsSnapshotKey returns the database key of a snapshot taken at the
+// provided time. Times before the unix epoch map to the first key.
+func metricsSnapshotKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	if t.Unix() > 0 {
+		binary.BigEndian.PutUint64(key, uint64(t.Unix()))
+	}
+	return key
+}
+
+// putMetricsSnapshot places a metrics snapshot into the database, overwriting
+// any snapshot that was taken during the same second.
+func putMetricsSnapshot(tx *bolt.Tx, snapshot modules.HostMetricsSnapshot) error {
+	snapshotBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketMetricsSnapshots).Put(metricsSnapshotKey(snapshot.Timestamp), snapshotBytes)
+}
+
+// managedSnapshotMetrics takes a snapshot of the host's current metrics and
+// persists it to the database.
+func (h *Host) managedSnapshotMetrics() error {
+	// Grab the storage usage and network metrics before acquiring the host
+	// lock, the storage manager has its own locking.
+	var totalStorage, remainingStorage uint64
+	for _, sf := range h.StorageFolders() {
+		totalStorage += sf.Capacity
+		remainingStorage += sf.CapacityRemaining
+	}
+	nm := h.NetworkMetrics()
+
+	h.mu.RLock()
+	snapshot := modules.HostMetricsSnapshot{
+		BlockHeight: h.blockHeight,
+		Timestamp:   time.Now(),
+
+		FinancialMetrics: h.financialMetrics,
+		NetworkMetrics:   nm,
+
+		RemainingStorage: remainingStorage,
+		TotalStorage:     totalStorage,
+	}
+	h.mu.RUnlock()
+
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return putMetricsSnapshot(tx, snapshot)
+	})
+}
+
+// threadedSnapshotMetrics periodically persists a snapshot of the host's
+// metrics until the host is shut down.
+func (h *Host) threadedSnapshotMetrics(closeChan chan struct{}) {
+	defer close(closeChan)
+
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(metricsSnapshotFrequency):
+		}
+
+		err := h.managedSnapshotMetrics()
+		if err != nil {
+			h.log.Println("WARN: unable to persist metrics snapshot:", err)
+		}
+	}
+}
+
+// MetricsHistory returns the metrics snapshots taken between start and end,
+// inclusive. A zero start time includes all snapshots up to end, and a zero
+// end time is interpreted as the current time. If
+// granularity is nonzero, the snapshots are grouped into periods of
+// granularity length, aligned to the unix epoch, and only the most recent
+// snapshot of each period is returned.
+func (h *Host) MetricsHistory(start, end time.Time, granularity time.Duration) ([]modules.HostMetricsSnapshot, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if end.Before(start) {
+		return nil, errBadMetricsRange
+	}
+	if granularity < 0 {
+		return nil, errBadMetricsGranularity
+	}
+	period := int64(granularity / time.Second)
+
+	var snapshots []modules.HostMetricsSnapshot
+	endKey := metricsSnapshotKey(end)
+	err = h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketMetricsSnapshots).Cursor()
+		lastPeriod := int64(-1)
+		for k, v := c.Seek(metricsSnapshotKey(start)); k != nil && string(k) <= string(endKey); k, v = c.Next() {
+			var snapshot modules.HostMetricsSnapshot
+			err := json.Unmarshal(v, &snapshot)
+			if err != nil {
+				return err
+			}
+
+			// If the snapshot falls into the same period as the previous
+			// snapshot, it replaces the previous snapshot.
+			if period > 0 {
+				p := snapshot.Timestamp.Unix() / period
+				if p == lastPeriod {
+					snapshots[len(snapshots)-1] = snapshot
+					continue
+				}
+				lastPeriod = p
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
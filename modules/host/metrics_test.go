@@ -0,0 +1,91 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestMetricsHistory checks that persisted metrics snapshots can be queried
+// by time range and granularity.
+func TestMetricsHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester("TestMetricsHistory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Place one snapshot per hour for two days, starting at midnight UTC.
+	base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = ht.host.db.Update(func(tx *bolt.Tx) error {
+		for i := 0; i < 48; i++ {
+			snapshot := modules.HostMetricsSnapshot{
+				BlockHeight: types.BlockHeight(i),
+				Timestamp:   base.Add(time.Duration(i) * time.Hour),
+			}
+			if err := putMetricsSnapshot(tx, snapshot); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Query all snapshots of the first day.
+	snapshots, err := ht.host.MetricsHistory(base, base.Add(23*time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 24 {
+		t.Fatal("expected 24 snapshots, got", len(snapshots))
+	}
+	if snapshots[0].BlockHeight != 0 || snapshots[23].BlockHeight != 23 {
+		t.Fatal("wrong snapshots returned")
+	}
+
+	// Query both days with a daily granularity. The last snapshot of each
+	// day should be returned.
+	snapshots, err = ht.host.MetricsHistory(base, base.Add(72*time.Hour), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatal("expected 2 snapshots, got", len(snapshots))
+	}
+	if snapshots[0].BlockHeight != 23 || snapshots[1].BlockHeight != 47 {
+		t.Fatal("wrong snapshots returned", snapshots[0].BlockHeight, snapshots[1].BlockHeight)
+	}
+
+	// Invalid ranges and granularities should be rejected.
+	_, err = ht.host.MetricsHistory(base.Add(time.Hour), base, 0)
+	if err != errBadMetricsRange {
+		t.Fatal("expected errBadMetricsRange, got", err)
+	}
+	_, err = ht.host.MetricsHistory(base, base.Add(time.Hour), -time.Hour)
+	if err != errBadMetricsGranularity {
+		t.Fatal("expected errBadMetricsGranularity, got", err)
+	}
+
+	// Taking a snapshot should persist the current state of the host.
+	err = ht.host.managedSnapshotMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err = ht.host.MetricsHistory(time.Now().Add(-time.Minute), time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatal("expected 1 snapshot, got", len(snapshots))
+	}
+}
@@ -43,6 +43,7 @@ func (h *Host) managedAddCollateral(settings modules.HostExternalSettings, txnSe
 		return
 	}
 	err = builder.FundSiacoins(hostPortion)
+	h.managedUpdateCollateralAlert(hostPortion, err)
 	if err != nil {
 		builder.Drop()
 		return nil, nil, nil, nil, extendErr("could not add collateral: ", ErrorInternal(err.Error()))
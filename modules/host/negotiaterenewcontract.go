@@ -56,6 +56,7 @@ func (h *Host) managedAddRenewCollateral(so storageObligation, settings modules.
 		return
 	}
 	err = builder.FundSiacoins(hostPortion)
+	h.managedUpdateCollateralAlert(hostPortion, err)
 	if err != nil {
 		builder.Drop()
 		return nil, nil, nil, nil, extendErr("could not add collateral: ", ErrorInternal(err.Error()))
@@ -95,6 +96,16 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
+	// Renewals are refused while the host is in maintenance mode. The host
+	// settings tell the renter that the host is not accepting contracts, so
+	// the connection can be closed.
+	h.mu.RLock()
+	maintenanceMode := h.settings.MaintenanceMode
+	h.mu.RUnlock()
+	if maintenanceMode {
+		h.log.Debugln("Turning down renewal because the host is in maintenance mode.")
+		return nil
+	}
 
 	// Set the renewal deadline.
 	conn.SetDeadline(time.Now().Add(modules.NegotiateRenewContractTime))
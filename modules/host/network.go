@@ -127,11 +127,11 @@ func (h *Host) threadedTrackConnectabilityStatus(closeChan chan struct{}) {
 			activeAddr = userAddr
 		}
 
-		dialer := &net.Dialer{
+		dialer := &modules.Dialer{
 			Cancel:  h.tg.StopChan(),
 			Timeout: connectabilityCheckTimeout,
 		}
-		conn, err := dialer.Dial("tcp", string(activeAddr))
+		conn, err := dialer.Dial(activeAddr)
 
 		var status modules.HostConnectabilityStatus
 		if err != nil {
@@ -143,6 +143,7 @@ func (h *Host) threadedTrackConnectabilityStatus(closeChan chan struct{}) {
 		h.mu.Lock()
 		h.connectabilityStatus = status
 		h.mu.Unlock()
+		h.managedUpdateConnectabilityAlert(status, activeAddr)
 
 		select {
 		case <-h.tg.StopChan():
@@ -31,6 +31,7 @@ package host
 // are not set or used.
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -912,6 +913,80 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 	}
 }
 
+// metadata returns the metadata of the storage obligation that is reported
+// to the user.
+func (so storageObligation) metadata() modules.StorageObligation {
+	return modules.StorageObligation{
+		ContractCost:             so.ContractCost,
+		DataSize:                 so.fileSize(),
+		LockedCollateral:         so.LockedCollateral,
+		ObligationId:             so.id(),
+		PotentialDownloadRevenue: so.PotentialDownloadRevenue,
+		PotentialStorageRevenue:  so.PotentialStorageRevenue,
+		PotentialUploadRevenue:   so.PotentialUploadRevenue,
+		RiskedCollateral:         so.RiskedCollateral,
+		SectorRootsCount:         uint64(len(so.SectorRoots)),
+		TransactionFeesAdded:     so.TransactionFeesAdded,
+
+		ExpirationHeight:  so.expiration(),
+		NegotiationHeight: so.NegotiationHeight,
+		ProofDeadLine:     so.proofDeadline(),
+
+		ObligationStatus:    so.ObligationStatus.String(),
+		OriginConfirmed:     so.OriginConfirmed,
+		ProofConfirmed:      so.ProofConfirmed,
+		ProofConstructed:    so.ProofConstructed,
+		RevisionConfirmed:   so.RevisionConfirmed,
+		RevisionConstructed: so.RevisionConstructed,
+	}
+}
+
+// StorageObligation returns the full storage obligation with the provided id,
+// including its sector roots, transaction sets, and the heights at which the
+// host still has action items queued for the obligation.
+func (h *Host) StorageObligation(id types.FileContractID) (modules.StorageObligationDetails, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.StorageObligationDetails{}, err
+	}
+	defer h.tg.Done()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var details modules.StorageObligationDetails
+	err = h.db.View(func(tx *bolt.Tx) error {
+		so, err := getStorageObligation(tx, id)
+		if err != nil {
+			return err
+		}
+		details = modules.StorageObligationDetails{
+			StorageObligation:      so.metadata(),
+			SectorRoots:            so.SectorRoots,
+			OriginTransactionSet:   so.OriginTransactionSet,
+			RevisionTransactionSet: so.RevisionTransactionSet,
+		}
+
+		// Action items at the current height have already been handled, only
+		// the ones at future heights are still queued.
+		c := tx.Bucket(bucketActionItems).Cursor()
+		heightBytes := make([]byte, 8)
+		binary.BigEndian.PutUint64(heightBytes, uint64(h.blockHeight+1))
+		for k, v := c.Seek(heightBytes); k != nil; k, v = c.Next() {
+			for i := 0; i+crypto.HashSize <= len(v); i += crypto.HashSize {
+				if bytes.Equal(v[i:i+crypto.HashSize], id[:]) {
+					details.ActionItemHeights = append(details.ActionItemHeights, types.BlockHeight(binary.BigEndian.Uint64(k)))
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.StorageObligationDetails{}, err
+	}
+	return details, nil
+}
+
 // StorageObligations fetches the set of storage obligations in the host and
 // returns metadata on them.
 func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
@@ -926,30 +1001,7 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 			if err != nil {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
-			mso := modules.StorageObligation{
-				ContractCost:             so.ContractCost,
-				DataSize:                 so.fileSize(),
-				LockedCollateral:         so.LockedCollateral,
-				ObligationId:             so.id(),
-				PotentialDownloadRevenue: so.PotentialDownloadRevenue,
-				PotentialStorageRevenue:  so.PotentialStorageRevenue,
-				PotentialUploadRevenue:   so.PotentialUploadRevenue,
-				RiskedCollateral:         so.RiskedCollateral,
-				SectorRootsCount:         uint64(len(so.SectorRoots)),
-				TransactionFeesAdded:     so.TransactionFeesAdded,
-
-				ExpirationHeight:  so.expiration(),
-				NegotiationHeight: so.NegotiationHeight,
-				ProofDeadLine:     so.proofDeadline(),
-
-				ObligationStatus:    so.ObligationStatus.String(),
-				OriginConfirmed:     so.OriginConfirmed,
-				ProofConfirmed:      so.ProofConfirmed,
-				ProofConstructed:    so.ProofConstructed,
-				RevisionConfirmed:   so.RevisionConfirmed,
-				RevisionConstructed: so.RevisionConstructed,
-			}
-			sos = append(sos, mso)
+			sos = append(sos, so.metadata())
 			return nil
 		})
 		if err != nil {
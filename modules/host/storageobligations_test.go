@@ -80,3 +80,55 @@ func TestStorageObligationID(t *testing.T) {
 		t.Error("id function of storage obligation incorrect for file contracts with dependencies")
 	}
 }
+
+// TestStorageObligationDetails checks that the full details of a storage
+// obligation, including its queued action items, can be retrieved.
+func TestStorageObligationDetails(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestStorageObligationDetails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	details, err := ht.host.StorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.ObligationId != so.id() {
+		t.Fatal("wrong storage obligation returned")
+	}
+	if len(details.OriginTransactionSet) != len(so.OriginTransactionSet) {
+		t.Fatal("origin transaction set was not returned")
+	}
+	// Adding the storage obligation queues action items for the origin
+	// transaction, the revision, and the storage proof.
+	if len(details.ActionItemHeights) == 0 {
+		t.Fatal("no action items were reported")
+	}
+	for i := 1; i < len(details.ActionItemHeights); i++ {
+		if details.ActionItemHeights[i] <= details.ActionItemHeights[i-1] {
+			t.Fatal("action item heights should be increasing")
+		}
+	}
+
+	// Looking up an unknown storage obligation should fail.
+	_, err = ht.host.StorageObligation(types.FileContractID{1})
+	if err != errNoStorageObligation {
+		t.Fatal("expected errNoStorageObligation, got", err)
+	}
+}
@@ -201,6 +201,11 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 				h.blockHeight--
 			}
 		}
+		// A consensus set that was bootstrapped from a snapshot starts at the
+		// snapshot height.
+		if cc.SnapshotHeight != 0 {
+			h.blockHeight = cc.SnapshotHeight - 1
+		}
 		for _, block := range cc.AppliedBlocks {
 			// Look for transactions relevant to open storage obligations.
 			for _, txn := range block.Transactions {
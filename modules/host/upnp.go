@@ -19,9 +19,10 @@ import (
 
 // managedLearnHostname discovers the external IP of the Host. If the host's
 // net address is blank and the host's auto address appears to have changed,
-// the host will make an announcement on the blockchain.
+// the host will make an announcement on the blockchain. If a proxy is set, the
+// IP is neither discovered nor announced, so that it is not revealed.
 func (h *Host) managedLearnHostname() {
-	if build.Release == "testing" {
+	if build.Release == "testing" || modules.Proxy() != "" {
 		return
 	}
 
@@ -120,6 +121,11 @@ func (h *Host) managedForwardPort(port string) error {
 		return nil
 	}
 
+	// If a proxy is set, the host is not meant to be reachable at its IP.
+	if modules.Proxy() != "" {
+		return nil
+	}
+
 	// If the port is invalid, there is no need to perform any of the other
 	// tasks.
 	portInt, err := strconv.Atoi(port)
@@ -161,6 +167,11 @@ func (h *Host) managedClearPort() error {
 		return nil
 	}
 
+	// If a proxy is set, no port was forwarded.
+	if modules.Proxy() != "" {
+		return nil
+	}
+
 	// If the port is invalid, there is no need to perform any of the other
 	// tasks.
 	h.mu.RLock()
@@ -197,7 +208,7 @@ func (h *Host) managedClearPort() error {
 // service, http://myexternalip.com.
 func myExternalIP() (string, error) {
 	// timeout after 10 seconds
-	client := http.Client{Timeout: time.Duration(10 * time.Second)}
+	client := modules.HTTPClient(10 * time.Second)
 	resp, err := client.Get("http://myexternalip.com/raw")
 	if err != nil {
 		return "", err
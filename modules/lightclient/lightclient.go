@@ -0,0 +1,132 @@
+// Package lightclient implements a header-only client of the Sia network. The
+// client follows the heaviest chain by downloading only the block headers from
+// its peers, and validates them using the proof of work, the timestamps and
+// the difficulty adjustment, without validating the blocks themselves. The
+// inclusion of a transaction in a block is verified with a proof that is
+// requested from a full node.
+//
+// The headers of the current chain are stored in a database in the persist
+// directory, so that they are not downloaded again after a restart.
+//
+// The light client registers the RelayHeader RPC, so it can not share a
+// gateway with a consensus set.
+//
+// The client is not used by siad yet, and a watch-only wallet can not run on
+// top of it: the wallet follows the outputs that every block creates and
+// spends, which a header-only client does not know. Serving the wallet
+// without a full consensus set is still an open part of the light client
+// mode.
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/persist"
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// logFile is the name of the file that the light client logs to.
+	logFile = "lightclient.log"
+)
+
+var (
+	errNilGateway = errors.New("cannot have a nil gateway as input")
+)
+
+// A Client is a header-only client of the Sia network.
+type Client struct {
+	// path holds the header states of the current chain, indexed by height,
+	// and heights maps the ids of the headers in the path to their height.
+	path    []consensus.HeaderState
+	heights map[types.BlockID]types.BlockHeight
+
+	db         *persist.BoltDatabase
+	gateway    modules.Gateway
+	log        *persist.Logger
+	mu         sync.RWMutex
+	persistDir string
+	tg         siasync.ThreadGroup
+}
+
+// New returns a light client that syncs the headers of the peers of the
+// gateway. The client continues with the headers stored in persistDir, or
+// starts with the genesis block.
+func New(gateway modules.Gateway, persistDir string) (*Client, error) {
+	if gateway == nil {
+		return nil, errNilGateway
+	}
+	c := &Client{
+		gateway:    gateway,
+		persistDir: persistDir,
+	}
+
+	// Initialize the logger.
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		return nil, err
+	}
+	var err error
+	c.log, err = persist.NewFileLogger(filepath.Join(persistDir, logFile))
+	if err != nil {
+		return nil, err
+	}
+	c.tg.AfterStop(func() {
+		err := c.log.Close()
+		if err != nil {
+			// State of the logger is unknown, a println will suffice.
+			fmt.Println("Error shutting down light client logger:", err)
+		}
+	})
+
+	// Load the headers of the current chain.
+	if err := c.initPersist(); err != nil {
+		return nil, err
+	}
+
+	// Register RPCs. The headers of new blocks are relayed by the peers, and
+	// the headers of the current chain are requested from every peer that
+	// the gateway connects to.
+	gateway.RegisterRPC("RelayHeader", c.threadedRPCRelayHeader)
+	gateway.RegisterConnectCall("SendHeaders", c.threadedReceiveHeaders)
+	c.tg.OnStop(func() {
+		c.gateway.UnregisterRPC("RelayHeader")
+		c.gateway.UnregisterConnectCall("SendHeaders")
+	})
+	return c, nil
+}
+
+// Close safely shuts down the light client.
+func (c *Client) Close() error {
+	return c.tg.Stop()
+}
+
+// CurrentHeader returns the header of the tip of the current chain.
+func (c *Client) CurrentHeader() types.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.path[len(c.path)-1].Header
+}
+
+// HeaderAtHeight returns the header of the current chain at the given height.
+func (c *Client) HeaderAtHeight(height types.BlockHeight) (types.BlockHeader, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height >= types.BlockHeight(len(c.path)) {
+		return types.BlockHeader{}, false
+	}
+	return c.path[height].Header, true
+}
+
+// Height returns the height of the current chain.
+func (c *Client) Height() types.BlockHeight {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return types.BlockHeight(len(c.path) - 1)
+}
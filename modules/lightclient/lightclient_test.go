@@ -0,0 +1,258 @@
+package lightclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// lightClientTester is a light client that is connected to a full node.
+type lightClientTester struct {
+	cs      *consensus.ConsensusSet
+	gateway *gateway.Gateway
+	client  *Client
+	clientG *gateway.Gateway
+}
+
+// newLightClientTester creates a full node and a light client that are not
+// connected yet.
+func newLightClientTester(name string) (*lightClientTester, error) {
+	testdir := build.TempDir("lightclient", name)
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		return nil, err
+	}
+	cs, err := consensus.New(g, false, filepath.Join(testdir, modules.ConsensusDir))
+	if err != nil {
+		return nil, err
+	}
+	clientG, err := gateway.New("localhost:0", false, filepath.Join(testdir, "client", modules.GatewayDir))
+	if err != nil {
+		return nil, err
+	}
+	client, err := New(clientG, filepath.Join(testdir, "client", "lightclient"))
+	if err != nil {
+		return nil, err
+	}
+	return &lightClientTester{
+		cs:      cs,
+		gateway: g,
+		client:  client,
+		clientG: clientG,
+	}, nil
+}
+
+// Close shuts down the light client and the full node.
+func (lct *lightClientTester) Close() error {
+	lct.client.Close()
+	lct.clientG.Close()
+	lct.cs.Close()
+	return lct.gateway.Close()
+}
+
+// mineBlock mines a block with the given transactions on top of the current
+// block of the full node, and adds it to the full node.
+func (lct *lightClientTester) mineBlock(txns []types.Transaction) (types.Block, error) {
+	parent := lct.cs.CurrentBlock()
+	target, _ := lct.cs.ChildTarget(parent.ID())
+	b := types.Block{
+		ParentID:     parent.ID(),
+		Timestamp:    types.CurrentTimestamp(),
+		MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(lct.cs.Height() + 1)}},
+		Transactions: txns,
+	}
+	for i := uint64(0); ; i++ {
+		binary.LittleEndian.PutUint64(b.Nonce[:], i)
+		id := b.ID()
+		if bytes.Compare(target[:], id[:]) >= 0 {
+			break
+		}
+	}
+	return b, lct.cs.AcceptBlock(b)
+}
+
+// waitForSync waits until the light client has the same current block as the
+// full node.
+func (lct *lightClientTester) waitForSync() error {
+	return build.Retry(50, 100*time.Millisecond, func() error {
+		if lct.client.CurrentHeader() != lct.cs.CurrentBlock().Header() {
+			return errors.New("light client is not synced")
+		}
+		return nil
+	})
+}
+
+// TestLightClient checks that the light client follows the chain of a full
+// node and verifies the transactions of its blocks.
+func TestLightClient(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	lct, err := newLightClientTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lct.Close()
+
+	// Mine past the Oak hardfork, so that both difficulty adjustments are
+	// used, and connect. The light client requests the headers in several
+	// batches.
+	for lct.cs.Height() < types.OakHardforkFixBlock+2*consensus.MaxCatchUpHeaders {
+		if _, err := lct.mineBlock(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lct.clientG.Connect(lct.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := lct.waitForSync(); err != nil {
+		t.Fatal(err)
+	}
+	if lct.client.Height() != lct.cs.Height() {
+		t.Fatal("light client has the wrong height:", lct.client.Height(), lct.cs.Height())
+	}
+
+	// New blocks are relayed to the light client.
+	txn := types.Transaction{ArbitraryData: [][]byte{[]byte("light client")}}
+	b, err := lct.mineBlock([]types.Transaction{txn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lct.mineBlock(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := lct.waitForSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The transaction can be fetched and verified.
+	fetched, height, err := lct.client.FetchTransaction(b.ID(), txn.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ID() != txn.ID() || height != lct.cs.Height()-1 {
+		t.Fatal("wrong transaction or height:", fetched.ID(), height)
+	}
+	proof, _ := b.TransactionProof(txn.ID())
+	if _, err := lct.client.VerifyTransaction(types.Transaction{}, b.ID(), proof); err != errInvalidProof {
+		t.Error("expected errInvalidProof, got", err)
+	}
+	if _, err := lct.client.VerifyTransaction(txn, types.BlockID{}, proof); err != errBlockNotInChain {
+		t.Error("expected errBlockNotInChain, got", err)
+	}
+	if _, _, err := lct.client.FetchTransaction(b.ID(), types.TransactionID{}); err != errNoProof {
+		t.Error("expected errNoProof, got", err)
+	}
+}
+
+// TestLightClientFork checks that the light client switches to a heavier
+// fork, and rejects headers that do not meet their target.
+func TestLightClientFork(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	lct, err := newLightClientTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lct.Close()
+	var headers []types.BlockHeader
+	for i := 0; i < 5; i++ {
+		b, err := lct.mineBlock(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, b.Header())
+	}
+	fork := new(headerFork)
+	if err := lct.client.managedAcceptHeaders(fork, headers); err != nil {
+		t.Fatal(err)
+	}
+	if lct.client.CurrentHeader() != headers[4] {
+		t.Fatal("light client did not accept the headers")
+	}
+
+	// Create a longer chain on a second full node, starting after the second
+	// block.
+	other, err := newLightClientTester(t.Name() + "-other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	for i := 0; i < 2; i++ {
+		b, _ := lct.cs.BlockAtHeight(types.BlockHeight(i + 1))
+		if err := other.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var forkHeaders []types.BlockHeader
+	for i := 0; i < 4; i++ {
+		b, err := other.mineBlock(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		forkHeaders = append(forkHeaders, b.Header())
+	}
+
+	// The fork is not heavier until it has more blocks than the current
+	// chain.
+	if err := lct.client.managedAcceptHeaders(fork, forkHeaders[:3]); err != nil {
+		t.Fatal(err)
+	}
+	if lct.client.CurrentHeader() != headers[4] {
+		t.Fatal("light client switched to a fork that is not heavier")
+	}
+	if err := lct.client.managedAcceptHeaders(fork, forkHeaders[3:]); err != nil {
+		t.Fatal(err)
+	}
+	if lct.client.CurrentHeader() != forkHeaders[3] || lct.client.Height() != 6 {
+		t.Fatal("light client did not switch to the heavier fork")
+	}
+	if h, _ := lct.client.HeaderAtHeight(2); h != headers[1] {
+		t.Fatal("light client replaced the common headers")
+	}
+
+	// The headers of the fork are kept across restarts.
+	if err := lct.client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lct.client, err = New(lct.clientG, lct.client.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lct.client.CurrentHeader() != forkHeaders[3] || lct.client.Height() != 6 {
+		t.Fatal("light client did not load its headers")
+	}
+	if h, _ := lct.client.HeaderAtHeight(2); h != headers[1] {
+		t.Fatal("light client did not load the common headers")
+	}
+
+	// Headers that do not meet their target or that have an unknown parent
+	// are rejected.
+	h := lct.client.CurrentHeader()
+	bad := types.BlockHeader{ParentID: h.ID(), Timestamp: types.CurrentTimestamp()}
+	for i := uint64(0); ; i++ {
+		binary.LittleEndian.PutUint64(bad.Nonce[:], i)
+		id := bad.ID()
+		if bytes.Compare(lct.client.path[6].ChildTarget[:], id[:]) < 0 {
+			break
+		}
+	}
+	if err := lct.client.managedAcceptHeaders(new(headerFork), []types.BlockHeader{bad}); err != modules.ErrBlockUnsolved {
+		t.Error("expected ErrBlockUnsolved, got", err)
+	}
+	if err := lct.client.managedAcceptHeaders(new(headerFork), []types.BlockHeader{{}}); err != errBadHeaders {
+		t.Error("expected errBadHeaders, got", err)
+	}
+}
@@ -0,0 +1,117 @@
+package lightclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// dbFile is the name of the database that holds the headers of the
+	// current chain.
+	dbFile = "lightclient.db"
+)
+
+var (
+	// bucketHeaders maps the heights of the current chain to the header
+	// states at those heights. Heights are encoded as big-endian integers, so
+	// that the headers are iterated in order.
+	bucketHeaders = []byte("Headers")
+
+	dbMetadata = persist.Metadata{
+		Header:  "Sia Light Client",
+		Version: "1.3.4",
+	}
+
+	errCorruptHeaders = errors.New("light client database is missing headers")
+	errWrongGenesis   = errors.New("light client database belongs to a different blockchain")
+)
+
+// heightKey returns the database key of a height.
+func heightKey(height types.BlockHeight) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return key
+}
+
+// putHeaders stores the header states in the database, and removes the
+// headers after them up to oldHeight, which belonged to the previous chain.
+func putHeaders(tx *bolt.Tx, headers []consensus.HeaderState, oldHeight types.BlockHeight) error {
+	b := tx.Bucket(bucketHeaders)
+	for _, hs := range headers {
+		if err := b.Put(heightKey(hs.Height), encoding.Marshal(hs)); err != nil {
+			return err
+		}
+	}
+	height := headers[len(headers)-1].Height
+	for h := height + 1; h <= oldHeight; h++ {
+		if err := b.Delete(heightKey(h)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initPersist opens the database and loads the headers of the current chain.
+// A new database starts with the genesis header.
+func (c *Client) initPersist() error {
+	if err := os.MkdirAll(c.persistDir, 0700); err != nil {
+		return err
+	}
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(c.persistDir, dbFile))
+	if err != nil {
+		return err
+	}
+	c.db = db
+	c.tg.AfterStop(func() {
+		if err := c.db.Close(); err != nil {
+			c.log.Println("ERROR: failed to close the database:", err)
+		}
+	})
+
+	genesis := consensus.GenesisHeaderState()
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketHeaders)
+		if err != nil {
+			return err
+		}
+		var path []consensus.HeaderState
+		err = b.ForEach(func(_, val []byte) error {
+			var hs consensus.HeaderState
+			if err := encoding.Unmarshal(val, &hs); err != nil {
+				return err
+			}
+			if hs.Height != types.BlockHeight(len(path)) {
+				return errCorruptHeaders
+			}
+			path = append(path, hs)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(path) == 0 {
+			path = []consensus.HeaderState{genesis}
+			if err := putHeaders(tx, path, 0); err != nil {
+				return err
+			}
+		} else if path[0].Header.ID() != genesis.Header.ID() {
+			return errWrongGenesis
+		}
+
+		c.path = path
+		c.heights = make(map[types.BlockID]types.BlockHeight, len(path))
+		for _, hs := range path {
+			c.heights[hs.Header.ID()] = hs.Height
+		}
+		return nil
+	})
+}
@@ -0,0 +1,127 @@
+package lightclient
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// maxProofHashes is the maximum number of hashes of a transaction proof that
+// is read from a peer. It is the height of a merkle tree with 2^64 leaves.
+const maxProofHashes = 64
+
+var (
+	errBlockNotInChain = errors.New("block is not part of the current chain")
+	errInvalidProof    = errors.New("transaction proof does not match the block header")
+	errNoProof         = errors.New("no peer sent a valid transaction proof")
+
+	// sendTxnProofTimeout is the timeout for the SendTxnProof RPC.
+	sendTxnProofTimeout = build.Select(build.Var{
+		Standard: 60 * time.Second,
+		Dev:      20 * time.Second,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+)
+
+// VerifyTransaction checks that a transaction is part of the block with the
+// given id using the proof, and that the block is part of the current chain.
+// It returns the height of the block. The number of confirmations of the
+// transaction is the difference between the height of the current chain and
+// the height of the block, plus one.
+func (c *Client) VerifyTransaction(txn types.Transaction, blockID types.BlockID, proof types.TransactionProof) (types.BlockHeight, error) {
+	c.mu.RLock()
+	height, exists := c.heights[blockID]
+	var header types.BlockHeader
+	if exists {
+		header = c.path[height].Header
+	}
+	c.mu.RUnlock()
+	if !exists {
+		return 0, errBlockNotInChain
+	}
+	if !proof.Verify(txn, header) {
+		return 0, errInvalidProof
+	}
+	return height, nil
+}
+
+// managedReceiveTxnProof is the calling end of the SendTxnProof RPC. It
+// requests the transaction with the given id from the block with the given id,
+// together with the proof that the transaction is part of the block.
+func (c *Client) managedReceiveTxnProof(conn modules.PeerConn, blockID types.BlockID, txid types.TransactionID) (txn types.Transaction, proof types.TransactionProof, err error) {
+	err = conn.SetDeadline(time.Now().Add(sendTxnProofTimeout))
+	if err != nil {
+		return types.Transaction{}, types.TransactionProof{}, err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-c.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+
+	if err := encoding.WriteObject(conn, blockID); err != nil {
+		return types.Transaction{}, types.TransactionProof{}, err
+	}
+	if err := encoding.WriteObject(conn, txid); err != nil {
+		return types.Transaction{}, types.TransactionProof{}, err
+	}
+	if err := encoding.ReadObject(conn, &txn, types.BlockSizeLimit); err != nil {
+		return types.Transaction{}, types.TransactionProof{}, err
+	}
+	if err := encoding.ReadObject(conn, &proof, 24+maxProofHashes*crypto.HashSize); err != nil {
+		return types.Transaction{}, types.TransactionProof{}, err
+	}
+	return txn, proof, nil
+}
+
+// FetchTransaction requests the transaction with the given id from the block
+// with the given id from the peers of the gateway, and verifies that it is
+// part of the block. The block must be part of the current chain. It returns
+// the transaction and the height of the block.
+func (c *Client) FetchTransaction(blockID types.BlockID, txid types.TransactionID) (types.Transaction, types.BlockHeight, error) {
+	if err := c.tg.Add(); err != nil {
+		return types.Transaction{}, 0, err
+	}
+	defer c.tg.Done()
+
+	c.mu.RLock()
+	_, exists := c.heights[blockID]
+	c.mu.RUnlock()
+	if !exists {
+		return types.Transaction{}, 0, errBlockNotInChain
+	}
+
+	// Peers can not forge a proof, so the first valid one is used.
+	for _, peer := range c.gateway.Peers() {
+		var txn types.Transaction
+		var proof types.TransactionProof
+		err := c.gateway.RPC(peer.NetAddress, "SendTxnProof", func(conn modules.PeerConn) (err error) {
+			txn, proof, err = c.managedReceiveTxnProof(conn, blockID, txid)
+			return err
+		})
+		if err != nil {
+			c.log.Debugln("WARN: failed to get transaction proof from peer:", err)
+			continue
+		}
+		if txn.ID() != txid {
+			c.log.Debugln("WARN: peer sent the wrong transaction")
+			continue
+		}
+		height, err := c.VerifyTransaction(txn, blockID, proof)
+		if err != nil {
+			c.log.Debugln("WARN: peer sent an invalid transaction proof:", err)
+			continue
+		}
+		return txn, height, nil
+	}
+	return types.Transaction{}, 0, errNoProof
+}
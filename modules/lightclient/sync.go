@@ -0,0 +1,272 @@
+package lightclient
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	errBadHeaders = errors.New("peer sent headers that do not extend a known header")
+
+	// relayHeaderTimeout is the timeout for the RelayHeader RPC.
+	relayHeaderTimeout = build.Select(build.Var{
+		Standard: 60 * time.Second,
+		Dev:      20 * time.Second,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// sendHeadersTimeout is the timeout for the SendHeaders RPC.
+	sendHeadersTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Dev:      30 * time.Second,
+		Testing:  4 * time.Second,
+	}).(time.Duration)
+)
+
+// A headerFork is a chain of validated headers received from a peer that
+// extends the header of the current chain at height 'base'. The headers are
+// added to the current chain once they are heavier than it. Because a peer
+// sends at most consensus.MaxCatchUpHeaders headers at a time, a fork can be
+// received over several calls of the SendHeaders RPC.
+type headerFork struct {
+	base    types.BlockHeight
+	baseID  types.BlockID
+	headers []consensus.HeaderState
+}
+
+// blockHistory returns the ids of the last 10 headers of the current chain,
+// followed by exponentially spaced headers and the genesis header, which is
+// the same block history that the consensus set sends in the SendHeaders
+// RPC.
+func (c *Client) blockHistory() (blockIDs [32]types.BlockID) {
+	height := types.BlockHeight(len(c.path) - 1)
+	step := types.BlockHeight(1)
+	for i := 0; i < 31; i++ {
+		blockIDs[i] = c.path[height].Header.ID()
+		if i >= 9 {
+			step *= 2
+		}
+		if height <= step {
+			break
+		}
+		height -= step
+	}
+	blockIDs[31] = c.path[0].Header.ID()
+	return blockIDs
+}
+
+// managedAcceptHeaders validates headers that extend either the fork or a
+// header of the current chain, and adds them to the fork. If the fork becomes
+// heavier than the current chain, the current chain is replaced by the fork
+// and the fork is emptied.
+func (c *Client) managedAcceptHeaders(fork *headerFork, headers []types.BlockHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Start a new fork if the headers do not extend the existing one, or if
+	// the base of the existing one is no longer part of the current chain.
+	if len(fork.headers) == 0 || headers[0].ParentID != fork.headers[len(fork.headers)-1].Header.ID() || c.heights[fork.baseID] != fork.base {
+		base, exists := c.heights[headers[0].ParentID]
+		if !exists {
+			return errBadHeaders
+		}
+		*fork = headerFork{base: base, baseID: headers[0].ParentID}
+	}
+
+	// The timestamps of the ancestors of the headers are taken from the
+	// current chain up to the base of the fork, and from the fork after it.
+	timestamp := func(height types.BlockHeight) types.Timestamp {
+		if height <= fork.base {
+			return c.path[height].Header.Timestamp
+		}
+		return fork.headers[height-fork.base-1].Header.Timestamp
+	}
+	for _, h := range headers {
+		parent := c.path[fork.base]
+		if len(fork.headers) > 0 {
+			parent = fork.headers[len(fork.headers)-1]
+		}
+		child, err := consensus.ChildHeaderState(parent, timestamp, h)
+		if err != nil {
+			return err
+		}
+		fork.headers = append(fork.headers, child)
+	}
+
+	// Switch to the fork if it is heavier than the current chain.
+	if !fork.headers[len(fork.headers)-1].HeavierThan(c.path[len(c.path)-1]) {
+		return nil
+	}
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		return putHeaders(tx, fork.headers, types.BlockHeight(len(c.path)-1))
+	})
+	if err != nil {
+		return err
+	}
+	for _, hs := range c.path[fork.base+1:] {
+		delete(c.heights, hs.Header.ID())
+	}
+	c.path = c.path[:fork.base+1]
+	for _, hs := range fork.headers {
+		c.heights[hs.Header.ID()] = hs.Height
+		c.path = append(c.path, hs)
+	}
+	*fork = headerFork{}
+	return nil
+}
+
+// managedReceiveHeaders is the calling end of the SendHeaders RPC. The
+// headers that follow the fork are requested, or the headers that follow the
+// current chain if the fork is empty, and added to the fork.
+func (c *Client) managedReceiveHeaders(conn modules.PeerConn, fork *headerFork) (moreAvailable bool, err error) {
+	err = conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+	if err != nil {
+		return false, err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-c.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+
+	// Send the block history, starting with the tip of the fork.
+	c.mu.RLock()
+	history := c.blockHistory()
+	c.mu.RUnlock()
+	if len(fork.headers) > 0 {
+		copy(history[1:31], history[:30])
+		history[0] = fork.headers[len(fork.headers)-1].Header.ID()
+	}
+	if err := encoding.WriteObject(conn, history); err != nil {
+		return false, err
+	}
+
+	// Read and validate the headers.
+	var headers []types.BlockHeader
+	if err := encoding.ReadObject(conn, &headers, uint64(consensus.MaxCatchUpHeaders)*types.BlockHeaderSize+8); err != nil {
+		return false, err
+	}
+	if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+		return false, err
+	}
+	if len(headers) == 0 {
+		return false, nil
+	}
+	err = c.managedAcceptHeaders(fork, headers)
+	if err == modules.ErrBlockUnsolved {
+		c.gateway.ReportViolation(conn.RPCAddr(), modules.ViolationMinor, "sent a header that does not meet its target")
+	}
+	return moreAvailable, err
+}
+
+// managedSyncPeer requests headers from a peer until the peer has no more
+// headers that extend the current chain.
+func (c *Client) managedSyncPeer(addr modules.NetAddress, fork *headerFork) error {
+	for {
+		var moreAvailable bool
+		err := c.gateway.RPC(addr, "SendHeaders", func(conn modules.PeerConn) (err error) {
+			moreAvailable, err = c.managedReceiveHeaders(conn, fork)
+			return err
+		})
+		if err != nil || !moreAvailable {
+			return err
+		}
+	}
+}
+
+// threadedReceiveHeaders is called when the gateway connects to a peer. It
+// requests the headers that follow the current chain, and keeps requesting
+// headers in a separate goroutine if the peer has more of them.
+func (c *Client) threadedReceiveHeaders(conn modules.PeerConn) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+
+	fork := new(headerFork)
+	moreAvailable, err := c.managedReceiveHeaders(conn, fork)
+	if err != nil || !moreAvailable {
+		return err
+	}
+	// The gateway can not be called from within an RPC, so the remaining
+	// headers are requested in a new goroutine.
+	go func() {
+		if err := c.tg.Add(); err != nil {
+			return
+		}
+		defer c.tg.Done()
+		if err := c.managedSyncPeer(conn.RPCAddr(), fork); err != nil {
+			c.log.Debugln("WARN: failed to sync headers with peer:", err)
+		}
+	}()
+	return nil
+}
+
+// threadedRPCRelayHeader is the receiving end of the RelayHeader RPC. A header
+// that extends the current chain is added to it. For any other header, the
+// headers that lead to it are requested from the peer.
+func (c *Client) threadedRPCRelayHeader(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(relayHeaderTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-c.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = c.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer c.tg.Done()
+
+	var h types.BlockHeader
+	err = encoding.ReadObject(conn, &h, types.BlockHeaderSize)
+	if err != nil {
+		return err
+	}
+	c.mu.RLock()
+	_, known := c.heights[h.ID()]
+	extends := h.ParentID == c.path[len(c.path)-1].Header.ID()
+	c.mu.RUnlock()
+	if known {
+		return nil
+	} else if extends {
+		err := c.managedAcceptHeaders(new(headerFork), []types.BlockHeader{h})
+		if err == modules.ErrBlockUnsolved {
+			c.gateway.ReportViolation(conn.RPCAddr(), modules.ViolationMinor, "relayed a header that does not meet its target")
+		}
+		return err
+	}
+
+	// The header is an orphan or belongs to a fork. The gateway can not be
+	// called from within an RPC, so the headers are requested in a new
+	// goroutine.
+	go func() {
+		if err := c.tg.Add(); err != nil {
+			return
+		}
+		defer c.tg.Done()
+		if err := c.managedSyncPeer(conn.RPCAddr(), new(headerFork)); err != nil {
+			c.log.Debugln("WARN: failed to get parents of orphan header:", err)
+		}
+	}()
+	return nil
+}
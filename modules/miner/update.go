@@ -275,6 +275,11 @@ func (m *Miner) ProcessConsensusChange(cc modules.ConsensusChange) {
 			m.persist.Height = 0
 		}
 	}
+	// A consensus set that was bootstrapped from a snapshot starts at the
+	// snapshot height.
+	if cc.SnapshotHeight != 0 {
+		m.persist.Height = cc.SnapshotHeight - 1
+	}
 	for _, block := range cc.AppliedBlocks {
 		// Only doing the block check if the height is above zero saves hashing
 		// and saves a nontrivial amount of time during IBD.
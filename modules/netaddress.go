@@ -60,6 +60,14 @@ func (na NetAddress) IsLoopback() bool {
 	return false
 }
 
+// IsOnion returns true if the host of the NetAddress is a Tor onion service
+// address. Onion addresses can only be reached through a SOCKS5 proxy, see
+// SetProxy.
+func (na NetAddress) IsOnion() bool {
+	host := strings.TrimSuffix(strings.ToLower(na.Host()), ".")
+	return strings.HasSuffix(host, ".onion")
+}
+
 // IsLocal returns true if the input IP address belongs to a local address
 // range such as 192.168.x.x or 127.x.x.x
 func (na NetAddress) IsLocal() bool {
@@ -164,6 +172,21 @@ func (na NetAddress) IsStdValid() error {
 				}
 			}
 		}
+
+		// The label in front of the .onion suffix of an onion address is the
+		// base32 encoded key of the onion service, which is 16 characters
+		// long for v2 services and 56 characters long for v3 services.
+		if na.IsOnion() {
+			key := strings.ToLower(labels[len(labels)-2])
+			if len(key) != 16 && len(key) != 56 {
+				return errors.New("onion address has invalid length")
+			}
+			for _, r := range key {
+				if !('a' <= r && r <= 'z' || '2' <= r && r <= '7') {
+					return errors.New("onion address is not base32 encoded")
+				}
+			}
+		}
 	}
 
 	return nil
@@ -67,6 +67,11 @@ var (
 		".:123",
 		".foo.com:123",
 		"foo.com..:123",
+		// Invalid onion addresses
+		"foo.onion:123",
+		strings.Repeat("a", 17) + ".onion:123",
+		strings.Repeat("a", 15) + "1.onion:123",
+		strings.Repeat("a", 55) + "8.onion:123",
 		// invalid port numbers
 		"foo:0",
 		"foo:65536",
@@ -97,6 +102,10 @@ var (
 		"[::2]:65535",
 		"111.111.111.111:111",
 		"12.34.45.64:7777",
+		// Onion addresses.
+		"expyuzz4wqqyqhjn.onion:9981",
+		"sp3k262uwy4r2k3ycr5awluarykdpag6a7y33jxop4cs2lu5uz5sseqd.onion:9982",
+		"www.expyuzz4wqqyqhjn.onion:9981",
 	}
 )
 
@@ -234,6 +243,29 @@ func TestIsValid(t *testing.T) {
 	}
 }
 
+// TestIsOnion checks that onion addresses are recognized.
+func TestIsOnion(t *testing.T) {
+	t.Parallel()
+
+	testSet := []struct {
+		query           NetAddress
+		desiredResponse bool
+	}{
+		{"expyuzz4wqqyqhjn.onion:9981", true},
+		{"EXPYUZZ4WQQYQHJN.ONION:9981", true},
+		{"expyuzz4wqqyqhjn.onion.:9981", true},
+		{"www.expyuzz4wqqyqhjn.onion:9981", true},
+		{"expyuzz4wqqyqhjn.onion", false},
+		{"onion.com:9981", false},
+		{"12.34.45.64:7777", false},
+	}
+	for _, test := range testSet {
+		if test.query.IsOnion() != test.desiredResponse {
+			t.Errorf("IsOnion returned %v for %q", !test.desiredResponse, test.query)
+		}
+	}
+}
+
 // TestIsLocal checks that the correct values are returned for all local IP
 // addresses.
 func TestIsLocal(t *testing.T) {
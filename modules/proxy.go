@@ -0,0 +1,229 @@
+package modules
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// The SOCKS5 protocol is specified in RFC 1928. Only the CONNECT command
+// without authentication is supported, which is what Tor and ssh -D provide.
+const (
+	socks5Version      = 5
+	socks5NoAuth       = 0
+	socks5Connect      = 1
+	socks5AddrIPv4     = 1
+	socks5AddrDomain   = 3
+	socks5AddrIPv6     = 4
+	socks5ReplySuccess = 0
+)
+
+var (
+	// ErrOnionWithoutProxy is returned when dialing an onion address while no
+	// proxy is set.
+	ErrOnionWithoutProxy = errors.New("onion addresses can only be reached through a SOCKS5 proxy")
+
+	errDialCanceled   = errors.New("dial was canceled")
+	errProxyAuth      = errors.New("proxy requires an unsupported authentication method")
+	errProxyVersion   = errors.New("proxy does not speak SOCKS5")
+	errProxyHostLen   = errors.New("host is too long to be sent to the proxy")
+	errProxyAddrType  = errors.New("proxy replied with an unknown address type")
+	socks5ReplyErrors = []string{
+		1: "general SOCKS server failure",
+		2: "connection not allowed by ruleset",
+		3: "network unreachable",
+		4: "host unreachable",
+		5: "connection refused",
+		6: "TTL expired",
+		7: "command not supported",
+		8: "address type not supported",
+	}
+)
+
+// proxy is the SOCKS5 proxy that outbound connections are routed through.
+var proxy struct {
+	addr NetAddress
+	mu   sync.RWMutex
+}
+
+// Proxy returns the address of the SOCKS5 proxy that outbound connections are
+// routed through, or the empty string if no proxy is set.
+func Proxy() NetAddress {
+	proxy.mu.RLock()
+	defer proxy.mu.RUnlock()
+	return proxy.addr
+}
+
+// SetProxy routes all outbound connections of the modules through the SOCKS5
+// proxy at addr. Hostnames are resolved by the proxy, so that neither
+// connections nor DNS lookups reveal the IP address of the node. An empty
+// address disables the proxy.
+func SetProxy(addr NetAddress) error {
+	if addr != "" {
+		if err := addr.IsStdValid(); err != nil {
+			return build.ExtendErr("invalid proxy address", err)
+		}
+	}
+	proxy.mu.Lock()
+	proxy.addr = addr
+	proxy.mu.Unlock()
+	return nil
+}
+
+// A Dialer dials the outbound connections of the modules. If a proxy is set,
+// the connections are made through the proxy. Onion addresses can only be
+// dialed through a proxy.
+type Dialer struct {
+	// Cancel and Timeout have the same meaning as the fields of net.Dialer.
+	// When dialing through a proxy, they also apply to the handshake with the
+	// proxy.
+	Cancel  <-chan struct{}
+	Timeout time.Duration
+}
+
+// Dial connects to the address.
+func (d *Dialer) Dial(addr NetAddress) (net.Conn, error) {
+	proxyAddr := Proxy()
+	if proxyAddr == "" {
+		if addr.IsOnion() {
+			return nil, ErrOnionWithoutProxy
+		}
+		return (&net.Dialer{Cancel: d.Cancel, Timeout: d.Timeout}).Dial("tcp", string(addr))
+	}
+	return d.dialProxy(proxyAddr, addr)
+}
+
+// HTTPClient returns an HTTP client whose connections are made by a Dialer,
+// so that requests are routed through the proxy if one is set. A timeout of
+// zero means no timeout.
+func HTTPClient(timeout time.Duration) *http.Client {
+	d := &Dialer{Timeout: timeout}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Dial: func(_, addr string) (net.Conn, error) {
+				return d.Dial(NetAddress(addr))
+			},
+		},
+	}
+}
+
+// dialProxy connects to the address through the SOCKS5 proxy at proxyAddr.
+func (d *Dialer) dialProxy(proxyAddr, addr NetAddress) (net.Conn, error) {
+	conn, err := (&net.Dialer{Cancel: d.Cancel, Timeout: d.Timeout}).Dial("tcp", string(proxyAddr))
+	if err != nil {
+		return nil, build.ExtendErr("could not connect to proxy", err)
+	}
+	if d.Timeout != 0 {
+		conn.SetDeadline(time.Now().Add(d.Timeout))
+	}
+
+	// Close the connection if the dial is canceled during the handshake. The
+	// goroutine reports whether it closed the connection, so that a canceled
+	// connection is never returned.
+	done := make(chan struct{})
+	canceled := make(chan bool, 1)
+	go func() {
+		select {
+		case <-d.Cancel:
+			conn.Close()
+			canceled <- true
+		case <-done:
+			canceled <- false
+		}
+	}()
+	err = socks5Handshake(conn, addr)
+	close(done)
+	if <-canceled {
+		return nil, errDialCanceled
+	} else if err != nil {
+		conn.Close()
+		return nil, build.ExtendErr("proxy handshake failed", err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// socks5Handshake asks the SOCKS5 proxy on the other end of conn to connect
+// to addr. IP addresses are passed to the proxy as such, and hostnames are
+// passed unresolved.
+func socks5Handshake(conn io.ReadWriter, addr NetAddress) error {
+	host := addr.Host()
+	port, err := strconv.ParseUint(addr.Port(), 10, 16)
+	if err != nil {
+		return err
+	}
+
+	// Negotiate the authentication method.
+	if _, err := conn.Write([]byte{socks5Version, 1, socks5NoAuth}); err != nil {
+		return err
+	}
+	var method [2]byte
+	if _, err := io.ReadFull(conn, method[:]); err != nil {
+		return err
+	} else if method[0] != socks5Version {
+		return errProxyVersion
+	} else if method[1] != socks5NoAuth {
+		return errProxyAuth
+	}
+
+	// Send the connect request.
+	req := []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return errProxyHostLen
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socks5AddrIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socks5AddrIPv6)
+		req = append(req, ip.To16()...)
+	}
+	var portBytes [2]byte
+	binary.BigEndian.PutUint16(portBytes[:], uint16(port))
+	req = append(req, portBytes[:]...)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	// Read the reply, and discard the address that the proxy bound to.
+	var reply [4]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	} else if reply[0] != socks5Version {
+		return errProxyVersion
+	} else if reply[1] != socks5ReplySuccess {
+		if int(reply[1]) < len(socks5ReplyErrors) {
+			return errors.New(socks5ReplyErrors[reply[1]])
+		}
+		return fmt.Errorf("proxy replied with unknown error code %v", reply[1])
+	}
+	var boundLen int
+	switch reply[3] {
+	case socks5AddrIPv4:
+		boundLen = net.IPv4len
+	case socks5AddrIPv6:
+		boundLen = net.IPv6len
+	case socks5AddrDomain:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return err
+		}
+		boundLen = int(l[0])
+	default:
+		return errProxyAddrType
+	}
+	_, err = io.ReadFull(conn, make([]byte, boundLen+2))
+	return err
+}
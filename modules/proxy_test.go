@@ -0,0 +1,286 @@
+package modules
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testProxy is a minimal SOCKS5 proxy that records the addresses it is asked
+// to connect to. Onion addresses are forwarded to onionTarget, and hosts
+// starting with "refused" are refused.
+type testProxy struct {
+	listener    net.Listener
+	onionTarget string
+
+	requests []string
+	mu       sync.Mutex
+}
+
+// newTestProxy starts a testProxy on the loopback address.
+func newTestProxy(t *testing.T, onionTarget string) *testProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProxy{listener: l, onionTarget: onionTarget}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.serve(conn)
+		}
+	}()
+	return p
+}
+
+// serve handles a single SOCKS5 connection.
+func (p *testProxy) serve(conn net.Conn) {
+	defer conn.Close()
+	var greeting [3]byte
+	if _, err := io.ReadFull(conn, greeting[:]); err != nil {
+		return
+	}
+	conn.Write([]byte{socks5Version, socks5NoAuth})
+
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case socks5AddrIPv4:
+		ip := make([]byte, net.IPv4len)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case socks5AddrIPv6:
+		ip := make([]byte, net.IPv6len)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case socks5AddrDomain:
+		var l [1]byte
+		io.ReadFull(conn, l[:])
+		name := make([]byte, l[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(conn, port[:]); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+	p.mu.Lock()
+	p.requests = append(p.requests, addr)
+	p.mu.Unlock()
+
+	target := addr
+	if NetAddress(addr).IsOnion() {
+		target = p.onionTarget
+	}
+	var targetConn net.Conn
+	var err error
+	if !strings.HasPrefix(host, "refused") {
+		targetConn, err = net.Dial("tcp", target)
+	}
+	if targetConn == nil || err != nil {
+		conn.Write([]byte{socks5Version, 5, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer targetConn.Close()
+	conn.Write([]byte{socks5Version, socks5ReplySuccess, 0, socks5AddrIPv4, 127, 0, 0, 1, 0, 0})
+	go io.Copy(targetConn, conn)
+	io.Copy(conn, targetConn)
+}
+
+// lastRequest returns the address of the last connect request.
+func (p *testProxy) lastRequest() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.requests) == 0 {
+		return ""
+	}
+	return p.requests[len(p.requests)-1]
+}
+
+// newEchoListener starts a listener on the loopback address that echoes
+// everything it receives.
+func newEchoListener(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l
+}
+
+// checkEcho checks that conn is connected to an echo listener.
+func checkEcho(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	if _, err := conn.Write([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	} else if string(buf) != "foo" {
+		t.Fatal("wrong echo:", string(buf))
+	}
+}
+
+// TestDialerProxy checks that the Dialer connects through the SOCKS5 proxy
+// set with SetProxy, and that onion addresses and hostnames are passed to the
+// proxy unresolved.
+func TestDialerProxy(t *testing.T) {
+	echo := newEchoListener(t)
+	defer echo.Close()
+	echoAddr := NetAddress(echo.Addr().String())
+	onionAddr := NetAddress(net.JoinHostPort("expyuzz4wqqyqhjn.onion", echoAddr.Port()))
+	p := newTestProxy(t, string(echoAddr))
+	defer p.listener.Close()
+
+	// Without a proxy, connections are direct and onion addresses can not be
+	// reached.
+	d := &Dialer{Timeout: time.Second}
+	conn, err := d.Dial(echoAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEcho(t, conn)
+	if _, err := d.Dial(onionAddr); err != ErrOnionWithoutProxy {
+		t.Fatal("expected ErrOnionWithoutProxy, got", err)
+	}
+
+	// Set the proxy.
+	if err := SetProxy("foo"); err == nil {
+		t.Fatal("expected invalid proxy address to be rejected")
+	}
+	if err := SetProxy(NetAddress(p.listener.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProxy("")
+
+	for _, addr := range []NetAddress{
+		echoAddr,
+		onionAddr,
+		NetAddress(net.JoinHostPort("localhost", echoAddr.Port())),
+	} {
+		conn, err := d.Dial(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEcho(t, conn)
+		if p.lastRequest() != string(addr) {
+			t.Fatalf("proxy was asked for %v, expected %v", p.lastRequest(), addr)
+		}
+	}
+
+	// Errors of the proxy are returned.
+	_, err = d.Dial("refused.example.com:9981")
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatal("expected the proxy to refuse the connection, got", err)
+	}
+}
+
+// TestDialerProxyCancel checks that the handshake with an unresponsive proxy
+// is subject to the timeout and cancel channel of the Dialer.
+func TestDialerProxyCancel(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		// Accept connections, but never reply.
+		var conns []net.Conn
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				break
+			}
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	if err := SetProxy(NetAddress(l.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProxy("")
+
+	d := &Dialer{Timeout: 100 * time.Millisecond}
+	if _, err := d.Dial("foo.com:9981"); err == nil {
+		t.Fatal("expected the handshake to time out")
+	}
+
+	cancel := make(chan struct{})
+	d = &Dialer{Cancel: cancel}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(cancel)
+	}()
+	if _, err := d.Dial("foo.com:9981"); err != errDialCanceled {
+		t.Fatal("expected errDialCanceled, got", err)
+	}
+}
+
+// TestHTTPClientProxy checks that the requests of HTTPClient are routed
+// through the proxy, with the hostname passed to the proxy unresolved.
+func TestHTTPClientProxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "foo")
+	}))
+	defer srv.Close()
+	srvAddr := NetAddress(srv.Listener.Addr().String())
+	p := newTestProxy(t, "")
+	defer p.listener.Close()
+
+	get := func(url string) {
+		resp, err := HTTPClient(time.Second).Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if body, err := ioutil.ReadAll(resp.Body); err != nil || string(body) != "foo" {
+			t.Fatal("wrong response:", string(body), err)
+		}
+	}
+
+	// Without a proxy, requests are made directly.
+	get(srv.URL)
+	if p.lastRequest() != "" {
+		t.Fatal("request was routed through the proxy:", p.lastRequest())
+	}
+
+	if err := SetProxy(NetAddress(p.listener.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProxy("")
+	localhost := NetAddress(net.JoinHostPort("localhost", srvAddr.Port()))
+	get("http://" + string(localhost))
+	if p.lastRequest() != string(localhost) {
+		t.Fatalf("proxy was asked for %v, expected %v", p.lastRequest(), localhost)
+	}
+}
@@ -49,6 +49,11 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 			c.blockHeight--
 		}
 	}
+	// A consensus set that was bootstrapped from a snapshot starts at the
+	// snapshot height.
+	if cc.SnapshotHeight != 0 {
+		c.blockHeight = cc.SnapshotHeight - 1
+	}
 	for _, block := range cc.AppliedBlocks {
 		if block.ID() != types.GenesisID {
 			c.blockHeight++
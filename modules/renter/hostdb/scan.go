@@ -5,7 +5,6 @@ package hostdb
 // settings of the hosts.
 
 import (
-	"net"
 	"sort"
 	"time"
 
@@ -284,12 +283,12 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 		}
 		hdb.mu.RUnlock()
 
-		dialer := &net.Dialer{
+		dialer := &modules.Dialer{
 			Cancel:  hdb.tg.StopChan(),
 			Timeout: timeout,
 		}
 		start := time.Now()
-		conn, err := dialer.Dial("tcp", string(netAddr))
+		conn, err := dialer.Dial(netAddr)
 		latency = time.Since(start)
 		if err != nil {
 			return err
@@ -91,6 +91,11 @@ func (hdb *HostDB) ProcessConsensusChange(cc modules.ConsensusChange) {
 			hdb.blockHeight = 0
 		}
 	}
+	// A consensus set that was bootstrapped from a snapshot starts at the
+	// snapshot height.
+	if cc.SnapshotHeight != 0 {
+		hdb.blockHeight = cc.SnapshotHeight - 1
+	}
 	for _, block := range cc.AppliedBlocks {
 		// Only doing the block check if the height is above zero saves hashing
 		// and saves a nontrivial amount of time during IBD.
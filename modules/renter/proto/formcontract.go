@@ -1,8 +1,6 @@
 package proto
 
 import (
-	"net"
-
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
@@ -116,11 +114,11 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	}()
 
 	// Initiate connection.
-	dialer := &net.Dialer{
+	dialer := &modules.Dialer{
 		Cancel:  cancel,
 		Timeout: connTimeout,
 	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, err := dialer.Dial(host.NetAddress)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -12,6 +12,11 @@ const (
 	// StorageManagerDir is standard name used for the directory that contains
 	// all of the storage manager files.
 	StorageManagerDir = "storagemanager"
+
+	// StorageFolderUnavailableFailures is the number of failed reads and
+	// failed writes that is reported for a storage folder that is
+	// unavailable, to flag the user's attention.
+	StorageFolderUnavailableFailures = 9999999999
 )
 
 type (
@@ -40,6 +45,14 @@ type (
 		// folder. Progress is always reported in bytes.
 		ProgressNumerator   uint64
 		ProgressDenominator uint64
+
+		// Migrating indicates whether sectors are currently being migrated
+		// out of the storage folder. SectorsMigrated and SectorsRemaining
+		// report the progress of the most recent migration, removal or
+		// shrink of the storage folder.
+		Migrating        bool   `json:"migrating"`
+		SectorsMigrated  uint64 `json:"sectorsmigrated"`
+		SectorsRemaining uint64 `json:"sectorsremaining"`
 	}
 
 	// A StorageManager is responsible for managing storage folders and
@@ -69,6 +82,11 @@ type (
 		// gracefully handle running out of storage unexpectedly.
 		AddStorageFolder(path string, size uint64) error
 
+		// CancelStorageFolderMigration will stop a migration that was started
+		// by MigrateStorageFolder. Sectors that have already been moved will
+		// remain in their new storage folders.
+		CancelStorageFolderMigration(index uint16) error
+
 		// The storage manager needs to be able to shut down.
 		Close() error
 
@@ -80,6 +98,14 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// MigrateStorageFolder will move all of the sectors in a storage
+		// folder into other storage folders in the background. No new
+		// sectors will be added to the storage folder while the migration is
+		// running. If destinations are provided, sectors will only be moved
+		// into those storage folders. Progress is reported through
+		// StorageFolders.
+		MigrateStorageFolder(index uint16, destinations []uint16) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -4,9 +4,11 @@ package transactionpool
 // between a file contract revision and a file contract.
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -21,6 +23,8 @@ var (
 	errFullTransactionPool = errors.New("transaction pool cannot accept more transactions")
 	errLowMinerFees        = errors.New("transaction set needs more miner fees to be accepted")
 	errObjectConflict      = errors.New("transaction set conflicts with an existing transaction set")
+	errLowReplacementFees  = errors.New("replacement transaction set does not pay enough fees to evict the transactions it conflicts with")
+	errTooManyEvictions    = errors.New("replacement transaction set would evict too many transactions")
 )
 
 // relatedObjectIDs determines all of the object ids related to a transaction.
@@ -57,6 +61,138 @@ func relatedObjectIDs(ts []types.Transaction) []ObjectID {
 	return oids
 }
 
+// transactionSetFees returns the sum of the miner fees of the transactions.
+func transactionSetFees(ts []types.Transaction) types.Currency {
+	var fees types.Currency
+	for _, txn := range ts {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	return fees
+}
+
+// evictedTransactions splits the transactions of the conflicting sets into
+// the ones that are double spent by ts and the ones that are not. A
+// transaction is double spent if it spends a siacoin or siafund output that
+// is also spent by ts, or if it depends on a transaction that is double
+// spent, however far removed. The order of the transactions within each set
+// is preserved.
+func evictedTransactions(ts []types.Transaction, conflictSets [][]types.Transaction) (evicted, kept []types.Transaction) {
+	spent := make(map[ObjectID]struct{})
+	for _, t := range ts {
+		for _, sci := range t.SiacoinInputs {
+			spent[ObjectID(sci.ParentID)] = struct{}{}
+		}
+		for _, sfi := range t.SiafundInputs {
+			spent[ObjectID(sfi.ParentID)] = struct{}{}
+		}
+	}
+
+	// Objects created by evicted transactions. Any transaction that uses one
+	// of them has to be evicted as well. A child may come before its parent
+	// if they are in different sets, so the sets are scanned until no more
+	// transactions are evicted.
+	evictedObjects := make(map[ObjectID]struct{})
+	evictedIDs := make(map[types.TransactionID]struct{})
+	for changed := true; changed; {
+		changed = false
+		for _, set := range conflictSets {
+			for _, t := range set {
+				id := t.ID()
+				if _, exists := evictedIDs[id]; exists {
+					continue
+				}
+				var parents []ObjectID
+				for _, sci := range t.SiacoinInputs {
+					parents = append(parents, ObjectID(sci.ParentID))
+				}
+				for _, sfi := range t.SiafundInputs {
+					parents = append(parents, ObjectID(sfi.ParentID))
+				}
+				for _, fcr := range t.FileContractRevisions {
+					parents = append(parents, ObjectID(fcr.ParentID))
+				}
+				for _, sp := range t.StorageProofs {
+					parents = append(parents, ObjectID(sp.ParentID))
+				}
+
+				evict := false
+				for _, parent := range parents {
+					_, doubleSpent := spent[parent]
+					_, dependent := evictedObjects[parent]
+					if doubleSpent || dependent {
+						evict = true
+						break
+					}
+				}
+				if !evict {
+					continue
+				}
+				evictedIDs[id] = struct{}{}
+				changed = true
+				for i := range t.SiacoinOutputs {
+					evictedObjects[ObjectID(t.SiacoinOutputID(uint64(i)))] = struct{}{}
+				}
+				for i := range t.FileContracts {
+					evictedObjects[ObjectID(t.FileContractID(uint64(i)))] = struct{}{}
+				}
+				for i := range t.SiafundOutputs {
+					evictedObjects[ObjectID(t.SiafundOutputID(uint64(i)))] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for _, set := range conflictSets {
+		for _, t := range set {
+			if _, exists := evictedIDs[t.ID()]; exists {
+				evicted = append(evicted, t)
+			} else {
+				kept = append(kept, t)
+			}
+		}
+	}
+	return evicted, kept
+}
+
+// sortedSetIDs returns the IDs of the set in ascending order, so that the
+// sets of the pool are always merged in the same order.
+func sortedSetIDs(setIDs map[TransactionSetID]struct{}) []TransactionSetID {
+	ids := make([]TransactionSetID, 0, len(setIDs))
+	for id := range setIDs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
+
+// checkReplacement checks that a transaction set is allowed to replace the
+// transactions that it double spends. The replacement must evict at most
+// maxEvictedTransactions transactions, it must pay at least the fees of the
+// evicted transactions plus minReplacementFeeIncrease for each of its own
+// bytes, and its fee per byte must be higher than that of the evicted
+// transactions.
+func checkReplacement(replacement, evicted []types.Transaction) error {
+	if len(evicted) > maxEvictedTransactions {
+		return errTooManyEvictions
+	}
+	newFees := transactionSetFees(replacement)
+	newSize := uint64(len(encoding.Marshal(replacement)))
+	oldFees := transactionSetFees(evicted)
+	oldSize := uint64(len(encoding.Marshal(evicted)))
+	if newFees.Cmp(oldFees.Add(minReplacementFeeIncrease.Mul64(newSize))) < 0 {
+		return errLowReplacementFees
+	}
+	// Compare newFees/newSize with oldFees/oldSize without dividing.
+	if newFees.Mul64(oldSize).Cmp(oldFees.Mul64(newSize)) <= 0 {
+		return errLowReplacementFees
+	}
+	return nil
+}
+
 // requiredFeesToExtendTpool returns the amount of fees required to extend the
 // transaction pool to fit another transaction set. The amount returned has the
 // unit 'currency per byte'.
@@ -100,8 +236,25 @@ func (tp *TransactionPool) checkTransactionSetComposition(ts []types.Transaction
 	return setSize, nil
 }
 
+// removeSet removes a transaction set from the pool, along with the known
+// objects that point to it.
+func (tp *TransactionPool) removeSet(setID TransactionSetID) {
+	set := tp.transactionSets[setID]
+	for _, oid := range relatedObjectIDs(set) {
+		if tp.knownObjects[oid] == setID {
+			delete(tp.knownObjects, oid)
+		}
+	}
+	tp.transactionListSize -= len(encoding.Marshal(set))
+	delete(tp.transactionSets, setID)
+	delete(tp.transactionSetDiffs, setID)
+}
+
 // handleConflicts detects whether the conflicts in the transaction pool are
-// legal children of the new transaction pool set or not.
+// legal children of the new transaction pool set or not. If the new set
+// double spends transactions in the pool, it replaces them as long as it
+// passes checkReplacement. Transactions of the conflicting sets that are not
+// double spent are kept and merged with the new set.
 func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
 	// Create a list of all the transaction ids that compose the set of
 	// conflicts.
@@ -151,13 +304,38 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	// yes, add the new set to the pool, and eliminate the old set. The output
 	// diff objects can be repeated, (no need to remove those). Just need to
 	// remove the conflicts from tp.transactionSets.
-	var superset []types.Transaction
 	supersetMap := make(map[TransactionSetID]struct{})
 	for _, conflict := range conflictMap {
 		supersetMap[conflict] = struct{}{}
 	}
-	for conflict := range supersetMap {
-		superset = append(superset, tp.transactionSets[conflict]...)
+	// Transactions that are double spent by the input set are left out of the
+	// superset, if the input set is allowed to replace them. Sets of the pool
+	// that depend on an evicted transaction are pulled into the superset, so
+	// that their transactions are evicted as well.
+	var evicted, superset []types.Transaction
+	for {
+		var conflictSets [][]types.Transaction
+		for _, conflict := range sortedSetIDs(supersetMap) {
+			conflictSets = append(conflictSets, tp.transactionSets[conflict])
+		}
+		evicted, superset = evictedTransactions(dedupSet, conflictSets)
+
+		dependents := false
+		for _, oid := range relatedObjectIDs(evicted) {
+			dependent, exists := tp.knownObjects[oid]
+			if _, merged := supersetMap[dependent]; exists && !merged {
+				supersetMap[dependent] = struct{}{}
+				dependents = true
+			}
+		}
+		if !dependents {
+			break
+		}
+	}
+	if len(evicted) > 0 {
+		if err := checkReplacement(dedupSet, evicted); err != nil {
+			return err
+		}
 	}
 	superset = append(superset, dedupSet...)
 
@@ -174,12 +352,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	if err != nil {
 		return err
 	}
-	var setFees types.Currency
-	for _, txn := range superset {
-		for _, fee := range txn.MinerFees {
-			setFees = setFees.Add(fee)
-		}
-	}
+	setFees := transactionSetFees(superset)
 	if requiredFees.Cmp(setFees) > 0 {
 		// TODO: check if there is an existing set with lower fees that we can
 		// kick out.
@@ -192,12 +365,13 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 		return modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
 	}
 
-	// Remove the conflicts from the transaction pool.
+	// Remove the conflicts from the transaction pool. This also removes any
+	// evicted transactions.
+	if len(evicted) > 0 {
+		tp.log.Debugf("evicted %v transactions in favor of a replacement paying %v", len(evicted), transactionSetFees(dedupSet).HumanString())
+	}
 	for conflict := range supersetMap {
-		conflictSet := tp.transactionSets[conflict]
-		tp.transactionListSize -= len(encoding.Marshal(conflictSet))
-		delete(tp.transactionSets, conflict)
-		delete(tp.transactionSetDiffs, conflict)
+		tp.removeSet(conflict)
 	}
 
 	// Add the transaction set to the pool.
@@ -260,12 +434,7 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 	if err != nil {
 		return err
 	}
-	var setFees types.Currency
-	for _, txn := range ts {
-		for _, fee := range txn.MinerFees {
-			setFees = setFees.Add(fee)
-		}
-	}
+	setFees := transactionSetFees(ts)
 	if requiredFees.Cmp(setFees) > 0 {
 		// TODO: check if there is an existing set with lower fees that we can
 		// kick out.
@@ -776,3 +776,117 @@ func TestPartialConfirmationWeave(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestReplaceByFee checks that a transaction set can replace the transactions
+// it double spends, along with their children, only if it pays enough fees.
+func TestReplaceByFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create an output that TransactionGraph can spend.
+	txns, err := tpt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), types.UnlockConditions{}.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceID := txns[len(txns)-1].SiacoinOutputID(0)
+
+	// graphTxns returns the transactions of a graph rooted at the source
+	// output.
+	graphTxns := func(edges ...types.TransactionGraphEdge) []types.Transaction {
+		gt, err := types.TransactionGraph(sourceID, edges)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return gt
+	}
+	sc := types.SiacoinPrecision
+
+	// Add a parent and a child, paying 20 SC in fees together.
+	original := graphTxns(
+		types.TransactionGraphEdge{Dest: 1, Fee: sc.Mul64(10), Source: 0, Value: sc.Mul64(90)},
+		types.TransactionGraphEdge{Dest: 2, Fee: sc.Mul64(10), Source: 1, Value: sc.Mul64(80)},
+	)
+	if err := tpt.tpool.AcceptTransactionSet(original); err != nil {
+		t.Fatal(err)
+	}
+
+	// A replacement paying less than the evicted parent and child is
+	// rejected, and so is one paying only slightly more.
+	low := graphTxns(types.TransactionGraphEdge{Dest: 1, Fee: sc.Mul64(15), Source: 0, Value: sc.Mul64(85)})
+	if err := tpt.tpool.AcceptTransactionSet(low); err != errLowReplacementFees {
+		t.Fatal("expected errLowReplacementFees, got", err)
+	}
+	tiny := graphTxns(types.TransactionGraphEdge{Dest: 1, Fee: sc.Mul64(20).Add(types.NewCurrency64(1)), Source: 0, Value: sc.Mul64(80).Sub(types.NewCurrency64(1))})
+	if err := tpt.tpool.AcceptTransactionSet(tiny); err != errLowReplacementFees {
+		t.Fatal("expected errLowReplacementFees, got", err)
+	}
+
+	// A replacement paying more evicts both the parent and the child.
+	high := graphTxns(types.TransactionGraphEdge{Dest: 1, Fee: sc.Mul64(30), Source: 0, Value: sc.Mul64(70)})
+	if err := tpt.tpool.AcceptTransactionSet(high); err != nil {
+		t.Fatal(err)
+	}
+	inPool := make(map[types.TransactionID]bool)
+	for _, txn := range tpt.tpool.TransactionList() {
+		inPool[txn.ID()] = true
+	}
+	if inPool[original[0].ID()] || inPool[original[1].ID()] {
+		t.Fatal("replaced transactions are still in the pool")
+	}
+	if !inPool[high[0].ID()] || !inPool[txns[len(txns)-1].ID()] {
+		t.Fatal("replacement or its parent is missing from the pool")
+	}
+	// The objects of the evicted transactions are no longer known to the
+	// pool.
+	for _, oid := range []types.SiacoinOutputID{original[0].SiacoinOutputID(0), original[1].SiacoinOutputID(0)} {
+		if _, exists := tpt.tpool.knownObjects[ObjectID(oid)]; exists {
+			t.Fatal("pool still knows an output of an evicted transaction")
+		}
+	}
+
+	// The replacement can be mined.
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("transaction pool should be empty after mining")
+	}
+}
+
+// TestEvictedTransactions checks that the descendants of double spent
+// transactions are evicted, even if they come before their parents.
+func TestEvictedTransactions(t *testing.T) {
+	parent := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+	}
+	child := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(0)}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+	}
+	grandchild := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: child.SiacoinOutputID(0)}},
+	}
+	unrelated := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{2}}},
+	}
+	replacement := []types.Transaction{{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+		MinerFees:     []types.Currency{types.NewCurrency64(1)},
+	}}
+
+	conflictSets := [][]types.Transaction{{grandchild}, {unrelated, child}, {parent}}
+	evicted, kept := evictedTransactions(replacement, conflictSets)
+	if len(evicted) != 3 || evicted[0].ID() != grandchild.ID() || evicted[1].ID() != child.ID() || evicted[2].ID() != parent.ID() {
+		t.Fatal("wrong transactions were evicted:", evicted)
+	}
+	if len(kept) != 1 || kept[0].ID() != unrelated.ID() {
+		t.Fatal("wrong transactions were kept:", kept)
+	}
+}
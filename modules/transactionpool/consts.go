@@ -39,6 +39,13 @@ const (
 	TransactionPoolSizeTarget = 3e6
 )
 
+// Constants related to replacing transactions in the transaction pool.
+const (
+	// maxEvictedTransactions is the largest number of transactions that a
+	// replacement transaction set is allowed to evict from the pool.
+	maxEvictedTransactions = 100
+)
+
 // Constants related to fee estimation.
 const (
 	// blockFeeEstimationDepth defines how far backwards in the blockchain the
@@ -69,6 +76,12 @@ var (
 	// minEstimation defines a sane minimum fee per byte for transactions.  This
 	// will typically be only suggested as a fee in the absence of congestion.
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
+
+	// minReplacementFeeIncrease is the fee per byte of the replacement that a
+	// replacement transaction set needs to pay on top of the fees of the
+	// transactions it evicts. This prevents peers from flooding the network
+	// with replacements that each add a negligible fee.
+	minReplacementFeeIncrease = minEstimation
 )
 
 // Variables related to propagating transactions through the network.
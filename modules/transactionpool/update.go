@@ -189,6 +189,13 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		tp.log.Critical("ERROR: Could not access recentID from tpool:", err)
 	}
 
+	// A consensus set that was bootstrapped from a snapshot starts at the
+	// snapshot height, and the parent of the first block is not known.
+	if cc.SnapshotHeight != 0 {
+		tp.blockHeight = cc.SnapshotHeight - 1
+		resetSanityCheck = true
+	}
+
 	// Update the database of confirmed transactions.
 	for _, block := range cc.RevertedBlocks {
 		// Sanity check - the id of each reverted block should match the recent
@@ -23,6 +23,54 @@ const (
 	WalletDir = "wallet"
 )
 
+// The roles of an ExportedTransaction.
+const (
+	// TransactionRoleTransfer is the role of transactions that only move
+	// siacoins or siafunds between addresses.
+	TransactionRoleTransfer = "transfer"
+
+	// TransactionRoleMinerPayout is the role of the miner payouts of a block.
+	TransactionRoleMinerPayout = "minerpayout"
+
+	// TransactionRoleFileContract is the role of transactions that form or
+	// revise file contracts.
+	TransactionRoleFileContract = "filecontract"
+
+	// TransactionRoleStorageProof is the role of transactions that submit
+	// storage proofs for file contracts.
+	TransactionRoleStorageProof = "storageproof"
+
+	// TransactionRoleSiafundClaim is the role of transactions that spend
+	// siafunds, which pays out the siafund claim of the spent outputs.
+	TransactionRoleSiafundClaim = "siafundclaim"
+)
+
+const (
+	// MaxAddressLabelLength is the maximum length of an address label or of a
+	// transaction tag, in bytes.
+	MaxAddressLabelLength = 256
+
+	// MaxTransactionNoteLength is the maximum length of a transaction note,
+	// in bytes.
+	MaxTransactionNoteLength = 4096
+)
+
+// The catch-up policies of a ScheduledPayment, which decide what happens to
+// runs that were missed because the daemon was offline or the wallet was
+// locked.
+const (
+	// CatchUpAll pays every missed run.
+	CatchUpAll = "all"
+
+	// CatchUpLatest pays only the most recent run and skips the runs before
+	// it.
+	CatchUpLatest = "latest"
+
+	// CatchUpSkip skips every missed run. The most recent run is still paid
+	// if it is not more than a short grace period late.
+	CatchUpSkip = "skip"
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -34,6 +82,10 @@ var (
 	// being 'unconfirmed' yet.
 	ErrIncompleteTransactions = errors.New("wallet has coins spent in incomplete transactions - not enough remaining coins")
 
+	// ErrLabelTooLong is returned if an address label or a transaction tag
+	// exceeds MaxAddressLabelLength.
+	ErrLabelTooLong = errors.New("label is too long")
+
 	// ErrLockedWallet is returned when an action cannot be performed due to
 	// the wallet being locked.
 	ErrLockedWallet = errors.New("wallet must be unlocked before it can be used")
@@ -42,11 +94,37 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrNoteTooLong is returned if a transaction note exceeds
+	// MaxTransactionNoteLength.
+	ErrNoteTooLong = errors.New("note is too long")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
 )
 
+// ValidateAddressLabel returns an error if the label cannot be assigned to an
+// address.
+func ValidateAddressLabel(label string) error {
+	if len(label) > MaxAddressLabelLength {
+		return ErrLabelTooLong
+	}
+	return nil
+}
+
+// Validate returns an error if the note cannot be attached to a transaction.
+func (tn TransactionNote) Validate() error {
+	if len(tn.Note) > MaxTransactionNoteLength {
+		return ErrNoteTooLong
+	}
+	for _, tag := range tn.Tags {
+		if err := ValidateAddressLabel(tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type (
 	// Seed is cryptographic entropy that is used to derive spendable wallet
 	// addresses.
@@ -55,6 +133,15 @@ type (
 	// WalletTransactionID is a unique identifier for a wallet transaction.
 	WalletTransactionID crypto.Hash
 
+	// A WatchOnlyAddress is an address that the wallet tracks without holding
+	// the keys to spend from it. The unlock conditions of the address are
+	// optional; they are needed to build transactions that spend from the
+	// address elsewhere.
+	WatchOnlyAddress struct {
+		Address          types.UnlockHash        `json:"address"`
+		UnlockConditions *types.UnlockConditions `json:"unlockconditions,omitempty"`
+	}
+
 	// A ProcessedInput represents funding to a transaction. The input is
 	// coming from an address and going to the outputs. The fund types are
 	// 'SiacoinInput', 'SiafundInput'.
@@ -87,6 +174,101 @@ type (
 		Value          types.Currency    `json:"value"`
 	}
 
+	// A ScheduledPayment is a payment of Amount to Destination that the
+	// wallet makes on a schedule. If StartTime is zero, the first run is at
+	// StartHeight and Interval is measured in blocks. Otherwise, the first run
+	// is at the first block whose timestamp is at least StartTime, and
+	// Interval is measured in seconds. A zero Interval means that the payment
+	// runs once. MaxCount is the number of runs after which the payment ends,
+	// with zero meaning no limit. Runs is the number of runs that have been
+	// paid or skipped so far, including runs whose transaction is being
+	// broadcast, and LastError is the error of the last failed attempt to pay.
+	ScheduledPayment struct {
+		ID          uint64            `json:"id"`
+		Destination types.UnlockHash  `json:"destination"`
+		Amount      types.Currency    `json:"amount"`
+		StartHeight types.BlockHeight `json:"startheight"`
+		StartTime   types.Timestamp   `json:"starttime"`
+		Interval    uint64            `json:"interval"`
+		MaxCount    uint64            `json:"maxcount"`
+		CatchUp     string            `json:"catchup"`
+
+		Runs      uint64 `json:"runs"`
+		LastError string `json:"lasterror"`
+	}
+
+	// A ScheduledPaymentExecution records a run of a ScheduledPayment that
+	// was either paid by the transaction TransactionID or skipped according
+	// to the payment's catch-up policy.
+	ScheduledPaymentExecution struct {
+		PaymentID     uint64              `json:"paymentid"`
+		Run           uint64              `json:"run"`
+		Height        types.BlockHeight   `json:"height"`
+		Timestamp     types.Timestamp     `json:"timestamp"`
+		Destination   types.UnlockHash    `json:"destination"`
+		Amount        types.Currency      `json:"amount"`
+		TransactionID types.TransactionID `json:"transactionid"`
+		Skipped       bool                `json:"skipped"`
+	}
+
+	// An UnspentOutput is a confirmed siacoin or siafund output that the
+	// wallet is able to spend. FundType is either 'SiacoinOutput' or
+	// 'SiafundOutput'. Locked outputs are not used to fund transactions
+	// unless they are explicitly selected as inputs. Outputs with a non-zero
+	// UnlockHeight belong to a time-locked address and cannot be spent before
+	// that height.
+	UnspentOutput struct {
+		ID                 types.OutputID    `json:"id"`
+		FundType           types.Specifier   `json:"fundtype"`
+		UnlockHash         types.UnlockHash  `json:"unlockhash"`
+		Value              types.Currency    `json:"value"`
+		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
+		Locked             bool              `json:"locked"`
+		UnlockHeight       types.BlockHeight `json:"unlockheight"`
+	}
+
+	// A TransactionNote is free-text metadata that the user has attached to
+	// a wallet transaction. Tags can be used to search the transaction
+	// history.
+	TransactionNote struct {
+		Note string   `json:"note"`
+		Tags []string `json:"tags"`
+	}
+
+	// An ExportedTransaction is a confirmed wallet transaction split into
+	// accounting categories. All amounts are from the perspective of the
+	// wallet: Incoming is received by wallet addresses from elsewhere,
+	// Outgoing is sent to addresses that the wallet does not own, and Fee is
+	// the miner fee paid by the wallet. Siacoins that the wallet puts into a
+	// file contract are counted as HostCollateral if the wallet owns the
+	// host's payout address, and as ContractFunding otherwise. Siacoins that
+	// the wallet received outside of transactions, such as the payouts of
+	// file contracts, are counted as ContractPayout by the transaction that
+	// spends them. Balance and SiafundBalance are the running balances after
+	// the transaction, computed from the start of the wallet's history.
+	ExportedTransaction struct {
+		TransactionID         types.TransactionID `json:"transactionid"`
+		ConfirmationHeight    types.BlockHeight   `json:"confirmationheight"`
+		ConfirmationTimestamp types.Timestamp     `json:"confirmationtimestamp"`
+		Role                  string              `json:"role"`
+
+		Incoming        types.Currency `json:"incoming"`
+		Outgoing        types.Currency `json:"outgoing"`
+		Fee             types.Currency `json:"fee"`
+		HostCollateral  types.Currency `json:"hostcollateral"`
+		ContractFunding types.Currency `json:"contractfunding"`
+		ContractPayout  types.Currency `json:"contractpayout"`
+		SiafundClaim    types.Currency `json:"siafundclaim"`
+		Balance         types.Currency `json:"balance"`
+
+		SiafundsIncoming types.Currency `json:"siafundsincoming"`
+		SiafundsOutgoing types.Currency `json:"siafundsoutgoing"`
+		SiafundBalance   types.Currency `json:"siafundbalance"`
+
+		Note string   `json:"note"`
+		Tags []string `json:"tags"`
+	}
+
 	// A ProcessedTransaction is a transaction that has been processed into
 	// explicit inputs and outputs and tagged with some header data such as
 	// confirmation height + timestamp.
@@ -104,6 +286,28 @@ type (
 		Outputs []ProcessedOutput `json:"outputs"`
 	}
 
+	// An UnsignedTransaction is a transaction that spends from watch-only
+	// addresses and needs to be signed elsewhere, for example by a seed that
+	// is kept offline or by the cosigners of a multisig address. Inputs lists
+	// the inputs that still need signatures; once every input has the
+	// signatures required by its unlock conditions, Inputs is empty and the
+	// transaction can be broadcast together with its parents.
+	UnsignedTransaction struct {
+		Transaction types.Transaction          `json:"transaction"`
+		Parents     []types.Transaction        `json:"parents"`
+		Inputs      []UnsignedTransactionInput `json:"inputs"`
+	}
+
+	// An UnsignedTransactionInput describes an input of an
+	// UnsignedTransaction that needs to be signed, and the fields of the
+	// transaction that the signatures should cover.
+	UnsignedTransactionInput struct {
+		ParentID         crypto.Hash            `json:"parentid"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		CoveredFields    types.CoveredFields    `json:"coveredfields"`
+		Value            types.Currency         `json:"value"`
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -282,6 +486,13 @@ type (
 		// byte-order.
 		AllAddresses() ([]types.UnlockHash, error)
 
+		// AddWatchAddresses adds a set of watch-only addresses to the wallet.
+		// Outputs sent to the addresses are tracked and their transactions
+		// are included in the wallet history, but the wallet is unable to
+		// spend them. Unless unused is set, the blockchain is rescanned to
+		// find existing outputs of the addresses.
+		AddWatchAddresses(addrs []WatchOnlyAddress, unused bool) error
+
 		// AllSeeds returns all of the seeds that are being tracked by the
 		// wallet, including the primary seed. Only the primary seed is used to
 		// generate new addresses, but the wallet can spend funds sent to
@@ -325,12 +536,21 @@ type (
 		// generated from the seed.
 		PrimarySeed() (Seed, uint64, error)
 
+		// RemoveWatchAddresses stops tracking a set of watch-only addresses.
+		// The outputs of the addresses are forgotten, but transactions that
+		// were already recorded remain in the wallet history.
+		RemoveWatchAddresses(addrs []types.UnlockHash) error
+
 		// SweepSeed scans the blockchain for outputs generated from seed and
 		// creates a transaction that transfers them to the wallet. Note that
 		// this incurs a transaction fee. It returns the total value of the
 		// outputs, minus the fee. If only siafunds were found, the fee is
 		// deducted from the wallet.
 		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
+
+		// WatchAddresses returns the watch-only addresses of the wallet,
+		// sorted in byte-order.
+		WatchAddresses() ([]WatchOnlyAddress, error)
 	}
 
 	// Wallet stores and manages siacoins and siafunds. The wallet file is
@@ -348,6 +568,16 @@ type (
 		// refund transactions.
 		ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siacoinClaimBalance types.Currency, err error)
 
+		// WatchOnlyBalance returns the confirmed balance of the watch-only
+		// addresses of the wallet. The balance is not included in
+		// ConfirmedBalance, because it cannot be spent by the wallet.
+		WatchOnlyBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, err error)
+
+		// TimelockedBalance returns the confirmed siacoins of the wallet that
+		// are held by time-locked addresses whose unlock height has not been
+		// reached yet. The balance is not included in ConfirmedBalance.
+		TimelockedBalance() (types.Currency, error)
+
 		// UnconfirmedBalance returns the unconfirmed balance of the wallet.
 		// Outgoing funds and incoming funds are reported separately. Refund
 		// outputs are included, meaning that sending a single coin to
@@ -376,6 +606,11 @@ type (
 		// included.
 		Transactions(startHeight types.BlockHeight, endHeight types.BlockHeight) ([]ProcessedTransaction, error)
 
+		// ExportTransactions returns the confirmed transactions at heights
+		// [startHeight, endHeight], classified into accounting categories.
+		// The wallet must be unlocked.
+		ExportTransactions(startHeight types.BlockHeight, endHeight types.BlockHeight) ([]ExportedTransaction, error)
+
 		// UnconfirmedTransactions returns all unconfirmed transactions
 		// relative to the wallet.
 		UnconfirmedTransactions() ([]ProcessedTransaction, error)
@@ -407,6 +642,17 @@ type (
 		// SendSiacoinsMulti sends coins to multiple addresses.
 		SendSiacoinsMulti(outputs []types.SiacoinOutput) ([]types.Transaction, error)
 
+		// SendSiacoinsFromInputs sends coins to multiple addresses, funded by
+		// exactly the provided outputs of the wallet. Change is returned to
+		// the wallet.
+		SendSiacoinsFromInputs(outputs []types.SiacoinOutput, inputs []types.SiacoinOutputID) ([]types.Transaction, error)
+
+		// BumpTransactionFee increases the fee of an unconfirmed transaction,
+		// either with a child transaction spending its change or, if replace
+		// is true, by replacing it and its unconfirmed parents. A zero fee
+		// uses the recommended fee.
+		BumpTransactionFee(txid types.TransactionID, fee types.Currency, replace bool) ([]types.Transaction, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -416,6 +662,76 @@ type (
 		// DustThreshold returns the quantity per byte below which a Currency is
 		// considered to be Dust.
 		DustThreshold() (types.Currency, error)
+
+		// CreateUnsignedTransaction creates a transaction that sends the
+		// outputs using the confirmed outputs of watch-only addresses whose
+		// unlock conditions are known. The transaction is not signed or
+		// broadcast; the change is returned to the first input's address.
+		CreateUnsignedTransaction(outputs []types.SiacoinOutput) (UnsignedTransaction, error)
+
+		// UnspentOutputs returns the confirmed siacoin and siafund outputs
+		// that the wallet is able to spend.
+		UnspentOutputs() ([]UnspentOutput, error)
+
+		// LockOutputs prevents the wallet from using the provided outputs to
+		// fund transactions, unless they are explicitly selected as inputs.
+		// Locks persist until UnlockOutputs is called.
+		LockOutputs(ids []types.OutputID) error
+
+		// UnlockOutputs removes the locks placed on outputs by LockOutputs.
+		UnlockOutputs(ids []types.OutputID) error
+
+		// AddMultisigAddress creates the unlock conditions of an M-of-N
+		// multisig address from the public keys of its cosigners and adds the
+		// address as a watch-only address. Unless unused is set, the
+		// blockchain is rescanned to find existing outputs of the address.
+		AddMultisigAddress(publicKeys []types.SiaPublicKey, signaturesRequired uint64, unused bool) (types.UnlockConditions, error)
+
+		// TimelockedAddress returns the unlock conditions of an address that
+		// can be spent with the key of the wallet address addr once
+		// unlockHeight has been reached, and starts tracking its outputs.
+		// Outputs sent to the address are spent like any other output of the
+		// wallet after the unlock height.
+		TimelockedAddress(addr types.UnlockHash, unlockHeight types.BlockHeight) (types.UnlockConditions, error)
+
+		// AddTransactionSignatures adds the wallet's signatures to the inputs
+		// of an unsigned transaction, without exceeding the number of
+		// signatures required by each input. Inputs that have all of their
+		// required signatures are removed from the transaction's Inputs.
+		AddTransactionSignatures(utxn *UnsignedTransaction) error
+
+		// SetAddressLabel assigns a label to an address. An empty label
+		// removes the address's label.
+		SetAddressLabel(addr types.UnlockHash, label string) error
+
+		// AddressLabels returns the labels that have been assigned to
+		// addresses.
+		AddressLabels() (map[types.UnlockHash]string, error)
+
+		// SetTransactionNote attaches a note and tags to a transaction. An
+		// empty note without tags removes the transaction's note.
+		SetTransactionNote(txid types.TransactionID, note TransactionNote) error
+
+		// TransactionNotes returns the notes that have been attached to
+		// transactions.
+		TransactionNotes() (map[types.TransactionID]TransactionNote, error)
+
+		// SchedulePayment adds a scheduled payment to the wallet, returning it
+		// with its ID filled in. Payments are only made while the wallet is
+		// unlocked.
+		SchedulePayment(sp ScheduledPayment) (ScheduledPayment, error)
+
+		// CancelScheduledPayment removes a scheduled payment. Runs that have
+		// already been paid are not affected.
+		CancelScheduledPayment(id uint64) error
+
+		// ScheduledPayments returns the scheduled payments that have not
+		// ended yet.
+		ScheduledPayments() ([]ScheduledPayment, error)
+
+		// ScheduledPaymentHistory returns the runs of scheduled payments that
+		// have been paid or skipped, in the order in which they happened.
+		ScheduledPaymentHistory() ([]ScheduledPaymentExecution, error)
 	}
 
 	// WalletSettings control the behavior of the Wallet.
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/errors"
+)
+
+var (
+	// errBumpNoChange is returned if a transaction has no change output that
+	// is large enough to pay the additional fee.
+	errBumpNoChange = errors.New("transaction has no change output large enough to pay the fee")
+
+	// errBumpNotReplaceable is returned when trying to replace a transaction
+	// that is not a siacoin transfer funded entirely by the wallet.
+	errBumpNotReplaceable = errors.New("only siacoin transfers funded entirely by the wallet can be replaced")
+
+	// errBumpNotUnconfirmed is returned when trying to bump the fee of a
+	// transaction that is not in the transaction pool.
+	errBumpNotUnconfirmed = errors.New("transaction is not in the transaction pool")
+)
+
+// unspentChainOutputs returns the siacoin outputs created by a chain of
+// transactions that are not spent within the chain, along with their ids.
+func unspentChainOutputs(chain []types.Transaction) (ids []types.SiacoinOutputID, outputs []types.SiacoinOutput) {
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range chain {
+		for _, sci := range txn.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	for _, txn := range chain {
+		for i, sco := range txn.SiacoinOutputs {
+			id := txn.SiacoinOutputID(uint64(i))
+			if _, exists := spent[id]; exists {
+				continue
+			}
+			ids = append(ids, id)
+			outputs = append(outputs, sco)
+		}
+	}
+	return ids, outputs
+}
+
+// changeIndex returns the index of the largest output that belongs to the
+// wallet, or -1 if none of the outputs belong to the wallet. Outputs of
+// time-locked addresses are never considered change.
+func (w *Wallet) changeIndex(outputs []types.SiacoinOutput) int {
+	index := -1
+	for i, sco := range outputs {
+		key, exists := w.keys[sco.UnlockHash]
+		if !exists || key.UnlockConditions.Timelock != 0 {
+			continue
+		}
+		if index == -1 || sco.Value.Cmp(outputs[index].Value) > 0 {
+			index = i
+		}
+	}
+	return index
+}
+
+// managedChildPaysForParent creates a child transaction that spends the change
+// of the chain and pays fee. The chain followed by the child is returned.
+func (w *Wallet) managedChildPaysForParent(chain []types.Transaction, fee types.Currency) ([]types.Transaction, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ids, outputs := unspentChainOutputs(chain)
+	i := w.changeIndex(outputs)
+	if i == -1 || outputs[i].Value.Cmp(fee) <= 0 {
+		return nil, errBumpNoChange
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	refundUnlockConditions, err := w.nextPrimarySeedAddress(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := w.keys[outputs[i].UnlockHash]
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         ids[i],
+			UnlockConditions: key.UnlockConditions,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      outputs[i].Value.Sub(fee),
+			UnlockHash: refundUnlockConditions.UnlockHash(),
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	addSignatures(&child, types.FullCoveredFields, key.UnlockConditions, crypto.Hash(ids[i]), key)
+	if err := dbPutSpentOutput(w.dbTx, types.OutputID(ids[i]), consensusHeight); err != nil {
+		return nil, err
+	}
+	return append(chain, child), nil
+}
+
+// managedReplaceTransaction creates a single transaction that replaces the
+// chain. The replacement spends the same inputs and creates the same outputs
+// as the chain, except that fee is taken out of the change and added to the
+// miner fees.
+func (w *Wallet) managedReplaceTransaction(chain []types.Transaction, fee types.Currency) ([]types.Transaction, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	created := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range chain {
+		if len(txn.FileContracts) != 0 || len(txn.FileContractRevisions) != 0 || len(txn.StorageProofs) != 0 ||
+			len(txn.SiafundInputs) != 0 || len(txn.SiafundOutputs) != 0 || len(txn.ArbitraryData) != 0 {
+			return nil, errBumpNotReplaceable
+		}
+		for i := range txn.SiacoinOutputs {
+			created[txn.SiacoinOutputID(uint64(i))] = struct{}{}
+		}
+	}
+
+	// Spend every input that comes from outside the chain. All of them have to
+	// belong to the wallet, since the replacement needs to be signed again.
+	var replacement types.Transaction
+	var fees types.Currency
+	for _, txn := range chain {
+		for _, sci := range txn.SiacoinInputs {
+			if _, exists := created[sci.ParentID]; exists {
+				continue
+			}
+			if _, exists := w.keys[sci.UnlockConditions.UnlockHash()]; !exists {
+				return nil, errBumpNotReplaceable
+			}
+			replacement.SiacoinInputs = append(replacement.SiacoinInputs, sci)
+		}
+		for _, minerFee := range txn.MinerFees {
+			fees = fees.Add(minerFee)
+		}
+	}
+	_, replacement.SiacoinOutputs = unspentChainOutputs(chain)
+	i := w.changeIndex(replacement.SiacoinOutputs)
+	if i == -1 || replacement.SiacoinOutputs[i].Value.Cmp(fee) <= 0 {
+		return nil, errBumpNoChange
+	}
+	replacement.SiacoinOutputs[i].Value = replacement.SiacoinOutputs[i].Value.Sub(fee)
+	replacement.MinerFees = []types.Currency{fees.Add(fee)}
+
+	for _, sci := range replacement.SiacoinInputs {
+		addSignatures(&replacement, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()])
+	}
+	return []types.Transaction{replacement}, nil
+}
+
+// BumpTransactionFee increases the fee paid for an unconfirmed transaction. If
+// replace is false, a child transaction that spends the change of the
+// transaction pays the additional fee. Otherwise, the transaction and its
+// unconfirmed parents are replaced by a single transaction that pays the
+// additional fee out of the change. If fee is zero, the recommended fee for
+// the size of the transaction and its parents is used. The transaction set
+// submitted to the transaction pool is returned.
+func (w *Wallet) BumpTransactionFee(txid types.TransactionID, fee types.Currency, replace bool) (txns []types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+
+	txn, parents, exists := w.tpool.Transaction(txid)
+	if !exists {
+		return nil, errBumpNotUnconfirmed
+	}
+	chain := append(append([]types.Transaction(nil), parents...), txn)
+	if fee.IsZero() {
+		_, maxFee := w.tpool.FeeEstimation()
+		size := uint64(len(encoding.Marshal(chain)))
+		if !replace {
+			size += bumpChildTxnSize
+		}
+		fee = maxFee.Mul64(size)
+	}
+
+	var txnSet []types.Transaction
+	if replace {
+		txnSet, err = w.managedReplaceTransaction(chain, fee)
+	} else {
+		txnSet, err = w.managedChildPaysForParent(chain, fee)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		w.log.Println("Attempt to bump transaction fee has failed - transaction pool rejected transaction:", err)
+		err = build.ExtendErr("unable to get transaction accepted", err)
+		if !replace {
+			// Return the change to the pool of spendable outputs.
+			w.mu.Lock()
+			err = errors.Compose(err, dbDeleteSpentOutput(w.dbTx, types.OutputID(txnSet[len(txnSet)-1].SiacoinInputs[0].ParentID)))
+			w.mu.Unlock()
+		}
+		return nil, err
+	}
+	w.log.Printf("Bumped the fee of transaction %v by %v with transaction %v", txid, fee.HumanString(), txnSet[len(txnSet)-1].ID())
+	return txnSet, nil
+}
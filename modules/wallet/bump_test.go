@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestBumpTransactionFee probes the BumpTransactionFee method of the wallet,
+// first with a child transaction and then with a replacement.
+func TestBumpTransactionFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	sendValue := types.SiacoinPrecision.Mul64(3)
+	_, tpoolFee := wt.wallet.tpool.FeeEstimation()
+	tpoolFee = tpoolFee.Mul64(750)
+	txns, err := wt.wallet.SendSiacoins(sendValue, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+
+	// checkOutgoing checks that the wallet is spending the sent value, the
+	// original fee and the extra fee.
+	checkOutgoing := func(extra types.Currency) {
+		out, in, err := wt.wallet.UnconfirmedBalance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := sendValue.Add(tpoolFee).Add(extra); !out.Equals(in.Add(expected)) {
+			t.Fatalf("expected to spend %v, spent %v", expected, out.Sub(in))
+		}
+	}
+	inPool := func(id types.TransactionID) bool {
+		_, _, exists := wt.tpool.Transaction(id)
+		return exists
+	}
+
+	// Bump the fee with a child transaction.
+	childFee := types.SiacoinPrecision
+	cpfp, err := wt.wallet.BumpTransactionFee(txid, childFee, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := cpfp[len(cpfp)-1]
+	if !inPool(txid) || !inPool(child.ID()) {
+		t.Fatal("transaction and child should both be in the pool")
+	}
+	checkOutgoing(childFee)
+
+	// A replacement has to pay for the evicted child as well.
+	if _, err := wt.wallet.BumpTransactionFee(txid, childFee, true); err == nil {
+		t.Fatal("replacement should not pay enough to evict the child")
+	}
+	replaceFee := types.SiacoinPrecision.Mul64(2)
+	rbf, err := wt.wallet.BumpTransactionFee(txid, replaceFee, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement := rbf[len(rbf)-1]
+	if inPool(txid) || inPool(child.ID()) || !inPool(replacement.ID()) {
+		t.Fatal("transaction and child should have been replaced")
+	}
+	checkOutgoing(replaceFee)
+
+	// Bumping a transaction that is no longer in the pool fails.
+	if _, err := wt.wallet.BumpTransactionFee(txid, types.ZeroCurrency, false); err != errBumpNotUnconfirmed {
+		t.Fatal("expected errBumpNotUnconfirmed, got", err)
+	}
+
+	// The replacement can be mined.
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := wt.wallet.Transaction(replacement.ID()); err != nil || !ok {
+		t.Fatal("replacement was not confirmed:", err)
+	}
+}
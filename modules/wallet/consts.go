@@ -5,6 +5,10 @@ import (
 )
 
 const (
+	// bumpChildTxnSize is the estimated size in bytes of the child transaction
+	// that pays for its parents when bumping the fee of a transaction.
+	bumpChildTxnSize = 750
+
 	// defragBatchSize defines how many outputs are combined during one defrag.
 	defragBatchSize = 35
 
@@ -15,6 +19,20 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// scheduledPaymentGraceTime is how many seconds late the most recent run
+	// of a time-based scheduled payment with the CatchUpSkip policy can be
+	// and still get paid.
+	scheduledPaymentGraceTime = 60 * 60
+
+	// scheduledPaymentTxnsPerExecution is the largest number of transactions
+	// that are sent each time scheduled payments are executed. Runs that do
+	// not fit are paid the next time.
+	scheduledPaymentTxnsPerExecution = 10
+
+	// scheduledPaymentSkipHistory is the number of skipped runs that are kept
+	// in the scheduled payment history. Older skipped runs are pruned.
+	scheduledPaymentSkipHistory = 1000
 )
 
 var (
@@ -33,6 +51,52 @@ var (
 		Standard: uint64(1000),
 		Testing:  uint64(10),
 	}).(uint64)
+
+	// offlineKeySearchLimit is the number of keys that are generated from a
+	// seed when searching for the keys that sign an unsigned transaction.
+	offlineKeySearchLimit = build.Select(build.Var{
+		Dev:      uint64(100e3),
+		Standard: uint64(1e6),
+		Testing:  uint64(10e3),
+	}).(uint64)
+
+	// scheduledPaymentGraceBlocks is how many blocks late the most recent run
+	// of a height-based scheduled payment with the CatchUpSkip policy can be
+	// and still get paid.
+	scheduledPaymentGraceBlocks = build.Select(build.Var{
+		Dev:      uint64(6),
+		Standard: uint64(6),
+		Testing:  uint64(2),
+	}).(uint64)
+
+	// scheduledPaymentOutputsPerTxn is the largest number of runs of
+	// scheduled payments that are paid with a single transaction. Backlogs of
+	// runs are split over several transactions.
+	scheduledPaymentOutputsPerTxn = build.Select(build.Var{
+		Dev:      100,
+		Standard: 100,
+		Testing:  2,
+	}).(int)
+)
+
+var (
+	// kdfTime, kdfMemory and kdfThreads are the Argon2id parameters used to
+	// derive the wallet's master key from the password. kdfMemory is in KiB.
+	kdfTime = build.Select(build.Var{
+		Dev:      uint32(1),
+		Standard: uint32(3),
+		Testing:  uint32(1),
+	}).(uint32)
+	kdfMemory = build.Select(build.Var{
+		Dev:      uint32(16 * 1024),
+		Standard: uint32(64 * 1024),
+		Testing:  uint32(64),
+	}).(uint32)
+	kdfThreads = build.Select(build.Var{
+		Dev:      uint8(2),
+		Standard: uint8(4),
+		Testing:  uint8(1),
+	}).(uint8)
 )
 
 func init() {
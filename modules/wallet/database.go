@@ -26,6 +26,25 @@ var (
 	// bucketAddrTransactions maps an UnlockHash to the
 	// ProcessedTransactions that it appears in.
 	bucketAddrTransactions = []byte("bucketAddrTransactions")
+	// bucketAddressLabels maps an UnlockHash to the label that the user has
+	// assigned to it.
+	bucketAddressLabels = []byte("bucketAddressLabels")
+	// bucketLockedOutputs contains the OutputIDs of outputs that the user
+	// has locked. Locked outputs are not used to fund transactions unless
+	// they are explicitly selected.
+	bucketLockedOutputs = []byte("bucketLockedOutputs")
+	// bucketScheduledPayments maps the ID of a ScheduledPayment to the
+	// payment.
+	bucketScheduledPayments = []byte("bucketScheduledPayments")
+	// bucketScheduledPaymentHistory stores ScheduledPaymentExecutions in
+	// chronological order. The key of this bucket is an autoincrementing
+	// integer.
+	bucketScheduledPaymentHistory = []byte("bucketScheduledPaymentHistory")
+	// bucketPendingScheduledPayments stores the pendingScheduledPayments
+	// that have been signed but are not known to have been broadcast, in the
+	// order in which they were created. The key of this bucket is an
+	// autoincrementing integer.
+	bucketPendingScheduledPayments = []byte("bucketPendingScheduledPayments")
 	// bucketSiacoinOutputs maps a SiacoinOutputID to its SiacoinOutput. Only
 	// outputs that the wallet controls are stored. The wallet uses these
 	// outputs to fund transactions.
@@ -39,18 +58,46 @@ var (
 	// these outputs so that it can reuse them if they are not confirmed on
 	// the blockchain.
 	bucketSpentOutputs = []byte("bucketSpentOutputs")
+	// bucketTimelockedAddresses maps the UnlockHash of a time-locked address
+	// to its UnlockConditions. The address is spendable by one of the
+	// wallet's keys once its timelock has been reached.
+	bucketTimelockedAddresses = []byte("bucketTimelockedAddresses")
+	// bucketTransactionNotes maps a TransactionID to the note and tags that
+	// the user has attached to the transaction.
+	bucketTransactionNotes = []byte("bucketTransactionNotes")
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketWatchedAddresses maps an UnlockHash to the WatchOnlyAddress that
+	// the wallet tracks without being able to spend from it.
+	bucketWatchedAddresses = []byte("bucketWatchedAddresses")
+	// bucketWatchedSiacoinOutputs maps a SiacoinOutputID to its
+	// SiacoinOutput for outputs sent to watch-only addresses. The outputs are
+	// kept separate from bucketSiacoinOutputs so that they are never used to
+	// fund transactions.
+	bucketWatchedSiacoinOutputs = []byte("bucketWatchedSiacoinOutputs")
+	// bucketWatchedSiafundOutputs maps a SiafundOutputID to its
+	// SiafundOutput for outputs sent to watch-only addresses.
+	bucketWatchedSiafundOutputs = []byte("bucketWatchedSiafundOutputs")
 
 	dbBuckets = [][]byte{
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
+		bucketAddressLabels,
+		bucketLockedOutputs,
+		bucketScheduledPayments,
+		bucketScheduledPaymentHistory,
+		bucketPendingScheduledPayments,
 		bucketSiacoinOutputs,
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
+		bucketTimelockedAddresses,
+		bucketTransactionNotes,
 		bucketWallet,
+		bucketWatchedAddresses,
+		bucketWatchedSiacoinOutputs,
+		bucketWatchedSiafundOutputs,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -60,6 +107,7 @@ var (
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyKDFParams              = []byte("keyKDFParams")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
@@ -221,6 +269,55 @@ func dbForEachSiafundOutput(tx *bolt.Tx, fn func(types.SiafundOutputID, types.Si
 	return dbForEach(tx.Bucket(bucketSiafundOutputs), fn)
 }
 
+func dbPutWatchedSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.SiacoinOutput) error {
+	return dbPut(tx.Bucket(bucketWatchedSiacoinOutputs), id, output)
+}
+func dbDeleteWatchedSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
+	return dbDelete(tx.Bucket(bucketWatchedSiacoinOutputs), id)
+}
+func dbForEachWatchedSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.SiacoinOutput)) error {
+	return dbForEach(tx.Bucket(bucketWatchedSiacoinOutputs), fn)
+}
+
+func dbPutWatchedSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.SiafundOutput) error {
+	return dbPut(tx.Bucket(bucketWatchedSiafundOutputs), id, output)
+}
+func dbDeleteWatchedSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) error {
+	return dbDelete(tx.Bucket(bucketWatchedSiafundOutputs), id)
+}
+func dbForEachWatchedSiafundOutput(tx *bolt.Tx, fn func(types.SiafundOutputID, types.SiafundOutput)) error {
+	return dbForEach(tx.Bucket(bucketWatchedSiafundOutputs), fn)
+}
+
+// watchedAddress is the database representation of a WatchOnlyAddress. The
+// encoding package cannot marshal nil pointers to types that implement
+// SiaMarshaler, so the optional unlock conditions are stored with a flag.
+type watchedAddress struct {
+	HasUnlockConditions bool
+	UnlockConditions    types.UnlockConditions
+}
+
+func dbPutWatchedAddress(tx *bolt.Tx, addr modules.WatchOnlyAddress) error {
+	var wa watchedAddress
+	if addr.UnlockConditions != nil {
+		wa.HasUnlockConditions = true
+		wa.UnlockConditions = *addr.UnlockConditions
+	}
+	return dbPut(tx.Bucket(bucketWatchedAddresses), addr.Address, wa)
+}
+func dbDeleteWatchedAddress(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketWatchedAddresses), addr)
+}
+func dbForEachWatchedAddress(tx *bolt.Tx, fn func(types.UnlockHash, modules.WatchOnlyAddress)) error {
+	return dbForEach(tx.Bucket(bucketWatchedAddresses), func(addr types.UnlockHash, wa watchedAddress) {
+		woa := modules.WatchOnlyAddress{Address: addr}
+		if wa.HasUnlockConditions {
+			woa.UnlockConditions = &wa.UnlockConditions
+		}
+		fn(addr, woa)
+	})
+}
+
 func dbPutSpentOutput(tx *bolt.Tx, id types.OutputID, height types.BlockHeight) error {
 	return dbPut(tx.Bucket(bucketSpentOutputs), id, height)
 }
@@ -232,6 +329,144 @@ func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
 
+func dbPutLockedOutput(tx *bolt.Tx, id types.OutputID) error {
+	return dbPut(tx.Bucket(bucketLockedOutputs), id, true)
+}
+func dbDeleteLockedOutput(tx *bolt.Tx, id types.OutputID) error {
+	return dbDelete(tx.Bucket(bucketLockedOutputs), id)
+}
+func dbIsLockedOutput(tx *bolt.Tx, id types.OutputID) bool {
+	var locked bool
+	return dbGet(tx.Bucket(bucketLockedOutputs), id, &locked) == nil && locked
+}
+
+func dbPutTimelockedAddress(tx *bolt.Tx, uc types.UnlockConditions) error {
+	return dbPut(tx.Bucket(bucketTimelockedAddresses), uc.UnlockHash(), uc)
+}
+func dbGetTimelockedAddress(tx *bolt.Tx, addr types.UnlockHash) (uc types.UnlockConditions, err error) {
+	err = dbGet(tx.Bucket(bucketTimelockedAddresses), addr, &uc)
+	return
+}
+func dbForEachTimelockedAddress(tx *bolt.Tx, fn func(types.UnlockHash, types.UnlockConditions)) error {
+	return dbForEach(tx.Bucket(bucketTimelockedAddresses), fn)
+}
+
+func dbPutAddressLabel(tx *bolt.Tx, addr types.UnlockHash, label string) error {
+	return dbPut(tx.Bucket(bucketAddressLabels), addr, label)
+}
+func dbDeleteAddressLabel(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketAddressLabels), addr)
+}
+func dbForEachAddressLabel(tx *bolt.Tx, fn func(types.UnlockHash, string)) error {
+	return dbForEach(tx.Bucket(bucketAddressLabels), fn)
+}
+
+func dbPutTransactionNote(tx *bolt.Tx, txid types.TransactionID, note modules.TransactionNote) error {
+	return dbPut(tx.Bucket(bucketTransactionNotes), txid, note)
+}
+func dbDeleteTransactionNote(tx *bolt.Tx, txid types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketTransactionNotes), txid)
+}
+func dbForEachTransactionNote(tx *bolt.Tx, fn func(types.TransactionID, modules.TransactionNote)) error {
+	return dbForEach(tx.Bucket(bucketTransactionNotes), fn)
+}
+
+func dbPutScheduledPayment(tx *bolt.Tx, sp modules.ScheduledPayment) error {
+	return dbPut(tx.Bucket(bucketScheduledPayments), sp.ID, sp)
+}
+func dbGetScheduledPayment(tx *bolt.Tx, id uint64) (sp modules.ScheduledPayment, err error) {
+	err = dbGet(tx.Bucket(bucketScheduledPayments), id, &sp)
+	return
+}
+func dbDeleteScheduledPayment(tx *bolt.Tx, id uint64) error {
+	return dbDelete(tx.Bucket(bucketScheduledPayments), id)
+}
+func dbForEachScheduledPayment(tx *bolt.Tx, fn func(uint64, modules.ScheduledPayment)) error {
+	return dbForEach(tx.Bucket(bucketScheduledPayments), fn)
+}
+
+// dbNextScheduledPaymentID returns an unused ID for a scheduled payment.
+func dbNextScheduledPaymentID(tx *bolt.Tx) (uint64, error) {
+	return tx.Bucket(bucketScheduledPayments).NextSequence()
+}
+
+func dbAppendScheduledPaymentExecution(tx *bolt.Tx, spe modules.ScheduledPaymentExecution) error {
+	b := tx.Bucket(bucketScheduledPaymentHistory)
+	key, err := b.NextSequence()
+	if err != nil {
+		return errors.AddContext(err, "failed to get next sequence from bucket")
+	}
+	// big-endian is used so that the keys are properly sorted
+	keyBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(keyBytes, key)
+	return b.Put(keyBytes, encoding.Marshal(spe))
+}
+func dbForEachScheduledPaymentExecution(tx *bolt.Tx, fn func(modules.ScheduledPaymentExecution)) error {
+	return tx.Bucket(bucketScheduledPaymentHistory).ForEach(func(_, val []byte) error {
+		var spe modules.ScheduledPaymentExecution
+		if err := encoding.Unmarshal(val, &spe); err != nil {
+			return err
+		}
+		fn(spe)
+		return nil
+	})
+}
+
+// dbPruneSkippedScheduledPaymentExecutions deletes the oldest skipped runs
+// from the scheduled payment history, so that at most limit skipped runs are
+// kept. Paid runs are never deleted.
+func dbPruneSkippedScheduledPaymentExecutions(tx *bolt.Tx, limit int) error {
+	b := tx.Bucket(bucketScheduledPaymentHistory)
+	var skipped [][]byte
+	err := b.ForEach(func(key, val []byte) error {
+		var spe modules.ScheduledPaymentExecution
+		if err := encoding.Unmarshal(val, &spe); err != nil {
+			return err
+		}
+		if spe.Skipped {
+			skipped = append(skipped, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for len(skipped) > limit {
+		if err := b.Delete(skipped[0]); err != nil {
+			return err
+		}
+		skipped = skipped[1:]
+	}
+	return nil
+}
+
+func dbAppendPendingScheduledPayment(tx *bolt.Tx, psp pendingScheduledPayment) (uint64, error) {
+	b := tx.Bucket(bucketPendingScheduledPayments)
+	key, err := b.NextSequence()
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to get next sequence from bucket")
+	}
+	// big-endian is used so that the keys are properly sorted
+	keyBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(keyBytes, key)
+	return key, b.Put(keyBytes, encoding.Marshal(psp))
+}
+func dbDeletePendingScheduledPayment(tx *bolt.Tx, key uint64) error {
+	keyBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(keyBytes, key)
+	return tx.Bucket(bucketPendingScheduledPayments).Delete(keyBytes)
+}
+func dbForEachPendingScheduledPayment(tx *bolt.Tx, fn func(uint64, pendingScheduledPayment)) error {
+	return tx.Bucket(bucketPendingScheduledPayments).ForEach(func(key, val []byte) error {
+		var psp pendingScheduledPayment
+		if err := encoding.Unmarshal(val, &psp); err != nil {
+			return err
+		}
+		fn(binary.BigEndian.Uint64(key), psp)
+		return nil
+	})
+}
+
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
 }
@@ -444,6 +679,23 @@ func dbPutPrimarySeedProgress(tx *bolt.Tx, progress uint64) error {
 	return tx.Bucket(bucketWallet).Put(keyPrimarySeedProgress, encoding.Marshal(progress))
 }
 
+// dbGetKDFParams returns the parameters used to derive the wallet's master
+// key. Wallets that were encrypted before the KDF was introduced have no
+// parameters, which is reported as kdfVersionLegacy.
+func dbGetKDFParams(tx *bolt.Tx) (params kdfParams, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyKDFParams)
+	if b == nil {
+		return kdfParams{Version: kdfVersionLegacy}, nil
+	}
+	err = encoding.Unmarshal(b, &params)
+	return
+}
+
+// dbPutKDFParams stores the parameters used to derive the wallet's master key.
+func dbPutKDFParams(tx *bolt.Tx, params kdfParams) error {
+	return tx.Bucket(bucketWallet).Put(keyKDFParams, encoding.Marshal(params))
+}
+
 // dbGetConsensusChangeID returns the ID of the last ConsensusChange processed by the wallet.
 func dbGetConsensusChangeID(tx *bolt.Tx) (cc modules.ConsensusChangeID) {
 	copy(cc[:], tx.Bucket(bucketWallet).Get(keyConsensusChange))
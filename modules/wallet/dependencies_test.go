@@ -11,6 +11,14 @@ type (
 		f bool // indicates if the next call should fail
 	}
 
+	// dependencyScheduledPaymentsInterrupted is a dependency used to stop
+	// the execution of scheduled payments after the signed transactions have
+	// been persisted, but before they are broadcast
+	dependencyScheduledPaymentsInterrupted struct {
+		modules.ProductionDependencies
+		f bool // indicates if the next call should fail
+	}
+
 	// dependencyDefragInterrupted is a dependency used to cause a defrag to
 	// fail before AcceptTransactionSet is called
 	dependencyDefragInterrupted struct {
@@ -50,3 +58,19 @@ func (d *dependencyDefragInterrupted) Disrupt(s string) bool {
 func (d *dependencyDefragInterrupted) fail() {
 	d.f = true
 }
+
+// Disrupt will return true if fail was called and the correct string value is
+// provided. It also resets f back to false. This means fail has to be called
+// once for each execution that should be interrupted.
+func (d *dependencyScheduledPaymentsInterrupted) Disrupt(s string) bool {
+	if d.f && s == "ScheduledPaymentsInterrupted" {
+		d.f = false
+		return true
+	}
+	return false
+}
+
+// fail causes the next ScheduledPaymentsInterrupted disrupt to return true
+func (d *dependencyScheduledPaymentsInterrupted) fail() {
+	d.f = true
+}
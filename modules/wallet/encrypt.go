@@ -54,7 +54,8 @@ func checkMasterKey(tx *bolt.Tx, masterKey crypto.TwofishKey) error {
 	return verifyEncryption(uk, encryptedVerification)
 }
 
-// initEncryption initializes and encrypts the primary SeedFile.
+// initEncryption initializes and encrypts the primary SeedFile. The master key
+// is derived from masterKey using a fresh set of key derivation parameters.
 func (w *Wallet) initEncryption(masterKey crypto.TwofishKey, seed modules.Seed, progress uint64) (modules.Seed, error) {
 	wb := w.dbTx.Bucket(bucketWallet)
 	// Check if the wallet encryption key has already been set.
@@ -62,11 +63,22 @@ func (w *Wallet) initEncryption(masterKey crypto.TwofishKey, seed modules.Seed,
 		return modules.Seed{}, errReencrypt
 	}
 
+	// derive the master key
+	params := newKDFParams()
+	masterKey, err := params.deriveKey(masterKey)
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	err = dbPutKDFParams(w.dbTx, params)
+	if err != nil {
+		return modules.Seed{}, err
+	}
+
 	// create a seedFile for the seed
 	sf := createSeedFile(masterKey, seed)
 
 	// set this as the primary seedFile
-	err := wb.Put(keyPrimarySeedFile, encoding.Marshal(sf))
+	err = wb.Put(keyPrimarySeedFile, encoding.Marshal(sf))
 	if err != nil {
 		return modules.Seed{}, err
 	}
@@ -109,12 +121,22 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	var primarySeedProgress uint64
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
+	var legacyKDF bool
 	err := func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
 
-		// verify masterKey
-		err := checkMasterKey(w.dbTx, masterKey)
+		// derive and verify masterKey
+		params, err := dbGetKDFParams(w.dbTx)
+		if err != nil {
+			return err
+		}
+		legacyKDF = params.Version == kdfVersionLegacy
+		masterKey, err = params.deriveKey(masterKey)
+		if err != nil {
+			return err
+		}
+		err = checkMasterKey(w.dbTx, masterKey)
 		if err != nil {
 			return err
 		}
@@ -183,12 +205,25 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 			}
 			w.integrateSpendableKey(masterKey, sk)
 		}
-		return nil
+
+		// timelocked addresses
+		return w.integrateTimelockedAddresses()
 	}()
 	if err != nil {
 		return err
 	}
 
+	// Wallets that were encrypted before key derivation was introduced are
+	// upgraded by re-encrypting them under a derived master key. For these
+	// wallets, masterKey is still the key that was provided by the caller.
+	if legacyKDF {
+		err = w.managedChangeKey(masterKey, masterKey)
+		if err != nil {
+			return fmt.Errorf("failed to upgrade wallet encryption: %v", err)
+		}
+		w.log.Println("INFO: Upgraded wallet encryption to use key derivation.")
+	}
+
 	// Subscribe to the consensus set if this is the first unlock for the
 	// wallet object.
 	w.mu.RLock()
@@ -225,6 +260,9 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	w.unlocked = true
 	w.subscribed = true
 	w.mu.Unlock()
+
+	// Pay the scheduled payments that came due while the wallet was locked.
+	go w.threadedExecuteScheduledPayments()
 	return nil
 }
 
@@ -341,6 +379,7 @@ func (w *Wallet) Reset() error {
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.watchedAddrs = make(map[types.UnlockHash]modules.WatchOnlyAddress)
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
@@ -415,7 +454,8 @@ func (w *Wallet) Lock() error {
 	return w.managedLock()
 }
 
-// ChangeKey changes the wallet's encryption key from masterKey to newKey.
+// ChangeKey changes the wallet's encryption key from masterKey to newKey. A
+// new salt is generated for the derivation of the new master key.
 func (w *Wallet) ChangeKey(masterKey crypto.TwofishKey, newKey crypto.TwofishKey) error {
 	if err := w.tg.Add(); err != nil {
 		return err
@@ -470,8 +510,13 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 		w.mu.Lock()
 		defer w.mu.Unlock()
 
-		// verify masterKey
-		err := checkMasterKey(w.dbTx, masterKey)
+		// derive and verify masterKey
+		var err error
+		masterKey, err = deriveMasterKey(w.dbTx, masterKey)
+		if err != nil {
+			return err
+		}
+		err = checkMasterKey(w.dbTx, masterKey)
 		if err != nil {
 			return err
 		}
@@ -526,6 +571,13 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 		spendableKeys = append(spendableKeys, sk)
 	}
 
+	// derive the new master key using fresh key derivation parameters
+	newParams := newKDFParams()
+	newKey, err = newParams.deriveKey(newKey)
+	if err != nil {
+		return err
+	}
+
 	// encrypt new keyfiles using newKey
 	var newPrimarySeedFile seedFile
 	var newAuxiliarySeedFiles []seedFile
@@ -571,6 +623,10 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 		if err != nil {
 			return err
 		}
+		err = dbPutKDFParams(w.dbTx, newParams)
+		if err != nil {
+			return err
+		}
 
 		return nil
 	}()
@@ -9,6 +9,7 @@ import (
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/miner"
 	"github.com/NebulousLabs/Sia/types"
@@ -533,3 +534,91 @@ func TestChangeKey(t *testing.T) {
 	}
 	postEncryptionTesting(wt.miner, wt.wallet, newKey)
 }
+
+// TestKDFUpgrade checks that a wallet encrypted without key derivation is
+// re-encrypted under a derived master key on the next successful unlock.
+func TestKDFUpgrade(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// New wallets store their key derivation parameters, and the key that
+	// was provided to Encrypt is not the master key.
+	wt.wallet.mu.Lock()
+	params, err := dbGetKDFParams(wt.wallet.dbTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Version != kdfVersionArgon2id || params.Salt == ([32]byte{}) {
+		t.Fatal("wallet was not encrypted with key derivation:", params)
+	}
+	if checkMasterKey(wt.wallet.dbTx, wt.walletMasterKey) == nil {
+		t.Fatal("provided key should not be the master key")
+	}
+	wt.wallet.mu.Unlock()
+
+	// Re-encrypt the wallet the way it was before key derivation was
+	// introduced.
+	err = wt.wallet.Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	derived, err := params.deriveKey(wt.walletMasterKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb := wt.wallet.dbTx.Bucket(bucketWallet)
+	var sf seedFile
+	if err := encoding.Unmarshal(wb.Get(keyPrimarySeedFile), &sf); err != nil {
+		t.Fatal(err)
+	}
+	seed, err := decryptSeedFile(derived, sf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.Put(keyPrimarySeedFile, encoding.Marshal(createSeedFile(wt.walletMasterKey, seed))); err != nil {
+		t.Fatal(err)
+	}
+	uk := uidEncryptionKey(wt.walletMasterKey, dbGetWalletUID(wt.wallet.dbTx))
+	if err := wb.Put(keyEncryptionVerification, uk.EncryptBytes(verificationPlaintext)); err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.Delete(keyKDFParams); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Unlock()
+
+	// Unlocking upgrades the wallet.
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	upgraded, err := dbGetKDFParams(wt.wallet.dbTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upgraded.Version != kdfVersionArgon2id || upgraded.Salt == params.Salt {
+		t.Fatal("wallet was not upgraded:", upgraded)
+	}
+	if checkMasterKey(wt.wallet.dbTx, wt.walletMasterKey) == nil {
+		t.Fatal("wallet is still encrypted with the provided key")
+	}
+	wt.wallet.mu.Unlock()
+
+	// The upgraded wallet can still be unlocked with the same key.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	if wt.wallet.primarySeed != seed {
+		t.Fatal("primary seed changed during upgrade")
+	}
+}
@@ -0,0 +1,216 @@
+package wallet
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// transactionRole determines the role of a processed transaction.
+func transactionRole(pt modules.ProcessedTransaction) string {
+	for _, output := range pt.Outputs {
+		if output.FundType == types.SpecifierMinerPayout {
+			return modules.TransactionRoleMinerPayout
+		}
+	}
+	switch {
+	case len(pt.Transaction.StorageProofs) != 0:
+		return modules.TransactionRoleStorageProof
+	case len(pt.Transaction.FileContracts) != 0 || len(pt.Transaction.FileContractRevisions) != 0:
+		return modules.TransactionRoleFileContract
+	case len(pt.Transaction.SiafundInputs) != 0:
+		return modules.TransactionRoleSiafundClaim
+	default:
+		return modules.TransactionRoleTransfer
+	}
+}
+
+// An exporter splits the processed transactions of a wallet into accounting
+// categories, in the order in which they were confirmed, and keeps track of
+// the running balances.
+type exporter struct {
+	isWalletAddress func(types.UnlockHash) bool
+
+	// known contains the wallet outputs that have been created by the
+	// transactions exported so far. Outputs that are spent without being
+	// known were received outside of transactions.
+	known map[types.OutputID]struct{}
+
+	balance        types.Currency
+	siafundBalance types.Currency
+}
+
+// newExporter returns an exporter for a wallet that owns the addresses for
+// which isWalletAddress returns true.
+func newExporter(isWalletAddress func(types.UnlockHash) bool) *exporter {
+	return &exporter{
+		isWalletAddress: isWalletAddress,
+		known:           make(map[types.OutputID]struct{}),
+	}
+}
+
+// export splits the next processed transaction into accounting categories
+// and updates the running balances.
+func (e *exporter) export(pt modules.ProcessedTransaction) modules.ExportedTransaction {
+	et := modules.ExportedTransaction{
+		TransactionID:         pt.TransactionID,
+		ConfirmationHeight:    pt.ConfirmationHeight,
+		ConfirmationTimestamp: pt.ConfirmationTimestamp,
+		Role:                  transactionRole(pt),
+	}
+
+	// Sum up the siacoins and siafunds moving in and out of the wallet.
+	var walletInputs, walletOutputs, externalOutputs, fees types.Currency
+	var siafundInputs, siafundOutputs types.Currency
+	for _, input := range pt.Inputs {
+		if !input.WalletAddress {
+			continue
+		}
+		_, known := e.known[input.ParentID]
+		delete(e.known, input.ParentID)
+		switch input.FundType {
+		case types.SpecifierSiacoinInput:
+			walletInputs = walletInputs.Add(input.Value)
+			if !known {
+				et.ContractPayout = et.ContractPayout.Add(input.Value)
+			}
+		case types.SpecifierSiafundInput:
+			siafundInputs = siafundInputs.Add(input.Value)
+			// Siafunds that were received outside of transactions, such
+			// as the siafunds of the genesis block, are counted as
+			// incoming when they are spent.
+			if !known {
+				siafundOutputs = siafundOutputs.Add(input.Value)
+			}
+		}
+	}
+	for _, output := range pt.Outputs {
+		switch output.FundType {
+		case types.SpecifierMinerPayout:
+			if output.WalletAddress {
+				et.Incoming = et.Incoming.Add(output.Value)
+				e.known[output.ID] = struct{}{}
+			}
+		case types.SpecifierSiacoinOutput:
+			if output.WalletAddress {
+				walletOutputs = walletOutputs.Add(output.Value)
+				e.known[output.ID] = struct{}{}
+			} else {
+				externalOutputs = externalOutputs.Add(output.Value)
+			}
+		case types.SpecifierClaimOutput:
+			// The ID of a claim output is the ID of the siafund output that
+			// it was claimed for.
+			if e.isWalletAddress(output.RelatedAddress) {
+				et.SiafundClaim = et.SiafundClaim.Add(output.Value)
+				e.known[types.OutputID(types.SiafundOutputID(output.ID).SiaClaimOutputID())] = struct{}{}
+			}
+		case types.SpecifierSiafundOutput:
+			if output.WalletAddress {
+				siafundOutputs = siafundOutputs.Add(output.Value)
+				e.known[output.ID] = struct{}{}
+			}
+		case types.SpecifierMinerFee:
+			fees = fees.Add(output.Value)
+		}
+	}
+
+	// Siafunds sent back to the wallet are change.
+	if siafundOutputs.Cmp(siafundInputs) >= 0 {
+		et.SiafundsIncoming = siafundOutputs.Sub(siafundInputs)
+	} else {
+		et.SiafundsOutgoing = siafundInputs.Sub(siafundOutputs)
+	}
+	e.siafundBalance = e.siafundBalance.Add(et.SiafundsIncoming).Sub(et.SiafundsOutgoing)
+	et.SiafundBalance = e.siafundBalance
+
+	if walletOutputs.Cmp(walletInputs) >= 0 {
+		// The wallet received siacoins.
+		et.Incoming = et.Incoming.Add(walletOutputs.Sub(walletInputs))
+	} else {
+		// The wallet spent siacoins. The miner fees are paid first, then
+		// the outputs to other addresses. Whatever remains went into file
+		// contracts.
+		spent := walletInputs.Sub(walletOutputs)
+		et.Fee = fees
+		if et.Fee.Cmp(spent) > 0 {
+			et.Fee = spent
+		}
+		spent = spent.Sub(et.Fee)
+		et.Outgoing = externalOutputs
+		if et.Outgoing.Cmp(spent) > 0 {
+			et.Outgoing = spent
+		}
+		spent = spent.Sub(et.Outgoing)
+		switch {
+		case spent.IsZero():
+		case et.Role != modules.TransactionRoleFileContract:
+			et.Outgoing = et.Outgoing.Add(spent)
+		case e.isHostContract(pt.Transaction):
+			et.HostCollateral = spent
+		default:
+			et.ContractFunding = spent
+		}
+	}
+
+	// Every siacoin input of the wallet has either been counted when it was
+	// created or is counted as a contract payout now, so the balance cannot
+	// become negative.
+	e.balance = e.balance.Add(et.Incoming).Add(et.SiafundClaim).Add(et.ContractPayout)
+	e.balance = e.balance.Sub(et.Outgoing).Sub(et.Fee).Sub(et.HostCollateral).Sub(et.ContractFunding)
+	et.Balance = e.balance
+	return et
+}
+
+// isHostContract reports whether the wallet is the host of a file contract in
+// txn, which is the case if it owns the host's payout address.
+func (e *exporter) isHostContract(txn types.Transaction) bool {
+	for _, fc := range txn.FileContracts {
+		if len(fc.ValidProofOutputs) > 1 && e.isWalletAddress(fc.ValidProofOutputs[1].UnlockHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportTransactions returns the confirmed transactions of the wallet at
+// heights [startHeight, endHeight], split into accounting categories. The
+// running balances are computed from the start of the wallet's history, so
+// that they are correct for any height range. The wallet must be unlocked, as
+// the transactions are classified by the addresses that the wallet owns.
+func (w *Wallet) ExportTransactions(startHeight, endHeight types.BlockHeight) ([]modules.ExportedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+	if startHeight > endHeight {
+		return nil, errOutOfBounds
+	}
+
+	notes := make(map[types.TransactionID]modules.TransactionNote)
+	err := dbForEachTransactionNote(w.dbTx, func(txid types.TransactionID, note modules.TransactionNote) {
+		notes[txid] = note
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ets []modules.ExportedTransaction
+	e := newExporter(w.isWalletAddress)
+	it := dbProcessedTransactionsIterator(w.dbTx)
+	for it.next() {
+		pt := it.value()
+		et := e.export(pt)
+		if pt.ConfirmationHeight < startHeight || pt.ConfirmationHeight > endHeight {
+			continue
+		}
+		note := notes[pt.TransactionID]
+		et.Note, et.Tags = note.Note, note.Tags
+		ets = append(ets, et)
+	}
+	return ets, nil
+}
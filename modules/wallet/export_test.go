@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestExportTransactions checks that the exported history of a wallet
+// classifies miner payouts and payments, and that the running balance matches
+// the balance of the wallet.
+func TestExportTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	sendValue := types.SiacoinPrecision.Mul64(100)
+	txns, err := wt.wallet.SendSiacoins(sendValue, types.UnlockHash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	if err := wt.wallet.SetTransactionNote(txid, modules.TransactionNote{Note: "invoice 42"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	ets, err := wt.wallet.ExportTransactions(0, types.BlockHeight(^uint64(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent *modules.ExportedTransaction
+	for i, et := range ets {
+		if et.Role == modules.TransactionRoleMinerPayout && et.Incoming.IsZero() {
+			t.Fatal("miner payout without incoming siacoins")
+		}
+		if et.TransactionID == txid {
+			sent = &ets[i]
+		}
+	}
+	if sent == nil {
+		t.Fatal("sent transaction was not exported")
+	}
+	if sent.Role != modules.TransactionRoleTransfer || !sent.Outgoing.Equals(sendValue) || sent.Fee.IsZero() || !sent.Incoming.IsZero() {
+		t.Fatalf("sent transaction was not classified correctly: %+v", *sent)
+	}
+	if sent.Note != "invoice 42" {
+		t.Fatal("note was not exported:", sent.Note)
+	}
+
+	// The running balance includes the miner payouts that have not matured
+	// yet.
+	height := wt.cs.Height()
+	var immature types.Currency
+	for _, et := range ets {
+		if et.Role == modules.TransactionRoleMinerPayout && et.ConfirmationHeight+types.MaturityDelay > height {
+			immature = immature.Add(et.Incoming)
+		}
+	}
+	confirmed, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := ets[len(ets)-1].Balance; !balance.Equals(confirmed.Add(immature)) {
+		t.Fatalf("running balance %v does not match confirmed balance %v plus immature payouts %v", balance, confirmed, immature)
+	}
+
+	// Exporting a height range keeps the running balances.
+	last, err := wt.wallet.ExportTransactions(height, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) == 0 || !last[len(last)-1].Balance.Equals(ets[len(ets)-1].Balance) {
+		t.Fatal("running balance differs for a height range")
+	}
+
+	// A locked wallet can not classify its transactions.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.ExportTransactions(0, height); err != modules.ErrLockedWallet {
+		t.Fatal("expected ErrLockedWallet, got", err)
+	}
+}
+
+// TestExporterCategories checks the classification of contract funding, host
+// collateral and outputs that were received outside of transactions.
+func TestExporterCategories(t *testing.T) {
+	wallet, host, other := types.UnlockHash{1}, types.UnlockHash{2}, types.UnlockHash{3}
+	e := newExporter(func(uh types.UnlockHash) bool { return uh == wallet || uh == host })
+
+	// The wallet spends a contract payout that it has not seen being
+	// created, funding a contract as the renter.
+	renterTxn := modules.ProcessedTransaction{
+		Transaction: types.Transaction{
+			FileContracts: []types.FileContract{{
+				ValidProofOutputs: []types.SiacoinOutput{{UnlockHash: wallet}, {UnlockHash: other}},
+			}},
+		},
+		Inputs: []modules.ProcessedInput{
+			{ParentID: types.OutputID{1}, FundType: types.SpecifierSiacoinInput, WalletAddress: true, Value: types.NewCurrency64(100)},
+		},
+		Outputs: []modules.ProcessedOutput{
+			{ID: types.OutputID{2}, FundType: types.SpecifierSiacoinOutput, WalletAddress: true, Value: types.NewCurrency64(30)},
+			{FundType: types.SpecifierMinerFee, Value: types.NewCurrency64(10)},
+		},
+	}
+	et := e.export(renterTxn)
+	if et.Role != modules.TransactionRoleFileContract {
+		t.Fatal("wrong role", et.Role)
+	}
+	if !et.ContractPayout.Equals64(100) || !et.Fee.Equals64(10) || !et.ContractFunding.Equals64(60) || !et.HostCollateral.IsZero() {
+		t.Fatalf("renter contract was not classified correctly: %+v", et)
+	}
+	if !et.Balance.Equals64(30) {
+		t.Fatal("wrong balance", et.Balance)
+	}
+
+	// The change is spent as host collateral.
+	hostTxn := modules.ProcessedTransaction{
+		Transaction: types.Transaction{
+			FileContracts: []types.FileContract{{
+				ValidProofOutputs: []types.SiacoinOutput{{UnlockHash: other}, {UnlockHash: host}},
+			}},
+		},
+		Inputs: []modules.ProcessedInput{
+			{ParentID: types.OutputID{2}, FundType: types.SpecifierSiacoinInput, WalletAddress: true, Value: types.NewCurrency64(30)},
+		},
+	}
+	et = e.export(hostTxn)
+	if !et.ContractPayout.IsZero() || !et.HostCollateral.Equals64(30) || !et.Balance.IsZero() {
+		t.Fatalf("host contract was not classified correctly: %+v", et)
+	}
+}
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/fastrand"
+	"github.com/coreos/bbolt"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// kdfVersionLegacy indicates that the wallet was encrypted before key
+	// derivation was introduced, and that the key provided by the caller is
+	// used as the master key directly.
+	kdfVersionLegacy = 0
+
+	// kdfVersionArgon2id indicates that the master key is derived from the
+	// key provided by the caller using Argon2id.
+	kdfVersionArgon2id = 1
+)
+
+var (
+	// errUnknownKDFVersion is returned if the wallet's key derivation
+	// parameters have a version that is not known to this release.
+	errUnknownKDFVersion = errors.New("wallet uses an unknown key derivation version")
+)
+
+// kdfParams are the parameters used to derive the wallet's master key from
+// the key provided to Encrypt, Unlock and ChangeKey. They are stored in the
+// database alongside the encrypted seed files, so that the cost of the
+// derivation can be raised without breaking existing wallets.
+type kdfParams struct {
+	Version uint8
+	Salt    [32]byte
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// newKDFParams returns a set of key derivation parameters with a random salt
+// and the current cost settings.
+func newKDFParams() kdfParams {
+	params := kdfParams{
+		Version: kdfVersionArgon2id,
+		Time:    kdfTime,
+		Memory:  kdfMemory,
+		Threads: kdfThreads,
+	}
+	fastrand.Read(params.Salt[:])
+	return params
+}
+
+// deriveKey derives the master key of the wallet from key.
+func (p kdfParams) deriveKey(key crypto.TwofishKey) (crypto.TwofishKey, error) {
+	switch p.Version {
+	case kdfVersionLegacy:
+		return key, nil
+	case kdfVersionArgon2id:
+		var derived crypto.TwofishKey
+		copy(derived[:], argon2.IDKey(key[:], p.Salt[:], p.Time, p.Memory, p.Threads, uint32(len(derived))))
+		return derived, nil
+	default:
+		return crypto.TwofishKey{}, errUnknownKDFVersion
+	}
+}
+
+// deriveMasterKey derives the master key of the wallet from key using the
+// key derivation parameters stored in the database.
+func deriveMasterKey(tx *bolt.Tx, key crypto.TwofishKey) (crypto.TwofishKey, error) {
+	params, err := dbGetKDFParams(tx)
+	if err != nil {
+		return crypto.TwofishKey{}, err
+	}
+	return params.deriveKey(key)
+}
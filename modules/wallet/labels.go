@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// SetAddressLabel assigns a label to an address. The address does not need to
+// belong to the wallet, so that the recipients of payments can be labeled as
+// well. An empty label removes the address's label.
+func (w *Wallet) SetAddressLabel(addr types.UnlockHash, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := modules.ValidateAddressLabel(label); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if label == "" {
+		err = dbDeleteAddressLabel(w.dbTx, addr)
+	} else {
+		err = dbPutAddressLabel(w.dbTx, addr, label)
+	}
+	if err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// AddressLabels returns the labels that have been assigned to addresses.
+func (w *Wallet) AddressLabels() (map[types.UnlockHash]string, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	labels := make(map[types.UnlockHash]string)
+	err := dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		labels[addr] = label
+	})
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// SetTransactionNote attaches a note and tags to a transaction. The
+// transaction does not need to be known to the wallet yet, so that a note can
+// be attached before the transaction is broadcast. An empty note without tags
+// removes the transaction's note.
+func (w *Wallet) SetTransactionNote(txid types.TransactionID, note modules.TransactionNote) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := note.Validate(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if note.Note == "" && len(note.Tags) == 0 {
+		err = dbDeleteTransactionNote(w.dbTx, txid)
+	} else {
+		err = dbPutTransactionNote(w.dbTx, txid, note)
+	}
+	if err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// TransactionNotes returns the notes that have been attached to transactions.
+func (w *Wallet) TransactionNotes() (map[types.TransactionID]modules.TransactionNote, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	notes := make(map[types.TransactionID]modules.TransactionNote)
+	err := dbForEachTransactionNote(w.dbTx, func(txid types.TransactionID, note modules.TransactionNote) {
+		notes[txid] = note
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
@@ -0,0 +1,65 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestLabelsAndNotes checks that address labels and transaction notes are
+// stored and removed correctly.
+func TestLabelsAndNotes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	addr := types.UnlockHash{1}
+	if err := wt.wallet.SetAddressLabel(addr, strings.Repeat("a", modules.MaxAddressLabelLength+1)); err != modules.ErrLabelTooLong {
+		t.Fatal("expected ErrLabelTooLong, got", err)
+	}
+	if err := wt.wallet.SetAddressLabel(addr, "customer"); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := wt.wallet.AddressLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 1 || labels[addr] != "customer" {
+		t.Fatal("unexpected labels", labels)
+	}
+	if err := wt.wallet.SetAddressLabel(addr, ""); err != nil {
+		t.Fatal(err)
+	}
+	if labels, err = wt.wallet.AddressLabels(); err != nil || len(labels) != 0 {
+		t.Fatal("label was not removed", labels, err)
+	}
+
+	txid := types.TransactionID{2}
+	note := modules.TransactionNote{Note: "invoice 42", Tags: []string{"invoices"}}
+	if err := wt.wallet.SetTransactionNote(txid, modules.TransactionNote{Note: strings.Repeat("a", modules.MaxTransactionNoteLength+1)}); err != modules.ErrNoteTooLong {
+		t.Fatal("expected ErrNoteTooLong, got", err)
+	}
+	if err := wt.wallet.SetTransactionNote(txid, note); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := wt.wallet.TransactionNotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := notes[txid]; len(notes) != 1 || n.Note != note.Note || len(n.Tags) != 1 || n.Tags[0] != "invoices" {
+		t.Fatal("unexpected notes", notes)
+	}
+	if err := wt.wallet.SetTransactionNote(txid, modules.TransactionNote{}); err != nil {
+		t.Fatal(err)
+	}
+	if notes, err = wt.wallet.TransactionNotes(); err != nil || len(notes) != 0 {
+		t.Fatal("note was not removed", notes, err)
+	}
+}
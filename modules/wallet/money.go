@@ -8,6 +8,12 @@ import (
 	"github.com/NebulousLabs/Sia/types"
 )
 
+var (
+	// errNoInputs is returned if SendSiacoinsFromInputs is called without
+	// any inputs.
+	errNoInputs = errors.New("no inputs provided")
+)
+
 // sortedOutputs is a struct containing a slice of siacoin outputs and their
 // corresponding ids. sortedOutputs can be sorted using the sort package.
 type sortedOutputs struct {
@@ -28,7 +34,8 @@ func (w *Wallet) DustThreshold() (types.Currency, error) {
 }
 
 // ConfirmedBalance returns the balance of the wallet according to all of the
-// confirmed transactions.
+// confirmed transactions. Siacoins held by time-locked addresses are only
+// included once they can be spent.
 func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siafundClaimBalance types.Currency, err error) {
 	if err := w.tg.Add(); err != nil {
 		return types.ZeroCurrency, types.ZeroCurrency, types.ZeroCurrency, modules.ErrWalletShutdown
@@ -49,8 +56,14 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalan
 		return
 	}
 
+	// Outputs of time-locked addresses are reported by TimelockedBalance
+	// until they can be spent.
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return
+	}
 	dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if sco.Value.Cmp(dustThreshold) > 0 {
+		if sco.Value.Cmp(dustThreshold) > 0 && unlockHeight(w.dbTx, sco.UnlockHash) <= height {
 			siacoinBalance = siacoinBalance.Add(sco.Value)
 		}
 	})
@@ -175,18 +188,71 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 		return nil, err
 	}
 	defer w.tg.Done()
+	return w.managedSendSiacoinsMulti(outputs, nil)
+}
+
+// SendSiacoinsFromInputs creates a transaction that includes the specified
+// outputs, funded by exactly the provided wallet outputs. Any value of the
+// inputs that is not needed for the outputs and the fee is returned to the
+// wallet. Locked outputs can be spent this way. The transaction is submitted
+// to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsFromInputs(outputs []types.SiacoinOutput, inputs []types.SiacoinOutputID) (txns []types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(inputs) == 0 {
+		return nil, errNoInputs
+	}
+	return w.managedSendSiacoinsMulti(outputs, inputs)
+}
+
+// managedSendSiacoinsMulti creates and broadcasts a transaction sending the
+// outputs. If inputs is nil, the wallet selects the outputs that fund the
+// transaction.
+func (w *Wallet) managedSendSiacoinsMulti(outputs []types.SiacoinOutput, inputs []types.SiacoinOutputID) (txns []types.Transaction, err error) {
+	txnBuilder, txnSet, err := w.managedSignSiacoinsMulti(outputs, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if w.deps.Disrupt("SendSiacoinsInterrupted") {
+		txnBuilder.Drop()
+		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
+	}
+	w.log.Println("Attempting to broadcast a multi-send over the network")
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		txnBuilder.Drop()
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+
+	// Log the success.
+	var outputList string
+	for _, output := range outputs {
+		outputList = outputList + "\n\tAddress: " + output.UnlockHash.String() + "\n\tValue: " + output.Value.HumanString() + "\n"
+	}
+	txn := txnSet[len(txnSet)-1]
+	w.log.Printf("Successfully broadcast transaction with id %v, fee %v, and the following outputs: %v", txn.ID(), txn.MinerFees[0].HumanString(), outputList)
+	return txnSet, nil
+}
+
+// managedSignSiacoinsMulti creates and signs a transaction sending the
+// outputs, without broadcasting it. If inputs is nil, the wallet selects the
+// outputs that fund the transaction. The returned transaction builder must be
+// dropped if the transaction is not broadcast.
+func (w *Wallet) managedSignSiacoinsMulti(outputs []types.SiacoinOutput, inputs []types.SiacoinOutputID) (_ *transactionBuilder, txnSet []types.Transaction, err error) {
 	w.mu.RLock()
 	unlocked := w.unlocked
 	w.mu.RUnlock()
 	if !unlocked {
 		w.log.Println("Attempt to send coins has failed - wallet is locked")
-		return nil, modules.ErrLockedWallet
+		return nil, nil, modules.ErrLockedWallet
 	}
 
-	txnBuilder, err := w.StartTransaction()
-	if err != nil {
-		return nil, err
-	}
+	w.mu.Lock()
+	txnBuilder := w.registerTransaction(types.Transaction{}, nil)
+	w.mu.Unlock()
 	defer func() {
 		if err != nil {
 			txnBuilder.Drop()
@@ -208,37 +274,21 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	for _, sco := range outputs {
 		totalCost = totalCost.Add(sco.Value)
 	}
-	err = txnBuilder.FundSiacoins(totalCost)
+	err = txnBuilder.fundSiacoins(totalCost, inputs)
 	if err != nil {
-		return nil, build.ExtendErr("unable to fund transaction", err)
+		return nil, nil, build.ExtendErr("unable to fund transaction", err)
 	}
 
 	for _, sco := range outputs {
 		txnBuilder.AddSiacoinOutput(sco)
 	}
 
-	txnSet, err := txnBuilder.Sign(true)
+	txnSet, err = txnBuilder.Sign(true)
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - failed to sign transaction:", err)
-		return nil, build.ExtendErr("unable to sign transaction", err)
+		return nil, nil, build.ExtendErr("unable to sign transaction", err)
 	}
-	if w.deps.Disrupt("SendSiacoinsInterrupted") {
-		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
-	}
-	w.log.Println("Attempting to broadcast a multi-send over the network")
-	err = w.tpool.AcceptTransactionSet(txnSet)
-	if err != nil {
-		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
-		return nil, build.ExtendErr("unable to get transaction accepted", err)
-	}
-
-	// Log the success.
-	var outputList string
-	for _, output := range outputs {
-		outputList = outputList + "\n\tAddress: " + output.UnlockHash.String() + "\n\tValue: " + output.Value.HumanString() + "\n"
-	}
-	w.log.Printf("Successfully broadcast transaction with id %v, fee %v, and the following outputs: %v", txnSet[len(txnSet)-1].ID(), tpoolFee.HumanString(), outputList)
-	return txnSet, nil
+	return txnBuilder, txnSet, nil
 }
 
 // SendSiafunds creates a transaction sending 'amount' to 'dest'. The transaction
@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errInvalidMultisig is returned if the number of required signatures of
+	// a multisig address is zero or exceeds the number of public keys.
+	errInvalidMultisig = errors.New("number of required signatures must be between 1 and the number of public keys")
+
+	// errNoTransactions is returned if no transactions are provided to
+	// MergeUnsignedTransactions.
+	errNoTransactions = errors.New("no transactions provided")
+
+	// errTransactionMismatch is returned when merging the signatures of
+	// transactions that are not the same transaction.
+	errTransactionMismatch = errors.New("transactions do not match")
+)
+
+// signedKeyIndices returns the public key indices of the signatures that have
+// been added to a transaction for the input with the provided parent ID.
+func signedKeyIndices(txn types.Transaction, parentID crypto.Hash) map[uint64]struct{} {
+	indices := make(map[uint64]struct{})
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID {
+			indices[sig.PublicKeyIndex] = struct{}{}
+		}
+	}
+	return indices
+}
+
+// pruneSignedInputs removes the inputs that have all of their required
+// signatures from the Inputs of an unsigned transaction.
+func pruneSignedInputs(utxn *modules.UnsignedTransaction) {
+	var remaining []modules.UnsignedTransactionInput
+	for _, in := range utxn.Inputs {
+		if uint64(len(signedKeyIndices(utxn.Transaction, in.ParentID))) < in.UnlockConditions.SignaturesRequired {
+			remaining = append(remaining, in)
+		}
+	}
+	utxn.Inputs = remaining
+}
+
+// signInputs adds signatures to the inputs of an unsigned transaction using
+// the secret keys returned by keyFor. Public keys that have already signed are
+// skipped, and no more signatures than required are added, because the
+// consensus rules reject frivolous signatures. The number of signatures that
+// were added is returned.
+func signInputs(utxn *modules.UnsignedTransaction, keyFor func(types.SiaPublicKey) (crypto.SecretKey, bool)) int {
+	added := 0
+	for _, in := range utxn.Inputs {
+		signed := signedKeyIndices(utxn.Transaction, in.ParentID)
+		for i, pk := range in.UnlockConditions.PublicKeys {
+			if uint64(len(signed)) >= in.UnlockConditions.SignaturesRequired {
+				break
+			}
+			if _, ok := signed[uint64(i)]; ok {
+				continue
+			}
+			sk, ok := keyFor(pk)
+			if !ok {
+				continue
+			}
+			utxn.Transaction.TransactionSignatures = append(utxn.Transaction.TransactionSignatures, types.TransactionSignature{
+				ParentID:       in.ParentID,
+				CoveredFields:  in.CoveredFields,
+				PublicKeyIndex: uint64(i),
+			})
+			sigIndex := len(utxn.Transaction.TransactionSignatures) - 1
+			encodedSig := crypto.SignHash(utxn.Transaction.SigHash(sigIndex), sk)
+			utxn.Transaction.TransactionSignatures[sigIndex].Signature = encodedSig[:]
+			signed[uint64(i)] = struct{}{}
+			added++
+		}
+	}
+	pruneSignedInputs(utxn)
+	return added
+}
+
+// AddMultisigAddress creates the unlock conditions of an M-of-N multisig
+// address and adds the address as a watch-only address, so that its outputs
+// are tracked and transactions spending them can be created with
+// CreateUnsignedTransaction.
+func (w *Wallet) AddMultisigAddress(publicKeys []types.SiaPublicKey, signaturesRequired uint64, unused bool) (types.UnlockConditions, error) {
+	if signaturesRequired == 0 || signaturesRequired > uint64(len(publicKeys)) {
+		return types.UnlockConditions{}, errInvalidMultisig
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         publicKeys,
+		SignaturesRequired: signaturesRequired,
+	}
+	err := w.AddWatchAddresses([]modules.WatchOnlyAddress{{Address: uc.UnlockHash(), UnlockConditions: &uc}}, unused)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return uc, nil
+}
+
+// AddTransactionSignatures signs the inputs of an unsigned transaction with
+// the keys of the wallet. Inputs that have all of their required signatures
+// are removed from the transaction's Inputs.
+func (w *Wallet) AddTransactionSignatures(utxn *modules.UnsignedTransaction) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+
+	// The wallet's keys are stored under the address of the single-key unlock
+	// conditions that they belong to.
+	added := signInputs(utxn, func(pk types.SiaPublicKey) (crypto.SecretKey, bool) {
+		uh := types.UnlockConditions{
+			PublicKeys:         []types.SiaPublicKey{pk},
+			SignaturesRequired: 1,
+		}.UnlockHash()
+		sk, ok := w.keys[uh]
+		if !ok || len(sk.SecretKeys) != 1 {
+			return crypto.SecretKey{}, false
+		}
+		return sk.SecretKeys[0], true
+	})
+	if added == 0 && len(utxn.Inputs) != 0 {
+		return errMissingSigningKeys
+	}
+	return nil
+}
+
+// MergeUnsignedTransactions combines the signatures of several copies of the
+// same transaction, each signed by a different set of cosigners. Inputs that
+// have all of their required signatures after merging are removed from the
+// returned transaction's Inputs.
+func MergeUnsignedTransactions(utxns ...modules.UnsignedTransaction) (modules.UnsignedTransaction, error) {
+	if len(utxns) == 0 {
+		return modules.UnsignedTransaction{}, errNoTransactions
+	}
+	merged := utxns[0]
+	merged.Transaction.TransactionSignatures = append([]types.TransactionSignature(nil), merged.Transaction.TransactionSignatures...)
+	merged.Inputs = append([]modules.UnsignedTransactionInput(nil), merged.Inputs...)
+
+	type sigKey struct {
+		parentID crypto.Hash
+		index    uint64
+	}
+	sigs := make(map[sigKey]struct{})
+	sigCounts := make(map[crypto.Hash]uint64)
+	for _, sig := range merged.Transaction.TransactionSignatures {
+		sigs[sigKey{sig.ParentID, sig.PublicKeyIndex}] = struct{}{}
+		sigCounts[sig.ParentID]++
+	}
+	// Signatures beyond the number required by an input are frivolous and
+	// would invalidate the transaction.
+	required := make(map[crypto.Hash]uint64)
+	for _, sci := range merged.Transaction.SiacoinInputs {
+		required[crypto.Hash(sci.ParentID)] = sci.UnlockConditions.SignaturesRequired
+	}
+	for _, sfi := range merged.Transaction.SiafundInputs {
+		required[crypto.Hash(sfi.ParentID)] = sfi.UnlockConditions.SignaturesRequired
+	}
+	inputs := make(map[crypto.Hash]struct{})
+	for _, in := range merged.Inputs {
+		inputs[in.ParentID] = struct{}{}
+	}
+
+	id := merged.Transaction.ID()
+	for _, utxn := range utxns[1:] {
+		// The transaction ID does not cover the signatures.
+		if utxn.Transaction.ID() != id {
+			return modules.UnsignedTransaction{}, errTransactionMismatch
+		}
+		for _, sig := range utxn.Transaction.TransactionSignatures {
+			if _, ok := sigs[sigKey{sig.ParentID, sig.PublicKeyIndex}]; ok {
+				continue
+			}
+			if req, ok := required[sig.ParentID]; ok && sigCounts[sig.ParentID] >= req {
+				continue
+			}
+			sigs[sigKey{sig.ParentID, sig.PublicKeyIndex}] = struct{}{}
+			sigCounts[sig.ParentID]++
+			merged.Transaction.TransactionSignatures = append(merged.Transaction.TransactionSignatures, sig)
+		}
+		for _, in := range utxn.Inputs {
+			if _, ok := inputs[in.ParentID]; ok {
+				continue
+			}
+			inputs[in.ParentID] = struct{}{}
+			merged.Inputs = append(merged.Inputs, in)
+		}
+	}
+	pruneSignedInputs(&merged)
+	return merged, nil
+}
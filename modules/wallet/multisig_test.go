@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestMultisigSigning checks that a transaction spending from a 2-of-3
+// multisig address can be signed by separate cosigners, merged, and accepted
+// by the transaction pool.
+func TestMultisigSigning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// The cosigners are the wallet, an offline seed and a standalone key.
+	walletUC, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	seedUC := generateKeys(seed, 0, 1)[0].UnlockConditions
+	sk, pk := crypto.GenerateKeyPair()
+	publicKeys := []types.SiaPublicKey{walletUC.PublicKeys[0], seedUC.PublicKeys[0], types.Ed25519PublicKey(pk)}
+
+	if _, err := wt.wallet.AddMultisigAddress(publicKeys, 4, true); err != errInvalidMultisig {
+		t.Fatal("expected errInvalidMultisig, got", err)
+	}
+	uc, err := wt.wallet.AddMultisigAddress(publicKeys, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	utxn, err := wt.wallet.CreateUnsignedTransaction([]types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(10)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each cosigner signs its own copy of the transaction.
+	signedByWallet := utxn
+	if err := wt.wallet.AddTransactionSignatures(&signedByWallet); err != nil {
+		t.Fatal(err)
+	}
+	if len(signedByWallet.Inputs) != 1 || len(signedByWallet.Transaction.TransactionSignatures) != 1 {
+		t.Fatal("wallet should add exactly one signature")
+	}
+	signedBySeed := utxn
+	if err := SignUnsignedTransaction(&signedBySeed, seed); err != nil {
+		t.Fatal(err)
+	}
+	signedByKey := utxn
+	signInputs(&signedByKey, func(spk types.SiaPublicKey) (crypto.SecretKey, bool) {
+		return sk, spk.String() == publicKeys[2].String()
+	})
+
+	// Merging transactions that differ fails.
+	other := utxn
+	other.Transaction.MinerFees = []types.Currency{types.SiacoinPrecision}
+	if _, err := MergeUnsignedTransactions(signedByWallet, other); err != errTransactionMismatch {
+		t.Fatal("expected errTransactionMismatch, got", err)
+	}
+
+	// Merging all three copies must not add a frivolous third signature.
+	merged, err := MergeUnsignedTransactions(signedByWallet, signedBySeed, signedByKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Inputs) != 0 {
+		t.Fatal("merged transaction still lists inputs to sign")
+	}
+	if len(merged.Transaction.TransactionSignatures) != 2 {
+		t.Fatal("expected 2 signatures, got", len(merged.Transaction.TransactionSignatures))
+	}
+	if err := wt.tpool.AcceptTransactionSet(append(merged.Parents, merged.Transaction)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errMissingSigningKeys is returned if none of the keys needed to sign an
+	// unsigned transaction are available.
+	errMissingSigningKeys = errors.New("none of the keys needed to sign the transaction are available")
+
+	// errNoOutputs is returned if an unsigned transaction is requested
+	// without any outputs.
+	errNoOutputs = errors.New("no outputs provided")
+)
+
+// CreateUnsignedTransaction creates a transaction that sends the provided
+// outputs, funded by the confirmed outputs of watch-only addresses whose unlock
+// conditions are known. The transaction is not signed or broadcast. The
+// outputs that fund the transaction are marked as spent, so that they are not
+// used for another unsigned transaction until RespendTimeout blocks have
+// passed.
+func (w *Wallet) CreateUnsignedTransaction(outputs []types.SiacoinOutput) (modules.UnsignedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.UnsignedTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(outputs) == 0 {
+		return modules.UnsignedTransaction{}, errNoOutputs
+	}
+
+	// Estimate the transaction fee the same way SendSiacoinsMulti does.
+	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee = tpoolFee.Mul64(2)
+	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs)))
+	totalCost := tpoolFee
+	for _, sco := range outputs {
+		totalCost = totalCost.Add(sco.Value)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.UnsignedTransaction{}, err
+	}
+
+	// Collect a value-sorted set of the watch-only outputs that can be spent
+	// by the known unlock conditions.
+	var so sortedOutputs
+	err = dbForEachWatchedSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		uc := w.watchedAddrs[sco.UnlockHash].UnlockConditions
+		if uc == nil || consensusHeight < uc.Timelock {
+			return
+		}
+		if spendHeight, err := dbGetSpentOutput(w.dbTx, types.OutputID(scoid)); err == nil && spendHeight+RespendTimeout > consensusHeight {
+			return
+		}
+		so.ids = append(so.ids, scoid)
+		so.outputs = append(so.outputs, sco)
+	})
+	if err != nil {
+		return modules.UnsignedTransaction{}, err
+	}
+	sort.Sort(sort.Reverse(so))
+
+	var utxn modules.UnsignedTransaction
+	var fund types.Currency
+	for i := range so.ids {
+		uc := *w.watchedAddrs[so.outputs[i].UnlockHash].UnlockConditions
+		utxn.Transaction.SiacoinInputs = append(utxn.Transaction.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: uc,
+		})
+		utxn.Inputs = append(utxn.Inputs, modules.UnsignedTransactionInput{
+			ParentID:         crypto.Hash(so.ids[i]),
+			UnlockConditions: uc,
+			CoveredFields:    types.FullCoveredFields,
+			Value:            so.outputs[i].Value,
+		})
+		fund = fund.Add(so.outputs[i].Value)
+		if fund.Cmp(totalCost) >= 0 {
+			break
+		}
+	}
+	if fund.Cmp(totalCost) < 0 {
+		return modules.UnsignedTransaction{}, modules.ErrLowBalance
+	}
+
+	utxn.Transaction.SiacoinOutputs = append(utxn.Transaction.SiacoinOutputs, outputs...)
+	if !fund.Equals(totalCost) {
+		utxn.Transaction.SiacoinOutputs = append(utxn.Transaction.SiacoinOutputs, types.SiacoinOutput{
+			Value:      fund.Sub(totalCost),
+			UnlockHash: utxn.Transaction.SiacoinInputs[0].UnlockConditions.UnlockHash(),
+		})
+	}
+	utxn.Transaction.MinerFees = []types.Currency{tpoolFee}
+
+	// Mark the outputs as spent.
+	for _, sci := range utxn.Transaction.SiacoinInputs {
+		if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+			return modules.UnsignedTransaction{}, err
+		}
+	}
+	return utxn, nil
+}
+
+// SignUnsignedTransaction signs the inputs of an unsigned transaction using
+// keys generated from seed. It does not require a wallet, so that it can be
+// used on a machine that is not connected to the network. Up to
+// offlineKeySearchLimit keys are generated to find the keys of the inputs. For
+// multisig inputs, only the signatures of the seed's keys are added; the
+// remaining signatures can be merged in with MergeUnsignedTransactions.
+func SignUnsignedTransaction(utxn *modules.UnsignedTransaction, seed modules.Seed) error {
+	// Determine which public keys could sign for the inputs.
+	needed := make(map[string]struct{})
+	for _, in := range utxn.Inputs {
+		for _, pk := range in.UnlockConditions.PublicKeys {
+			needed[pk.String()] = struct{}{}
+		}
+	}
+
+	// enoughKeys reports whether the keys found so far provide the
+	// signatures that each input is missing.
+	keys := make(map[string]crypto.SecretKey)
+	enoughKeys := func() bool {
+		for _, in := range utxn.Inputs {
+			signed := signedKeyIndices(utxn.Transaction, in.ParentID)
+			available := uint64(len(signed))
+			for i, pk := range in.UnlockConditions.PublicKeys {
+				if _, ok := signed[uint64(i)]; ok {
+					continue
+				}
+				if _, ok := keys[pk.String()]; ok {
+					available++
+				}
+			}
+			if available < in.UnlockConditions.SignaturesRequired {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Generate keys from the seed until all of the keys have been found.
+	for start := uint64(0); start < offlineKeySearchLimit && len(keys) < len(needed) && !enoughKeys(); start += modules.PublicKeysPerSeed {
+		for _, sk := range generateKeys(seed, start, modules.PublicKeysPerSeed) {
+			pk := sk.UnlockConditions.PublicKeys[0].String()
+			if _, ok := needed[pk]; ok {
+				keys[pk] = sk.SecretKeys[0]
+			}
+		}
+	}
+
+	added := signInputs(utxn, func(pk types.SiaPublicKey) (crypto.SecretKey, bool) {
+		sk, ok := keys[pk.String()]
+		return sk, ok
+	})
+	if added == 0 && len(utxn.Inputs) != 0 {
+		return errMissingSigningKeys
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestOfflineSigning checks that a transaction created from the watch-only
+// addresses of an offline seed can be signed with that seed and accepted by
+// the transaction pool.
+func TestOfflineSigning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Watch an address of an offline seed. A key that is not the first one
+	// is used to check that signing searches the seed's keys.
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	uc := generateKeys(seed, 5, 1)[0].UnlockConditions
+	addr := uc.UnlockHash()
+	err = wt.wallet.AddWatchAddresses([]modules.WatchOnlyAddress{{Address: addr, UnlockConditions: &uc}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without confirmed outputs, no transaction can be created.
+	sendValue := types.SiacoinPrecision.Mul64(10)
+	outputs := []types.SiacoinOutput{{Value: sendValue, UnlockHash: types.UnlockHash{}}}
+	if _, err := wt.wallet.CreateUnsignedTransaction(outputs); err != modules.ErrLowBalance {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+
+	// Fund the watched address.
+	fundValue := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(fundValue, addr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	utxn, err := wt.wallet.CreateUnsignedTransaction(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxn.Inputs) != 1 || utxn.Inputs[0].UnlockConditions.UnlockHash() != addr {
+		t.Fatal("unsigned transaction does not spend the watched output")
+	}
+	// The output is now marked as spent and cannot be used again.
+	if _, err := wt.wallet.CreateUnsignedTransaction(outputs); err != modules.ErrLowBalance {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+
+	// Signing with the wrong seed fails.
+	var wrongSeed modules.Seed
+	fastrand.Read(wrongSeed[:])
+	wrong := utxn
+	if err := SignUnsignedTransaction(&wrong, wrongSeed); err != errMissingSigningKeys {
+		t.Fatal("expected errMissingSigningKeys, got", err)
+	}
+
+	if err := SignUnsignedTransaction(&utxn, seed); err != nil {
+		t.Fatal(err)
+	}
+	if len(utxn.Inputs) != 0 {
+		t.Fatal("signed transaction still lists inputs to sign")
+	}
+	err = wt.tpool.AcceptTransactionSet(append(utxn.Parents, utxn.Transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The change is returned to the watched address.
+	siacoins, _, err := wt.wallet.WatchOnlyBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fundValue.Sub(sendValue).Sub(utxn.Transaction.MinerFees[0])
+	if !siacoins.Equals(expected) {
+		t.Fatalf("expected watch-only balance of %v, got %v", expected, siacoins)
+	}
+}
@@ -0,0 +1,453 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errInvalidCatchUp is returned when scheduling a payment with an unknown
+	// catch-up policy.
+	errInvalidCatchUp = errors.New("catch-up policy must be 'all', 'latest' or 'skip'")
+
+	// errScheduleStart is returned when scheduling a payment that sets both a
+	// start height and a start time.
+	errScheduleStart = errors.New("a scheduled payment can start at a height or at a time, but not both")
+
+	// errUnknownScheduledPayment is returned when cancelling a scheduled
+	// payment that does not exist.
+	errUnknownScheduledPayment = errors.New("scheduled payment does not exist")
+
+	// errZeroAmount is returned when scheduling a payment without an amount.
+	errZeroAmount = errors.New("cannot schedule a payment of zero siacoins")
+)
+
+// scheduledRuns returns the number of runs of sp that are due at the provided
+// height and time, and how late the most recent due run is, in blocks or
+// seconds depending on the schedule.
+func scheduledRuns(sp modules.ScheduledPayment, height types.BlockHeight, timestamp types.Timestamp) (due uint64, late uint64) {
+	now, start := uint64(height), uint64(sp.StartHeight)
+	if sp.StartTime != 0 {
+		now, start = uint64(timestamp), uint64(sp.StartTime)
+	}
+	if now < start {
+		return 0, 0
+	}
+	due = 1
+	if sp.Interval != 0 {
+		due = (now-start)/sp.Interval + 1
+	}
+	if limit := scheduledRunLimit(sp); limit != 0 && due > limit {
+		due = limit
+	}
+	return due, now - (start + (due-1)*sp.Interval)
+}
+
+// scheduledRunLimit returns the number of runs after which sp ends, or zero
+// if sp runs forever.
+func scheduledRunLimit(sp modules.ScheduledPayment) uint64 {
+	if sp.Interval == 0 {
+		return 1
+	}
+	return sp.MaxCount
+}
+
+// A pendingScheduledPayment is a signed transaction set paying runs of
+// scheduled payments. It is recorded together with the advanced runs of the
+// payments before it is broadcast, so that a run is never paid twice, and it
+// is removed once the outcome of the broadcast has been recorded.
+type pendingScheduledPayment struct {
+	TransactionSet []types.Transaction
+	Executions     []modules.ScheduledPaymentExecution
+}
+
+// removeEndedScheduledPayments removes the scheduled payments that have
+// reached their last run and are not waiting for a pending transaction.
+func (w *Wallet) removeEndedScheduledPayments() error {
+	pending := make(map[uint64]struct{})
+	err := dbForEachPendingScheduledPayment(w.dbTx, func(_ uint64, psp pendingScheduledPayment) {
+		for _, spe := range psp.Executions {
+			pending[spe.PaymentID] = struct{}{}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	var ended []uint64
+	err = dbForEachScheduledPayment(w.dbTx, func(id uint64, sp modules.ScheduledPayment) {
+		_, isPending := pending[id]
+		if limit := scheduledRunLimit(sp); limit != 0 && sp.Runs >= limit && !isPending {
+			ended = append(ended, id)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ended {
+		if err := dbDeleteScheduledPayment(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleScheduledPayment records the outcome of broadcasting a pending
+// scheduled payment. If the broadcast succeeded, the paid runs are added to
+// the history. Otherwise, the runs of the payments are rewound so that they
+// are paid the next time.
+func (w *Wallet) settleScheduledPayment(key uint64, psp pendingScheduledPayment, broadcastErr error) error {
+	for _, spe := range psp.Executions {
+		if broadcastErr == nil {
+			if err := dbAppendScheduledPaymentExecution(w.dbTx, spe); err != nil {
+				return err
+			}
+			continue
+		}
+		// The payment might have been cancelled in the meantime.
+		sp, err := dbGetScheduledPayment(w.dbTx, spe.PaymentID)
+		if err != nil {
+			continue
+		}
+		if spe.Run < sp.Runs {
+			sp.Runs = spe.Run
+		}
+		sp.LastError = broadcastErr.Error()
+		if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+			return err
+		}
+	}
+	if err := dbDeletePendingScheduledPayment(w.dbTx, key); err != nil {
+		return err
+	}
+	if err := w.removeEndedScheduledPayments(); err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// managedBroadcastScheduledPayment broadcasts the transaction set of a
+// pending scheduled payment. Sets that have already been confirmed or
+// accepted by the transaction pool count as broadcast.
+func (w *Wallet) managedBroadcastScheduledPayment(psp pendingScheduledPayment) error {
+	txid := psp.TransactionSet[len(psp.TransactionSet)-1].ID()
+	if confirmed, err := w.tpool.TransactionConfirmed(txid); err == nil && confirmed {
+		return nil
+	}
+	err := w.tpool.AcceptTransactionSet(psp.TransactionSet)
+	if err == modules.ErrDuplicateTransactionSet {
+		return nil
+	}
+	return err
+}
+
+// managedSettleScheduledPayments broadcasts the pending scheduled payments in
+// order and records the outcome. Once a broadcast fails, the remaining
+// payments are not broadcast either, so that the runs of each payment are
+// paid in order. If the payments were signed by this session, builders holds
+// their transaction builders, which are dropped if they are not broadcast.
+func (w *Wallet) managedSettleScheduledPayments(keys []uint64, pending []pendingScheduledPayment, builders []*transactionBuilder) {
+	var broadcastErr error
+	for i, psp := range pending {
+		if broadcastErr == nil {
+			broadcastErr = w.managedBroadcastScheduledPayment(psp)
+			if broadcastErr != nil {
+				w.log.Println("WARN: failed to make scheduled payments:", broadcastErr)
+			}
+		}
+		if broadcastErr != nil && builders != nil {
+			builders[i].Drop()
+		}
+		w.mu.Lock()
+		err := w.settleScheduledPayment(keys[i], psp, broadcastErr)
+		w.mu.Unlock()
+		if err != nil {
+			w.log.Println("ERROR: failed to record scheduled payments:", err)
+		}
+	}
+}
+
+// threadedExecuteScheduledPayments pays the runs of scheduled payments that
+// are due, following the catch-up policy of each payment for runs that were
+// missed. Up to scheduledPaymentOutputsPerTxn runs are paid with each
+// transaction. Nothing is done while the wallet is locked; the runs are
+// handled once it is unlocked.
+//
+// The advanced runs and the signed transactions are persisted before the
+// transactions are broadcast. If the daemon stopped before the outcome of a
+// broadcast was recorded, the transaction is broadcast again here before any
+// new runs are considered.
+func (w *Wallet) threadedExecuteScheduledPayments() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	// Executions must not overlap, or the same run could be paid twice.
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	// Settle the payments that were signed but whose broadcast was not
+	// recorded before the daemon stopped.
+	w.mu.Lock()
+	unlocked := w.unlocked
+	var keys []uint64
+	var pending []pendingScheduledPayment
+	err := dbForEachPendingScheduledPayment(w.dbTx, func(key uint64, psp pendingScheduledPayment) {
+		keys = append(keys, key)
+		pending = append(pending, psp)
+	})
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("ERROR: failed to load scheduled payments:", err)
+		return
+	} else if !unlocked {
+		return
+	}
+	w.managedSettleScheduledPayments(keys, pending, nil)
+
+	w.mu.Lock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	var payments []modules.ScheduledPayment
+	if err == nil {
+		err = dbForEachScheduledPayment(w.dbTx, func(_ uint64, sp modules.ScheduledPayment) {
+			payments = append(payments, sp)
+		})
+	}
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("ERROR: failed to load scheduled payments:", err)
+		return
+	} else if len(payments) == 0 {
+		return
+	}
+	timestamp := w.cs.CurrentBlock().Timestamp
+
+	// Decide which runs are paid and which are skipped. Only the most recent
+	// skipped runs of each payment are recorded.
+	var paid, skipped []modules.ScheduledPaymentExecution
+	runs := make(map[uint64]uint64)
+	maxPaid := scheduledPaymentOutputsPerTxn * scheduledPaymentTxnsPerExecution
+	for _, sp := range payments {
+		due, late := scheduledRuns(sp, height, timestamp)
+		if due <= sp.Runs {
+			continue
+		}
+		grace := scheduledPaymentGraceBlocks
+		if sp.StartTime != 0 {
+			grace = scheduledPaymentGraceTime
+		}
+		run := sp.Runs
+		if sp.CatchUp != modules.CatchUpAll && due-run > scheduledPaymentSkipHistory+1 {
+			run = due - 1 - scheduledPaymentSkipHistory
+		}
+		for ; run < due && len(paid) < maxPaid; run++ {
+			latest := run == due-1
+			pay := sp.CatchUp == modules.CatchUpAll ||
+				(sp.CatchUp == modules.CatchUpLatest && latest) ||
+				(sp.CatchUp == modules.CatchUpSkip && latest && late <= grace)
+			spe := modules.ScheduledPaymentExecution{
+				PaymentID:   sp.ID,
+				Run:         run,
+				Height:      height,
+				Timestamp:   timestamp,
+				Destination: sp.Destination,
+				Amount:      sp.Amount,
+				Skipped:     !pay,
+			}
+			if pay {
+				paid = append(paid, spe)
+			} else {
+				skipped = append(skipped, spe)
+			}
+		}
+		runs[sp.ID] = run
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	// Sign the transactions paying the runs. If a transaction can not be
+	// signed, the runs that it would have paid are tried again after the
+	// next block.
+	var signErr error
+	var builders []*transactionBuilder
+	pending = nil
+	for i := 0; i < len(paid); i += scheduledPaymentOutputsPerTxn {
+		end := i + scheduledPaymentOutputsPerTxn
+		if end > len(paid) {
+			end = len(paid)
+		}
+		var outputs []types.SiacoinOutput
+		for _, spe := range paid[i:end] {
+			outputs = append(outputs, types.SiacoinOutput{
+				Value:      spe.Amount,
+				UnlockHash: spe.Destination,
+			})
+		}
+		txnBuilder, txnSet, err := w.managedSignSiacoinsMulti(outputs, nil)
+		if err != nil {
+			w.log.Println("WARN: failed to make scheduled payments:", err)
+			signErr = err
+			for _, spe := range paid[i:] {
+				if spe.Run < runs[spe.PaymentID] {
+					runs[spe.PaymentID] = spe.Run
+				}
+			}
+			break
+		}
+		executions := append([]modules.ScheduledPaymentExecution(nil), paid[i:end]...)
+		for j := range executions {
+			executions[j].TransactionID = txnSet[len(txnSet)-1].ID()
+		}
+		builders = append(builders, txnBuilder)
+		pending = append(pending, pendingScheduledPayment{
+			TransactionSet: txnSet,
+			Executions:     executions,
+		})
+	}
+
+	// Persist the advanced runs, the skipped runs and the signed transactions
+	// before broadcasting anything.
+	w.mu.Lock()
+	for id, n := range runs {
+		// The payment might have been cancelled in the meantime.
+		sp, err := dbGetScheduledPayment(w.dbTx, id)
+		if err != nil {
+			continue
+		}
+		sp.Runs = n
+		sp.LastError = ""
+		if signErr != nil {
+			sp.LastError = signErr.Error()
+		}
+		if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+			w.log.Println("ERROR: failed to update scheduled payment:", err)
+		}
+	}
+	for _, spe := range skipped {
+		if err := dbAppendScheduledPaymentExecution(w.dbTx, spe); err != nil {
+			w.log.Println("ERROR: failed to record scheduled payment:", err)
+		}
+	}
+	err = dbPruneSkippedScheduledPaymentExecutions(w.dbTx, scheduledPaymentSkipHistory)
+	keys = nil
+	for _, psp := range pending {
+		if err != nil {
+			break
+		}
+		var key uint64
+		key, err = dbAppendPendingScheduledPayment(w.dbTx, psp)
+		keys = append(keys, key)
+	}
+	if err == nil {
+		err = w.removeEndedScheduledPayments()
+	}
+	if err == nil {
+		err = w.syncDB()
+	}
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("ERROR: failed to sync scheduled payments:", err)
+		for _, txnBuilder := range builders {
+			txnBuilder.Drop()
+		}
+		return
+	}
+	if w.deps.Disrupt("ScheduledPaymentsInterrupted") {
+		return
+	}
+
+	w.managedSettleScheduledPayments(keys, pending, builders)
+}
+
+// SchedulePayment adds a scheduled payment to the wallet, returning it with
+// its ID filled in. If the first run of the payment is already due, it is paid
+// right away.
+func (w *Wallet) SchedulePayment(sp modules.ScheduledPayment) (modules.ScheduledPayment, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.ScheduledPayment{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	switch {
+	case sp.Amount.IsZero():
+		return modules.ScheduledPayment{}, errZeroAmount
+	case sp.StartHeight != 0 && sp.StartTime != 0:
+		return modules.ScheduledPayment{}, errScheduleStart
+	case sp.CatchUp != modules.CatchUpAll && sp.CatchUp != modules.CatchUpLatest && sp.CatchUp != modules.CatchUpSkip:
+		return modules.ScheduledPayment{}, errInvalidCatchUp
+	}
+
+	w.mu.Lock()
+	id, err := dbNextScheduledPaymentID(w.dbTx)
+	if err == nil {
+		sp.ID = id
+		sp.Runs = 0
+		sp.LastError = ""
+		err = dbPutScheduledPayment(w.dbTx, sp)
+	}
+	if err == nil {
+		err = w.syncDB()
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return modules.ScheduledPayment{}, err
+	}
+	go w.threadedExecuteScheduledPayments()
+	return sp, nil
+}
+
+// CancelScheduledPayment removes a scheduled payment.
+func (w *Wallet) CancelScheduledPayment(id uint64) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := dbGetScheduledPayment(w.dbTx, id); err != nil {
+		return errUnknownScheduledPayment
+	}
+	if err := dbDeleteScheduledPayment(w.dbTx, id); err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// ScheduledPayments returns the scheduled payments that have not ended yet.
+func (w *Wallet) ScheduledPayments() (payments []modules.ScheduledPayment, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err = dbForEachScheduledPayment(w.dbTx, func(_ uint64, sp modules.ScheduledPayment) {
+		payments = append(payments, sp)
+	})
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].ID < payments[j].ID
+	})
+	return payments, err
+}
+
+// ScheduledPaymentHistory returns the runs of scheduled payments that have
+// been paid or skipped, oldest first.
+func (w *Wallet) ScheduledPaymentHistory() (history []modules.ScheduledPaymentExecution, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err = dbForEachScheduledPaymentExecution(w.dbTx, func(spe modules.ScheduledPaymentExecution) {
+		history = append(history, spe)
+	})
+	return history, err
+}
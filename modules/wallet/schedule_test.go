@@ -0,0 +1,296 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestScheduledRuns probes the scheduledRuns function.
+func TestScheduledRuns(t *testing.T) {
+	tests := []struct {
+		sp        modules.ScheduledPayment
+		height    types.BlockHeight
+		timestamp types.Timestamp
+		due, late uint64
+	}{
+		// Not started yet.
+		{modules.ScheduledPayment{StartHeight: 10, Interval: 5}, 9, 0, 0, 0},
+		// One-off payments run once.
+		{modules.ScheduledPayment{StartHeight: 10}, 10, 0, 1, 0},
+		{modules.ScheduledPayment{StartHeight: 10, MaxCount: 5}, 20, 0, 1, 10},
+		// Repeating payments.
+		{modules.ScheduledPayment{StartHeight: 10, Interval: 5}, 14, 0, 1, 4},
+		{modules.ScheduledPayment{StartHeight: 10, Interval: 5}, 15, 0, 2, 0},
+		{modules.ScheduledPayment{StartHeight: 10, Interval: 5, MaxCount: 3}, 100, 0, 3, 80},
+		// Time-based payments ignore the height.
+		{modules.ScheduledPayment{StartTime: 1000, Interval: 60}, 500, 999, 0, 0},
+		{modules.ScheduledPayment{StartTime: 1000, Interval: 60}, 0, 1130, 3, 10},
+	}
+	for i, test := range tests {
+		due, late := scheduledRuns(test.sp, test.height, test.timestamp)
+		if due != test.due || late != test.late {
+			t.Errorf("%v: expected %v runs %v late, got %v runs %v late", i, test.due, test.late, due, late)
+		}
+	}
+}
+
+// TestScheduledPayments checks that scheduled payments are made as the chain
+// grows, and that runs missed while the wallet was locked follow the
+// catch-up policy of each payment.
+func TestScheduledPayments(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// waitForHistory waits until the history contains n executions.
+	waitForHistory := func(n int) []modules.ScheduledPaymentExecution {
+		var history []modules.ScheduledPaymentExecution
+		err := build.Retry(100, 50*time.Millisecond, func() (err error) {
+			history, err = wt.wallet.ScheduledPaymentHistory()
+			if err == nil && len(history) != n {
+				err = errors.New("wrong history length")
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("expected %v executions, got %v", n, len(history))
+		}
+		return history
+	}
+
+	// Invalid payments are rejected.
+	if _, err := wt.wallet.SchedulePayment(modules.ScheduledPayment{Amount: types.SiacoinPrecision, CatchUp: "never"}); err != errInvalidCatchUp {
+		t.Fatal("expected errInvalidCatchUp, got", err)
+	}
+	if _, err := wt.wallet.SchedulePayment(modules.ScheduledPayment{CatchUp: modules.CatchUpAll}); err != errZeroAmount {
+		t.Fatal("expected errZeroAmount, got", err)
+	}
+
+	// Schedule two payments, two blocks apart.
+	height := wt.cs.Height()
+	sp, err := wt.wallet.SchedulePayment(modules.ScheduledPayment{
+		Destination: types.UnlockHash{1},
+		Amount:      types.SiacoinPrecision,
+		StartHeight: height + 1,
+		Interval:    2,
+		MaxCount:    2,
+		CatchUp:     modules.CatchUpAll,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	history := waitForHistory(1)
+	if history[0].PaymentID != sp.ID || history[0].Skipped || history[0].TransactionID == (types.TransactionID{}) {
+		t.Fatal("first run was not paid:", history[0])
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history = waitForHistory(2)
+	if history[1].Run != 1 || history[1].Skipped {
+		t.Fatal("second run was not paid:", history[1])
+	}
+	if payments, err := wt.wallet.ScheduledPayments(); err != nil || len(payments) != 0 {
+		t.Fatal("payment should have ended after two runs:", payments, err)
+	}
+
+	// Lock the wallet and schedule a payment for each catch-up policy,
+	// starting four blocks ago, as if the wallet had been locked since.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	height = wt.cs.Height()
+	ids := make(map[string]uint64)
+	for _, policy := range []string{modules.CatchUpAll, modules.CatchUpLatest, modules.CatchUpSkip} {
+		interval := uint64(10)
+		if policy == modules.CatchUpAll {
+			interval = 1
+		}
+		sp, err := wt.wallet.SchedulePayment(modules.ScheduledPayment{
+			Destination: types.UnlockHash{2},
+			Amount:      types.SiacoinPrecision,
+			StartHeight: height - 4,
+			Interval:    interval,
+			CatchUp:     policy,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[policy] = sp.ID
+	}
+	wt.wallet.threadedExecuteScheduledPayments()
+	if history, _ := wt.wallet.ScheduledPaymentHistory(); len(history) != 2 {
+		t.Fatal("payments were made while the wallet was locked")
+	}
+
+	// After unlocking, every run of the 'all' payment is paid, the 'latest'
+	// payment pays its only run, and the 'skip' payment skips it because it
+	// is more than the grace period late.
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	history = waitForHistory(2 + 5 + 1 + 1)
+	paid := make(map[uint64]int)
+	skipped := make(map[uint64]int)
+	for _, spe := range history[2:] {
+		if spe.Skipped {
+			skipped[spe.PaymentID]++
+		} else {
+			paid[spe.PaymentID]++
+		}
+	}
+	if paid[ids[modules.CatchUpAll]] != 5 || paid[ids[modules.CatchUpLatest]] != 1 || skipped[ids[modules.CatchUpSkip]] != 1 {
+		t.Fatal("catch-up policies were not followed:", paid, skipped)
+	}
+	// The five runs of the 'all' payment are split over several
+	// transactions.
+	txids := make(map[types.TransactionID]int)
+	for _, spe := range history[2:] {
+		if spe.PaymentID == ids[modules.CatchUpAll] {
+			txids[spe.TransactionID]++
+		}
+	}
+	if len(txids) != 3 {
+		t.Fatal("expected the runs to be paid with 3 transactions, got", len(txids))
+	}
+
+	// Cancelled payments are removed.
+	if err := wt.wallet.CancelScheduledPayment(ids[modules.CatchUpAll]); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.CancelScheduledPayment(ids[modules.CatchUpAll]); err != errUnknownScheduledPayment {
+		t.Fatal("expected errUnknownScheduledPayment, got", err)
+	}
+	if payments, err := wt.wallet.ScheduledPayments(); err != nil || len(payments) != 2 {
+		t.Fatal("expected two remaining payments:", payments, err)
+	}
+}
+
+// TestScheduledPaymentsInterrupted checks that a scheduled payment whose
+// transaction was signed and persisted, but not broadcast, is broadcast the
+// next time scheduled payments are executed, and is not paid twice.
+func TestScheduledPaymentsInterrupted(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	deps := &dependencyScheduledPaymentsInterrupted{}
+	wt, err := createWalletTester(t.Name(), deps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Add a payment that is due directly to the database, so that it is not
+	// executed in the background, and interrupt its execution.
+	wt.wallet.mu.Lock()
+	sp := modules.ScheduledPayment{
+		Destination: types.UnlockHash{1},
+		Amount:      types.SiacoinPrecision,
+		StartHeight: wt.cs.Height(),
+		CatchUp:     modules.CatchUpAll,
+	}
+	sp.ID, err = dbNextScheduledPaymentID(wt.wallet.dbTx)
+	if err == nil {
+		err = dbPutScheduledPayment(wt.wallet.dbTx, sp)
+	}
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps.fail()
+	wt.wallet.threadedExecuteScheduledPayments()
+
+	// The run is recorded as pending rather than paid.
+	wt.wallet.mu.Lock()
+	var pending int
+	dbForEachPendingScheduledPayment(wt.wallet.dbTx, func(uint64, pendingScheduledPayment) {
+		pending++
+	})
+	current, err := dbGetScheduledPayment(wt.wallet.dbTx, sp.ID)
+	wt.wallet.mu.Unlock()
+	if err != nil || current.Runs != 1 || pending != 1 {
+		t.Fatal("run was not recorded as pending:", current, pending, err)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("transaction was broadcast before being recorded")
+	}
+
+	// The next execution broadcasts the transaction and records the run.
+	wt.wallet.threadedExecuteScheduledPayments()
+	history, err := wt.wallet.ScheduledPaymentHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Skipped {
+		t.Fatal("pending run was not paid:", history)
+	}
+	if _, _, exists := wt.tpool.Transaction(history[0].TransactionID); !exists {
+		t.Fatal("pending transaction was not broadcast")
+	}
+	if payments, err := wt.wallet.ScheduledPayments(); err != nil || len(payments) != 0 {
+		t.Fatal("payment should have ended:", payments, err)
+	}
+	wt.wallet.threadedExecuteScheduledPayments()
+	if history, _ := wt.wallet.ScheduledPaymentHistory(); len(history) != 1 {
+		t.Fatal("run was paid twice")
+	}
+}
+
+// TestScheduledPaymentsSkipHistory checks that only the most recent skipped
+// runs are kept in the scheduled payment history.
+func TestScheduledPaymentsSkipHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Schedule two payments that have missed a run every second since 1970.
+	for i := 0; i < 2; i++ {
+		_, err := wt.wallet.SchedulePayment(modules.ScheduledPayment{
+			Destination: types.UnlockHash{1},
+			Amount:      types.SiacoinPrecision,
+			StartTime:   1,
+			Interval:    1,
+			CatchUp:     modules.CatchUpLatest,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wt.wallet.threadedExecuteScheduledPayments()
+
+	history, err := wt.wallet.ScheduledPaymentHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paid, skipped int
+	for _, spe := range history {
+		if spe.Skipped {
+			skipped++
+		} else {
+			paid++
+		}
+	}
+	if paid != 2 || skipped != scheduledPaymentSkipHistory {
+		t.Fatalf("expected 2 paid and %v skipped runs, got %v and %v", scheduledPaymentSkipHistory, paid, skipped)
+	}
+}
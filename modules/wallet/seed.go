@@ -269,7 +269,11 @@ func (w *Wallet) LoadSeed(masterKey crypto.TwofishKey, seed modules.Seed) error
 		w.mu.Lock()
 		defer w.mu.Unlock()
 
-		err := checkMasterKey(w.dbTx, masterKey)
+		masterKey, err := deriveMasterKey(w.dbTx, masterKey)
+		if err != nil {
+			return err
+		}
+		err = checkMasterKey(w.dbTx, masterKey)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errTimelockAddress is returned when creating a time-locked address from
+	// an address that is not a plain address of the wallet.
+	errTimelockAddress = errors.New("time-locked addresses can only be created from addresses of the wallet without a timelock")
+
+	// errTimelockHeight is returned when creating a time-locked address with
+	// an unlock height that has already been reached.
+	errTimelockHeight = errors.New("unlock height must be greater than the current height")
+)
+
+// unlockHeight returns the height at which the outputs of addr become
+// spendable, or zero if addr is not a time-locked address of the wallet.
+func unlockHeight(tx *bolt.Tx, addr types.UnlockHash) types.BlockHeight {
+	uc, err := dbGetTimelockedAddress(tx, addr)
+	if err != nil {
+		return 0
+	}
+	return uc.Timelock
+}
+
+// integrateTimelockedAddresses adds the keys of the time-locked addresses of
+// the wallet to the set of spendable keys. It must be called after the keys
+// of the seeds and the unseeded keys have been loaded.
+func (w *Wallet) integrateTimelockedAddresses() error {
+	return dbForEachTimelockedAddress(w.dbTx, func(addr types.UnlockHash, uc types.UnlockConditions) {
+		base := uc
+		base.Timelock = 0
+		key, exists := w.keys[base.UnlockHash()]
+		if !exists {
+			w.log.Println("WARN: no key found for time-locked address", addr)
+			return
+		}
+		w.keys[addr] = spendableKey{
+			UnlockConditions: uc,
+			SecretKeys:       key.SecretKeys,
+		}
+	})
+}
+
+// TimelockedAddress returns the unlock conditions of an address that can be
+// spent with the key of the wallet address addr once unlockHeight has been
+// reached. The wallet tracks the outputs of the address from then on, and
+// spends them like any other output after the unlock height. The address is
+// assumed to be unused, so the blockchain is not rescanned.
+func (w *Wallet) TimelockedAddress(addr types.UnlockHash, unlockHeight types.BlockHeight) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+
+	key, exists := w.keys[addr]
+	if !exists || key.UnlockConditions.Timelock != 0 {
+		return types.UnlockConditions{}, errTimelockAddress
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if unlockHeight <= height {
+		return types.UnlockConditions{}, errTimelockHeight
+	}
+
+	uc := key.UnlockConditions
+	uc.Timelock = unlockHeight
+	if err := dbPutTimelockedAddress(w.dbTx, uc); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	w.keys[uc.UnlockHash()] = spendableKey{
+		UnlockConditions: uc,
+		SecretKeys:       key.SecretKeys,
+	}
+	return uc, w.syncDB()
+}
+
+// TimelockedBalance returns the confirmed siacoins of the wallet that are held
+// by time-locked addresses whose unlock height has not been reached yet.
+func (w *Wallet) TimelockedBalance() (siacoinBalance types.Currency, err error) {
+	if err := w.tg.Add(); err != nil {
+		return types.ZeroCurrency, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.ZeroCurrency, err
+	}
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if unlockHeight(w.dbTx, sco.UnlockHash) > height {
+			siacoinBalance = siacoinBalance.Add(sco.Value)
+		}
+	})
+	return siacoinBalance, err
+}
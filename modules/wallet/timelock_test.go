@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestTimelockedAddress checks that siacoins sent to a time-locked address of
+// the wallet are tracked as locked until the unlock height, and are spendable
+// afterwards.
+func TestTimelockedAddress(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := wt.cs.Height()
+
+	// Only plain addresses of the wallet can be time-locked, and only with an
+	// unlock height in the future.
+	if _, err := wt.wallet.TimelockedAddress(types.UnlockHash{1}, height+3); err != errTimelockAddress {
+		t.Fatal("expected errTimelockAddress, got", err)
+	}
+	if _, err := wt.wallet.TimelockedAddress(uc.UnlockHash(), height); err != errTimelockHeight {
+		t.Fatal("expected errTimelockHeight, got", err)
+	}
+	tuc, err := wt.wallet.TimelockedAddress(uc.UnlockHash(), height+3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tuc.Timelock != height+3 || tuc.UnlockHash() == uc.UnlockHash() {
+		t.Fatal("wrong unlock conditions:", tuc)
+	}
+	if _, err := wt.wallet.TimelockedAddress(tuc.UnlockHash(), height+5); err != errTimelockAddress {
+		t.Fatal("expected errTimelockAddress, got", err)
+	}
+
+	// Send siacoins to the address.
+	amount := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(amount, tuc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	locked, err := wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !locked.Equals(amount) {
+		t.Fatalf("time-locked balance is %v, expected %v", locked, amount)
+	}
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id types.SiacoinOutputID
+	var total types.Currency
+	for _, o := range outputs {
+		if o.FundType != types.SpecifierSiacoinOutput {
+			continue
+		}
+		if o.UnlockHash == tuc.UnlockHash() {
+			if o.UnlockHeight != tuc.Timelock {
+				t.Fatal("wrong unlock height:", o.UnlockHeight)
+			}
+			id = types.SiacoinOutputID(o.ID)
+			continue
+		}
+		total = total.Add(o.Value)
+	}
+	confirmed, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmed.Equals(total) {
+		t.Fatalf("confirmed balance is %v, expected %v without the time-locked output", confirmed, total)
+	}
+
+	// The output cannot be spent before the unlock height, even if it is
+	// selected explicitly.
+	_, err = wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutput{{Value: types.SiacoinPrecision}}, []types.SiacoinOutputID{id})
+	if err == nil || !strings.Contains(err.Error(), errOutputTimelock.Error()) {
+		t.Fatal("expected errOutputTimelock, got", err)
+	}
+
+	// The address is still spendable after the wallet is locked and unlocked.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	for wt.cs.Height() < tuc.Timelock {
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locked, err = wt.wallet.TimelockedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !locked.IsZero() {
+		t.Fatal("time-locked balance should be zero after the unlock height, got", locked)
+	}
+	txns, err := wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutput{{Value: types.SiacoinPrecision}}, []types.SiacoinOutputID{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txns[0].SiacoinInputs[0].UnlockConditions.UnlockHash() != tuc.UnlockHash() {
+		t.Fatal("time-locked output was not spent")
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		if types.SiacoinOutputID(o.ID) == id {
+			t.Fatal("time-locked output was not spent")
+		}
+	}
+}
@@ -19,15 +19,26 @@ var (
 	// meaning that future calls to Sign will result in an invalid transaction.
 	errBuilderAlreadySigned = errors.New("sign has already been called on this transaction builder, multiple calls can cause issues")
 
+	// errDuplicateOutput indicates that an output was selected more than once
+	// to fund a transaction.
+	errDuplicateOutput = errors.New("output was selected more than once")
+
 	// errDustOutput indicates an output is not spendable because it is dust.
 	errDustOutput = errors.New("output is too small")
 
+	// errOutputLocked indicates an output has been locked by the user.
+	errOutputLocked = errors.New("output is locked")
+
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
 	// errSpendHeightTooHigh indicates an output's spend height is greater than
 	// the allowed height.
 	errSpendHeightTooHigh = errors.New("output spend height exceeds the allowed height")
+
+	// errUnknownOutput indicates that an output selected to fund a
+	// transaction is not a confirmed or unconfirmed output of the wallet.
+	errUnknownOutput = errors.New("output does not belong to the wallet")
 )
 
 // transactionBuilder allows transactions to be manually constructed, including
@@ -108,6 +119,11 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	if currentHeight < outputUnlockConditions.Timelock {
 		return errOutputTimelock
 	}
+	// Check that the output has not been locked by the user. This check comes
+	// last so that explicitly selected outputs can ignore it.
+	if dbIsLockedOutput(tx, types.OutputID(id)) {
+		return errOutputLocked
+	}
 
 	return nil
 }
@@ -117,6 +133,14 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 // correct value. The siacoin input will not be signed until 'Sign' is called
 // on the transaction builder.
 func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
+	return tb.fundSiacoins(amount, nil)
+}
+
+// fundSiacoins adds a siacoin input of exactly 'amount' to the transaction. If
+// inputs is nil, the wallet selects the outputs that fund the transaction.
+// Otherwise, all of the selected outputs are spent, and an error is returned
+// if any of them cannot be used.
+func (tb *transactionBuilder) fundSiacoins(amount types.Currency, inputs []types.SiacoinOutputID) error {
 	// dustThreshold has to be obtained separate from the lock
 	dustThreshold, err := tb.wallet.DustThreshold()
 	if err != nil {
@@ -154,6 +178,31 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	}
 	sort.Sort(sort.Reverse(so))
 
+	// Restrict the outputs to the ones that were selected.
+	if inputs != nil {
+		selected := make(map[types.SiacoinOutputID]int)
+		for i, id := range so.ids {
+			selected[id] = i
+		}
+		seen := make(map[types.SiacoinOutputID]struct{})
+		for _, id := range inputs {
+			if _, exists := seen[id]; exists {
+				return errDuplicateOutput
+			}
+			seen[id] = struct{}{}
+		}
+		var restricted sortedOutputs
+		for _, id := range inputs {
+			i, ok := selected[id]
+			if !ok {
+				return errUnknownOutput
+			}
+			restricted.ids = append(restricted.ids, so.ids[i])
+			restricted.outputs = append(restricted.outputs, so.outputs[i])
+		}
+		so = restricted
+	}
+
 	// Create and fund a parent transaction that will add the correct amount of
 	// siacoins to the transaction.
 	var fund types.Currency
@@ -168,7 +217,10 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 		scoid := so.ids[i]
 		sco := so.outputs[i]
 		// Check that the output can be spent.
-		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
+		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil && !(inputs != nil && err == errOutputLocked) {
+			if inputs != nil {
+				return err
+			}
 			if err == errSpendHeightTooHigh {
 				potentialFund = potentialFund.Add(sco.Value)
 			}
@@ -186,7 +238,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 		// Add the output to the total fund
 		fund = fund.Add(sco.Value)
 		potentialFund = potentialFund.Add(sco.Value)
-		if fund.Cmp(amount) >= 0 {
+		if fund.Cmp(amount) >= 0 && inputs == nil {
 			break
 		}
 	}
@@ -283,6 +335,11 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 			return err
 		}
 
+		// Skip outputs that have been locked by the user.
+		if dbIsLockedOutput(tb.wallet.dbTx, types.OutputID(sfoid)) {
+			continue
+		}
+
 		// Check that this output has not recently been spent by the wallet.
 		spendHeight, err := dbGetSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid))
 		if err != nil {
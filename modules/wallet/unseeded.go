@@ -81,7 +81,8 @@ func (w *Wallet) integrateSpendableKey(masterKey crypto.TwofishKey, sk spendable
 	w.keys[sk.UnlockConditions.UnlockHash()] = sk
 }
 
-// loadSpendableKey loads a spendable key into the wallet database.
+// loadSpendableKey loads a spendable key into the wallet database. masterKey
+// must already have been derived with deriveMasterKey.
 func (w *Wallet) loadSpendableKey(masterKey crypto.TwofishKey, sk spendableKey) error {
 	// Duplication is detected by looking at the set of unlock conditions. If
 	// the wallet is locked, correct deduplication is uncertain.
@@ -164,7 +165,11 @@ func (w *Wallet) loadSiagKeys(masterKey crypto.TwofishKey, keyfiles []string) er
 	for _, skp := range skps {
 		sk.SecretKeys = append(sk.SecretKeys, skp.SecretKey)
 	}
-	err := w.loadSpendableKey(masterKey, sk)
+	masterKey, err := deriveMasterKey(w.dbTx, masterKey)
+	if err != nil {
+		return err
+	}
+	err = w.loadSpendableKey(masterKey, sk)
 	if err != nil {
 		return err
 	}
@@ -239,6 +244,10 @@ func (w *Wallet) Load033xWallet(masterKey crypto.TwofishKey, filepath033x string
 		if err != nil {
 			return err
 		}
+		masterKey, err := deriveMasterKey(w.dbTx, masterKey)
+		if err != nil {
+			return err
+		}
 		var seedsLoaded int
 		for _, savedKey := range savedKeys {
 			spendKey := spendableKey{
@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// UnspentOutputs returns the confirmed siacoin and siafund outputs that the
+// wallet is able to spend, sorted by confirmation height.
+func (w *Wallet) UnspentOutputs() ([]modules.UnspentOutput, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var outputs []modules.UnspentOutput
+	err := dbForEachSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		outputs = append(outputs, modules.UnspentOutput{
+			ID:         types.OutputID(id),
+			FundType:   types.SpecifierSiacoinOutput,
+			UnlockHash: sco.UnlockHash,
+			Value:      sco.Value,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(id types.SiafundOutputID, sfo types.SiafundOutput) {
+		outputs = append(outputs, modules.UnspentOutput{
+			ID:         types.OutputID(id),
+			FundType:   types.SpecifierSiafundOutput,
+			UnlockHash: sfo.UnlockHash,
+			Value:      sfo.Value,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The outputs do not store the height at which they were created, so it
+	// is looked up in the transaction history.
+	indices := make(map[types.OutputID]int)
+	for i := range outputs {
+		outputs[i].Locked = dbIsLockedOutput(w.dbTx, outputs[i].ID)
+		outputs[i].UnlockHeight = unlockHeight(w.dbTx, outputs[i].UnlockHash)
+		indices[outputs[i].ID] = i
+	}
+	it := dbProcessedTransactionsIterator(w.dbTx)
+	for it.next() {
+		pt := it.value()
+		for _, output := range pt.Outputs {
+			if i, ok := indices[output.ID]; ok {
+				outputs[i].ConfirmationHeight = pt.ConfirmationHeight
+			}
+		}
+	}
+
+	sort.Slice(outputs, func(i, j int) bool {
+		if outputs[i].ConfirmationHeight != outputs[j].ConfirmationHeight {
+			return outputs[i].ConfirmationHeight < outputs[j].ConfirmationHeight
+		}
+		return bytes.Compare(outputs[i].ID[:], outputs[j].ID[:]) < 0
+	})
+	return outputs, nil
+}
+
+// LockOutputs prevents the wallet from using the provided outputs to fund
+// transactions, unless they are explicitly selected as inputs. Only confirmed
+// outputs of the wallet can be locked.
+func (w *Wallet) LockOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, id := range ids {
+		_, scoErr := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(id))
+		_, sfoErr := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(id))
+		if scoErr != nil && sfoErr != nil {
+			return errUnknownOutput
+		}
+	}
+	for _, id := range ids {
+		if err := dbPutLockedOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return w.syncDB()
+}
+
+// UnlockOutputs removes the locks placed on outputs by LockOutputs.
+func (w *Wallet) UnlockOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, id := range ids {
+		if err := dbDeleteLockedOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return w.syncDB()
+}
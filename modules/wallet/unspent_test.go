@@ -0,0 +1,98 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestUnspentOutputs checks that the spendable outputs of the wallet are
+// listed, and that locked outputs are only spent when selected explicitly.
+func TestUnspentOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) == 0 {
+		t.Fatal("expected unspent outputs")
+	}
+	var total types.Currency
+	var ids []types.OutputID
+	for _, o := range outputs {
+		if o.FundType != types.SpecifierSiacoinOutput {
+			continue
+		}
+		if o.ConfirmationHeight == 0 || o.Locked {
+			t.Fatal("unexpected output", o)
+		}
+		total = total.Add(o.Value)
+		ids = append(ids, o.ID)
+	}
+	confirmed, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !total.Equals(confirmed) {
+		t.Fatalf("unspent outputs sum to %v, confirmed balance is %v", total, confirmed)
+	}
+
+	// Only outputs of the wallet can be locked.
+	if err := wt.wallet.LockOutputs([]types.OutputID{{1}}); err != errUnknownOutput {
+		t.Fatal("expected errUnknownOutput, got", err)
+	}
+
+	// With every output locked, neither sending nor defragging can use them.
+	if err := wt.wallet.LockOutputs(ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{}); err == nil {
+		t.Fatal("wallet should not fund transactions with locked outputs")
+	}
+	if _, err := wt.wallet.managedCreateDefragTransaction(); err != errDefragNotNeeded {
+		t.Fatal("expected errDefragNotNeeded, got", err)
+	}
+	outputs, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		if o.FundType == types.SpecifierSiacoinOutput && !o.Locked {
+			t.Fatal("output should be locked", o)
+		}
+	}
+
+	// An output can not be selected twice.
+	_, err = wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutput{{Value: types.SiacoinPrecision}}, []types.SiacoinOutputID{types.SiacoinOutputID(ids[0]), types.SiacoinOutputID(ids[0])})
+	if err == nil || !strings.Contains(err.Error(), errDuplicateOutput.Error()) {
+		t.Fatal("expected errDuplicateOutput, got", err)
+	}
+
+	// Locked outputs can still be spent explicitly.
+	txns, err := wt.wallet.SendSiacoinsFromInputs([]types.SiacoinOutput{{Value: types.SiacoinPrecision}}, []types.SiacoinOutputID{types.SiacoinOutputID(ids[0])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := txns[0]
+	if len(parent.SiacoinInputs) != 1 || types.OutputID(parent.SiacoinInputs[0].ParentID) != ids[0] {
+		t.Fatal("transaction was not funded by the selected output")
+	}
+
+	// Unlocking makes the outputs available again.
+	if err := wt.wallet.UnlockOutputs(ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{}); err != nil {
+		t.Fatal(err)
+	}
+}
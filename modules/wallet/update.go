@@ -106,6 +106,20 @@ func (w *Wallet) updateLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool,
 // outputs as understood by the wallet.
 func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) error {
 	for _, diff := range cc.SiacoinOutputDiffs {
+		// Outputs of watch-only addresses are tracked separately.
+		if w.isWatchedAddress(diff.SiacoinOutput.UnlockHash) {
+			var err error
+			if diff.Direction == modules.DiffApply {
+				err = dbPutWatchedSiacoinOutput(tx, diff.ID, diff.SiacoinOutput)
+			} else {
+				err = dbDeleteWatchedSiacoinOutput(tx, diff.ID)
+			}
+			if err != nil {
+				w.log.Severe("Could not update watch-only siacoin output:", err)
+				return err
+			}
+			continue
+		}
 		// Verify that the diff is relevant to the wallet.
 		if !w.isWalletAddress(diff.SiacoinOutput.UnlockHash) {
 			continue
@@ -125,6 +139,20 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 		}
 	}
 	for _, diff := range cc.SiafundOutputDiffs {
+		// Outputs of watch-only addresses are tracked separately.
+		if w.isWatchedAddress(diff.SiafundOutput.UnlockHash) {
+			var err error
+			if diff.Direction == modules.DiffApply {
+				err = dbPutWatchedSiafundOutput(tx, diff.ID, diff.SiafundOutput)
+			} else {
+				err = dbDeleteWatchedSiafundOutput(tx, diff.ID)
+			}
+			if err != nil {
+				w.log.Severe("Could not update watch-only siafund output:", err)
+				return err
+			}
+			continue
+		}
 		// Verify that the diff is relevant to the wallet.
 		if !w.isWalletAddress(diff.SiafundOutput.UnlockHash) {
 			continue
@@ -247,7 +275,7 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 	// Find ProcessedTransactions from miner payouts.
 	relevant := false
 	for _, mp := range block.MinerPayouts {
-		relevant = relevant || w.isWalletAddress(mp.UnlockHash)
+		relevant = relevant || w.isRelevantAddress(mp.UnlockHash)
 	}
 	if relevant {
 		w.log.Println("Wallet has received new miner payouts:", block.ID())
@@ -277,16 +305,16 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 		// Determine if transaction is relevant.
 		relevant := false
 		for _, sci := range txn.SiacoinInputs {
-			relevant = relevant || w.isWalletAddress(sci.UnlockConditions.UnlockHash())
+			relevant = relevant || w.isRelevantAddress(sci.UnlockConditions.UnlockHash())
 		}
 		for _, sco := range txn.SiacoinOutputs {
-			relevant = relevant || w.isWalletAddress(sco.UnlockHash)
+			relevant = relevant || w.isRelevantAddress(sco.UnlockHash)
 		}
 		for _, sfi := range txn.SiafundInputs {
-			relevant = relevant || w.isWalletAddress(sfi.UnlockConditions.UnlockHash())
+			relevant = relevant || w.isRelevantAddress(sfi.UnlockConditions.UnlockHash())
 		}
 		for _, sfo := range txn.SiafundOutputs {
-			relevant = relevant || w.isWalletAddress(sfo.UnlockHash)
+			relevant = relevant || w.isRelevantAddress(sfo.UnlockHash)
 		}
 
 		// Only create a ProcessedTransaction if transaction is relevant.
@@ -405,6 +433,13 @@ func (w *Wallet) applyHistory(tx *bolt.Tx, cc modules.ConsensusChange) error {
 	spentSiacoinOutputs := computeSpentSiacoinOutputSet(cc.SiacoinOutputDiffs)
 	spentSiafundOutputs := computeSpentSiafundOutputSet(cc.SiafundOutputDiffs)
 
+	// A consensus set that was bootstrapped from a snapshot starts at the
+	// snapshot height.
+	if cc.SnapshotHeight != 0 {
+		if err := dbPutConsensusHeight(tx, cc.SnapshotHeight-1); err != nil {
+			return errors.AddContext(err, "failed to store consensus height in database")
+		}
+	}
 	for _, block := range cc.AppliedBlocks {
 		consensusHeight, err := dbGetConsensusHeight(tx)
 		if err != nil {
@@ -467,6 +502,7 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 
 	if cc.Synced {
 		go w.threadedDefragWallet()
+		go w.threadedExecuteScheduledPayments()
 	}
 }
 
@@ -537,10 +573,10 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 			// determine whether transaction is relevant to the wallet
 			relevant := false
 			for _, sci := range txn.SiacoinInputs {
-				relevant = relevant || w.isWalletAddress(sci.UnlockConditions.UnlockHash())
+				relevant = relevant || w.isRelevantAddress(sci.UnlockConditions.UnlockHash())
 			}
 			for _, sco := range txn.SiacoinOutputs {
-				relevant = relevant || w.isWalletAddress(sco.UnlockHash)
+				relevant = relevant || w.isRelevantAddress(sco.UnlockHash)
 			}
 
 			// only create a ProcessedTransaction if txn is relevant
@@ -75,6 +75,11 @@ type Wallet struct {
 	keys      map[types.UnlockHash]spendableKey
 	lookahead map[types.UnlockHash]uint64
 
+	// watchedAddrs are the addresses that the wallet tracks without being
+	// able to spend from them. Their outputs are stored separately from the
+	// spendable outputs, so that they are never used to fund transactions.
+	watchedAddrs map[types.UnlockHash]modules.WatchOnlyAddress
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -104,6 +109,10 @@ type Wallet struct {
 	// initialization.
 	scanLock siasync.TryMutex
 
+	// scheduleMu prevents scheduled payments from being executed
+	// concurrently.
+	scheduleMu sync.Mutex
+
 	// The wallet's ThreadGroup tells tracked functions to shut down and
 	// blocks until they have all exited before returning from Close.
 	tg threadgroup.ThreadGroup
@@ -156,8 +165,9 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		cs:    cs,
 		tpool: tpool,
 
-		keys:      make(map[types.UnlockHash]spendableKey),
-		lookahead: make(map[types.UnlockHash]uint64),
+		keys:         make(map[types.UnlockHash]spendableKey),
+		lookahead:    make(map[types.UnlockHash]uint64),
+		watchedAddrs: make(map[types.UnlockHash]modules.WatchOnlyAddress),
 
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
@@ -188,6 +198,11 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 			return nil, err
 		}
 	}
+
+	// Load the watch-only addresses.
+	if err = w.loadWatchedAddresses(); err != nil {
+		return nil, err
+	}
 	return w, nil
 }
 
@@ -0,0 +1,227 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errWatchAddressMismatch is returned if the unlock conditions of a
+	// watch-only address do not hash to the address.
+	errWatchAddressMismatch = errors.New("unlock conditions do not match the watch-only address")
+
+	// errWatchSpendableAddress is returned when trying to watch an address
+	// that the wallet is already able to spend from.
+	errWatchSpendableAddress = errors.New("address is already spendable by the wallet")
+
+	// errUnknownWatchAddress is returned when trying to remove an address
+	// that is not being watched.
+	errUnknownWatchAddress = errors.New("address is not being watched")
+)
+
+// isWatchedAddress is a helper function that checks if an UnlockHash is one
+// of the wallet's watch-only addresses.
+func (w *Wallet) isWatchedAddress(uh types.UnlockHash) bool {
+	_, exists := w.watchedAddrs[uh]
+	return exists
+}
+
+// isRelevantAddress is a helper function that checks if an UnlockHash is
+// either spendable by the wallet or watched by it.
+func (w *Wallet) isRelevantAddress(uh types.UnlockHash) bool {
+	return w.isWalletAddress(uh) || w.isWatchedAddress(uh)
+}
+
+// loadWatchedAddresses loads the watch-only addresses from the database into
+// memory.
+func (w *Wallet) loadWatchedAddresses() error {
+	return dbForEachWatchedAddress(w.dbTx, func(addr types.UnlockHash, woa modules.WatchOnlyAddress) {
+		w.watchedAddrs[addr] = woa
+	})
+}
+
+// AddWatchAddresses adds a set of watch-only addresses to the wallet. Unless
+// unused is set, the blockchain is rescanned so that existing outputs of the
+// addresses are found. The wallet must be unlocked, so that its own addresses
+// are recognized and rejected.
+func (w *Wallet) AddWatchAddresses(addrs []modules.WatchOnlyAddress, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+
+		// Validate all addresses before adding any of them.
+		for _, addr := range addrs {
+			if addr.UnlockConditions != nil && addr.UnlockConditions.UnlockHash() != addr.Address {
+				return errWatchAddressMismatch
+			}
+			if w.isWalletAddress(addr.Address) {
+				return errWatchSpendableAddress
+			}
+		}
+		for _, addr := range addrs {
+			if err := dbPutWatchedAddress(w.dbTx, addr); err != nil {
+				return err
+			}
+			w.watchedAddrs[addr.Address] = addr
+		}
+		if unused {
+			return w.syncDB()
+		}
+
+		// Reset the transaction history and the consensus change ID in
+		// preparation for a rescan.
+		for _, bucket := range [][]byte{bucketProcessedTransactions, bucketProcessedTxnIndex, bucketAddrTransactions} {
+			if err := w.dbTx.DeleteBucket(bucket); err != nil {
+				return err
+			}
+			if _, err := w.dbTx.CreateBucket(bucket); err != nil {
+				return err
+			}
+		}
+		w.unconfirmedProcessedTransactions = nil
+		if err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning); err != nil {
+			return err
+		}
+		return dbPutConsensusHeight(w.dbTx, 0)
+	}()
+	if err != nil || unused {
+		return err
+	}
+
+	// If the wallet has not subscribed to the consensus set yet, the rescan
+	// happens when the wallet is unlocked.
+	w.mu.RLock()
+	subscribed := w.subscribed
+	w.mu.RUnlock()
+	if !subscribed {
+		return nil
+	}
+
+	// rescan the blockchain
+	w.cs.Unsubscribe(w)
+	w.tpool.Unsubscribe(w)
+
+	done := make(chan struct{})
+	go w.rescanMessage(done)
+	defer close(done)
+
+	err = w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan())
+	if err != nil {
+		return err
+	}
+	w.tpool.TransactionPoolSubscribe(w)
+	return nil
+}
+
+// RemoveWatchAddresses stops tracking a set of watch-only addresses and
+// forgets their outputs. Transactions that were already recorded remain in the
+// wallet history.
+func (w *Wallet) RemoveWatchAddresses(addrs []types.UnlockHash) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	removed := make(map[types.UnlockHash]struct{})
+	for _, addr := range addrs {
+		if !w.isWatchedAddress(addr) {
+			return errUnknownWatchAddress
+		}
+		removed[addr] = struct{}{}
+	}
+	for addr := range removed {
+		if err := dbDeleteWatchedAddress(w.dbTx, addr); err != nil {
+			return err
+		}
+		delete(w.watchedAddrs, addr)
+	}
+
+	// Delete the outputs of the removed addresses. The outputs are collected
+	// first because bolt does not allow modifying a bucket while iterating
+	// over it.
+	var scoids []types.SiacoinOutputID
+	var sfoids []types.SiafundOutputID
+	err := dbForEachWatchedSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, ok := removed[sco.UnlockHash]; ok {
+			scoids = append(scoids, id)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	err = dbForEachWatchedSiafundOutput(w.dbTx, func(id types.SiafundOutputID, sfo types.SiafundOutput) {
+		if _, ok := removed[sfo.UnlockHash]; ok {
+			sfoids = append(sfoids, id)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range scoids {
+		if err := dbDeleteWatchedSiacoinOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range sfoids {
+		if err := dbDeleteWatchedSiafundOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return w.syncDB()
+}
+
+// WatchAddresses returns the watch-only addresses of the wallet, sorted in
+// byte-order.
+func (w *Wallet) WatchAddresses() ([]modules.WatchOnlyAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	addrs := make([]modules.WatchOnlyAddress, 0, len(w.watchedAddrs))
+	for _, addr := range w.watchedAddrs {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Address[:], addrs[j].Address[:]) < 0
+	})
+	return addrs, nil
+}
+
+// WatchOnlyBalance returns the confirmed balance of the watch-only addresses
+// of the wallet.
+func (w *Wallet) WatchOnlyBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, err error) {
+	if err := w.tg.Add(); err != nil {
+		return types.ZeroCurrency, types.ZeroCurrency, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err = dbForEachWatchedSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		siacoinBalance = siacoinBalance.Add(sco.Value)
+	})
+	if err != nil {
+		return
+	}
+	err = dbForEachWatchedSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		siafundBalance = siafundBalance.Add(sfo.Value)
+	})
+	return
+}
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// watchOnlyUnlockConditions returns unlock conditions for a random key that
+// the wallet does not know.
+func watchOnlyUnlockConditions() types.UnlockConditions {
+	_, pk := crypto.GenerateKeyPair()
+	return types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+}
+
+// TestWatchOnlyAddresses checks that the outputs of watch-only addresses are
+// tracked and reported separately from the spendable balance, including
+// outputs found by rescanning the blockchain.
+func TestWatchOnlyAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// The wallet's own addresses cannot be watched.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.wallet.AddWatchAddresses([]modules.WatchOnlyAddress{{Address: uc.UnlockHash()}}, true)
+	if err != errWatchSpendableAddress {
+		t.Fatal("expected errWatchSpendableAddress, got", err)
+	}
+	// While the wallet is locked, its own addresses can not be recognized,
+	// so no addresses can be watched.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	err = wt.wallet.AddWatchAddresses([]modules.WatchOnlyAddress{{Address: uc.UnlockHash()}}, true)
+	if err != modules.ErrLockedWallet {
+		t.Fatal("expected ErrLockedWallet, got", err)
+	}
+	if addrs, err := wt.wallet.WatchAddresses(); err != nil || len(addrs) != 0 {
+		t.Fatal("address was watched while the wallet was locked:", addrs, err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Watch an unused address, then send coins to it.
+	watchedUC := watchOnlyUnlockConditions()
+	watched := watchedUC.UnlockHash()
+	err = wt.wallet.AddWatchAddresses([]modules.WatchOnlyAddress{{Address: watched, UnlockConditions: &watchedUC}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendValue := types.SiacoinPrecision.Mul64(10)
+	_, err = wt.wallet.SendSiacoins(sendValue, watched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Send coins to a second address before it is watched.
+	unwatched := watchOnlyUnlockConditions().UnlockHash()
+	_, err = wt.wallet.SendSiacoins(sendValue, unwatched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	siacoins, _, err := wt.wallet.WatchOnlyBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !siacoins.Equals(sendValue) {
+		t.Fatalf("expected watch-only balance of %v, got %v", sendValue, siacoins)
+	}
+	txns, err := wt.wallet.AddressTransactions(watched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) == 0 {
+		t.Fatal("transaction of the watch-only address is missing from the history")
+	}
+
+	// Watching the second address should find its output by rescanning.
+	err = wt.wallet.AddWatchAddresses([]modules.WatchOnlyAddress{{Address: unwatched}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siacoins, _, err = wt.wallet.WatchOnlyBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !siacoins.Equals(sendValue.Mul64(2)) {
+		t.Fatalf("expected watch-only balance of %v, got %v", sendValue.Mul64(2), siacoins)
+	}
+	addrs, err := wt.wallet.WatchAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 {
+		t.Fatal("expected 2 watch-only addresses, got", len(addrs))
+	}
+
+	// The watch-only outputs must never be used to fund transactions. Sending
+	// the entire spendable balance leaves them untouched.
+	confirmed, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.wallet.SendSiacoins(confirmed, types.UnlockHash{})
+	if err == nil {
+		t.Fatal("wallet should not be able to cover the fee without the watch-only outputs")
+	}
+
+	// Removing an address forgets its outputs.
+	if err := wt.wallet.RemoveWatchAddresses([]types.UnlockHash{watched}); err != nil {
+		t.Fatal(err)
+	}
+	siacoins, _, err = wt.wallet.WatchOnlyBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !siacoins.Equals(sendValue) {
+		t.Fatalf("expected watch-only balance of %v, got %v", sendValue, siacoins)
+	}
+	if err := wt.wallet.RemoveWatchAddresses([]types.UnlockHash{watched}); err != errUnknownWatchAddress {
+		t.Fatal("expected errUnknownWatchAddress, got", err)
+	}
+}
@@ -1,10 +1,16 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/errors"
 )
 
 // ConsensusGet requests the /consensus api resource
@@ -24,3 +30,80 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	err = c.get("/consensus/blocks?height="+fmt.Sprint(height), &cbg)
 	return
 }
+
+// ConsensusFileContractsGet requests the /consensus/filecontracts/:id api
+// resource
+func (c *Client) ConsensusFileContractsGet(id types.FileContractID) (cfcg api.ConsensusFileContractGET, err error) {
+	err = c.get("/consensus/filecontracts/"+id.String(), &cfcg)
+	return
+}
+
+// ConsensusProofGet requests the /consensus/proof/:txid api resource for the
+// transaction with the given id in the block with the given id.
+func (c *Client) ConsensusProofGet(blockID types.BlockID, txid types.TransactionID) (ctpg api.ConsensusTransactionProofGET, err error) {
+	values := url.Values{}
+	values.Set("blockid", blockID.String())
+	err = c.get("/consensus/proof/"+txid.String()+"?"+values.Encode(), &ctpg)
+	return
+}
+
+// ConsensusSiacoinOutputsGet requests the /consensus/siacoinoutputs/:id api
+// resource
+func (c *Client) ConsensusSiacoinOutputsGet(id types.SiacoinOutputID) (csog api.ConsensusSiacoinOutputGET, err error) {
+	err = c.get("/consensus/siacoinoutputs/"+id.String(), &csog)
+	return
+}
+
+// ConsensusSiafundOutputsGet requests the /consensus/siafundoutputs/:id api
+// resource
+func (c *Client) ConsensusSiafundOutputsGet(id types.SiafundOutputID) (csog api.ConsensusSiafundOutputGET, err error) {
+	err = c.get("/consensus/siafundoutputs/"+id.String(), &csog)
+	return
+}
+
+// ConsensusSnapshotGet uses the /consensus/snapshot endpoint to write a
+// snapshot of the consensus set at the given height to destination.
+func (c *Client) ConsensusSnapshotGet(destination string, height types.BlockHeight) (csg api.ConsensusSnapshotGET, err error) {
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("height", fmt.Sprint(height))
+	err = c.get("/consensus/snapshot?"+values.Encode(), &csg)
+	return
+}
+
+// ConsensusSubscribeGet streams the consensus changes that follow the change
+// with the given id from the /consensus/subscribe endpoint, and calls fn for
+// each of them. Streaming continues until fn returns an error or the
+// connection is closed, and the error that ended the stream is returned.
+// Streaming can be resumed by passing the id of the last change that fn
+// processed.
+func (c *Client) ConsensusSubscribeGet(start modules.ConsensusChangeID, fn func(api.ConsensusChangeGET) error) error {
+	resource := "/consensus/subscribe/" + crypto.Hash(start).String()
+	req, err := c.NewRequest("GET", resource, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.AddContext(err, "request failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errors.New("API call not recognized: " + resource)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return readAPIError(res.Body)
+	}
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		var cc api.ConsensusChangeGET
+		if err := dec.Decode(&cc); err != nil {
+			return errors.AddContext(err, "could not read consensus change")
+		}
+		if err := fn(cc); err != nil {
+			return err
+		}
+	}
+}
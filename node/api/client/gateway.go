@@ -1,6 +1,9 @@
 package client
 
 import (
+	"net/url"
+	"time"
+
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/errors"
@@ -35,3 +38,32 @@ func (c *Client) GatewayGet() (gwg api.GatewayGET, err error) {
 	err = c.get("/gateway", &gwg)
 	return
 }
+
+// GatewayBansGet requests the /gateway/bans api resource
+func (c *Client) GatewayBansGet() (gbg api.GatewayBansGET, err error) {
+	err = c.get("/gateway/bans", &gbg)
+	return
+}
+
+// GatewayBanPost uses the /gateway/bans endpoint to ban an IP address or a
+// subnet in CIDR notation. A duration of 0 uses the default ban duration of
+// the gateway.
+func (c *Client) GatewayBanPost(host string, duration time.Duration, reason string) (err error) {
+	values := url.Values{}
+	values.Set("host", host)
+	if duration != 0 {
+		values.Set("duration", duration.String())
+	}
+	values.Set("reason", reason)
+	err = c.post("/gateway/bans", values.Encode(), nil)
+	return
+}
+
+// GatewayUnbanPost uses the /gateway/bans/remove endpoint to lift the ban of
+// an IP address or subnet.
+func (c *Client) GatewayUnbanPost(host string) (err error) {
+	values := url.Values{}
+	values.Set("host", host)
+	err = c.post("/gateway/bans/remove", values.Encode(), nil)
+	return
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // HostParam is a parameter in the host's settings that can be changed via the
@@ -36,6 +38,9 @@ const (
 	// HostParamAcceptingContracts indicates if the host is accepting new
 	// contracts.
 	HostParamAcceptingContracts = HostParam("acceptingcontracts")
+	// HostParamMaintenanceMode indicates if the host is in maintenance mode,
+	// refusing new contracts and renewals.
+	HostParamMaintenanceMode = HostParam("maintenancemode")
 	// HostParamMaxDuration is the max duration of a contract in blocks.
 	HostParamMaxDuration = HostParam("maxduration")
 	// HostParamWindowSize is the size of the proof window in blocks.
@@ -70,6 +75,38 @@ func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
 	return
 }
 
+// HostContractInfoFilteredGet uses the /host/contracts endpoint to get
+// information about the contracts on the host that match the provided status
+// and expiration range. A maxExpiration of zero leaves the range unbounded,
+// and a limit of zero returns all contracts after the offset.
+func (c *Client) HostContractInfoFilteredGet(status string, minExpiration, maxExpiration types.BlockHeight, offset, limit int) (cg api.ContractInfoGET, err error) {
+	values := url.Values{}
+	if status != "" {
+		values.Set("status", status)
+	}
+	values.Set("minexpiration", fmt.Sprint(minExpiration))
+	if maxExpiration != 0 {
+		values.Set("maxexpiration", fmt.Sprint(maxExpiration))
+	}
+	values.Set("offset", strconv.Itoa(offset))
+	values.Set("limit", strconv.Itoa(limit))
+	err = c.get("/host/contracts?"+values.Encode(), &cg)
+	return
+}
+
+// HostContractGet uses the /host/contracts/:id endpoint to get the full
+// details of a contract on the host.
+func (c *Client) HostContractGet(id types.FileContractID) (hcg api.HostContractGET, err error) {
+	err = c.get("/host/contracts/"+id.String(), &hcg)
+	return
+}
+
+// HostAlertsGet requests the /host/alerts endpoint.
+func (c *Client) HostAlertsGet() (hag api.HostAlertsGET, err error) {
+	err = c.get("/host/alerts", &hag)
+	return
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -82,6 +119,41 @@ func (c *Client) HostGet() (hg api.HostGET, err error) {
 	return
 }
 
+// HostMetricsGet requests the /host/metrics endpoint. start and end are unix
+// timestamps, and granularity is either "hour", "day", "week" or a number of
+// seconds. Empty values are omitted from the request.
+func (c *Client) HostMetricsGet(start, end, granularity string) (hmg api.HostMetricsGET, err error) {
+	values := url.Values{}
+	if start != "" {
+		values.Set("start", start)
+	}
+	if end != "" {
+		values.Set("end", end)
+	}
+	if granularity != "" {
+		values.Set("granularity", granularity)
+	}
+	err = c.get("/host/metrics?"+values.Encode(), &hmg)
+	return
+}
+
+// HostMaintenanceGet requests the /host/maintenance endpoint.
+func (c *Client) HostMaintenanceGet() (hmg api.HostMaintenanceGET, err error) {
+	err = c.get("/host/maintenance", &hmg)
+	return
+}
+
+// HostMaintenancePost uses the /host/maintenance endpoint to enable or
+// disable the maintenance mode of the host, optionally announcing the host
+// afterwards.
+func (c *Client) HostMaintenancePost(enabled, announce bool) (err error) {
+	values := url.Values{}
+	values.Set("enabled", strconv.FormatBool(enabled))
+	values.Set("announce", strconv.FormatBool(announce))
+	err = c.post("/host/maintenance", values.Encode(), nil)
+	return
+}
+
 // HostModifySettingPost uses the /host endpoint to change a param of the host
 // settings to a certain value.
 func (c *Client) HostModifySettingPost(param HostParam, value interface{}) (err error) {
@@ -99,6 +171,29 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersMigratePost uses the /host/storage/folders/migrate api
+// endpoint to start migrating the sectors of a storage folder into the
+// destination folders.
+func (c *Client) HostStorageFoldersMigratePost(path string, destinations []string) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	if len(destinations) > 0 {
+		values.Set("destinations", strings.Join(destinations, ","))
+	}
+	err = c.post("/host/storage/folders/migrate", values.Encode(), nil)
+	return
+}
+
+// HostStorageFoldersMigrateCancelPost uses the
+// /host/storage/folders/migrate/cancel api endpoint to cancel the migration
+// of a storage folder.
+func (c *Client) HostStorageFoldersMigrateCancelPost(path string) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	err = c.post("/host/storage/folders/migrate/cancel", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersRemovePost uses the /host/storage/folders/remove api
 // endpoint to remove a storage folder from a host.
 func (c *Client) HostStorageFoldersRemovePost(path string) (err error) {
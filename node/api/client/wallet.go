@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strconv"
 
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -16,6 +17,13 @@ func (c *Client) WalletAddressGet() (wag api.WalletAddressGET, err error) {
 	return
 }
 
+// WalletAddressTimelockedGet requests a new address from the /wallet/address
+// endpoint whose outputs cannot be spent before unlockHeight.
+func (c *Client) WalletAddressTimelockedGet(unlockHeight types.BlockHeight) (wag api.WalletAddressGET, err error) {
+	err = c.get(fmt.Sprintf("/wallet/address?unlockheight=%v", unlockHeight), &wag)
+	return
+}
+
 // WalletAddressesGet requests the wallets known addresses from the
 // /wallet/addresses endpoint.
 func (c *Client) WalletAddressesGet() (wag api.WalletAddressesGET, err error) {
@@ -33,6 +41,25 @@ func (c *Client) WalletChangePasswordPost(currentPassword, newPassword string) (
 	return
 }
 
+// WalletBumpPost uses the /wallet/bump/:id endpoint to increase the fee of
+// an unconfirmed transaction. A zero fee uses the recommended fee.
+func (c *Client) WalletBumpPost(txid types.TransactionID, fee types.Currency, replace bool) (wbp api.WalletBumpPOST, err error) {
+	values := url.Values{}
+	if !fee.IsZero() {
+		values.Set("fee", fee.String())
+	}
+	values.Set("replace", strconv.FormatBool(replace))
+	err = c.post("/wallet/bump/"+txid.String(), values.Encode(), &wbp)
+	return
+}
+
+// WalletExportGet requests the /wallet/export endpoint, returning the
+// classified transaction history at heights [startHeight, endHeight].
+func (c *Client) WalletExportGet(startHeight, endHeight types.BlockHeight) (weg api.WalletExportGET, err error) {
+	err = c.get(fmt.Sprintf("/wallet/export?startheight=%v&endheight=%v", startHeight, endHeight), &weg)
+	return
+}
+
 // WalletInitPost uses the /wallet/init endpoint to initialize and encrypt a
 // wallet
 func (c *Client) WalletInitPost(password string, force bool) (wip api.WalletInitPOST, err error) {
@@ -66,6 +93,41 @@ func (c *Client) WalletLockPost() (err error) {
 	return
 }
 
+// WalletScheduleGet requests the /wallet/schedule endpoint, returning the
+// wallet's scheduled payments.
+func (c *Client) WalletScheduleGet() (wsg api.WalletScheduleGET, err error) {
+	err = c.get("/wallet/schedule", &wsg)
+	return
+}
+
+// WalletSchedulePost uses the /wallet/schedule endpoint to schedule a payment.
+func (c *Client) WalletSchedulePost(sp modules.ScheduledPayment) (wsp api.WalletSchedulePOST, err error) {
+	values := url.Values{}
+	values.Set("destination", sp.Destination.String())
+	values.Set("amount", sp.Amount.String())
+	values.Set("startheight", fmt.Sprint(sp.StartHeight))
+	values.Set("starttime", fmt.Sprint(sp.StartTime))
+	values.Set("interval", fmt.Sprint(sp.Interval))
+	values.Set("maxcount", fmt.Sprint(sp.MaxCount))
+	values.Set("catchup", sp.CatchUp)
+	err = c.post("/wallet/schedule", values.Encode(), &wsp)
+	return
+}
+
+// WalletScheduleCancelPost uses the /wallet/schedule/cancel/:id endpoint to
+// cancel a scheduled payment.
+func (c *Client) WalletScheduleCancelPost(id uint64) (err error) {
+	err = c.post(fmt.Sprintf("/wallet/schedule/cancel/%v", id), "", nil)
+	return
+}
+
+// WalletScheduleHistoryGet requests the /wallet/schedule/history endpoint,
+// returning the runs of scheduled payments that have been paid or skipped.
+func (c *Client) WalletScheduleHistoryGet() (wshg api.WalletScheduleHistoryGET, err error) {
+	err = c.get("/wallet/schedule/history", &wshg)
+	return
+}
+
 // WalletSeedPost uses the /wallet/seed endpoint to add a seed to the wallet's list
 // of seeds.
 func (c *Client) WalletSeedPost(seed, password string) (err error) {
@@ -96,6 +158,24 @@ func (c *Client) WalletSiacoinsMultiPost(outputs []types.SiacoinOutput) (wsp api
 	return
 }
 
+// WalletSiacoinsInputsPost uses the /wallet/siacoins api endpoint to send
+// money to multiple addresses, funded by exactly the provided wallet outputs.
+func (c *Client) WalletSiacoinsInputsPost(outputs []types.SiacoinOutput, inputs []types.SiacoinOutputID) (wsp api.WalletSiacoinsPOST, err error) {
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	marshaledInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("outputs", string(marshaledOutputs))
+	values.Set("inputs", string(marshaledInputs))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
 // WalletSiacoinsPost uses the /wallet/siacoins api endpoint to send money to a
 // single address
 func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiacoinsPOST, err error) {
@@ -106,6 +186,51 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiacoinsNotePost uses the /wallet/siacoins api endpoint to send money
+// to a single address, attaching a note to the transaction and a label to the
+// destination address.
+func (c *Client) WalletSiacoinsNotePost(amount types.Currency, destination types.UnlockHash, note modules.TransactionNote, label string) (wsp api.WalletSiacoinsPOST, err error) {
+	values, err := transactionNoteValues(note)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	values.Set("label", label)
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// WalletSiacoinsTimelockedPost uses the /wallet/siacoins api endpoint to send
+// money to a time-locked address derived from destination, which must be an
+// address of the wallet. The money cannot be spent before unlockHeight.
+func (c *Client) WalletSiacoinsTimelockedPost(amount types.Currency, destination types.UnlockHash, unlockHeight types.BlockHeight, note modules.TransactionNote, label string) (wsp api.WalletSiacoinsPOST, err error) {
+	values, err := transactionNoteValues(note)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	values.Set("unlockheight", fmt.Sprint(unlockHeight))
+	values.Set("label", label)
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// transactionNoteValues encodes a transaction note as query string values.
+func transactionNoteValues(note modules.TransactionNote) (url.Values, error) {
+	values := url.Values{}
+	values.Set("note", note.Note)
+	if len(note.Tags) != 0 {
+		marshaledTags, err := json.Marshal(note.Tags)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("tags", string(marshaledTags))
+	}
+	return values, nil
+}
+
 // WalletSiafundsPost uses the /wallet/siafunds api endpoint to send siafunds
 // to a single address.
 func (c *Client) WalletSiafundsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiafundsPOST, err error) {
@@ -146,7 +271,36 @@ func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight
 // WalletTransactionGet requests the /wallet/transaction/:id api resource for a
 // certain TransactionID.
 func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTransactionGETid, err error) {
-	err = c.get("/wallet/transaction/"+id.String(), wtg)
+	err = c.get("/wallet/transaction/"+id.String(), &wtg)
+	return
+}
+
+// WalletTransactionNotePost uses the /wallet/transaction/:id endpoint to
+// attach a note and tags to a transaction.
+func (c *Client) WalletTransactionNotePost(id types.TransactionID, note modules.TransactionNote) (err error) {
+	values, err := transactionNoteValues(note)
+	if err != nil {
+		return err
+	}
+	err = c.post("/wallet/transaction/"+id.String(), values.Encode(), nil)
+	return
+}
+
+// WalletTransactionsLabelGet requests the /wallet/transactions api resource
+// for a certain startheight and endheight, returning only the transactions
+// with the provided label.
+func (c *Client) WalletTransactionsLabelGet(startHeight types.BlockHeight, endHeight types.BlockHeight, label string) (wtg api.WalletTransactionsGET, err error) {
+	err = c.get(fmt.Sprintf("/wallet/transactions?startheight=%v&endheight=%v&label=%v",
+		startHeight, endHeight, url.QueryEscape(label)), &wtg)
+	return
+}
+
+// WalletLabelPost uses the /wallet/label/:addr endpoint to assign a label to
+// an address. An empty label removes the address's label.
+func (c *Client) WalletLabelPost(addr types.UnlockHash, label string) (err error) {
+	values := url.Values{}
+	values.Set("label", label)
+	err = c.post("/wallet/label/"+addr.String(), values.Encode(), nil)
 	return
 }
 
@@ -168,3 +322,131 @@ func (c *Client) Wallet033xPost(path, password string) (err error) {
 	err = c.post("/wallet/033x", values.Encode(), nil)
 	return
 }
+
+// WalletMultisigPost uses the /wallet/multisig endpoint to add a multisig
+// address that requires signaturesRequired signatures from publicKeys.
+func (c *Client) WalletMultisigPost(publicKeys []types.SiaPublicKey, signaturesRequired uint64, unused bool) (wmp api.WalletMultisigPOST, err error) {
+	marshaledKeys, err := json.Marshal(publicKeys)
+	if err != nil {
+		return api.WalletMultisigPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("publickeys", string(marshaledKeys))
+	values.Set("signaturesrequired", strconv.FormatUint(signaturesRequired, 10))
+	values.Set("unused", strconv.FormatBool(unused))
+	err = c.post("/wallet/multisig", values.Encode(), &wmp)
+	return
+}
+
+// WalletPublicKeyGet requests the /wallet/publickey endpoint to get a new
+// public key of the wallet, for use in a multisig address.
+func (c *Client) WalletPublicKeyGet() (wpkg api.WalletPublicKeyGET, err error) {
+	err = c.get("/wallet/publickey", &wpkg)
+	return
+}
+
+// WalletSignPost uses the /wallet/sign endpoint to add the wallet's
+// signatures to an unsigned transaction.
+func (c *Client) WalletSignPost(utxn modules.UnsignedTransaction) (wsp api.WalletSignPOST, err error) {
+	marshaledTxn, err := json.Marshal(utxn)
+	if err != nil {
+		return api.WalletSignPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("transaction", string(marshaledTxn))
+	err = c.post("/wallet/sign", values.Encode(), &wsp)
+	return
+}
+
+// WalletUnsignedTransactionPost uses the /wallet/unsignedtransaction endpoint
+// to create an unsigned transaction that sends the provided outputs using the
+// wallet's watch-only addresses.
+func (c *Client) WalletUnsignedTransactionPost(outputs []types.SiacoinOutput) (wutp api.WalletUnsignedTransactionPOST, err error) {
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletUnsignedTransactionPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("outputs", string(marshaledOutputs))
+	err = c.post("/wallet/unsignedtransaction", values.Encode(), &wutp)
+	return
+}
+
+// WalletUnspentGet requests the /wallet/unspent endpoint to get the spendable
+// outputs of the wallet.
+func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
+	err = c.get("/wallet/unspent", &wug)
+	return
+}
+
+// WalletUnspentLockPost uses the /wallet/unspent/lock endpoint to prevent the
+// wallet from funding transactions with the provided outputs.
+func (c *Client) WalletUnspentLockPost(ids []types.OutputID) (err error) {
+	return c.walletUnspentLockPost("/wallet/unspent/lock", ids)
+}
+
+// WalletUnspentUnlockPost uses the /wallet/unspent/unlock endpoint to remove
+// the locks on the provided outputs.
+func (c *Client) WalletUnspentUnlockPost(ids []types.OutputID) (err error) {
+	return c.walletUnspentLockPost("/wallet/unspent/unlock", ids)
+}
+
+// walletUnspentLockPost is a helper for locking and unlocking outputs.
+func (c *Client) walletUnspentLockPost(resource string, ids []types.OutputID) error {
+	marshaledIDs, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("outputids", string(marshaledIDs))
+	return c.post(resource, values.Encode(), nil)
+}
+
+// WalletWatchGet requests the /wallet/watch endpoint to get the watch-only
+// addresses of the wallet.
+func (c *Client) WalletWatchGet() (wwg api.WalletWatchGET, err error) {
+	err = c.get("/wallet/watch", &wwg)
+	return
+}
+
+// WalletWatchAddPost uses the /wallet/watch endpoint to add watch-only
+// addresses to the wallet. If unused is set, the blockchain is not rescanned.
+func (c *Client) WalletWatchAddPost(addrs []types.UnlockHash, unused bool) (err error) {
+	return c.walletWatchPost(addrs, nil, false, unused)
+}
+
+// WalletWatchUnlockConditionsPost uses the /wallet/watch endpoint to add
+// watch-only addresses to the wallet by their unlock conditions. Transactions
+// spending from these addresses can be created with
+// WalletUnsignedTransactionPost.
+func (c *Client) WalletWatchUnlockConditionsPost(ucs []types.UnlockConditions, unused bool) (err error) {
+	return c.walletWatchPost(nil, ucs, false, unused)
+}
+
+// WalletWatchRemovePost uses the /wallet/watch endpoint to remove watch-only
+// addresses from the wallet.
+func (c *Client) WalletWatchRemovePost(addrs []types.UnlockHash) (err error) {
+	return c.walletWatchPost(addrs, nil, true, false)
+}
+
+// walletWatchPost is a helper for adding and removing watch-only addresses.
+func (c *Client) walletWatchPost(addrs []types.UnlockHash, ucs []types.UnlockConditions, remove, unused bool) error {
+	values := url.Values{}
+	if len(addrs) > 0 {
+		marshaledAddrs, err := json.Marshal(addrs)
+		if err != nil {
+			return err
+		}
+		values.Set("addresses", string(marshaledAddrs))
+	}
+	if len(ucs) > 0 {
+		marshaledUCs, err := json.Marshal(ucs)
+		if err != nil {
+			return err
+		}
+		values.Set("unlockconditions", string(marshaledUCs))
+	}
+	values.Set("remove", strconv.FormatBool(remove))
+	values.Set("unused", strconv.FormatBool(unused))
+	return c.post("/wallet/watch", values.Encode(), nil)
+}
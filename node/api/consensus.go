@@ -4,13 +4,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"sync"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
 )
 
+const (
+	// consensusSubscribeBatchSize is the maximum number of consensus changes
+	// that /consensus/subscribe buffers before they are written to the
+	// client. Clients that fall further behind are caught up from the change
+	// log in batches of this size.
+	consensusSubscribeBatchSize = 100
+)
+
 // ConsensusGET contains general information about the consensus set, with tags
 // to support idiomatic json encodings.
 type ConsensusGET struct {
@@ -19,6 +31,51 @@ type ConsensusGET struct {
 	CurrentBlock types.BlockID     `json:"currentblock"`
 	Target       types.Target      `json:"target"`
 	Difficulty   types.Currency    `json:"difficulty"`
+	SiafundPool  types.Currency    `json:"siafundpool"`
+}
+
+// ConsensusFileContractGET is a file contract of the consensus set, returned
+// by /consensus/filecontracts/:id.
+type ConsensusFileContractGET struct {
+	types.FileContract
+	ID types.FileContractID `json:"id"`
+
+	// ProofWindowOpen is true if a storage proof for the contract can be
+	// submitted, in which case StorageProofSegment is the index of the
+	// segment that has to be proven.
+	ProofWindowOpen     bool   `json:"proofwindowopen"`
+	StorageProofSegment uint64 `json:"storageproofsegment"`
+}
+
+// ConsensusSiacoinOutputGET is an unspent siacoin output of the consensus
+// set, returned by /consensus/siacoinoutputs/:id.
+type ConsensusSiacoinOutputGET struct {
+	ID             types.SiacoinOutputID `json:"id"`
+	Value          types.Currency        `json:"value"`
+	UnlockHash     types.UnlockHash      `json:"unlockhash"`
+	MaturityHeight types.BlockHeight     `json:"maturityheight"`
+}
+
+// ConsensusSiafundOutputGET is an unspent siafund output of the consensus
+// set, returned by /consensus/siafundoutputs/:id.
+type ConsensusSiafundOutputGET struct {
+	ID         types.SiafundOutputID `json:"id"`
+	Value      types.Currency        `json:"value"`
+	UnlockHash types.UnlockHash      `json:"unlockhash"`
+	ClaimStart types.Currency        `json:"claimstart"`
+	ClaimValue types.Currency        `json:"claimvalue"`
+}
+
+// ConsensusTransactionProofGET is a transaction of a block in the current
+// path, together with the proof that the transaction is part of the block,
+// returned by /consensus/proof/:txid. The proof can be verified against the
+// merkle root of the block header.
+type ConsensusTransactionProofGET struct {
+	Transaction types.Transaction      `json:"transaction"`
+	BlockID     types.BlockID          `json:"blockid"`
+	Height      types.BlockHeight      `json:"height"`
+	Header      types.BlockHeader      `json:"header"`
+	Proof       types.TransactionProof `json:"proof"`
 }
 
 // ConsensusHeadersGET contains information from a blocks header.
@@ -26,6 +83,73 @@ type ConsensusHeadersGET struct {
 	BlockID types.BlockID `json:"blockid"`
 }
 
+// ConsensusSnapshotGET describes a snapshot of the consensus set that was
+// written by /consensus/snapshot.
+type ConsensusSnapshotGET struct {
+	Height  types.BlockHeight `json:"height"`
+	BlockID types.BlockID     `json:"blockid"`
+	Hash    crypto.Hash       `json:"hash"`
+}
+
+// ConsensusChangeGET is a modules.ConsensusChange as it is streamed by
+// /consensus/subscribe.
+type ConsensusChangeGET struct {
+	ID                         modules.ConsensusChangeID          `json:"id"`
+	RevertedBlocks             []types.Block                      `json:"revertedblocks"`
+	AppliedBlocks              []types.Block                      `json:"appliedblocks"`
+	SiacoinOutputDiffs         []modules.SiacoinOutputDiff        `json:"siacoinoutputdiffs"`
+	FileContractDiffs          []modules.FileContractDiff         `json:"filecontractdiffs"`
+	SiafundOutputDiffs         []modules.SiafundOutputDiff        `json:"siafundoutputdiffs"`
+	DelayedSiacoinOutputDiffs  []modules.DelayedSiacoinOutputDiff `json:"delayedsiacoinoutputdiffs"`
+	SiafundPoolDiffs           []modules.SiafundPoolDiff          `json:"siafundpooldiffs"`
+	ChildTarget                types.Target                       `json:"childtarget"`
+	MinimumValidChildTimestamp types.Timestamp                    `json:"minimumvalidchildtimestamp"`
+	Synced                     bool                               `json:"synced"`
+	SnapshotHeight             types.BlockHeight                  `json:"snapshotheight"`
+}
+
+// consensusChangeCollector is a consensus set subscriber that buffers the
+// consensus changes for /consensus/subscribe, so that a slow client never
+// blocks the consensus set. Once the buffer is full, cancel is closed, which
+// stops a subscription that is still catching up, and further changes are
+// dropped and flagged as an overflow.
+type consensusChangeCollector struct {
+	changes  []modules.ConsensusChange
+	overflow bool
+	full     bool
+
+	cancel chan struct{}
+	notify chan struct{}
+	mu     sync.Mutex
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (c *consensusChangeCollector) ProcessConsensusChange(cc modules.ConsensusChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.changes) >= consensusSubscribeBatchSize {
+		c.overflow = true
+		return
+	}
+	c.changes = append(c.changes, cc)
+	if len(c.changes) == consensusSubscribeBatchSize && !c.full {
+		c.full = true
+		close(c.cancel)
+	}
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the buffered consensus changes and empties the buffer.
+func (c *consensusChangeCollector) take() (changes []modules.ConsensusChange, overflow bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changes, c.changes = c.changes, nil
+	return changes, c.overflow
+}
+
 // ConsensusBlocksGet contains all fields of a types.Block and additional
 // fields for ID and Height.
 type ConsensusBlocksGet struct {
@@ -178,6 +302,7 @@ func (api *API) consensusHandler(w http.ResponseWriter, req *http.Request, _ htt
 		CurrentBlock: cbid,
 		Target:       currentTarget,
 		Difficulty:   currentTarget.Difficulty(),
+		SiafundPool:  api.cs.SiafundPool(),
 	})
 }
 
@@ -223,6 +348,234 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 	WriteJSON(w, consensusBlocksGetFromBlock(b, h))
 }
 
+// consensusFileContractsHandler handles the API calls to
+// /consensus/filecontracts/:id.
+func (api *API) consensusFileContractsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse file contract id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	id := types.FileContractID(hash)
+	fc, err := api.cs.FileContract(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /consensus/filecontracts/:id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// The storage proof segment is only known once the proof window has
+	// opened.
+	segment, err := api.cs.StorageProofSegment(id)
+	WriteJSON(w, ConsensusFileContractGET{
+		FileContract:        fc,
+		ID:                  id,
+		ProofWindowOpen:     err == nil,
+		StorageProofSegment: segment,
+	})
+}
+
+// consensusSiacoinOutputsHandler handles the API calls to
+// /consensus/siacoinoutputs/:id.
+func (api *API) consensusSiacoinOutputsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse siacoin output id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	id := types.SiacoinOutputID(hash)
+	sco, maturityHeight, err := api.cs.SiacoinOutput(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /consensus/siacoinoutputs/:id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusSiacoinOutputGET{
+		ID:             id,
+		Value:          sco.Value,
+		UnlockHash:     sco.UnlockHash,
+		MaturityHeight: maturityHeight,
+	})
+}
+
+// consensusSiafundOutputsHandler handles the API calls to
+// /consensus/siafundoutputs/:id.
+func (api *API) consensusSiafundOutputsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse siafund output id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	id := types.SiafundOutputID(hash)
+	sfo, err := api.cs.SiafundOutput(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /consensus/siafundoutputs/:id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// The claim is computed the same way as when the output is spent.
+	claimValue := api.cs.SiafundPool().Sub(sfo.ClaimStart).Div(types.SiafundCount).Mul(sfo.Value)
+	WriteJSON(w, ConsensusSiafundOutputGET{
+		ID:         id,
+		Value:      sfo.Value,
+		UnlockHash: sfo.UnlockHash,
+		ClaimStart: sfo.ClaimStart,
+		ClaimValue: claimValue,
+	})
+}
+
+// consensusProofHandler handles the API calls to /consensus/proof/:txid.
+func (api *API) consensusProofHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	txid, err := scanHash(ps.ByName("txid"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse transaction id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// The consensus set does not index transactions, so the block that
+	// contains the transaction has to be provided.
+	blockID, err := scanHash(req.FormValue("blockid"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse block id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txn, proof, header, height, err := api.cs.TransactionProof(types.BlockID(blockID), types.TransactionID(txid))
+	if err != nil {
+		WriteError(w, Error{"error when calling /consensus/proof/:txid: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusTransactionProofGET{
+		Transaction: txn,
+		BlockID:     types.BlockID(blockID),
+		Height:      height,
+		Header:      header,
+		Proof:       proof,
+	})
+}
+
+// consensusSnapshotHandler handles the API calls to /consensus/snapshot.
+func (api *API) consensusSnapshotHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	destination := req.FormValue("destination")
+	// Check that the destination is absolute.
+	if !filepath.IsAbs(destination) {
+		WriteError(w, Error{"error when calling /consensus/snapshot: destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// The height is optional; by default the current height is used.
+	height := api.cs.Height()
+	if h := req.FormValue("height"); h != "" {
+		if _, err := fmt.Sscan(h, &height); err != nil {
+			WriteError(w, Error{"failed to parse block height"}, http.StatusBadRequest)
+			return
+		}
+	}
+	snapshot, err := api.cs.ExportSnapshot(destination, height)
+	if err != nil {
+		WriteError(w, Error{"error when calling /consensus/snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusSnapshotGET{
+		Height:  snapshot.Height,
+		BlockID: snapshot.BlockID,
+		Hash:    snapshot.Hash,
+	})
+}
+
+// consensusSubscribeHandler handles the API calls to
+// /consensus/subscribe/:changeid. It streams the consensus changes that follow
+// the given change, and keeps streaming new changes until the client
+// disconnects.
+func (api *API) consensusSubscribeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var start modules.ConsensusChangeID
+	if err := (*crypto.Hash)(&start).LoadString(ps.ByName("changeid")); err != nil {
+		WriteError(w, Error{"failed to parse consensus change id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var write func(ConsensusChangeGET) error
+	switch format := req.FormValue("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		write = func(cc ConsensusChangeGET) error { return enc.Encode(cc) }
+	case "sia":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		enc := encoding.NewEncoder(w)
+		write = func(cc ConsensusChangeGET) error { return enc.Encode(cc) }
+	default:
+		WriteError(w, Error{"unrecognized format: " + format}, http.StatusBadRequest)
+		return
+	}
+	flush := func() {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	streaming := false
+	for {
+		// Subscribe to the consensus set. The subscription is cancelled once a
+		// batch of changes has been collected, otherwise the collector keeps
+		// receiving new changes until it is unsubscribed.
+		c := &consensusChangeCollector{
+			cancel: make(chan struct{}),
+			notify: make(chan struct{}, 1),
+		}
+		err := api.cs.ConsensusSetSubscribe(c, start, c.cancel)
+		subscribed := err == nil
+		c.mu.Lock()
+		full := c.full
+		c.mu.Unlock()
+		if !subscribed && !full {
+			if !streaming {
+				WriteError(w, Error{"error when calling /consensus/subscribe: " + err.Error()}, http.StatusBadRequest)
+			}
+			return
+		}
+		if !streaming {
+			w.WriteHeader(http.StatusOK)
+			streaming = true
+		}
+
+		for {
+			changes, overflow := c.take()
+			for _, cc := range changes {
+				err := write(ConsensusChangeGET{
+					ID:                         cc.ID,
+					RevertedBlocks:             cc.RevertedBlocks,
+					AppliedBlocks:              cc.AppliedBlocks,
+					SiacoinOutputDiffs:         cc.SiacoinOutputDiffs,
+					FileContractDiffs:          cc.FileContractDiffs,
+					SiafundOutputDiffs:         cc.SiafundOutputDiffs,
+					DelayedSiacoinOutputDiffs:  cc.DelayedSiacoinOutputDiffs,
+					SiafundPoolDiffs:           cc.SiafundPoolDiffs,
+					ChildTarget:                cc.ChildTarget,
+					MinimumValidChildTimestamp: cc.MinimumValidChildTimestamp,
+					Synced:                     cc.Synced,
+					SnapshotHeight:             cc.SnapshotHeight,
+				})
+				if err != nil {
+					if subscribed {
+						api.cs.Unsubscribe(c)
+					}
+					return
+				}
+				start = cc.ID
+			}
+			flush()
+			if !subscribed {
+				// Continue catching up with the next batch.
+				break
+			}
+			if overflow {
+				// The client fell behind, catch up from the change log.
+				api.cs.Unsubscribe(c)
+				break
+			}
+			select {
+			case <-c.notify:
+			case <-req.Context().Done():
+				api.cs.Unsubscribe(c)
+				return
+			}
+		}
+	}
+}
+
 // consensusValidateTransactionsetHandler handles the API calls to
 // /consensus/validate/transactionset.
 func (api *API) consensusValidateTransactionsetHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -2,8 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -36,6 +42,162 @@ func TestIntegrationConsensusGET(t *testing.T) {
 	}
 }
 
+// TestConsensusQueriesGET probes the GET calls to /consensus/siacoinoutputs,
+// /consensus/siafundoutputs and /consensus/filecontracts.
+func TestConsensusQueriesGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// The payout of the current block is a delayed output.
+	height := st.cs.Height()
+	current := st.cs.CurrentBlock()
+	var csog ConsensusSiacoinOutputGET
+	if err := st.getAPI("/consensus/siacoinoutputs/"+current.MinerPayoutID(0).String(), &csog); err != nil {
+		t.Fatal(err)
+	}
+	if csog.MaturityHeight != height+types.MaturityDelay || !csog.Value.Equals(current.MinerPayouts[0].Value) {
+		t.Fatal("wrong siacoin output:", csog)
+	}
+
+	// The genesis siafund outputs have a claim start of zero.
+	var csfog ConsensusSiafundOutputGET
+	if err := st.getAPI("/consensus/siafundoutputs/"+types.GenesisBlock.Transactions[0].SiafundOutputID(0).String(), &csfog); err != nil {
+		t.Fatal(err)
+	}
+	if !csfog.Value.Equals(types.GenesisSiafundAllocation[0].Value) || !csfog.ClaimStart.IsZero() {
+		t.Fatal("wrong siafund output:", csfog)
+	}
+
+	// Create a file contract whose proof window opens in a few blocks.
+	payout := types.NewCurrency64(400e6)
+	fc := types.FileContract{
+		FileSize:           4e3,
+		WindowStart:        height + 3,
+		WindowEnd:          height + 10,
+		Payout:             payout,
+		ValidProofOutputs:  []types.SiacoinOutput{{Value: types.PostTax(height, payout)}},
+		MissedProofOutputs: []types.SiacoinOutput{{Value: types.PostTax(height, payout)}},
+	}
+	txnBuilder, err := st.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(payout); err != nil {
+		t.Fatal(err)
+	}
+	fcIndex := txnBuilder.AddFileContract(fc)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	fcid := txnSet[len(txnSet)-1].FileContractID(fcIndex)
+	var cfcg ConsensusFileContractGET
+	if err := st.getAPI("/consensus/filecontracts/"+fcid.String(), &cfcg); err != nil {
+		t.Fatal(err)
+	}
+	if cfcg.ID != fcid || cfcg.WindowStart != fc.WindowStart || cfcg.WindowEnd != fc.WindowEnd || cfcg.ProofWindowOpen {
+		t.Fatal("wrong file contract:", cfcg)
+	}
+	var cg ConsensusGET
+	if err := st.getAPI("/consensus", &cg); err != nil {
+		t.Fatal(err)
+	}
+	if cg.SiafundPool.IsZero() {
+		t.Fatal("siafund pool did not receive the contract tax")
+	}
+
+	// The proof window opens once the block before the window start is
+	// mined.
+	for st.cs.Height() < fc.WindowStart-1 {
+		if _, err := st.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.getAPI("/consensus/filecontracts/"+fcid.String(), &cfcg); err != nil {
+		t.Fatal(err)
+	}
+	segment, err := st.cs.StorageProofSegment(fcid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfcg.ProofWindowOpen || cfcg.StorageProofSegment != segment {
+		t.Fatal("proof window was not reported as open:", cfcg)
+	}
+
+	// Unknown and invalid ids are rejected.
+	var id crypto.Hash
+	id[0] = 1
+	for _, call := range []string{
+		"/consensus/siacoinoutputs/" + id.String(),
+		"/consensus/siafundoutputs/" + id.String(),
+		"/consensus/filecontracts/" + id.String(),
+		"/consensus/filecontracts/foo",
+	} {
+		if err := st.getAPI(call, nil); err == nil {
+			t.Error("expected an error for", call)
+		}
+	}
+}
+
+// TestConsensusProofGET probes the GET call to /consensus/proof/:txid.
+func TestConsensusProofGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	txns, err := st.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	var ctpg ConsensusTransactionProofGET
+	if err := st.getAPI("/consensus/proof/"+txid.String()+"?blockid="+b.ID().String(), &ctpg); err != nil {
+		t.Fatal(err)
+	}
+	if ctpg.Transaction.ID() != txid || ctpg.BlockID != b.ID() || ctpg.Height != st.cs.Height() || ctpg.Header != b.Header() {
+		t.Fatal("wrong transaction proof:", ctpg)
+	}
+	if !ctpg.Proof.Verify(ctpg.Transaction, ctpg.Header) {
+		t.Fatal("proof does not verify against the block header")
+	}
+
+	// Transactions that are not in the block, and malformed ids, are
+	// rejected.
+	for _, call := range []string{
+		"/consensus/proof/" + types.TransactionID{}.String() + "?blockid=" + b.ID().String(),
+		"/consensus/proof/" + txid.String() + "?blockid=" + types.BlockID{}.String(),
+		"/consensus/proof/" + txid.String(),
+		"/consensus/proof/foo?blockid=" + b.ID().String(),
+	} {
+		if err := st.getAPI(call, &ctpg); err == nil {
+			t.Error("expected an error for", call)
+		}
+	}
+}
+
 // TestConsensusValidateTransactionSet probes the POST call to
 // /consensus/validate/transactionset.
 func TestConsensusValidateTransactionSet(t *testing.T) {
@@ -83,3 +245,103 @@ func TestConsensusValidateTransactionSet(t *testing.T) {
 		t.Fatal("expected validation error")
 	}
 }
+
+// TestConsensusSnapshotGET probes the GET call to /consensus/snapshot.
+func TestConsensusSnapshotGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Snapshots can only be created past the oak hardfork.
+	for st.cs.Height() < types.OakHardforkBlock {
+		if _, err := st.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The destination must be an absolute path.
+	var csg ConsensusSnapshotGET
+	if err := st.getAPI("/consensus/snapshot?destination=snapshot", &csg); err == nil {
+		t.Fatal("snapshot was exported to a relative path")
+	}
+
+	// Export a snapshot at the current height.
+	destination := filepath.Join(st.dir, "snapshot")
+	if err := st.getAPI("/consensus/snapshot?destination="+destination, &csg); err != nil {
+		t.Fatal(err)
+	}
+	if csg.Height != st.cs.Height() || csg.BlockID != st.cs.CurrentBlock().ID() {
+		t.Fatal("wrong snapshot returned:", csg)
+	}
+	if _, err := os.Stat(destination); err != nil {
+		t.Fatal("snapshot file was not written:", err)
+	}
+
+	// Export a snapshot at an earlier height.
+	height := st.cs.Height()
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	var csg2 ConsensusSnapshotGET
+	if err := st.getAPI(fmt.Sprintf("/consensus/snapshot?destination=%v&height=%v", destination, height), &csg2); err != nil {
+		t.Fatal(err)
+	}
+	if csg2 != csg {
+		t.Fatal("snapshots of the same height do not match:", csg, csg2)
+	}
+}
+
+// TestConsensusSubscribeGET probes the /consensus/subscribe endpoint with the
+// Sia encoding, streaming more changes than fit in a single batch.
+func TestConsensusSubscribeGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	for st.cs.Height() < 2*consensusSubscribeBatchSize {
+		if _, err := st.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Invalid ids and formats are rejected.
+	beginning := "/consensus/subscribe/" + crypto.Hash(modules.ConsensusChangeBeginning).String()
+	for _, call := range []string{"/consensus/subscribe/foo", beginning + "?format=xml"} {
+		if err := st.getAPI(call, nil); err == nil {
+			t.Error("invalid call succeeded:", call)
+		}
+	}
+
+	// Stream all of the changes.
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + beginning + "?format=sia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if non2xx(resp.StatusCode) {
+		t.Fatal(decodeError(resp))
+	}
+	dec := encoding.NewDecoder(resp.Body)
+	parent := types.BlockID{}
+	for parent != st.cs.CurrentBlock().ID() {
+		var cc ConsensusChangeGET
+		if err := dec.Decode(&cc); err != nil {
+			t.Fatal(err)
+		}
+		if len(cc.RevertedBlocks) != 0 || len(cc.AppliedBlocks) != 1 || cc.AppliedBlocks[0].ParentID != parent {
+			t.Fatal("streamed changes are not consecutive")
+		}
+		parent = cc.AppliedBlocks[0].ID()
+	}
+}
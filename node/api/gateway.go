@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 
@@ -14,6 +15,12 @@ type GatewayGET struct {
 	Peers      []modules.Peer     `json:"peers"`
 }
 
+// GatewayBansGET contains the fields returned by a GET call to
+// "/gateway/bans".
+type GatewayBansGET struct {
+	Bans []modules.PeerBan `json:"bans"`
+}
+
 // gatewayHandler handles the API call asking for the gatway status.
 func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	peers := api.gateway.Peers()
@@ -49,3 +56,50 @@ func (api *API) gatewayDisconnectHandler(w http.ResponseWriter, req *http.Reques
 
 	WriteSuccess(w)
 }
+
+// gatewayBansHandlerGET handles the API call asking for the banned IP
+// addresses and subnets.
+func (api *API) gatewayBansHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	bans := api.gateway.Bans()
+	if bans == nil {
+		bans = make([]modules.PeerBan, 0)
+	}
+	WriteJSON(w, GatewayBansGET{bans})
+}
+
+// gatewayBansHandlerPOST handles the API call to ban an IP address or subnet.
+func (api *API) gatewayBansHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	host := req.FormValue("host")
+	if host == "" {
+		WriteError(w, Error{"host must be specified"}, http.StatusBadRequest)
+		return
+	}
+	var duration time.Duration
+	if d := req.FormValue("duration"); d != "" {
+		var err error
+		duration, err = time.ParseDuration(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse duration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := api.gateway.Ban(host, duration, req.FormValue("reason"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// gatewayBansRemoveHandler handles the API call to lift the ban of an IP
+// address or subnet.
+func (api *API) gatewayBansRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.gateway.Unban(req.FormValue("host"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -111,3 +112,75 @@ func TestGatewayPeerDisconnect(t *testing.T) {
 		t.Fatal("/gateway/disconnect did not disconnect from peer", peer.Address())
 	}
 }
+
+// TestGatewayBans checks that /gateway/bans bans and unbans hosts.
+func TestGatewayBans(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	peer, err := gateway.New("localhost:0", false, build.TempDir("api", t.Name()+"2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	if err := st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ban the peer.
+	values := url.Values{}
+	values.Set("host", peer.Address().Host())
+	values.Set("duration", "1h")
+	values.Set("reason", "test")
+	if err := st.stdPostAPI("/gateway/bans", values); err != nil {
+		t.Fatal(err)
+	}
+	var bg GatewayBansGET
+	if err := st.getAPI("/gateway/bans", &bg); err != nil {
+		t.Fatal(err)
+	}
+	if len(bg.Bans) != 1 || bg.Bans[0].Host != peer.Address().Host() || bg.Bans[0].Reason != "test" {
+		t.Fatal("/gateway/bans did not ban the peer:", bg.Bans)
+	}
+	var info GatewayGET
+	if err := st.getAPI("/gateway", &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Peers) != 0 {
+		t.Fatal("banned peer is still connected")
+	}
+
+	// Invalid arguments are rejected.
+	values.Set("host", "foo")
+	if err := st.stdPostAPI("/gateway/bans", values); err == nil {
+		t.Fatal("expected an error for an invalid host")
+	}
+	values.Set("host", peer.Address().Host())
+	values.Set("duration", "forever")
+	if err := st.stdPostAPI("/gateway/bans", values); err == nil {
+		t.Fatal("expected an error for an invalid duration")
+	}
+
+	// Lift the ban.
+	values = url.Values{}
+	values.Set("host", peer.Address().Host())
+	if err := st.stdPostAPI("/gateway/bans/remove", values); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/gateway/bans", &bg); err != nil {
+		t.Fatal(err)
+	}
+	if len(bg.Bans) != 0 {
+		t.Fatal("/gateway/bans/remove did not lift the ban:", bg.Bans)
+	}
+	if err := st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil); err != nil {
+		t.Fatal(err)
+	}
+}
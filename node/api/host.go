@@ -1,9 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
@@ -24,10 +29,28 @@ var (
 )
 
 type (
+	// HostAlertsGET contains the information that is returned after a GET
+	// request to /host/alerts - the problems the host is currently
+	// experiencing.
+	HostAlertsGET struct {
+		Alerts []modules.HostAlert `json:"alerts"`
+	}
+
 	// ContractInfoGET contains the information that is returned after a GET request
 	// to /host/contracts - information for the host about stored obligations.
 	ContractInfoGET struct {
 		Contracts []modules.StorageObligation `json:"contracts"`
+
+		// Total is the number of contracts matching the filters of the
+		// request, before pagination is applied.
+		Total int `json:"total"`
+	}
+
+	// HostContractGET contains the information that is returned after a GET
+	// request to /host/contracts/:id - the full details of a single storage
+	// obligation.
+	HostContractGET struct {
+		Contract modules.StorageObligationDetails `json:"contract"`
 	}
 
 	// HostGET contains the information that is returned after a GET request to
@@ -48,6 +71,20 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostMaintenanceGET contains the information that is returned after a
+	// GET request to /host/maintenance - the progress of the host's contract
+	// wind-down.
+	HostMaintenanceGET struct {
+		modules.HostMaintenanceStatus
+	}
+
+	// HostMetricsGET contains the information that is returned after a GET
+	// request to /host/metrics - the host's metrics snapshots within the
+	// requested time range.
+	HostMetricsGET struct {
+		Snapshots []modules.HostMetricsSnapshot `json:"snapshots"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -67,13 +104,159 @@ func folderIndex(folderPath string, storageFolders []modules.StorageFolderMetada
 	return -1, errStorageFolderNotFound
 }
 
+// hostAlertsHandlerGET handles the API call to get the alerts of the host.
+func (api *API) hostAlertsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostAlertsGET{Alerts: api.host.Alerts()})
+}
+
 // hostContractInfoHandler handles the API call to get the contract information of the host.
 // Information is retrieved via the storage obligations from the host database.
+// The contracts can be filtered by status and expiration height, and are
+// returned sorted by expiration height.
 func (api *API) hostContractInfoHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	cg := ContractInfoGET{
-		Contracts: api.host.StorageObligations(),
+	status := strings.ToLower(req.FormValue("status"))
+	var minExpiration, maxExpiration types.BlockHeight
+	maxExpiration = types.BlockHeight(math.MaxUint64)
+	if req.FormValue("minexpiration") != "" {
+		_, err := fmt.Sscan(req.FormValue("minexpiration"), &minExpiration)
+		if err != nil {
+			WriteError(w, Error{"unable to parse minexpiration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("maxexpiration") != "" {
+		_, err := fmt.Sscan(req.FormValue("maxexpiration"), &maxExpiration)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxexpiration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var offset, limit int
+	if req.FormValue("offset") != "" {
+		_, err := fmt.Sscan(req.FormValue("offset"), &offset)
+		if err != nil || offset < 0 {
+			WriteError(w, Error{"offset must be a non-negative integer"}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("limit") != "" {
+		_, err := fmt.Sscan(req.FormValue("limit"), &limit)
+		if err != nil || limit < 0 {
+			WriteError(w, Error{"limit must be a non-negative integer"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Filter the contracts. The status can be provided with or without the
+	// "obligation" prefix, e.g. "unresolved" or "obligationUnresolved".
+	contracts := []modules.StorageObligation{}
+	for _, so := range api.host.StorageObligations() {
+		if status != "" && strings.ToLower(so.ObligationStatus) != status && strings.ToLower(strings.TrimPrefix(so.ObligationStatus, "obligation")) != status {
+			continue
+		}
+		if so.ExpirationHeight < minExpiration || so.ExpirationHeight > maxExpiration {
+			continue
+		}
+		contracts = append(contracts, so)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		if contracts[i].ExpirationHeight != contracts[j].ExpirationHeight {
+			return contracts[i].ExpirationHeight < contracts[j].ExpirationHeight
+		}
+		return bytes.Compare(contracts[i].ObligationId[:], contracts[j].ObligationId[:]) < 0
+	})
+
+	// Apply the pagination.
+	total := len(contracts)
+	if offset > len(contracts) {
+		offset = len(contracts)
+	}
+	contracts = contracts[offset:]
+	if limit > 0 && limit < len(contracts) {
+		contracts = contracts[:limit]
+	}
+	WriteJSON(w, ContractInfoGET{
+		Contracts: contracts,
+		Total:     total,
+	})
+}
+
+// hostContractHandlerGET handles the API call to get the full details of a
+// single storage obligation.
+func (api *API) hostContractHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse contract id: " + err.Error()}, http.StatusBadRequest)
+		return
 	}
-	WriteJSON(w, cg)
+	so, err := api.host.StorageObligation(types.FileContractID(hash))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostContractGET{
+		Contract: so,
+	})
+}
+
+// parseMetricsGranularity parses the granularity of a /host/metrics request.
+// The granularity can either be one of "hour", "day" and "week", or a number
+// of seconds.
+func parseMetricsGranularity(granularity string) (time.Duration, error) {
+	switch granularity {
+	case "":
+		return 0, nil
+	case "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	case "week":
+		return 7 * 24 * time.Hour, nil
+	}
+	var seconds int64
+	_, err := fmt.Sscan(granularity, &seconds)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// hostMetricsHandlerGET handles GET requests to the /host/metrics API
+// endpoint, returning the host's metrics history.
+func (api *API) hostMetricsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start, end time.Time
+	if req.FormValue("start") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("start"), &x)
+		if err != nil {
+			WriteError(w, Error{"error parsing start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = time.Unix(x, 0)
+	}
+	if req.FormValue("end") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("end"), &x)
+		if err != nil {
+			WriteError(w, Error{"error parsing end: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		end = time.Unix(x, 0)
+	}
+	granularity, err := parseMetricsGranularity(req.FormValue("granularity"))
+	if err != nil {
+		WriteError(w, Error{"error parsing granularity: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := api.host.MetricsHistory(start, end, granularity)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostMetricsGET{
+		Snapshots: snapshots,
+	})
 }
 
 // hostHandlerGET handles GET requests to the /host API endpoint, returning key
@@ -110,6 +293,14 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.AcceptingContracts = x
 	}
+	if req.FormValue("maintenancemode") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("maintenancemode"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceMode = x
+	}
 	if req.FormValue("maxdownloadbatchsize") != "" {
 		var x uint64
 		_, err := fmt.Sscan(req.FormValue("maxdownloadbatchsize"), &x)
@@ -295,6 +486,53 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// hostMaintenanceHandlerGET handles the API call to get the maintenance
+// status of the host.
+func (api *API) hostMaintenanceHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	status, err := api.host.MaintenanceStatus()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostMaintenanceGET{status})
+}
+
+// hostMaintenanceHandlerPOST handles the API call to enable or disable the
+// maintenance mode of the host, optionally announcing the host so that
+// renters pick up the new settings.
+func (api *API) hostMaintenanceHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var enabled bool
+	_, err := fmt.Sscan(req.FormValue("enabled"), &enabled)
+	if err != nil {
+		WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var announce bool
+	if req.FormValue("announce") != "" {
+		_, err = fmt.Sscan(req.FormValue("announce"), &announce)
+		if err != nil {
+			WriteError(w, Error{"unable to parse announce: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	settings := api.host.InternalSettings()
+	settings.MaintenanceMode = enabled
+	err = api.host.SetInternalSettings(settings)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if announce {
+		err = api.host.Announce()
+		if err != nil {
+			WriteError(w, Error{"maintenance mode updated, but announcement failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteSuccess(w)
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -374,6 +612,65 @@ func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// storageFoldersMigrateHandler starts migrating the sectors of a storage
+// folder into other storage folders.
+func (api *API) storageFoldersMigrateHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	index, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// The destinations are provided as a comma separated list of paths.
+	var destinations []uint16
+	if req.FormValue("destinations") != "" {
+		for _, destPath := range strings.Split(req.FormValue("destinations"), ",") {
+			destIndex, err := folderIndex(destPath, storageFolders)
+			if err != nil {
+				WriteError(w, Error{"unable to find destination folder " + destPath + ": " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			destinations = append(destinations, uint16(destIndex))
+		}
+	}
+
+	err = api.host.MigrateStorageFolder(uint16(index), destinations)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// storageFoldersMigrateCancelHandler cancels the migration of a storage
+// folder.
+func (api *API) storageFoldersMigrateCancelHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	index, err := folderIndex(folderPath, api.host.StorageFolders())
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.CancelStorageFolderMigration(uint16(index))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageSectorsDeleteHandler handles the call to delete a sector from the
 // storage manager.
 func (api *API) storageSectorsDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -304,6 +304,146 @@ func TestConnectabilityStatus(t *testing.T) {
 	}
 }
 
+// TestHostContractsHandlerFilters checks that invalid filters and unknown
+// contract ids are rejected by the /host/contracts endpoints.
+func TestHostContractsHandlerFilters(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// A valid filter should return an empty list.
+	var cg ContractInfoGET
+	if err := st.getAPI("/host/contracts?status=unresolved&minexpiration=10&maxexpiration=20&offset=5&limit=10", &cg); err != nil {
+		t.Fatal(err)
+	}
+	if len(cg.Contracts) != 0 || cg.Total != 0 {
+		t.Fatal("expected no contracts to be returned")
+	}
+
+	// Invalid filters should be rejected.
+	if err := st.getAPI("/host/contracts?limit=-1", &cg); err == nil {
+		t.Fatal("expected an error for a negative limit")
+	}
+	if err := st.getAPI("/host/contracts?minexpiration=foo", &cg); err == nil {
+		t.Fatal("expected an error for an invalid expiration")
+	}
+
+	// Unknown and malformed contract ids should be rejected.
+	var hcg HostContractGET
+	if err := st.getAPI("/host/contracts/"+types.FileContractID{1}.String(), &hcg); err == nil {
+		t.Fatal("expected an error for an unknown contract")
+	}
+	if err := st.getAPI("/host/contracts/foo", &hcg); err == nil {
+		t.Fatal("expected an error for a malformed contract id")
+	}
+}
+
+// TestHostAlertsHandler checks that a healthy host reports no alerts.
+func TestHostAlertsHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var hag HostAlertsGET
+	if err := st.getAPI("/host/alerts", &hag); err != nil {
+		t.Fatal(err)
+	}
+	if len(hag.Alerts) != 0 {
+		t.Fatal("expected no alerts, got", hag.Alerts)
+	}
+}
+
+// TestHostMaintenanceHandler checks that the maintenance mode of the host can
+// be toggled and queried through the API.
+func TestHostMaintenanceHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Enable maintenance mode.
+	maintenanceValues := url.Values{}
+	maintenanceValues.Set("enabled", "true")
+	if err := st.stdPostAPI("/host/maintenance", maintenanceValues); err != nil {
+		t.Fatal(err)
+	}
+	var hmg HostMaintenanceGET
+	if err := st.getAPI("/host/maintenance", &hmg); err != nil {
+		t.Fatal(err)
+	}
+	if !hmg.MaintenanceMode || hmg.ActiveObligations != 0 {
+		t.Fatal("wrong maintenance status reported:", hmg)
+	}
+	var hg HostGET
+	if err := st.getAPI("/host", &hg); err != nil {
+		t.Fatal(err)
+	}
+	if !hg.InternalSettings.MaintenanceMode || hg.ExternalSettings.AcceptingContracts {
+		t.Fatal("host in maintenance mode should not advertise that it accepts contracts")
+	}
+
+	// The enabled parameter is required.
+	if err := st.stdPostAPI("/host/maintenance", url.Values{}); err == nil {
+		t.Fatal("expected an error when enabled is missing")
+	}
+}
+
+// TestHostMetricsHandler tests that the host's metrics snapshots are reported
+// through the /host/metrics endpoint.
+func TestHostMetricsHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Wait for the host to persist a snapshot.
+	err = build.Retry(30, time.Second, func() error {
+		var hmg HostMetricsGET
+		err := st.getAPI("/host/metrics?granularity=hour", &hmg)
+		if err != nil {
+			return err
+		}
+		if len(hmg.Snapshots) == 0 {
+			return errors.New("no metrics snapshots were returned")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid granularities and ranges should be rejected.
+	var hmg HostMetricsGET
+	if err := st.getAPI("/host/metrics?granularity=fortnight", &hmg); err == nil {
+		t.Fatal("expected an error for an invalid granularity")
+	}
+	if err := st.getAPI("/host/metrics?start=100&end=50", &hmg); err == nil {
+		t.Fatal("expected an error for an invalid range")
+	}
+}
+
 // TestStorageHandler tests that host storage is being reported correctly.
 func TestStorageHandler(t *testing.T) {
 	if testing.Short() {
@@ -912,6 +1052,57 @@ func TestRemoveStorageFolderError(t *testing.T) {
 	}
 }
 
+// TestMigrateStorageFolderError checks that invalid calls to
+// /host/storage/folders/migrate and /host/storage/folders/migrate/cancel fail
+// with the appropriate error.
+func TestMigrateStorageFolderError(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Set up a storage folder for the host.
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Try migrating a nonexistent folder.
+	migrateValues := url.Values{}
+	migrateValues.Set("path", "/foo/bar")
+	err = st.stdPostAPI("/host/storage/folders/migrate", migrateValues)
+	if err == nil || err.Error() != errStorageFolderNotFound.Error() {
+		t.Fatalf("expected error %v, got %v", errStorageFolderNotFound, err)
+	}
+
+	// Try migrating into a nonexistent folder.
+	migrateValues.Set("path", st.dir)
+	migrateValues.Set("destinations", "/foo/bar")
+	err = st.stdPostAPI("/host/storage/folders/migrate", migrateValues)
+	if err == nil {
+		t.Fatal("expected migration into a nonexistent folder to fail")
+	}
+
+	// Cancelling a migration that is not running should fail.
+	cancelValues := url.Values{}
+	cancelValues.Set("path", st.dir)
+	err = st.stdPostAPI("/host/storage/folders/migrate/cancel", cancelValues)
+	if err == nil {
+		t.Fatal("expected cancelling a nonexistent migration to fail")
+	}
+
+	// The folder path can't be an empty string.
+	migrateValues.Set("path", "")
+	err = st.stdPostAPI("/host/storage/folders/migrate", migrateValues)
+	if err == nil || err.Error() != errNoPath.Error() {
+		t.Fatalf("expected error to be %v; got %v", errNoPath, err)
+	}
+}
+
 // TestRemoveStorageFolderForced checks that if a call to remove a storage
 // folder will result in data loss, that call succeeds if and only if "force"
 // has been set to "true".
@@ -22,6 +22,12 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 	if api.cs != nil {
 		router.GET("/consensus", api.consensusHandler)
 		router.GET("/consensus/blocks", api.consensusBlocksHandler)
+		router.GET("/consensus/filecontracts/:id", api.consensusFileContractsHandler)
+		router.GET("/consensus/proof/:txid", api.consensusProofHandler)
+		router.GET("/consensus/siacoinoutputs/:id", api.consensusSiacoinOutputsHandler)
+		router.GET("/consensus/siafundoutputs/:id", api.consensusSiafundOutputsHandler)
+		router.GET("/consensus/snapshot", RequirePassword(api.consensusSnapshotHandler, requiredPassword))
+		router.GET("/consensus/subscribe/:changeid", api.consensusSubscribeHandler)
 		router.POST("/consensus/validate/transactionset", api.consensusValidateTransactionsetHandler)
 	}
 
@@ -35,6 +41,9 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 	// Gateway API Calls
 	if api.gateway != nil {
 		router.GET("/gateway", api.gatewayHandler)
+		router.GET("/gateway/bans", api.gatewayBansHandlerGET)
+		router.POST("/gateway/bans", RequirePassword(api.gatewayBansHandlerPOST, requiredPassword))
+		router.POST("/gateway/bans/remove", RequirePassword(api.gatewayBansRemoveHandler, requiredPassword))
 		router.POST("/gateway/connect/:netaddress", RequirePassword(api.gatewayConnectHandler, requiredPassword))
 		router.POST("/gateway/disconnect/:netaddress", RequirePassword(api.gatewayDisconnectHandler, requiredPassword))
 	}
@@ -44,13 +53,20 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		// Calls directly pertaining to the host.
 		router.GET("/host", api.hostHandlerGET)                                                   // Get the host status.
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
+		router.GET("/host/alerts", api.hostAlertsHandlerGET)                                      // Get the problems the host is experiencing.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
 		router.GET("/host/contracts", api.hostContractInfoHandler)                                // Get info about contracts.
+		router.GET("/host/contracts/:id", api.hostContractHandlerGET)                             // Get the details of a contract.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.GET("/host/maintenance", api.hostMaintenanceHandlerGET)
+		router.POST("/host/maintenance", RequirePassword(api.hostMaintenanceHandlerPOST, requiredPassword))
+		router.GET("/host/metrics", api.hostMetricsHandlerGET) // Get the history of the host metrics.
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
+		router.POST("/host/storage/folders/migrate", RequirePassword(api.storageFoldersMigrateHandler, requiredPassword))
+		router.POST("/host/storage/folders/migrate/cancel", RequirePassword(api.storageFoldersMigrateCancelHandler, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
@@ -115,19 +131,36 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/wallet/address", RequirePassword(api.walletAddressHandler, requiredPassword))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
 		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
+		router.POST("/wallet/bump/:id", RequirePassword(api.walletBumpHandler, requiredPassword))
+		router.GET("/wallet/export", api.walletExportHandler)
 		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
 		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
+		router.POST("/wallet/label/:addr", RequirePassword(api.walletLabelHandler, requiredPassword))
 		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
+		router.POST("/wallet/multisig", RequirePassword(api.walletMultisigHandler, requiredPassword))
+		router.GET("/wallet/publickey", RequirePassword(api.walletPublicKeyHandler, requiredPassword))
+		router.GET("/wallet/schedule", api.walletScheduleHandlerGET)
+		router.POST("/wallet/schedule", RequirePassword(api.walletScheduleHandlerPOST, requiredPassword))
+		router.POST("/wallet/schedule/cancel/:id", RequirePassword(api.walletScheduleCancelHandler, requiredPassword))
+		router.GET("/wallet/schedule/history", api.walletScheduleHistoryHandler)
 		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
 		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
 		router.POST("/wallet/siacoins", RequirePassword(api.walletSiacoinsHandler, requiredPassword))
 		router.POST("/wallet/siafunds", RequirePassword(api.walletSiafundsHandler, requiredPassword))
 		router.POST("/wallet/siagkey", RequirePassword(api.walletSiagkeyHandler, requiredPassword))
+		router.POST("/wallet/sign", RequirePassword(api.walletSignHandler, requiredPassword))
 		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
+		router.POST("/wallet/transaction/:id", RequirePassword(api.walletTransactionHandlerPOST, requiredPassword))
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
+		router.GET("/wallet/unspent", api.walletUnspentHandler)
+		router.POST("/wallet/unspent/lock", RequirePassword(api.walletUnspentLockHandler, requiredPassword))
+		router.POST("/wallet/unspent/unlock", RequirePassword(api.walletUnspentUnlockHandler, requiredPassword))
+		router.POST("/wallet/unsignedtransaction", RequirePassword(api.walletUnsignedTransactionHandler, requiredPassword))
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
+		router.GET("/wallet/watch", api.walletWatchHandlerGET)
+		router.POST("/wallet/watch", RequirePassword(api.walletWatchHandlerPOST, requiredPassword))
 		router.POST("/wallet/unlock", RequirePassword(api.walletUnlockHandler, requiredPassword))
 		router.POST("/wallet/changepassword", RequirePassword(api.walletChangePasswordHandler, requiredPassword))
 	}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -32,6 +33,11 @@ type (
 		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
 		SiafundBalance      types.Currency `json:"siafundbalance"`
 
+		WatchOnlySiacoinBalance types.Currency `json:"watchonlysiacoinbalance"`
+		WatchOnlySiafundBalance types.Currency `json:"watchonlysiafundbalance"`
+
+		TimelockedSiacoinBalance types.Currency `json:"timelockedsiacoinbalance"`
+
 		DustThreshold types.Currency `json:"dustthreshold"`
 	}
 
@@ -45,6 +51,19 @@ type (
 	// GET call to /wallet/addresses.
 	WalletAddressesGET struct {
 		Addresses []types.UnlockHash `json:"addresses"`
+		Labels    map[string]string  `json:"labels"`
+	}
+
+	// WalletBumpPOST contains the transaction set submitted by a POST call to
+	// /wallet/bump/:id.
+	WalletBumpPOST struct {
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// WalletExportGET contains the transaction history returned by a GET
+	// call to /wallet/export.
+	WalletExportGET struct {
+		Transactions []modules.ExportedTransaction `json:"transactions"`
 	}
 
 	// WalletInitPOST contains the primary seed that gets generated during a
@@ -53,10 +72,51 @@ type (
 		PrimarySeed string `json:"primaryseed"`
 	}
 
+	// WalletMultisigPOST contains the multisig address created by a POST call
+	// to /wallet/multisig.
+	WalletMultisigPOST struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
+	// WalletPublicKeyGET contains a public key returned by a GET call to
+	// /wallet/publickey.
+	WalletPublicKeyGET struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+	}
+
+	// WalletScheduleGET contains the scheduled payments returned by a GET
+	// call to /wallet/schedule.
+	WalletScheduleGET struct {
+		Payments []modules.ScheduledPayment `json:"payments"`
+	}
+
+	// WalletSchedulePOST contains the scheduled payment created by a POST
+	// call to /wallet/schedule.
+	WalletSchedulePOST struct {
+		Payment modules.ScheduledPayment `json:"payment"`
+	}
+
+	// WalletScheduleHistoryGET contains the executed runs of scheduled
+	// payments returned by a GET call to /wallet/schedule/history.
+	WalletScheduleHistoryGET struct {
+		Executions []modules.ScheduledPaymentExecution `json:"executions"`
+	}
+
+	// WalletSignPOST contains the transaction signed by a POST call to
+	// /wallet/sign.
+	WalletSignPOST struct {
+		Transaction modules.UnsignedTransaction `json:"transaction"`
+	}
+
 	// WalletSiacoinsPOST contains the transaction sent in the POST call to
 	// /wallet/siacoins.
 	WalletSiacoinsPOST struct {
 		TransactionIDs []types.TransactionID `json:"transactionids"`
+
+		// MetadataError is set if the coins were sent, but the note or the
+		// label could not be saved.
+		MetadataError string `json:"metadataerror,omitempty"`
 	}
 
 	// WalletSiafundsPOST contains the transaction sent in the POST call to
@@ -83,21 +143,45 @@ type (
 	// /wallet/transaction/:id
 	WalletTransactionGETid struct {
 		Transaction modules.ProcessedTransaction `json:"transaction"`
+		Note        modules.TransactionNote      `json:"note"`
+		Labels      map[string]string            `json:"labels"`
 	}
 
 	// WalletTransactionsGET contains the specified set of confirmed and
 	// unconfirmed transactions.
 	WalletTransactionsGET struct {
-		ConfirmedTransactions   []modules.ProcessedTransaction `json:"confirmedtransactions"`
-		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
+		ConfirmedTransactions   []modules.ProcessedTransaction     `json:"confirmedtransactions"`
+		UnconfirmedTransactions []modules.ProcessedTransaction     `json:"unconfirmedtransactions"`
+		Notes                   map[string]modules.TransactionNote `json:"notes"`
+		Labels                  map[string]string                  `json:"labels"`
 	}
 
 	// WalletTransactionsGETaddr contains the set of wallet transactions
 	// relevant to the input address provided in the call to
 	// /wallet/transaction/:addr
 	WalletTransactionsGETaddr struct {
-		ConfirmedTransactions   []modules.ProcessedTransaction `json:"confirmedtransactions"`
-		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
+		ConfirmedTransactions   []modules.ProcessedTransaction     `json:"confirmedtransactions"`
+		UnconfirmedTransactions []modules.ProcessedTransaction     `json:"unconfirmedtransactions"`
+		Notes                   map[string]modules.TransactionNote `json:"notes"`
+		Labels                  map[string]string                  `json:"labels"`
+	}
+
+	// WalletUnsignedTransactionPOST contains the unsigned transaction
+	// created by a POST call to /wallet/unsignedtransaction.
+	WalletUnsignedTransactionPOST struct {
+		Transaction modules.UnsignedTransaction `json:"transaction"`
+	}
+
+	// WalletUnspentGET contains the spendable outputs of the wallet returned
+	// by a GET call to /wallet/unspent.
+	WalletUnspentGET struct {
+		Outputs []modules.UnspentOutput `json:"outputs"`
+	}
+
+	// WalletWatchGET contains the set of watch-only addresses returned by a
+	// GET call to /wallet/watch.
+	WalletWatchGET struct {
+		Addresses []modules.WatchOnlyAddress `json:"addresses"`
 	}
 
 	// WalletVerifyAddressGET contains a bool indicating if the address passed to
@@ -134,6 +218,16 @@ func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httpro
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	watchSiacoinBal, watchSiafundBal, err := api.wallet.WatchOnlyBalance()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	timelockedSiacoinBal, err := api.wallet.TimelockedBalance()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
 	dustThreshold, err := api.wallet.DustThreshold()
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
@@ -172,6 +266,11 @@ func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httpro
 		SiafundBalance:      siafundBal,
 		SiacoinClaimBalance: siaclaimBal,
 
+		WatchOnlySiacoinBalance: watchSiacoinBal,
+		WatchOnlySiafundBalance: watchSiafundBal,
+
+		TimelockedSiacoinBalance: timelockedSiacoinBal,
+
 		DustThreshold: dustThreshold,
 	})
 }
@@ -206,11 +305,80 @@ func (api *API) walletAddressHandler(w http.ResponseWriter, req *http.Request, _
 		WriteError(w, Error{"error when calling /wallet/addresses: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	// A time-locked address is derived from the new address.
+	if req.FormValue("unlockheight") != "" {
+		var unlockHeight types.BlockHeight
+		if _, err := fmt.Sscan(req.FormValue("unlockheight"), &unlockHeight); err != nil {
+			WriteError(w, Error{"could not read unlockheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		unlockConditions, err = api.wallet.TimelockedAddress(unlockConditions.UnlockHash(), unlockHeight)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteJSON(w, WalletAddressGET{
 		Address: unlockConditions.UnlockHash(),
 	})
 }
 
+// walletMultisigHandler handles API calls to /wallet/multisig.
+func (api *API) walletMultisigHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var publicKeys []types.SiaPublicKey
+	err := json.Unmarshal([]byte(req.FormValue("publickeys")), &publicKeys)
+	if err != nil {
+		WriteError(w, Error{"could not decode publickeys: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	required, err := strconv.ParseUint(req.FormValue("signaturesrequired"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"could not decode signaturesrequired: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	unused, err := scanBool(req.FormValue("unused"))
+	if err != nil {
+		WriteError(w, Error{"could not decode unused: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	uc, err := api.wallet.AddMultisigAddress(publicKeys, required, unused)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+	})
+}
+
+// walletPublicKeyHandler handles API calls to /wallet/publickey.
+func (api *API) walletPublicKeyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	unlockConditions, err := api.wallet.NextAddress()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/publickey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPublicKeyGET{
+		PublicKey: unlockConditions.PublicKeys[0],
+	})
+}
+
+// walletSignHandler handles API calls to /wallet/sign.
+func (api *API) walletSignHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var utxn modules.UnsignedTransaction
+	err := json.Unmarshal([]byte(req.FormValue("transaction")), &utxn)
+	if err != nil {
+		WriteError(w, Error{"could not decode transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.AddTransactionSignatures(&utxn); err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSignPOST{Transaction: utxn})
+}
+
 // walletAddressHandler handles API calls to /wallet/addresses.
 func (api *API) walletAddressesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	addresses, err := api.wallet.AllAddresses()
@@ -218,8 +386,58 @@ func (api *API) walletAddressesHandler(w http.ResponseWriter, req *http.Request,
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	allLabels, err := api.wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	labels := make(map[string]string)
+	for _, addr := range addresses {
+		if label, ok := allLabels[addr]; ok {
+			labels[addr.String()] = label
+		}
+	}
 	WriteJSON(w, WalletAddressesGET{
 		Addresses: addresses,
+		Labels:    labels,
+	})
+}
+
+// walletBumpHandler handles API calls to /wallet/bump/:id.
+func (api *API) walletBumpHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	err := id.UnmarshalJSON([]byte(jsonID))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/bump/id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var fee types.Currency
+	if feeStr := req.FormValue("fee"); feeStr != "" {
+		var ok bool
+		fee, ok = scanAmount(feeStr)
+		if !ok {
+			WriteError(w, Error{"could not read fee from POST call to /wallet/bump/id"}, http.StatusBadRequest)
+			return
+		}
+	}
+	replace, err := scanBool(req.FormValue("replace"))
+	if err != nil {
+		WriteError(w, Error{"could not read replace from POST call to /wallet/bump/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	txns, err := api.wallet.BumpTransactionFee(id, fee, replace)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/bump/id: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletBumpPOST{
+		TransactionIDs: txids,
 	})
 }
 
@@ -239,6 +457,36 @@ func (api *API) walletBackupHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// walletExportHandler handles API calls to /wallet/export.
+func (api *API) walletExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// The height range is optional; by default the whole history is
+	// exported.
+	start, end := uint64(0), uint64(math.MaxUint64)
+	var err error
+	if s := req.FormValue("startheight"); s != "" {
+		start, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if s := req.FormValue("endheight"); s != "" && s != "-1" {
+		end, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `endheight` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	ets, err := api.wallet.ExportTransactions(types.BlockHeight(start), types.BlockHeight(end))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/export: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletExportGET{
+		Transactions: ets,
+	})
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func (api *API) walletInitHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.TwofishKey
@@ -361,6 +609,21 @@ func (api *API) walletSiagkeyHandler(w http.ResponseWriter, req *http.Request, _
 	WriteError(w, Error{"error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
 }
 
+// walletLabelHandler handles API calls to /wallet/label/:addr.
+func (api *API) walletLabelHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.wallet.SetAddressLabel(addr, req.FormValue("label"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletLockHanlder handles API calls to /wallet/lock.
 func (api *API) walletLockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	err := api.wallet.Lock()
@@ -412,9 +675,124 @@ func (api *API) walletSeedsHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
+// scanScheduledPayment reads the scheduled payment of a POST call to
+// /wallet/schedule.
+func scanScheduledPayment(req *http.Request) (sp modules.ScheduledPayment, err error) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		return sp, errors.New("could not read amount")
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		return sp, errors.New("could not read destination")
+	}
+	sp = modules.ScheduledPayment{
+		Destination: dest,
+		Amount:      amount,
+		CatchUp:     modules.CatchUpAll,
+	}
+	if catchUp := req.FormValue("catchup"); catchUp != "" {
+		sp.CatchUp = catchUp
+	}
+	for _, param := range []struct {
+		name string
+		val  *uint64
+	}{
+		{"startheight", (*uint64)(&sp.StartHeight)},
+		{"starttime", (*uint64)(&sp.StartTime)},
+		{"interval", &sp.Interval},
+		{"maxcount", &sp.MaxCount},
+	} {
+		if str := req.FormValue(param.name); str != "" {
+			if *param.val, err = strconv.ParseUint(str, 10, 64); err != nil {
+				return sp, fmt.Errorf("could not read %v: %v", param.name, err)
+			}
+		}
+	}
+	return sp, nil
+}
+
+// walletScheduleHandlerGET handles GET calls to /wallet/schedule.
+func (api *API) walletScheduleHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	payments, err := api.wallet.ScheduledPayments()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletScheduleGET{
+		Payments: payments,
+	})
+}
+
+// walletScheduleHandlerPOST handles POST calls to /wallet/schedule.
+func (api *API) walletScheduleHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	sp, err := scanScheduledPayment(req)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sp, err = api.wallet.SchedulePayment(sp)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSchedulePOST{
+		Payment: sp,
+	})
+}
+
+// walletScheduleCancelHandler handles API calls to
+// /wallet/schedule/cancel/:id.
+func (api *API) walletScheduleCancelHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	id, err := strconv.ParseUint(ps.ByName("id"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedule/cancel/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.CancelScheduledPayment(id); err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedule/cancel/id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletScheduleHistoryHandler handles API calls to /wallet/schedule/history.
+func (api *API) walletScheduleHistoryHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	history, err := api.wallet.ScheduledPaymentHistory()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/schedule/history: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletScheduleHistoryGET{
+		Executions: history,
+	})
+}
+
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	var txns []types.Transaction
+	// The note and the label are checked before sending, so that a request
+	// that is rejected never sends any coins.
+	note, err := scanTransactionNote(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	label := req.FormValue("label")
+	if err := modules.ValidateAddressLabel(label); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	var inputs []types.SiacoinOutputID
+	if req.FormValue("inputs") != "" {
+		err := json.Unmarshal([]byte(req.FormValue("inputs")), &inputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode inputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var outputs []types.SiacoinOutput
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
 		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
@@ -422,17 +800,11 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 
-		var outputs []types.SiacoinOutput
 		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
 		if err != nil {
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = api.wallet.SendSiacoinsMulti(outputs)
-		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
-			return
-		}
 	} else {
 		// single amount + destination
 		amount, ok := scanAmount(req.FormValue("amount"))
@@ -445,22 +817,66 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 			return
 		}
+		outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	}
 
-		txns, err = api.wallet.SendSiacoins(amount, dest)
-		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+	// The outputs are sent to time-locked addresses derived from the
+	// destinations, which must belong to the wallet.
+	if req.FormValue("unlockheight") != "" {
+		var unlockHeight types.BlockHeight
+		if _, err := fmt.Sscan(req.FormValue("unlockheight"), &unlockHeight); err != nil {
+			WriteError(w, Error{"could not read unlockheight: " + err.Error()}, http.StatusBadRequest)
 			return
 		}
+		for i := range outputs {
+			uc, err := api.wallet.TimelockedAddress(outputs[i].UnlockHash, unlockHeight)
+			if err != nil {
+				WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			outputs[i].UnlockHash = uc.UnlockHash()
+		}
+	}
 
+	var txns []types.Transaction
+	if len(inputs) != 0 {
+		txns, err = api.wallet.SendSiacoinsFromInputs(outputs, inputs)
+	} else if req.FormValue("outputs") != "" {
+		txns, err = api.wallet.SendSiacoinsMulti(outputs)
+	} else {
+		txns, err = api.wallet.SendSiacoins(outputs[0].Value, outputs[0].UnlockHash)
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+		return
 	}
 
 	var txids []types.TransactionID
 	for _, txn := range txns {
 		txids = append(txids, txn.ID())
 	}
-	WriteJSON(w, WalletSiacoinsPOST{
+	resp := WalletSiacoinsPOST{
 		TransactionIDs: txids,
-	})
+	}
+
+	// The note is attached to the transaction that sends the outputs, which
+	// is the last transaction of the set. The coins have already been sent,
+	// so failing to save the note or the label is reported alongside the
+	// transaction IDs rather than as an error, which would invite a retry.
+	if note.Note != "" || len(note.Tags) != 0 {
+		if err := api.wallet.SetTransactionNote(txids[len(txids)-1], note); err != nil {
+			resp.MetadataError = "the note could not be saved: " + err.Error()
+		}
+	}
+	if label != "" && resp.MetadataError == "" {
+		for _, sco := range outputs {
+			if err := api.wallet.SetAddressLabel(sco.UnlockHash, label); err != nil {
+				resp.MetadataError = "the label could not be saved: " + err.Error()
+				break
+			}
+		}
+	}
+	WriteJSON(w, resp)
 }
 
 // walletSiafundsHandler handles API calls to /wallet/siafunds.
@@ -514,6 +930,85 @@ func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// scanTransactionNote reads the 'note' and 'tags' parameters of a request.
+// Tags are provided as a JSON array of strings.
+func scanTransactionNote(req *http.Request) (modules.TransactionNote, error) {
+	note := modules.TransactionNote{Note: req.FormValue("note")}
+	if req.FormValue("tags") != "" {
+		err := json.Unmarshal([]byte(req.FormValue("tags")), &note.Tags)
+		if err != nil {
+			return modules.TransactionNote{}, errors.New("could not decode tags: " + err.Error())
+		}
+	}
+	if err := note.Validate(); err != nil {
+		return modules.TransactionNote{}, err
+	}
+	return note, nil
+}
+
+// walletTransactionMetadata returns the notes of the provided transactions
+// and the labels of the addresses that appear in them. Both are keyed by the
+// string representation of the transaction ID or address.
+func (api *API) walletTransactionMetadata(txns []modules.ProcessedTransaction) (map[string]modules.TransactionNote, map[string]string, error) {
+	allNotes, err := api.wallet.TransactionNotes()
+	if err != nil {
+		return nil, nil, err
+	}
+	allLabels, err := api.wallet.AddressLabels()
+	if err != nil {
+		return nil, nil, err
+	}
+	notes := make(map[string]modules.TransactionNote)
+	labels := make(map[string]string)
+	addLabel := func(addr types.UnlockHash) {
+		if label, ok := allLabels[addr]; ok {
+			labels[addr.String()] = label
+		}
+	}
+	for _, txn := range txns {
+		if note, ok := allNotes[txn.TransactionID]; ok {
+			notes[txn.TransactionID.String()] = note
+		}
+		for _, input := range txn.Inputs {
+			addLabel(input.RelatedAddress)
+		}
+		for _, output := range txn.Outputs {
+			addLabel(output.RelatedAddress)
+		}
+	}
+	return notes, labels, nil
+}
+
+// filterTransactionsByLabel returns the transactions that have label as a tag
+// or that have an input or output related to an address with the label.
+func filterTransactionsByLabel(txns []modules.ProcessedTransaction, label string, notes map[types.TransactionID]modules.TransactionNote, labels map[types.UnlockHash]string) []modules.ProcessedTransaction {
+	hasLabel := func(txn modules.ProcessedTransaction) bool {
+		for _, tag := range notes[txn.TransactionID].Tags {
+			if tag == label {
+				return true
+			}
+		}
+		for _, input := range txn.Inputs {
+			if labels[input.RelatedAddress] == label {
+				return true
+			}
+		}
+		for _, output := range txn.Outputs {
+			if labels[output.RelatedAddress] == label {
+				return true
+			}
+		}
+		return false
+	}
+	var filtered []modules.ProcessedTransaction
+	for _, txn := range txns {
+		if hasLabel(txn) {
+			filtered = append(filtered, txn)
+		}
+	}
+	return filtered
+}
+
 // walletTransactionHandler handles API calls to /wallet/transaction/:id.
 func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse the id from the url.
@@ -534,11 +1029,41 @@ func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Reques
 		WriteError(w, Error{"error when calling /wallet/transaction/:id  :  transaction not found"}, http.StatusBadRequest)
 		return
 	}
+	notes, labels, err := api.walletTransactionMetadata([]modules.ProcessedTransaction{txn})
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, WalletTransactionGETid{
 		Transaction: txn,
+		Note:        notes[id.String()],
+		Labels:      labels,
 	})
 }
 
+// walletTransactionHandlerPOST handles API calls to POST
+// /wallet/transaction/:id, which attaches a note and tags to a transaction.
+func (api *API) walletTransactionHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	err := id.UnmarshalJSON([]byte(jsonID))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	note, err := scanTransactionNote(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.wallet.SetTransactionNote(id, note)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletTransactionsHandler handles API calls to /wallet/transactions.
 func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
@@ -575,9 +1100,33 @@ func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	// If a label is provided, only the transactions that have the label as a
+	// tag or that involve an address with the label are returned.
+	if label := req.FormValue("label"); label != "" {
+		notes, err := api.wallet.TransactionNotes()
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		labels, err := api.wallet.AddressLabels()
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		confirmedTxns = filterTransactionsByLabel(confirmedTxns, label, notes, labels)
+		unconfirmedTxns = filterTransactionsByLabel(unconfirmedTxns, label, notes, labels)
+	}
+
+	notes, labels, err := api.walletTransactionMetadata(append(confirmedTxns, unconfirmedTxns...))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, WalletTransactionsGET{
 		ConfirmedTransactions:   confirmedTxns,
 		UnconfirmedTransactions: unconfirmedTxns,
+		Notes:                   notes,
+		Labels:                  labels,
 	})
 }
 
@@ -603,9 +1152,16 @@ func (api *API) walletTransactionsAddrHandler(w http.ResponseWriter, req *http.R
 		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	notes, labels, err := api.walletTransactionMetadata(append(confirmedATs, unconfirmedATs...))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, WalletTransactionsGETaddr{
 		ConfirmedTransactions:   confirmedATs,
 		UnconfirmedTransactions: unconfirmedATs,
+		Notes:                   notes,
+		Labels:                  labels,
 	})
 }
 
@@ -658,3 +1214,150 @@ func (api *API) walletVerifyAddressHandler(w http.ResponseWriter, req *http.Requ
 	err := new(types.UnlockHash).LoadString(addrString)
 	WriteJSON(w, WalletVerifyAddressGET{Valid: err == nil})
 }
+
+// walletUnsignedTransactionHandler handles API calls to
+// /wallet/unsignedtransaction.
+func (api *API) walletUnsignedTransactionHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var outputs []types.SiacoinOutput
+	if req.FormValue("outputs") != "" {
+		// multiple amounts + destinations
+		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
+			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair"}, http.StatusBadRequest)
+			return
+		}
+		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		// single amount + destination
+		amount, ok := scanAmount(req.FormValue("amount"))
+		if !ok {
+			WriteError(w, Error{"could not read amount from POST call to /wallet/unsignedtransaction"}, http.StatusBadRequest)
+			return
+		}
+		dest, err := scanAddress(req.FormValue("destination"))
+		if err != nil {
+			WriteError(w, Error{"could not read address from POST call to /wallet/unsignedtransaction"}, http.StatusBadRequest)
+			return
+		}
+		outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	}
+
+	utxn, err := api.wallet.CreateUnsignedTransaction(outputs)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/unsignedtransaction: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletUnsignedTransactionPOST{Transaction: utxn})
+}
+
+// walletUnspentHandler handles API calls to /wallet/unspent.
+func (api *API) walletUnspentHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	outputs, err := api.wallet.UnspentOutputs()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/unspent: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletUnspentGET{Outputs: outputs})
+}
+
+// walletUnspentLockHandler handles API calls to /wallet/unspent/lock.
+func (api *API) walletUnspentLockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var ids []types.OutputID
+	err := json.Unmarshal([]byte(req.FormValue("outputids")), &ids)
+	if err != nil {
+		WriteError(w, Error{"could not decode outputids: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.LockOutputs(ids); err != nil {
+		WriteError(w, Error{"error when calling /wallet/unspent/lock: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletUnspentUnlockHandler handles API calls to /wallet/unspent/unlock.
+func (api *API) walletUnspentUnlockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var ids []types.OutputID
+	err := json.Unmarshal([]byte(req.FormValue("outputids")), &ids)
+	if err != nil {
+		WriteError(w, Error{"could not decode outputids: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.UnlockOutputs(ids); err != nil {
+		WriteError(w, Error{"error when calling /wallet/unspent/unlock: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletWatchHandlerGET handles GET calls to /wallet/watch.
+func (api *API) walletWatchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addrs, err := api.wallet.WatchAddresses()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/watch: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletWatchGET{Addresses: addrs})
+}
+
+// walletWatchHandlerPOST handles POST calls to /wallet/watch. Addresses can be
+// provided either directly or through their unlock conditions.
+func (api *API) walletWatchHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var addrs []types.UnlockHash
+	if req.FormValue("addresses") != "" {
+		err := json.Unmarshal([]byte(req.FormValue("addresses")), &addrs)
+		if err != nil {
+			WriteError(w, Error{"could not decode addresses: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var ucs []types.UnlockConditions
+	if req.FormValue("unlockconditions") != "" {
+		err := json.Unmarshal([]byte(req.FormValue("unlockconditions")), &ucs)
+		if err != nil {
+			WriteError(w, Error{"could not decode unlock conditions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if len(addrs) == 0 && len(ucs) == 0 {
+		WriteError(w, Error{"no addresses or unlock conditions provided"}, http.StatusBadRequest)
+		return
+	}
+	remove, err := scanBool(req.FormValue("remove"))
+	if err != nil {
+		WriteError(w, Error{"could not decode remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	unused, err := scanBool(req.FormValue("unused"))
+	if err != nil {
+		WriteError(w, Error{"could not decode unused: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if remove {
+		for _, uc := range ucs {
+			addrs = append(addrs, uc.UnlockHash())
+		}
+		err = api.wallet.RemoveWatchAddresses(addrs)
+	} else {
+		watched := make([]modules.WatchOnlyAddress, 0, len(addrs)+len(ucs))
+		for _, addr := range addrs {
+			watched = append(watched, modules.WatchOnlyAddress{Address: addr})
+		}
+		for i := range ucs {
+			watched = append(watched, modules.WatchOnlyAddress{
+				Address:          ucs[i].UnlockHash(),
+				UnlockConditions: &ucs[i],
+			})
+		}
+		err = api.wallet.AddWatchAddresses(watched, unused)
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/watch: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
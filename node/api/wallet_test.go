@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -1762,3 +1763,621 @@ func TestWalletTransactionsGetAddr(t *testing.T) {
 		t.Errorf("There should be exactly 0 unconfirmed and 1 confirmed related txns")
 	}
 }
+
+// TestWalletWatch checks that watch-only addresses can be added, listed and
+// removed through the API.
+func TestWalletWatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	addr := types.UnlockHash{1, 2, 3}
+	addrsJSON, err := json.Marshal([]types.UnlockHash{addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watchValues := url.Values{}
+	watchValues.Set("addresses", string(addrsJSON))
+	watchValues.Set("unused", "true")
+	if err := st.stdPostAPI("/wallet/watch", watchValues); err != nil {
+		t.Fatal(err)
+	}
+	var wwg WalletWatchGET
+	if err := st.getAPI("/wallet/watch", &wwg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wwg.Addresses) != 1 || wwg.Addresses[0].Address != addr {
+		t.Fatal("watch-only address was not added:", wwg.Addresses)
+	}
+
+	// A request without addresses should be rejected.
+	if err := st.stdPostAPI("/wallet/watch", url.Values{}); err == nil {
+		t.Fatal("expected an error when no addresses are provided")
+	}
+
+	watchValues.Set("remove", "true")
+	if err := st.stdPostAPI("/wallet/watch", watchValues); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/wallet/watch", &wwg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wwg.Addresses) != 0 {
+		t.Fatal("watch-only address was not removed")
+	}
+}
+
+// TestWalletUnsignedTransaction probes the POST call to
+// /wallet/unsignedtransaction.
+func TestWalletUnsignedTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Watch an address by its unlock conditions and fund it.
+	sk, pk := crypto.GenerateKeyPair()
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+	ucsJSON, err := json.Marshal([]types.UnlockConditions{uc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watchValues := url.Values{}
+	watchValues.Set("unlockconditions", string(ucsJSON))
+	watchValues.Set("unused", "true")
+	if err := st.stdPostAPI("/wallet/watch", watchValues); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	utxnValues := url.Values{}
+	utxnValues.Set("amount", types.SiacoinPrecision.Mul64(10).String())
+	utxnValues.Set("destination", types.UnlockHash{}.String())
+	var wutp WalletUnsignedTransactionPOST
+	if err := st.postAPI("/wallet/unsignedtransaction", utxnValues, &wutp); err != nil {
+		t.Fatal(err)
+	}
+	utxn := wutp.Transaction
+	if len(utxn.Inputs) != 1 || utxn.Inputs[0].UnlockConditions.UnlockHash() != uc.UnlockHash() {
+		t.Fatal("unsigned transaction does not spend the watched output")
+	}
+
+	// Sign the transaction by hand and submit it to the transaction pool.
+	txn := utxn.Transaction
+	txn.TransactionSignatures = []types.TransactionSignature{{
+		ParentID:       utxn.Inputs[0].ParentID,
+		CoveredFields:  utxn.Inputs[0].CoveredFields,
+		PublicKeyIndex: 0,
+	}}
+	sigHash := txn.SigHash(0)
+	encodedSig := crypto.SignHash(sigHash, sk)
+	txn.TransactionSignatures[0].Signature = encodedSig[:]
+	if err := st.tpool.AcceptTransactionSet(append(utxn.Parents, txn)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The watched output is marked as spent.
+	if err := st.stdPostAPI("/wallet/unsignedtransaction", utxnValues); err == nil {
+		t.Fatal("expected an error when the watched outputs are spent")
+	}
+}
+
+// TestWalletMultisig probes the /wallet/publickey, /wallet/multisig and
+// /wallet/sign endpoints.
+func TestWalletMultisig(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Create a 1-of-2 multisig address with a key of the wallet.
+	var wpkg WalletPublicKeyGET
+	if err := st.getAPI("/wallet/publickey", &wpkg); err != nil {
+		t.Fatal(err)
+	}
+	_, pk := crypto.GenerateKeyPair()
+	keysJSON, err := json.Marshal([]types.SiaPublicKey{types.Ed25519PublicKey(pk), wpkg.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	multisigValues := url.Values{}
+	multisigValues.Set("publickeys", string(keysJSON))
+	multisigValues.Set("signaturesrequired", "3")
+	multisigValues.Set("unused", "true")
+	if err := st.stdPostAPI("/wallet/multisig", multisigValues); err == nil {
+		t.Fatal("expected an error when requiring more signatures than keys")
+	}
+	multisigValues.Set("signaturesrequired", "1")
+	var wmp WalletMultisigPOST
+	if err := st.postAPI("/wallet/multisig", multisigValues, &wmp); err != nil {
+		t.Fatal(err)
+	}
+	if wmp.UnlockConditions.UnlockHash() != wmp.Address {
+		t.Fatal("multisig address does not match its unlock conditions")
+	}
+
+	// Fund the address and spend from it.
+	if _, err := st.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), wmp.Address); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	utxnValues := url.Values{}
+	utxnValues.Set("amount", types.SiacoinPrecision.Mul64(10).String())
+	utxnValues.Set("destination", types.UnlockHash{}.String())
+	var wutp WalletUnsignedTransactionPOST
+	if err := st.postAPI("/wallet/unsignedtransaction", utxnValues, &wutp); err != nil {
+		t.Fatal(err)
+	}
+	txnJSON, err := json.Marshal(wutp.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signValues := url.Values{}
+	signValues.Set("transaction", string(txnJSON))
+	var wsp WalletSignPOST
+	if err := st.postAPI("/wallet/sign", signValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	if len(wsp.Transaction.Inputs) != 0 {
+		t.Fatal("signed transaction still lists inputs to sign")
+	}
+	if err := st.tpool.AcceptTransactionSet(append(wsp.Transaction.Parents, wsp.Transaction.Transaction)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWalletUnspent probes the /wallet/unspent endpoints and the inputs
+// parameter of /wallet/siacoins.
+func TestWalletUnspent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var wug WalletUnspentGET
+	if err := st.getAPI("/wallet/unspent", &wug); err != nil {
+		t.Fatal(err)
+	}
+	if len(wug.Outputs) == 0 {
+		t.Fatal("expected unspent outputs")
+	}
+	id := wug.Outputs[0].ID
+	idsJSON, err := json.Marshal([]types.OutputID{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockValues := url.Values{}
+	lockValues.Set("outputids", string(idsJSON))
+	if err := st.stdPostAPI("/wallet/unspent/lock", lockValues); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/wallet/unspent", &wug); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range wug.Outputs {
+		if o.Locked != (o.ID == id) {
+			t.Fatal("unexpected lock state of output", o.ID)
+		}
+	}
+
+	// Spend the locked output explicitly.
+	inputsJSON, err := json.Marshal([]types.SiacoinOutputID{types.SiacoinOutputID(id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendValues := url.Values{}
+	sendValues.Set("amount", types.SiacoinPrecision.String())
+	sendValues.Set("destination", types.UnlockHash{}.String())
+	sendValues.Set("inputs", string(inputsJSON))
+	var wsp WalletSiacoinsPOST
+	if err := st.postAPI("/wallet/siacoins", sendValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	if len(wsp.TransactionIDs) == 0 {
+		t.Fatal("no transactions were created")
+	}
+
+	if err := st.stdPostAPI("/wallet/unspent/unlock", lockValues); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWalletLabels checks that labels and notes set through the API are
+// returned with the addresses and transactions of the wallet, and that the
+// transaction history can be searched by label.
+func TestWalletLabels(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Label one of the wallet's addresses.
+	var wag WalletAddressesGET
+	if err := st.getAPI("/wallet/addresses", &wag); err != nil {
+		t.Fatal(err)
+	}
+	if len(wag.Addresses) == 0 {
+		t.Fatal("expected wallet addresses")
+	}
+	labelValues := url.Values{}
+	labelValues.Set("label", "savings")
+	if err := st.stdPostAPI("/wallet/label/"+wag.Addresses[0].String(), labelValues); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/wallet/addresses", &wag); err != nil {
+		t.Fatal(err)
+	}
+	if wag.Labels[wag.Addresses[0].String()] != "savings" {
+		t.Fatal("address label was not returned:", wag.Labels)
+	}
+
+	// Coins are not sent if the label is too long.
+	dest := types.UnlockHash{1}
+	sendValues := url.Values{}
+	sendValues.Set("amount", types.SiacoinPrecision.String())
+	sendValues.Set("destination", dest.String())
+	sendValues.Set("label", strings.Repeat("x", modules.MaxAddressLabelLength+1))
+	if err := st.stdPostAPI("/wallet/siacoins", sendValues); err == nil {
+		t.Fatal("expected an overly long label to be rejected")
+	}
+	var wtg WalletTransactionsGET
+	if err := st.getAPI("/wallet/transactions?startheight=0&endheight=-1", &wtg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wtg.UnconfirmedTransactions) != 0 {
+		t.Fatal("coins were sent despite the invalid label")
+	}
+
+	// Send coins with a note and a label for the destination.
+	sendValues = url.Values{}
+	sendValues.Set("amount", types.SiacoinPrecision.String())
+	sendValues.Set("destination", dest.String())
+	sendValues.Set("note", "invoice 42")
+	sendValues.Set("tags", `["invoices"]`)
+	sendValues.Set("label", "customer")
+	var wsp WalletSiacoinsPOST
+	if err := st.postAPI("/wallet/siacoins", sendValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	if wsp.MetadataError != "" {
+		t.Fatal("unexpected metadata error:", wsp.MetadataError)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+
+	if err := st.getAPI("/wallet/transactions?startheight=0&endheight=-1", &wtg); err != nil {
+		t.Fatal(err)
+	}
+	if note := wtg.Notes[txid.String()]; note.Note != "invoice 42" || len(note.Tags) != 1 || note.Tags[0] != "invoices" {
+		t.Fatal("transaction note was not returned:", wtg.Notes)
+	}
+	if wtg.Labels[dest.String()] != "customer" {
+		t.Fatal("destination label was not returned:", wtg.Labels)
+	}
+
+	// Searching by tag and by address label only returns the sent
+	// transaction.
+	for _, label := range []string{"invoices", "customer"} {
+		if err := st.getAPI("/wallet/transactions?startheight=0&endheight=-1&label="+label, &wtg); err != nil {
+			t.Fatal(err)
+		}
+		txns := append(wtg.ConfirmedTransactions, wtg.UnconfirmedTransactions...)
+		if len(txns) != 1 || txns[0].TransactionID != txid {
+			t.Fatalf("expected only the sent transaction for label %q, got %v transactions", label, len(txns))
+		}
+	}
+
+	// Notes can be changed after sending.
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	noteValues := url.Values{}
+	noteValues.Set("note", "refunded")
+	if err := st.stdPostAPI("/wallet/transaction/"+txid.String(), noteValues); err != nil {
+		t.Fatal(err)
+	}
+	var wtgid WalletTransactionGETid
+	if err := st.getAPI("/wallet/transaction/"+txid.String(), &wtgid); err != nil {
+		t.Fatal(err)
+	}
+	if wtgid.Note.Note != "refunded" || len(wtgid.Note.Tags) != 0 {
+		t.Fatal("transaction note was not updated:", wtgid.Note)
+	}
+}
+
+// TestWalletExport probes the /wallet/export endpoint.
+func TestWalletExport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Send coins to an external address and confirm the transaction.
+	sendValues := url.Values{}
+	sendValues.Set("amount", types.SiacoinPrecision.String())
+	sendValues.Set("destination", types.UnlockHash{1}.String())
+	sendValues.Set("note", "rent")
+	var wsp WalletSiacoinsPOST
+	if err := st.postAPI("/wallet/siacoins", sendValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	var weg WalletExportGET
+	if err := st.getAPI("/wallet/export?startheight=0&endheight=-1", &weg); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, et := range weg.Transactions {
+		if et.TransactionID != txid {
+			continue
+		}
+		found = true
+		if et.Role != modules.TransactionRoleTransfer {
+			t.Fatal("wrong role:", et.Role)
+		}
+		if !et.Outgoing.Equals(types.SiacoinPrecision) {
+			t.Fatal("wrong outgoing amount:", et.Outgoing)
+		}
+		if et.Note != "rent" {
+			t.Fatal("note was not exported:", et.Note)
+		}
+	}
+	if !found {
+		t.Fatal("sent transaction was not exported")
+	}
+
+	// Restricting the range to a height without transactions returns
+	// nothing, and an inverted range is rejected.
+	if err := st.getAPI("/wallet/export?startheight=100000&endheight=100001", &weg); err != nil {
+		t.Fatal(err)
+	}
+	if len(weg.Transactions) != 0 {
+		t.Fatal("expected no transactions, got", len(weg.Transactions))
+	}
+	if err := st.getAPI("/wallet/export?startheight=2&endheight=1", &weg); err == nil {
+		t.Fatal("expected an error for an inverted range")
+	}
+}
+
+// TestWalletBump probes the /wallet/bump/:id endpoint.
+func TestWalletBump(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	sendValues := url.Values{}
+	sendValues.Set("amount", types.SiacoinPrecision.String())
+	sendValues.Set("destination", types.UnlockHash{1}.String())
+	var wsp WalletSiacoinsPOST
+	if err := st.postAPI("/wallet/siacoins", sendValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+
+	// Bump the fee with a child transaction, then replace the transaction
+	// using the recommended fee.
+	bumpValues := url.Values{}
+	bumpValues.Set("fee", types.SiacoinPrecision.String())
+	var wbp WalletBumpPOST
+	if err := st.postAPI("/wallet/bump/"+txid.String(), bumpValues, &wbp); err != nil {
+		t.Fatal(err)
+	}
+	child := wbp.TransactionIDs[len(wbp.TransactionIDs)-1]
+	if _, _, exists := st.tpool.Transaction(child); !exists {
+		t.Fatal("child transaction is not in the transaction pool")
+	}
+	bumpValues = url.Values{}
+	bumpValues.Set("fee", types.SiacoinPrecision.Mul64(2).String())
+	bumpValues.Set("replace", "true")
+	if err := st.postAPI("/wallet/bump/"+txid.String(), bumpValues, &wbp); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, exists := st.tpool.Transaction(txid); exists {
+		t.Fatal("replaced transaction is still in the transaction pool")
+	}
+
+	// The original transaction can no longer be bumped.
+	if err := st.postAPI("/wallet/bump/"+txid.String(), url.Values{}, &wbp); err == nil {
+		t.Fatal("expected an error when bumping a replaced transaction")
+	}
+}
+
+// TestWalletSchedule probes the /wallet/schedule endpoints.
+func TestWalletSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// An unknown catch-up policy is rejected.
+	scheduleValues := url.Values{}
+	scheduleValues.Set("amount", types.SiacoinPrecision.String())
+	scheduleValues.Set("destination", types.UnlockHash{1}.String())
+	scheduleValues.Set("catchup", "never")
+	var wsp WalletSchedulePOST
+	if err := st.postAPI("/wallet/schedule", scheduleValues, &wsp); err == nil {
+		t.Fatal("expected an error for an unknown catch-up policy")
+	}
+
+	// Schedule a payment every block, starting at the next block.
+	scheduleValues.Set("catchup", modules.CatchUpLatest)
+	scheduleValues.Set("startheight", fmt.Sprint(st.cs.Height()+1))
+	scheduleValues.Set("interval", "1")
+	if err := st.postAPI("/wallet/schedule", scheduleValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	var wsg WalletScheduleGET
+	if err := st.getAPI("/wallet/schedule", &wsg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wsg.Payments) != 1 || wsg.Payments[0].ID != wsp.Payment.ID || wsg.Payments[0].CatchUp != modules.CatchUpLatest {
+		t.Fatal("scheduled payment was not returned:", wsg.Payments)
+	}
+
+	// The payment is made after the next block.
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	var wshg WalletScheduleHistoryGET
+	err = build.Retry(100, 50*time.Millisecond, func() error {
+		if err := st.getAPI("/wallet/schedule/history", &wshg); err != nil {
+			return err
+		}
+		if len(wshg.Executions) != 1 {
+			return errors.New("payment was not made")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spe := wshg.Executions[0]; spe.PaymentID != wsp.Payment.ID || spe.Skipped || spe.TransactionID == (types.TransactionID{}) {
+		t.Fatal("wrong execution:", spe)
+	}
+
+	// Cancel the payment.
+	if err := st.stdPostAPI(fmt.Sprintf("/wallet/schedule/cancel/%v", wsp.Payment.ID), url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/wallet/schedule", &wsg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wsg.Payments) != 0 {
+		t.Fatal("payment was not cancelled")
+	}
+}
+
+// TestWalletTimelock probes the unlockheight parameter of /wallet/address
+// and /wallet/siacoins.
+func TestWalletTimelock(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Time-locked addresses can only be derived from addresses of the
+	// wallet.
+	unlockHeight := st.cs.Height() + 3
+	sendValues := url.Values{}
+	sendValues.Set("amount", types.SiacoinPrecision.Mul64(100).String())
+	sendValues.Set("destination", types.UnlockHash{1}.String())
+	sendValues.Set("unlockheight", fmt.Sprint(unlockHeight))
+	if err := st.stdPostAPI("/wallet/siacoins", sendValues); err == nil {
+		t.Fatal("expected an error for a destination outside of the wallet")
+	}
+
+	var wag WalletAddressGET
+	if err := st.getAPI("/wallet/address", &wag); err != nil {
+		t.Fatal(err)
+	}
+	sendValues.Set("destination", wag.Address.String())
+	var wsp WalletSiacoinsPOST
+	if err := st.postAPI("/wallet/siacoins", sendValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	var wg WalletGET
+	if err := st.getAPI("/wallet", &wg); err != nil {
+		t.Fatal(err)
+	}
+	if !wg.TimelockedSiacoinBalance.Equals(types.SiacoinPrecision.Mul64(100)) {
+		t.Fatal("wrong time-locked balance:", wg.TimelockedSiacoinBalance)
+	}
+
+	// A time-locked address can be requested directly.
+	var timelocked WalletAddressGET
+	if err := st.getAPI(fmt.Sprintf("/wallet/address?unlockheight=%v", unlockHeight), &timelocked); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI(fmt.Sprintf("/wallet/address?unlockheight=%v", st.cs.Height()), &timelocked); err == nil {
+		t.Fatal("expected an error for an unlock height in the past")
+	}
+
+	// The siacoins become spendable at the unlock height.
+	for st.cs.Height() < unlockHeight {
+		if _, err := st.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.getAPI("/wallet", &wg); err != nil {
+		t.Fatal(err)
+	}
+	if !wg.TimelockedSiacoinBalance.IsZero() {
+		t.Fatal("time-locked balance should be zero, got", wg.TimelockedSiacoinBalance)
+	}
+	var wug WalletUnspentGET
+	if err := st.getAPI("/wallet/unspent", &wug); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, o := range wug.Outputs {
+		if o.UnlockHeight == unlockHeight {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("time-locked output is not listed")
+	}
+}
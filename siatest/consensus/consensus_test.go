@@ -1,10 +1,14 @@
 package consensus
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node"
+	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/siatest"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -200,3 +204,89 @@ func TestConsensusBlocksIDGet(t *testing.T) {
 		}
 	}
 }
+
+// TestConsensusSubscribe tests that the /consensus/subscribe endpoint streams
+// all consensus changes, including new ones, and that streaming can be resumed
+// after the last change that was processed.
+func TestConsensusSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testdir, err := siatest.TestDir(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNode, err := siatest.NewNode(node.AllModules(testdir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Stream all changes up to the current block.
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errDone := errors.New("done")
+	var applied []types.BlockID
+	var lastID modules.ConsensusChangeID
+	err = testNode.ConsensusSubscribeGet(modules.ConsensusChangeBeginning, func(cc api.ConsensusChangeGET) error {
+		if len(cc.RevertedBlocks) != 0 {
+			t.Fatal("unexpected reverted blocks")
+		}
+		for _, b := range cc.AppliedBlocks {
+			applied = append(applied, b.ID())
+		}
+		lastID = cc.ID
+		if applied[len(applied)-1] == cg.CurrentBlock {
+			return errDone
+		}
+		return nil
+	})
+	if err != errDone {
+		t.Fatal(err)
+	}
+	if types.BlockHeight(len(applied)) != cg.Height+1 || applied[0] != types.GenesisID {
+		t.Fatal("wrong blocks were streamed:", len(applied), cg.Height)
+	}
+
+	// Resume streaming while new blocks are mined.
+	go func() {
+		for i := 0; i < 3; i++ {
+			if err := testNode.MineBlock(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	var resumed []types.Block
+	err = testNode.ConsensusSubscribeGet(lastID, func(cc api.ConsensusChangeGET) error {
+		resumed = append(resumed, cc.AppliedBlocks...)
+		if len(resumed) == 3 {
+			return errDone
+		}
+		return nil
+	})
+	if err != errDone {
+		t.Fatal(err)
+	}
+	if resumed[0].ParentID != cg.CurrentBlock {
+		t.Fatal("resumed stream does not continue after the last change")
+	}
+	for i := 1; i < len(resumed); i++ {
+		if resumed[i].ParentID != resumed[i-1].ID() {
+			t.Fatal("streamed blocks are not consecutive")
+		}
+	}
+
+	// Unknown change ids are rejected.
+	err = testNode.ConsensusSubscribeGet(modules.ConsensusChangeID{2}, func(api.ConsensusChangeGET) error {
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInvalidConsensusChangeID.Error()) {
+		t.Fatal("expected ErrInvalidConsensusChangeID, got", err)
+	}
+}
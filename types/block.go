@@ -51,6 +51,15 @@ type (
 	// The BlockNonce is a "scratch space" that miners can freely alter to produce
 	// a BlockID that satisfies a given Target.
 	BlockNonce [8]byte
+
+	// A TransactionProof is a Merkle proof that a transaction is one of the
+	// leaves of the Merkle root of a block. Index is the position of the
+	// transaction's leaf, which follows the leaves of the miner payouts.
+	TransactionProof struct {
+		Index     uint64        `json:"index"`
+		NumLeaves uint64        `json:"numleaves"`
+		HashSet   []crypto.Hash `json:"hashset"`
+	}
 )
 
 // CalculateCoinbase calculates the coinbase for a given height. The coinbase
@@ -149,6 +158,50 @@ func (b Block) MerkleRoot() crypto.Hash {
 	return tree.Root()
 }
 
+// TransactionProof returns a Merkle proof that the transaction with the given
+// id is part of the block, and false if the block does not contain the
+// transaction.
+func (b Block) TransactionProof(id TransactionID) (TransactionProof, bool) {
+	index := -1
+	for i, txn := range b.Transactions {
+		if txn.ID() == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return TransactionProof{}, false
+	}
+
+	// Build the tree the same way as MerkleRoot.
+	tree := crypto.NewTree()
+	if err := tree.SetIndex(uint64(len(b.MinerPayouts) + index)); err != nil {
+		build.Critical(err)
+	}
+	for _, payout := range b.MinerPayouts {
+		tree.PushObject(payout)
+	}
+	for _, txn := range b.Transactions {
+		tree.PushObject(txn)
+	}
+	_, proofSet, proofIndex, numLeaves := tree.Prove()
+	tp := TransactionProof{
+		Index:     proofIndex,
+		NumLeaves: numLeaves,
+		HashSet:   make([]crypto.Hash, len(proofSet)-1),
+	}
+	for i, p := range proofSet[1:] {
+		copy(tp.HashSet[i][:], p)
+	}
+	return tp, true
+}
+
+// Verify returns true if the proof shows that the transaction is part of the
+// Merkle root of a block header.
+func (tp TransactionProof) Verify(txn Transaction, header BlockHeader) bool {
+	return crypto.VerifySegment(encoding.Marshal(txn), tp.HashSet, tp.NumLeaves, tp.Index, header.MerkleRoot)
+}
+
 // MinerPayoutID returns the ID of the miner payout at the given index, which
 // is calculated by hashing the concatenation of the BlockID and the payout
 // index.
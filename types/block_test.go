@@ -231,3 +231,45 @@ func TestBlockMinerPayoutID(t *testing.T) {
 		knownIDs[id] = struct{}{}
 	}
 }
+
+// TestBlockTransactionProof checks that the transaction proofs of a block
+// verify against the block header, and only for the proven transaction.
+func TestBlockTransactionProof(t *testing.T) {
+	for _, numTxns := range []int{1, 2, 5, 8} {
+		b := Block{
+			MinerPayouts: []SiacoinOutput{
+				{Value: CalculateCoinbase(0)},
+				{Value: CalculateCoinbase(1)},
+			},
+		}
+		for i := 0; i < numTxns; i++ {
+			b.Transactions = append(b.Transactions, Transaction{ArbitraryData: [][]byte{{byte(i)}}})
+		}
+		header := b.Header()
+		for i, txn := range b.Transactions {
+			tp, ok := b.TransactionProof(txn.ID())
+			if !ok {
+				t.Fatal("no proof for transaction", i)
+			}
+			if tp.Index != uint64(len(b.MinerPayouts)+i) || tp.NumLeaves != uint64(len(b.MinerPayouts)+numTxns) {
+				t.Fatal("wrong proof index:", tp.Index, tp.NumLeaves)
+			}
+			if !tp.Verify(txn, header) {
+				t.Fatalf("proof for transaction %v of %v did not verify", i, numTxns)
+			}
+
+			// The proof does not verify other transactions or other blocks.
+			if tp.Verify(Transaction{ArbitraryData: [][]byte{{0xFF}}}, header) {
+				t.Fatal("proof verified the wrong transaction")
+			}
+			if tp.Verify(txn, BlockHeader{MerkleRoot: crypto.Hash{1}}) {
+				t.Fatal("proof verified against the wrong header")
+			}
+		}
+	}
+
+	// Transactions that are not in the block have no proof.
+	if _, ok := (Block{}).TransactionProof(TransactionID{1}); ok {
+		t.Fatal("proof returned for a missing transaction")
+	}
+}
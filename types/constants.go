@@ -529,4 +529,5 @@ func init() {
 	}
 	// Calculate the genesis ID.
 	GenesisID = GenesisBlock.ID()
+	builtinGenesisID = GenesisID
 }
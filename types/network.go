@@ -0,0 +1,172 @@
+package types
+
+// network.go allows the network parameters that are chosen by the build tags
+// in constants.go to be replaced at runtime, so that a private Sia network can
+// be run without patching the binary. The parameters must be replaced before
+// any module is created, because the modules read them without
+// synchronization.
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+var (
+	errNetworkBlockFrequency  = errors.New("network block frequency must be greater than zero")
+	errNetworkFutureThreshold = errors.New("network future threshold must be lower than the extreme future threshold")
+	errNetworkGenesis         = errors.New("network must have a different genesis block than the built-in network")
+	errNetworkMaturityDelay   = errors.New("network maturity delay must be greater than zero")
+	errNetworkOakHardfork     = errors.New("network oak hardfork fix height must not be lower than the oak hardfork height")
+	errNetworkRootTarget      = errors.New("network root target must be greater than zero")
+	errNetworkSiafundCount    = errors.New("network genesis siafund allocation must add up to the siafund count")
+	errNetworkTargetWindow    = errors.New("network target window must be greater than zero")
+)
+
+// builtinGenesisID is the id of the genesis block of the network that was
+// chosen by the build tags. It is set by init in constants.go.
+var builtinGenesisID BlockID
+
+// A Network contains the parameters of a Sia network that can be set at
+// runtime. Parameters that are omitted from a network definition file keep
+// the values chosen by the build tags.
+type Network struct {
+	// Name is a human readable name for the network. It is not part of the
+	// consensus rules.
+	Name string `json:"name"`
+
+	// BootstrapPeers are the addresses of the peers that nodes of the network
+	// connect to in order to find other peers.
+	BootstrapPeers []string `json:"bootstrappeers"`
+
+	GenesisTimestamp         Timestamp       `json:"genesistimestamp"`
+	GenesisSiafundAllocation []SiafundOutput `json:"genesissiafundallocation"`
+	RootTarget               Target          `json:"roottarget"`
+
+	BlockFrequency         BlockHeight `json:"blockfrequency"`
+	MaturityDelay          BlockHeight `json:"maturitydelay"`
+	TargetWindow           BlockHeight `json:"targetwindow"`
+	FutureThreshold        Timestamp   `json:"futurethreshold"`
+	ExtremeFutureThreshold Timestamp   `json:"extremefuturethreshold"`
+	MinimumCoinbase        uint64      `json:"minimumcoinbase"`
+
+	TaxHardforkHeight   BlockHeight `json:"taxhardforkheight"`
+	OakHardforkBlock    BlockHeight `json:"oakhardforkblock"`
+	OakHardforkFixBlock BlockHeight `json:"oakhardforkfixblock"`
+}
+
+// CurrentNetwork returns the parameters of the network that the node is
+// currently configured for.
+func CurrentNetwork() Network {
+	return Network{
+		GenesisTimestamp:         GenesisTimestamp,
+		GenesisSiafundAllocation: append([]SiafundOutput(nil), GenesisSiafundAllocation...),
+		RootTarget:               RootTarget,
+
+		BlockFrequency:         BlockFrequency,
+		MaturityDelay:          MaturityDelay,
+		TargetWindow:           TargetWindow,
+		FutureThreshold:        FutureThreshold,
+		ExtremeFutureThreshold: ExtremeFutureThreshold,
+		MinimumCoinbase:        MinimumCoinbase,
+
+		TaxHardforkHeight:   TaxHardforkHeight,
+		OakHardforkBlock:    OakHardforkBlock,
+		OakHardforkFixBlock: OakHardforkFixBlock,
+	}
+}
+
+// GenesisBlock returns the genesis block of the network.
+func (n Network) GenesisBlock() Block {
+	return Block{
+		Timestamp: n.GenesisTimestamp,
+		Transactions: []Transaction{
+			{SiafundOutputs: n.GenesisSiafundAllocation},
+		},
+	}
+}
+
+// Validate returns an error if the parameters of the network are unusable.
+func (n Network) Validate() error {
+	if n.BlockFrequency == 0 {
+		return errNetworkBlockFrequency
+	}
+	if n.MaturityDelay == 0 {
+		return errNetworkMaturityDelay
+	}
+	if n.TargetWindow == 0 {
+		return errNetworkTargetWindow
+	}
+	if n.FutureThreshold >= n.ExtremeFutureThreshold {
+		return errNetworkFutureThreshold
+	}
+	if n.OakHardforkFixBlock < n.OakHardforkBlock {
+		return errNetworkOakHardfork
+	}
+	if n.RootTarget == (Target{}) {
+		return errNetworkRootTarget
+	}
+	var siafunds Currency
+	for _, sfo := range n.GenesisSiafundAllocation {
+		siafunds = siafunds.Add(sfo.Value)
+	}
+	if !siafunds.Equals(SiafundCount) {
+		return errNetworkSiafundCount
+	}
+	if n.GenesisBlock().ID() == builtinGenesisID {
+		return errNetworkGenesis
+	}
+	return nil
+}
+
+// LoadNetwork reads a network definition file in JSON format and returns the
+// network that it describes. The parameters that are omitted from the file
+// keep the values of the built-in network.
+func LoadNetwork(filename string) (Network, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Network{}, err
+	}
+	defer f.Close()
+
+	// The siafund allocation is decoded into a new slice, because decoding
+	// into the built-in outputs would overwrite their values, which share
+	// memory with the built-in allocation.
+	n := CurrentNetwork()
+	n.GenesisSiafundAllocation = nil
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&n); err != nil {
+		return Network{}, err
+	}
+	if n.GenesisSiafundAllocation == nil {
+		n.GenesisSiafundAllocation = CurrentNetwork().GenesisSiafundAllocation
+	}
+	if err := n.Validate(); err != nil {
+		return Network{}, err
+	}
+	return n, nil
+}
+
+// SetNetwork replaces the parameters of the network that the node is
+// configured for, including the genesis block and its id. It must be called
+// before any module is created.
+func SetNetwork(n Network) {
+	GenesisTimestamp = n.GenesisTimestamp
+	GenesisSiafundAllocation = n.GenesisSiafundAllocation
+	RootTarget = n.RootTarget
+
+	BlockFrequency = n.BlockFrequency
+	MaturityDelay = n.MaturityDelay
+	TargetWindow = n.TargetWindow
+	FutureThreshold = n.FutureThreshold
+	ExtremeFutureThreshold = n.ExtremeFutureThreshold
+	MinimumCoinbase = n.MinimumCoinbase
+
+	TaxHardforkHeight = n.TaxHardforkHeight
+	OakHardforkBlock = n.OakHardforkBlock
+	OakHardforkFixBlock = n.OakHardforkFixBlock
+
+	GenesisBlock = n.GenesisBlock()
+	GenesisID = GenesisBlock.ID()
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// TestLoadNetwork checks that network definition files are loaded on top of
+// the built-in network, and that unusable networks are rejected.
+func TestLoadNetwork(t *testing.T) {
+	dir := build.TempDir("types", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		def string
+		err error
+	}{
+		{`{"name": "staging", "genesistimestamp": 1500000000, "blockfrequency": 30, "oakhardforkblock": 0, "oakhardforkfixblock": 0}`, nil},
+		{`{"name": "staging"}`, errNetworkGenesis},
+		{`{"genesistimestamp": 1500000000, "blockfrequency": 0}`, errNetworkBlockFrequency},
+		{`{"genesistimestamp": 1500000000, "maturitydelay": 0}`, errNetworkMaturityDelay},
+		{`{"genesistimestamp": 1500000000, "targetwindow": 0}`, errNetworkTargetWindow},
+		{`{"genesistimestamp": 1500000000, "futurethreshold": 10, "extremefuturethreshold": 10}`, errNetworkFutureThreshold},
+		{`{"genesistimestamp": 1500000000, "oakhardforkblock": 10, "oakhardforkfixblock": 9}`, errNetworkOakHardfork},
+		{`{"genesistimestamp": 1500000000, "roottarget": [0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]}`, errNetworkRootTarget},
+		{`{"genesistimestamp": 1500000000, "genesissiafundallocation": [{"value": "1", "unlockhash": "` + UnlockConditions{}.UnlockHash().String() + `"}]}`, errNetworkSiafundCount},
+	}
+	for i, test := range tests {
+		filename := filepath.Join(dir, "network.json")
+		if err := ioutil.WriteFile(filename, []byte(test.def), 0600); err != nil {
+			t.Fatal(err)
+		}
+		n, err := LoadNetwork(filename)
+		if err != test.err {
+			t.Errorf("test %v: expected %v, got %v", i, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if n.Name != "staging" || n.BlockFrequency != 30 || n.OakHardforkBlock != 0 {
+			t.Error("network parameters were not loaded:", n)
+		}
+		if n.MaturityDelay != MaturityDelay || n.TargetWindow != TargetWindow || len(n.GenesisSiafundAllocation) != len(GenesisSiafundAllocation) {
+			t.Error("omitted network parameters did not keep their built-in values:", n)
+		}
+	}
+
+	// Unknown parameters and malformed files are rejected.
+	for _, def := range []string{`{"blockfrequence": 30}`, `{"blockfrequency": "30"}`, `{`} {
+		filename := filepath.Join(dir, "network.json")
+		if err := ioutil.WriteFile(filename, []byte(def), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadNetwork(filename); err == nil {
+			t.Errorf("%s was loaded", def)
+		}
+	}
+	if _, err := LoadNetwork(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Error("expected a missing file error, got", err)
+	}
+}
+
+// TestSetNetwork checks that setting a network replaces the network
+// parameters and the genesis block.
+func TestSetNetwork(t *testing.T) {
+	builtin := CurrentNetwork()
+	defer SetNetwork(builtin)
+
+	n := CurrentNetwork()
+	n.GenesisTimestamp = 1500000000
+	n.BlockFrequency = 30
+	n.OakHardforkBlock = 0
+	if err := n.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	SetNetwork(n)
+	if BlockFrequency != 30 || OakHardforkBlock != 0 || GenesisTimestamp != 1500000000 {
+		t.Fatal("network parameters were not set")
+	}
+	if GenesisBlock.Timestamp != 1500000000 || GenesisID != n.GenesisBlock().ID() || GenesisID == builtinGenesisID {
+		t.Fatal("genesis block was not replaced")
+	}
+
+	// Restoring the built-in network restores the genesis block.
+	SetNetwork(builtin)
+	if GenesisID != builtinGenesisID {
+		t.Fatal("built-in genesis block was not restored")
+	}
+}